@@ -32,6 +32,12 @@ func SystemAddress() string {
 	return viper.GetString(setting.ENVSystemAddress)
 }
 
+// OTLPEndpoint is the address of the OpenTelemetry collector receiving spans over OTLP/HTTP,
+// for example: otel-collector:4318, https://otel.foo.com. Spans are not exported if it is empty.
+func OTLPEndpoint() string {
+	return viper.GetString(setting.ENVOTLPEndpoint)
+}
+
 func Enterprise() bool {
 	return viper.GetBool(setting.ENVEnterprise)
 }
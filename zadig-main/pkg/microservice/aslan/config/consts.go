@@ -142,6 +142,8 @@ const (
 	TaskTrigger         TaskType = "trigger"
 	TaskExtension       TaskType = "extension"
 	TaskArtifactPackage TaskType = "artifact_package"
+	TaskExternalPlugin  TaskType = "external_plugin"
+	TaskApproval        TaskType = "approval"
 )
 
 type DistributeType string
@@ -150,6 +152,13 @@ const (
 	File  DistributeType = "file"
 	Image DistributeType = "image"
 	Chart DistributeType = "chart"
+	SBOM  DistributeType = "sbom"
+)
+
+// 镜像 SBOM 格式
+const (
+	SBOMFormatSPDX      = "spdx-json"
+	SBOMFormatCycloneDX = "cyclonedx-json"
 )
 
 type NotifyType int
@@ -160,6 +169,27 @@ var (
 	Message        NotifyType = 3 // 消息
 )
 
+// NotificationEvent 用户可订阅的通知事件
+type NotificationEvent string
+
+const (
+	NotificationEventTaskFailed             NotificationEvent = "task_failed"
+	NotificationEventEnvDeployed            NotificationEvent = "env_deployed"
+	NotificationEventDeliveryVersionCreated NotificationEvent = "delivery_version_created"
+	NotificationEventApprovalRequired       NotificationEvent = "approval_required"
+	NotificationEventClusterDisconnected    NotificationEvent = "cluster_disconnected"
+	NotificationEventEnvExpiring            NotificationEvent = "env_expiring"
+)
+
+// NotificationChannel 订阅的通知渠道
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp NotificationChannel = "in_app"
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelIM    NotificationChannel = "im"
+)
+
 // Validation constants
 const (
 	NameSpaceRegexString = "[^a-z0-9.-]"
@@ -220,3 +250,14 @@ const (
 	RoleBindingNameEdit = setting.ProductName + "-edit"
 	RoleBindingNameView = setting.ProductName + "-view"
 )
+
+// 漏洞门禁不通过时的处理方式
+const (
+	SecurityPolicyActionFail = "fail"
+	SecurityPolicyActionWarn = "warn"
+)
+
+// 镜像漏洞扫描后端
+const (
+	ScannerClair = "clair"
+)
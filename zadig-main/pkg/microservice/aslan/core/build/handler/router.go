@@ -35,6 +35,13 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		build.POST("/targets", gin2.UpdateOperationLogStatus, UpdateBuildTargets)
 	}
 
+	// 编译结果缓存，供 warpdrive 在集群内直接调用，经网关的外部访问仅限系统管理员
+	buildCache := router.Group("buildcache")
+	{
+		buildCache.GET("/:key", GetBuildCache)
+		buildCache.POST("", CreateBuildCache)
+	}
+
 	target := router.Group("targets")
 	{
 		target.GET("", ListDeployTarget)
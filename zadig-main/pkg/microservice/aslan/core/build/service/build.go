@@ -19,12 +19,14 @@ package service
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
@@ -33,6 +35,8 @@ import (
 	e "github.com/koderover/zadig/pkg/tool/errors"
 )
 
+var platformRegex = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
 type BuildResp struct {
 	ID          string                              `json:"id"`
 	Name        string                              `json:"name"`
@@ -115,6 +119,9 @@ func CreateBuild(username string, build *commonmodels.Build, log *zap.SugaredLog
 	if err := commonutil.CheckDefineResourceParam(build.PreBuild.ResReq, build.PreBuild.ResReqSpec); err != nil {
 		return e.ErrCreateBuildModule.AddDesc(err.Error())
 	}
+	if err := validateDockerBuild(build.PostBuild); err != nil {
+		return e.ErrCreateBuildModule.AddDesc(err.Error())
+	}
 
 	build.UpdateBy = username
 	correctFields(build)
@@ -134,6 +141,9 @@ func UpdateBuild(username string, build *commonmodels.Build, log *zap.SugaredLog
 	if err := commonutil.CheckDefineResourceParam(build.PreBuild.ResReq, build.PreBuild.ResReqSpec); err != nil {
 		return e.ErrUpdateBuildModule.AddDesc(err.Error())
 	}
+	if err := validateDockerBuild(build.PostBuild); err != nil {
+		return e.ErrUpdateBuildModule.AddDesc(err.Error())
+	}
 
 	existed, err := commonrepo.NewBuildColl().Find(&commonrepo.BuildFindOption{Name: build.Name, ProductName: build.ProductName})
 	if err == nil && existed.PreBuild != nil && build.PreBuild != nil {
@@ -333,7 +343,46 @@ func correctFields(build *commonmodels.Build) {
 	if build.PostBuild != nil && build.PostBuild.DockerBuild != nil {
 		build.PostBuild.DockerBuild.DockerFile = strings.Trim(build.PostBuild.DockerBuild.DockerFile, " ")
 		build.PostBuild.DockerBuild.WorkDir = strings.Trim(build.PostBuild.DockerBuild.WorkDir, " ")
+
+		platforms := make([]string, 0, len(build.PostBuild.DockerBuild.Platforms))
+		for _, platform := range build.PostBuild.DockerBuild.Platforms {
+			if platform = strings.TrimSpace(platform); platform != "" {
+				platforms = append(platforms, platform)
+			}
+		}
+		build.PostBuild.DockerBuild.Platforms = platforms
+	}
+}
+
+// validateDockerBuild platforms and remote cache are only supported by buildx,
+// the sbom format and the signing key must be valid if they are set
+func validateDockerBuild(postBuild *commonmodels.PostBuild) error {
+	if postBuild == nil || postBuild.DockerBuild == nil {
+		return nil
+	}
+
+	dockerBuild := postBuild.DockerBuild
+	if !dockerBuild.EnableBuildkit && (len(dockerBuild.Platforms) > 0 || dockerBuild.RemoteCache) {
+		return errors.New("platforms and remote cache require buildkit to be enabled")
+	}
+	for _, platform := range dockerBuild.Platforms {
+		platform = strings.TrimSpace(platform)
+		if platform != "" && !platformRegex.MatchString(platform) {
+			return fmt.Errorf("invalid platform %q, it should be like linux/amd64 or linux/arm/v7", platform)
+		}
 	}
+
+	switch dockerBuild.SBOMFormat {
+	case "", config.SBOMFormatSPDX, config.SBOMFormatCycloneDX:
+	default:
+		return fmt.Errorf("invalid sbom format %q, it should be %s or %s", dockerBuild.SBOMFormat, config.SBOMFormatSPDX, config.SBOMFormatCycloneDX)
+	}
+	if dockerBuild.SigningKeyID != "" {
+		if _, err := commonrepo.NewSigningKeyColl().Find(dockerBuild.SigningKeyID); err != nil {
+			return fmt.Errorf("signing key %s is not found", dockerBuild.SigningKeyID)
+		}
+	}
+	return nil
 }
 
 func verifyBuildTargets(name, productName string, targets []*commonmodels.ServiceModuleTarget, log *zap.SugaredLogger) error {
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// GetBuildCache 查询编译结果缓存，命中时记录命中次数
+func GetBuildCache(key string, log *zap.SugaredLogger) (*commonmodels.BuildCache, error) {
+	cacheColl := commonrepo.NewBuildCacheColl()
+	cache, err := cacheColl.Find(key)
+	if err != nil {
+		log.Debugf("build cache %s not found: %s", key, err)
+		return nil, e.ErrGetBuildCache.AddErr(err)
+	}
+
+	if err := cacheColl.Hit(key); err != nil {
+		log.Warnf("failed to record hit of build cache %s: %s", key, err)
+	}
+	return cache, nil
+}
+
+func CreateBuildCache(args *commonmodels.BuildCache, log *zap.SugaredLogger) error {
+	if args.Key == "" {
+		return e.ErrCreateBuildCache.AddDesc("key is empty")
+	}
+	if args.Image == "" && args.PackageFile == "" {
+		return e.ErrCreateBuildCache.AddDesc("neither image nor package file is set")
+	}
+
+	if err := commonrepo.NewBuildCacheColl().Upsert(args); err != nil {
+		log.Errorf("failed to save build cache %s: %s", args.Key, err)
+		return e.ErrCreateBuildCache.AddErr(err)
+	}
+	return nil
+}
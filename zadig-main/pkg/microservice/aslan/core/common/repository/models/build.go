@@ -52,6 +52,9 @@ type Build struct {
 	CacheEnable  bool               `bson:"cache_enable"        json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"      json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
+
+	// ResultCacheEnable 相同代码版本和编译配置时复用之前的编译结果
+	ResultCacheEnable bool `bson:"result_cache_enable" json:"result_cache_enable"`
 }
 
 // PreBuild prepares an environment for a job
@@ -115,6 +118,16 @@ type DockerBuild struct {
 	TemplateID string `bson:"template_id"            json:"template_id"`
 	// TemplateName is the name of the template dockerfile
 	TemplateName string `bson:"template_name"        json:"template_name"`
+	// EnableBuildkit builds the image with docker buildx
+	EnableBuildkit bool `bson:"enable_buildkit"      json:"enable_buildkit"`
+	// Platforms are the target platforms of the multi-arch image, e.g. linux/amd64, buildx only
+	Platforms []string `bson:"platforms,omitempty"  json:"platforms"`
+	// RemoteCache imports and exports the layer cache from the image registry, buildx only
+	RemoteCache bool `bson:"remote_cache"           json:"remote_cache"`
+	// SBOMFormat generates the SBOM of the image after it is pushed, spdx-json or cyclonedx-json, empty means disabled
+	SBOMFormat string `bson:"sbom_format,omitempty" json:"sbom_format"`
+	// SigningKeyID signs the pushed image with the key in the signing key store, empty means disabled
+	SigningKeyID string `bson:"signing_key_id,omitempty" json:"signing_key_id"`
 }
 
 type JenkinsBuild struct {
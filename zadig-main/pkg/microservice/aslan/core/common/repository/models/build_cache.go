@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// BuildCache 编译结果缓存的索引，Key 由代码版本、编译脚本、环境变量和编译镜像计算得到
+type BuildCache struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	Key          string             `bson:"key"                    json:"key"`
+	ProductName  string             `bson:"product_name"           json:"product_name"`
+	Service      string             `bson:"service"                json:"service"`
+	ServiceName  string             `bson:"service_name"           json:"service_name"`
+	PipelineName string             `bson:"pipeline_name"          json:"pipeline_name"`
+	TaskID       int64              `bson:"task_id"                json:"task_id"`
+	Image        string             `bson:"image,omitempty"        json:"image,omitempty"`
+	PackageFile  string             `bson:"package_file,omitempty" json:"package_file,omitempty"`
+	HitCount     int                `bson:"hit_count"              json:"hit_count"`
+	CreateTime   int64              `bson:"create_time"            json:"create_time"`
+	// UpdateTime 最近一次写入或命中的时间，留存策略以此为准
+	UpdateTime int64 `bson:"update_time"            json:"update_time"`
+}
+
+func (BuildCache) TableName() string {
+	return "build_cache"
+}
@@ -36,6 +36,9 @@ type DeliveryArtifact struct {
 	Layers              []Descriptor       `bson:"layers,omitempty"                json:"layers,omitempty"`
 	PackageFileLocation string             `bson:"package_file_location,omitempty" json:"package_file_location,omitempty"`
 	PackageStorageURI   string             `bson:"package_storage_uri,omitempty"   json:"package_storage_uri,omitempty"`
+	SBOMFormat          string             `bson:"sbom_format,omitempty"           json:"sbom_format,omitempty"`
+	Signed              bool               `bson:"signed,omitempty"                json:"signed,omitempty"`
+	SigningKey          string             `bson:"signing_key,omitempty"           json:"signing_key,omitempty"`
 	CreatedBy           string             `bson:"created_by"                      json:"created_by"`
 	CreatedTime         int64              `bson:"created_time"                    json:"created_time"`
 }
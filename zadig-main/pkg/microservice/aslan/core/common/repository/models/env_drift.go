@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DriftReasonLiveModified 线上资源被手动修改，与上次部署的内容不一致
+	DriftReasonLiveModified = "live_modified"
+	// DriftReasonPendingUpdate 服务模板或变量有更新，尚未部署到环境
+	DriftReasonPendingUpdate = "pending_update"
+
+	DriftAdoptVariable = "variable"
+	DriftAdoptImage    = "image"
+)
+
+// EnvDrift 环境最近一次漂移检测的结果
+type EnvDrift struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductName string             `bson:"product_name"  json:"product_name"`
+	EnvName     string             `bson:"env_name"      json:"env_name"`
+	Drifted     bool               `bson:"drifted"       json:"drifted"`
+	Resources   []*ResourceDrift   `bson:"resources"     json:"resources"`
+	Error       string             `bson:"error"         json:"error"`
+	CheckTime   int64              `bson:"check_time"    json:"check_time"`
+}
+
+type ResourceDrift struct {
+	ServiceName string `bson:"service_name" json:"service_name"`
+	Kind        string `bson:"kind"         json:"kind"`
+	Name        string `bson:"name"         json:"name"`
+	// Missing 渲染结果中的资源在线上不存在
+	Missing bool          `bson:"missing"      json:"missing"`
+	Fields  []*DriftField `bson:"fields"       json:"fields"`
+}
+
+type DriftField struct {
+	Path        string      `bson:"path"                   json:"path"`
+	Desired     interface{} `bson:"desired"                json:"desired"`
+	Live        interface{} `bson:"live"                   json:"live"`
+	LastApplied interface{} `bson:"last_applied,omitempty" json:"last_applied,omitempty"`
+	Reason      string      `bson:"reason"                 json:"reason"`
+	// AdoptType 不为空时可以将线上的值写回服务变量或镜像
+	AdoptType   string `bson:"adopt_type,omitempty"   json:"adopt_type,omitempty"`
+	VariableKey string `bson:"variable_key,omitempty" json:"variable_key,omitempty"`
+	Container   string `bson:"container,omitempty"    json:"container,omitempty"`
+}
+
+func (EnvDrift) TableName() string {
+	return "env_drift"
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnvSnapshot 环境快照，包含重建环境所需的环境记录、渲染集、服务版本和线上镜像
+type EnvSnapshot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"        json:"id,omitempty"`
+	Name        string             `bson:"name"                 json:"name"`
+	ProductName string             `bson:"product_name"         json:"product_name"`
+	EnvName     string             `bson:"env_name"             json:"env_name"`
+	Description string             `bson:"description"          json:"description"`
+	Product     *Product           `bson:"product"              json:"product"`
+	RenderSet   *RenderSet         `bson:"render_set,omitempty" json:"render_set,omitempty"`
+	Services    []*ServiceSnapshot `bson:"services"             json:"services"`
+	// Images 快照时集群中工作负载实际运行的镜像
+	Images     []*WorkloadImage `bson:"images"               json:"images"`
+	CreateBy   string           `bson:"create_by"            json:"create_by"`
+	CreateTime int64            `bson:"create_time"          json:"create_time"`
+}
+
+// ServiceSnapshot 环境中使用的服务模板版本，k8s 服务同时保存模板内容便于跨系统复现
+type ServiceSnapshot struct {
+	ServiceName string `bson:"service_name"   json:"service_name"`
+	ProductName string `bson:"product_name"   json:"product_name"`
+	Type        string `bson:"type"           json:"type"`
+	Revision    int64  `bson:"revision"       json:"revision"`
+	Yaml        string `bson:"yaml,omitempty" json:"yaml,omitempty"`
+	// Source、Visibility 和 Containers 用于在目标系统中重新创建服务模板
+	Source     string       `bson:"source,omitempty"     json:"source,omitempty"`
+	Visibility string       `bson:"visibility,omitempty" json:"visibility,omitempty"`
+	Containers []*Container `bson:"containers,omitempty" json:"containers,omitempty"`
+	// Manifest 快照时服务在集群中的资源 yaml
+	Manifest string `bson:"manifest,omitempty" json:"manifest,omitempty"`
+}
+
+type WorkloadImage struct {
+	ServiceName string `bson:"service_name" json:"service_name"`
+	Kind        string `bson:"kind"         json:"kind"`
+	Name        string `bson:"name"         json:"name"`
+	Container   string `bson:"container"    json:"container"`
+	Image       string `bson:"image"        json:"image"`
+}
+
+func (EnvSnapshot) TableName() string {
+	return "env_snapshot"
+}
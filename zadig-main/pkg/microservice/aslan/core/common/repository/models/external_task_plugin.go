@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExternalTaskPlugin is a task type implemented out of process, warpdrive drives it through the plugin HTTP protocol
+type ExternalTaskPlugin struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty"         json:"id,omitempty"`
+	Name        string                 `bson:"name"                  json:"name"`
+	Description string                 `bson:"description"           json:"description"`
+	Address     string                 `bson:"address"               json:"address"`
+	Headers     []*KeyVal              `bson:"headers"               json:"headers"`
+	Params      []*ExternalPluginParam `bson:"params"                json:"params"`
+	// Timeout in minutes
+	Timeout   int    `bson:"timeout"               json:"timeout"`
+	UpdateBy  string `bson:"update_by"             json:"update_by"`
+	CreatedAt int64  `bson:"created_at"            json:"created_at"`
+	UpdatedAt int64  `bson:"updated_at"            json:"updated_at"`
+}
+
+type ExternalPluginParam struct {
+	Key          string `bson:"key"                   json:"key"`
+	Description  string `bson:"description"           json:"description"`
+	DefaultValue string `bson:"default_value"         json:"default_value"`
+	Required     bool   `bson:"required"              json:"required"`
+}
+
+func (ExternalTaskPlugin) TableName() string {
+	return "external_task_plugin"
+}
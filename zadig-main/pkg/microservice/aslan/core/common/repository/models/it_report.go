@@ -68,6 +68,20 @@ type TestSuite struct {
 	TestCases []TestCase `bson:"testcase"                json:"testcase"                 xml:"testcase"`
 	SuiteType string     `bson:"-"                       json:"-"                        xml:"-"`
 	Name      string     `bson:"name"                    json:"-"                        xml:"-"`
+	// Coverage 测试结果目录中的覆盖率报告汇总，BaseCoverage 为 PR 目标分支上最近一次的覆盖率
+	Coverage     *CoverageSummary `bson:"coverage,omitempty"      json:"coverage,omitempty"       xml:"coverage,omitempty"`
+	BaseCoverage *CoverageSummary `bson:"base_coverage,omitempty" json:"base_coverage,omitempty"  xml:"-"`
+}
+
+// CoverageSummary 覆盖率汇总，LineRate 和 BranchRate 取值范围 0~1
+type CoverageSummary struct {
+	Format          string  `bson:"format"                  json:"format"                   xml:"format,attr"`
+	LineRate        float64 `bson:"line_rate"               json:"line_rate"                xml:"line-rate,attr"`
+	LinesCovered    int     `bson:"lines_covered"           json:"lines_covered"            xml:"lines-covered,attr"`
+	LinesValid      int     `bson:"lines_valid"             json:"lines_valid"              xml:"lines-valid,attr"`
+	BranchRate      float64 `bson:"branch_rate"             json:"branch_rate"              xml:"branch-rate,attr"`
+	BranchesCovered int     `bson:"branches_covered"        json:"branches_covered"         xml:"branches-covered,attr"`
+	BranchesValid   int     `bson:"branches_valid"          json:"branches_valid"           xml:"branches-valid,attr"`
 }
 
 type Skipped struct {
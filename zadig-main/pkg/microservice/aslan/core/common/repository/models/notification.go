@@ -48,6 +48,9 @@ type PrTaskInfo struct {
 	EnvName          string `bson:"env_name,omitempty"                  json:"env_name,omitempty"`
 	EnvRecyclePolicy string `bson:"env_recycle_policy,omitempty"        json:"env_recycle_policy,omitempty"`
 	ProductName      string `bson:"product_name,omitempty"              json:"product_name,omitempty"`
+	// PreviewURLs 预览环境的 ingress 访问地址
+	PreviewURLs      []string `bson:"preview_urls,omitempty"              json:"preview_urls,omitempty"`
+	PreviewCommented bool     `bson:"preview_commented,omitempty"         json:"preview_commented,omitempty"`
 }
 
 type NotificationTask struct {
@@ -83,6 +86,19 @@ func (t NotificationTask) StatusVerbose() string {
 	}
 }
 
+// CoverageVerbose 评论中展示的行覆盖率，以及相对 PR 目标分支的变化
+func (t TestSuite) CoverageVerbose() string {
+	if t.Coverage == nil {
+		return ""
+	}
+
+	verbose := fmt.Sprintf(" 覆盖率: %.2f%%", t.Coverage.LineRate*100)
+	if t.BaseCoverage != nil {
+		verbose = fmt.Sprintf("%s (%+.2f%%)", verbose, (t.Coverage.LineRate-t.BaseCoverage.LineRate)*100)
+	}
+	return verbose
+}
+
 func (Notification) TableName() string {
 	return "scm_notify"
 }
@@ -109,7 +125,7 @@ func (n *Notification) CreateCommentBody() (comment string, err error) {
 				"|触发的工作流|状态| \n |---|---| \n {{range .Tasks}}|[{{.PipelineName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/single/{{.PipelineName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | \n {{end}}"
 		} else {
 			tmplSource =
-				"|触发的工作流|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.PipelineName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/single/{{.PipelineName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}} <br> {{end}} | \n {{end}}"
+				"|触发的工作流|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.PipelineName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/single/{{.PipelineName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}}{{.CoverageVerbose}} <br> {{end}} | \n {{end}}"
 		}
 	} else if n.IsTest {
 		if len(n.Tasks) == 0 {
@@ -119,7 +135,7 @@ func (n *Notification) CreateCommentBody() (comment string, err error) {
 				"|触发的测试|状态| \n |---|---| \n {{range .Tasks}}|[{{.TestName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/test/detail/function/{{.TestName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | \n {{end}}"
 		} else {
 			tmplSource =
-				"|触发的测试|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.TestName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/test/detail/function/{{.TestName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}} <br> {{end}} | \n {{end}}"
+				"|触发的测试|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.TestName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/test/detail/function/{{.TestName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}}{{.CoverageVerbose}} <br> {{end}} | \n {{end}}"
 		}
 	} else {
 		if len(n.Tasks) == 0 {
@@ -129,7 +145,7 @@ func (n *Notification) CreateCommentBody() (comment string, err error) {
 				"|触发的工作流|状态| \n |---|---| \n {{range .Tasks}}|[{{.WorkflowName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/multi/{{.WorkflowName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | \n {{end}}"
 		} else {
 			tmplSource =
-				"|触发的工作流|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.WorkflowName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/multi/{{.WorkflowName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}} <br> {{end}} | \n {{end}}"
+				"|触发的工作流|状态|测试结果（成功数/总用例数量）| \n |---|---|---| \n {{range .Tasks}}|[{{.WorkflowName}}#{{.ID}}]({{$.BaseURI}}/v1/projects/detail/{{.ProductName}}/pipelines/multi/{{.WorkflowName}}/{{.ID}}) | {{if eq .StatusVerbose $.Success}} {+ {{.StatusVerbose}} +}{{else}}{- {{.StatusVerbose}} -}{{end}} | {{range .TestReports}}{{.Name}}: {{.Successes}}/{{.Tests}}{{.CoverageVerbose}} <br> {{end}} | \n {{end}}"
 		}
 	}
 
@@ -139,6 +155,14 @@ func (n *Notification) CreateCommentBody() (comment string, err error) {
 			tmplSource = fmt.Sprintf("%s%s", content, tmplSource)
 		}
 
+		if len(n.PrTask.PreviewURLs) > 0 {
+			content := "预览地址："
+			for _, url := range n.PrTask.PreviewURLs {
+				content = fmt.Sprintf("%s [%s](%s)", content, url, url)
+			}
+			tmplSource = fmt.Sprintf("%s%s \n\n", tmplSource, content)
+		}
+
 		if n.PrTask.EnvRecyclePolicy != "" {
 			policyName := getEnvRecyclePolicy(n.PrTask.EnvRecyclePolicy)
 			content := fmt.Sprintf("根据策略清理环境：[%s]({{$.BaseURI}}/v1/projects/detail/%s/envs/detail?envName=%s) 回收策略：%s \n\n", n.PrTask.EnvName, n.PrTask.ProductName, n.PrTask.EnvName, policyName)
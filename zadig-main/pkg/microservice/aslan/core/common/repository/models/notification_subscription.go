@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// NotificationSubscription 用户对项目、工作流或环境事件的通知订阅
+type NotificationSubscription struct {
+	ID       primitive.ObjectID         `bson:"_id,omitempty"  json:"id,omitempty"`
+	UserID   string                     `bson:"user_id"        json:"user_id"`
+	UserName string                     `bson:"user_name"      json:"user_name"`
+	Events   []config.NotificationEvent `bson:"events"         json:"events"`
+	// 订阅范围，为空表示不限制
+	ProjectName  string `bson:"project_name"  json:"project_name"`
+	WorkflowName string `bson:"workflow_name" json:"workflow_name"`
+	EnvName      string `bson:"env_name"      json:"env_name"`
+
+	Channels []config.NotificationChannel `bson:"channels"              json:"channels"`
+	Email    string                       `bson:"email"                 json:"email"`
+	// IM 复用工作流的通知配置，只使用其中的 webhook 信息
+	IM         *NotifyCtl  `bson:"im,omitempty"          json:"im,omitempty"`
+	QuietHours *QuietHours `bson:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+	Enabled    bool        `bson:"enabled"               json:"enabled"`
+	CreateTime int64       `bson:"create_time"           json:"create_time"`
+	UpdateTime int64       `bson:"update_time"           json:"update_time"`
+}
+
+// QuietHours 免打扰时段，格式为 HH:MM，Start 大于 End 时表示跨天
+type QuietHours struct {
+	Start    string `bson:"start"    json:"start"`
+	End      string `bson:"end"      json:"end"`
+	Timezone string `bson:"timezone" json:"timezone"`
+}
+
+func (NotificationSubscription) TableName() string {
+	return "notification_subscription"
+}
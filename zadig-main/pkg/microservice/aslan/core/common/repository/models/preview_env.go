@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PreviewEnv 记录由 PR 触发创建的预览环境，PR 合并或关闭时回收
+type PreviewEnv struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"   json:"id,omitempty"`
+	ProductName string             `bson:"product_name"    json:"product_name"`
+	EnvName     string             `bson:"env_name"        json:"env_name"`
+	BaseEnvName string             `bson:"base_env_name"   json:"base_env_name"`
+	// PR 来源信息，gerrit 没有 repo owner
+	Source     string `bson:"source"          json:"source"`
+	CodehostID int    `bson:"codehost_id"     json:"codehost_id"`
+	RepoOwner  string `bson:"repo_owner"      json:"repo_owner"`
+	RepoName   string `bson:"repo_name"       json:"repo_name"`
+	PrID       int    `bson:"pr_id"           json:"pr_id"`
+
+	WorkflowName   string   `bson:"workflow_name"   json:"workflow_name"`
+	LastTaskID     int64    `bson:"last_task_id"    json:"last_task_id"`
+	CommitID       string   `bson:"commit_id"       json:"commit_id"`
+	NotificationID string   `bson:"notification_id" json:"notification_id"`
+	URLs           []string `bson:"urls"            json:"urls"`
+	CreateTime     int64    `bson:"create_time"     json:"create_time"`
+	UpdateTime     int64    `bson:"update_time"     json:"update_time"`
+}
+
+func (PreviewEnv) TableName() string {
+	return "preview_env"
+}
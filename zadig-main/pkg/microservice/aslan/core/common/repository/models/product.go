@@ -54,6 +54,16 @@ type Product struct {
 	BaseName     string                        `bson:"base_name" json:"base_name"`
 	// IsExisted is true if this environment is created from an existing one
 	IsExisted bool `bson:"is_existed"                json:"is_existed"`
+	// SleepSchedule 环境定时休眠/唤醒配置
+	SleepSchedule *EnvSleepSchedule `bson:"sleep_schedule,omitempty" json:"sleep_schedule,omitempty"`
+	// SleepState 环境当前的休眠状态
+	SleepState *EnvSleepState `bson:"sleep_state,omitempty"    json:"sleep_state,omitempty"`
+	// ExpireTime 环境到期时间，到期后自动删除，0 表示永不过期
+	ExpireTime int64 `bson:"expire_time"              json:"expire_time"`
+	// ExpireWarned 是否已经发送过即将到期的提醒
+	ExpireWarned bool `bson:"expire_warned"            json:"expire_warned"`
+	// QuotaSetting 环境级别的资源配额，优先于项目级别的配置
+	QuotaSetting *EnvQuotaSetting `bson:"quota_setting,omitempty"  json:"quota_setting,omitempty"`
 	// TODO: temp flag
 	IsForkedProduct bool `bson:"-" json:"-"`
 }
@@ -81,6 +91,31 @@ type ProductService struct {
 	EnvConfigs  []*EnvConfig `bson:"-"                          json:"env_configs,omitempty"`
 }
 
+// EnvSleepSchedule 在 Weekdays 指定的日期 WakeTime 唤醒、SleepTime 休眠，时间格式为 HH:MM
+type EnvSleepSchedule struct {
+	Enabled   bool   `bson:"enabled"    json:"enabled"`
+	SleepTime string `bson:"sleep_time" json:"sleep_time"`
+	WakeTime  string `bson:"wake_time"  json:"wake_time"`
+	// Weekdays 0 表示周日，为空表示每天
+	Weekdays []int `bson:"weekdays"   json:"weekdays"`
+	// Timezone 例如 Asia/Shanghai，为空表示 UTC
+	Timezone string `bson:"timezone"   json:"timezone"`
+}
+
+type EnvSleepState struct {
+	Sleeping   bool   `bson:"sleeping"    json:"sleeping"`
+	UpdateTime int64  `bson:"update_time" json:"update_time"`
+	UpdateBy   string `bson:"update_by"   json:"update_by"`
+	// Replicas 休眠前各工作负载的副本数，唤醒时恢复
+	Replicas []*WorkloadReplicas `bson:"replicas" json:"replicas"`
+}
+
+type WorkloadReplicas struct {
+	Kind     string `bson:"kind"     json:"kind"`
+	Name     string `bson:"name"     json:"name"`
+	Replicas int    `bson:"replicas" json:"replicas"`
+}
+
 type ServiceConfig struct {
 	ConfigName string `bson:"config_name"           json:"config_name"`
 	Revision   int64  `bson:"revision"              json:"revision"`
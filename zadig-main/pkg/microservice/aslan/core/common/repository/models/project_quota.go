@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectQuota 项目级别的环境资源配额和资源单价
+type ProjectQuota struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductName string             `bson:"product_name"  json:"product_name"`
+	// EnvQuota 项目下每个环境默认的资源配额
+	EnvQuota   *EnvQuotaSetting `bson:"env_quota"     json:"env_quota"`
+	Price      *ResourcePrice   `bson:"price"         json:"price"`
+	UpdateBy   string           `bson:"update_by"     json:"update_by"`
+	UpdateTime int64            `bson:"update_time"   json:"update_time"`
+}
+
+// EnvQuotaSetting 对应环境 namespace 中的 ResourceQuota 和 LimitRange，为空表示不限制
+type EnvQuotaSetting struct {
+	ResourceQuota *ResourceQuotaSetting `bson:"resource_quota,omitempty" json:"resource_quota,omitempty"`
+	LimitRange    *LimitRangeSetting    `bson:"limit_range,omitempty"    json:"limit_range,omitempty"`
+}
+
+// ResourceQuotaSetting 中的 CPU 和内存使用 k8s quantity 格式，例如 500m、2Gi
+type ResourceQuotaSetting struct {
+	RequestsCPU    string `bson:"requests_cpu"    json:"requests_cpu"`
+	RequestsMemory string `bson:"requests_memory" json:"requests_memory"`
+	LimitsCPU      string `bson:"limits_cpu"      json:"limits_cpu"`
+	LimitsMemory   string `bson:"limits_memory"   json:"limits_memory"`
+	Pods           int64  `bson:"pods"            json:"pods"`
+}
+
+// LimitRangeSetting 为没有设置 resources 的容器提供默认值
+type LimitRangeSetting struct {
+	DefaultRequestCPU    string `bson:"default_request_cpu"    json:"default_request_cpu"`
+	DefaultRequestMemory string `bson:"default_request_memory" json:"default_request_memory"`
+	DefaultLimitCPU      string `bson:"default_limit_cpu"      json:"default_limit_cpu"`
+	DefaultLimitMemory   string `bson:"default_limit_memory"   json:"default_limit_memory"`
+}
+
+// ResourcePrice 每核 CPU 和每 GiB 内存每小时的价格，用于估算环境成本
+type ResourcePrice struct {
+	CPUCoreHour   float64 `bson:"cpu_core_hour"   json:"cpu_core_hour"`
+	MemoryGiBHour float64 `bson:"memory_gib_hour" json:"memory_gib_hour"`
+	Currency      string  `bson:"currency"        json:"currency"`
+}
+
+func (ProjectQuota) TableName() string {
+	return "project_quota"
+}
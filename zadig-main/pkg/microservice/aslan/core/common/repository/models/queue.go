@@ -67,10 +67,13 @@ type Queue struct {
 	RwLock                  sync.Mutex                   `bson:"-"                                          json:"-"`
 	ResetImage              bool                         `bson:"resetImage"                                 json:"resetImage"`
 	ResetImagePolicy        setting.ResetImagePolicyType `bson:"reset_image_policy"                         json:"reset_image_policy"`
+	AutoRollback            bool                         `bson:"auto_rollback"                              json:"auto_rollback"`
+	VerifyImageSignature    bool                         `bson:"verify_image_signature"                     json:"verify_image_signature"`
 	TriggerBy               *TriggerBy                   `bson:"trigger_by,omitempty"                       json:"trigger_by,omitempty"`
 	Features                []string                     `bson:"features"                                   json:"features"`
 	IsRestart               bool                         `bson:"is_restart"                                 json:"is_restart"`
 	StorageEndpoint         string                       `bson:"storage_endpoint"                           json:"storage_endpoint"`
+	TraceContext            map[string]string            `bson:"trace_context,omitempty"                    json:"trace_context,omitempty"`
 }
 
 type TriggerBy struct {
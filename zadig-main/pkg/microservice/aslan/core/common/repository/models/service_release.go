@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+)
+
+// ServiceRelease records the state of a service in an environment after a successful deploy,
+// it is used as the last-known-good revision when the service needs to be rolled back
+type ServiceRelease struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"            json:"id,omitempty"`
+	ProductName     string             `bson:"product_name"             json:"product_name"`
+	EnvName         string             `bson:"env_name"                 json:"env_name"`
+	Namespace       string             `bson:"namespace"                json:"namespace"`
+	ServiceName     string             `bson:"service_name"             json:"service_name"`
+	Type            string             `bson:"type"                     json:"type"`
+	ServiceRevision int64              `bson:"service_revision"         json:"service_revision"`
+	Containers      []*Container       `bson:"containers"               json:"containers"`
+	Render          *RenderInfo        `bson:"render,omitempty"         json:"render,omitempty"`
+	// RenderChart helm 服务发布时的 values 信息
+	RenderChart *template.RenderChart `bson:"render_chart,omitempty"   json:"render_chart,omitempty"`
+	// HelmRevision helm release 的版本号，回滚时使用
+	HelmRevision int    `bson:"helm_revision,omitempty"  json:"helm_revision,omitempty"`
+	PipelineName string `bson:"pipeline_name,omitempty"  json:"pipeline_name,omitempty"`
+	TaskID       int64  `bson:"task_id,omitempty"        json:"task_id,omitempty"`
+	CreateBy     string `bson:"create_by"                json:"create_by"`
+	CreateTime   int64  `bson:"create_time"              json:"create_time"`
+}
+
+func (ServiceRelease) TableName() string {
+	return "service_release"
+}
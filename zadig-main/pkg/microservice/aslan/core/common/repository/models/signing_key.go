@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SigningKey 镜像签名密钥，格式与 cosign generate-key-pair 生成的密钥对一致
+// PrivateKey 为加密私钥 (ENCRYPTED COSIGN PRIVATE KEY)，Password 为其解密密码
+type SigningKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	Name        string             `bson:"name"                   json:"name"`
+	Description string             `bson:"description"            json:"description"`
+	PrivateKey  string             `bson:"private_key"            json:"private_key,omitempty"`
+	Password    string             `bson:"password"               json:"password,omitempty"`
+	PublicKey   string             `bson:"public_key"             json:"public_key"`
+	CreateTime  int64              `bson:"create_time"            json:"create_time"`
+	UpdateTime  int64              `bson:"update_time"            json:"update_time"`
+	UpdateBy    string             `bson:"update_by"              json:"update_by"`
+}
+
+func (SigningKey) TableName() string {
+	return "signing_key"
+}
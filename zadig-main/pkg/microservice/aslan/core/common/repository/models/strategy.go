@@ -24,6 +24,8 @@ type CapacityTarget string
 const (
 	// 工作流任务的留存
 	WorkflowTaskRetention CapacityTarget = "WorkflowTaskRetention"
+	// 编译结果缓存的留存
+	BuildCacheRetention CapacityTarget = "BuildCacheRetention"
 )
 
 // RetentionConfig 资源留存相关的配置
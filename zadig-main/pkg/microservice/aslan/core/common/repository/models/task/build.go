@@ -67,6 +67,11 @@ type Build struct {
 	CacheEnable  bool               `bson:"cache_enable"                    json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"                  json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"                  json:"cache_user_dir"`
+
+	// ResultCacheEnable 相同代码版本和编译配置的编译结果可以直接复用
+	ResultCacheEnable bool   `bson:"result_cache_enable"             json:"result_cache_enable"`
+	CacheKey          string `bson:"cache_key,omitempty"             json:"cache_key,omitempty"`
+	Cached            bool   `bson:"cached"                          json:"cached"`
 }
 
 type ArtifactInfo struct {
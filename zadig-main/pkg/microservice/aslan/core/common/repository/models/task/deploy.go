@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/setting"
 )
 
@@ -53,6 +54,17 @@ type Deploy struct {
 	IsRestart        bool                         `bson:"is_restart"                    json:"is_restart"`
 	ResetImage       bool                         `bson:"reset_image"                   json:"reset_image"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy"            json:"reset_image_policy"`
+	Strategy         *models.DeployStrategy       `bson:"strategy,omitempty"            json:"strategy,omitempty"`
+	StrategySteps    []*DeployStrategyStep        `bson:"strategy_steps,omitempty"      json:"strategy_steps,omitempty"`
+}
+
+// DeployStrategyStep 记录灰度或蓝绿发布每一步的执行状态
+type DeployStrategyStep struct {
+	Name      string        `bson:"name"                          json:"name"`
+	Status    config.Status `bson:"status"                        json:"status"`
+	Message   string        `bson:"message,omitempty"             json:"message,omitempty"`
+	StartTime int64         `bson:"start_time,omitempty"          json:"start_time,omitempty"`
+	EndTime   int64         `bson:"end_time,omitempty"            json:"end_time,omitempty"`
 }
 
 // SetNamespace ...
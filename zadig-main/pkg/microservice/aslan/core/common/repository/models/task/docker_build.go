@@ -37,6 +37,10 @@ type DockerBuild struct {
 	EndTime    int64           `bson:"end_time"                json:"end_time,omitempty"`
 	JobName    string          `bson:"job_name"                json:"job_name"`
 	LogFile    string          `bson:"log_file"                json:"log_file"`
+	// buildx 构建参数
+	EnableBuildkit bool     `bson:"enable_buildkit"         json:"enable_buildkit"`
+	Platforms      []string `bson:"platforms,omitempty"     json:"platforms,omitempty"`
+	RemoteCache    bool     `bson:"remote_cache"            json:"remote_cache"`
 }
 
 // ToSubTask ...
@@ -61,6 +65,20 @@ type DockerBuildCtx struct {
 	BuildArgs             string `yaml:"build_args" bson:"build_args" json:"build_args"`
 	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
 	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	// EnableBuildkit 使用 buildx 构建, Platforms 和 RemoteCache 仅在 buildx 下生效
+	EnableBuildkit bool     `yaml:"enable_buildkit" bson:"enable_buildkit" json:"enable_buildkit"`
+	Platforms      []string `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	RemoteCache    bool     `yaml:"remote_cache" bson:"remote_cache" json:"remote_cache"`
+	// SBOMFormat 非空时推送镜像后生成 SBOM, 文件名为 SBOMFile; SigningKey 非空时使用其签名镜像
+	SBOMFormat string      `yaml:"sbom_format,omitempty" bson:"sbom_format,omitempty" json:"sbom_format,omitempty"`
+	SBOMFile   string      `yaml:"sbom_file,omitempty" bson:"sbom_file,omitempty" json:"sbom_file,omitempty"`
+	SigningKey *SigningKey `yaml:"signing_key,omitempty" bson:"signing_key,omitempty" json:"signing_key,omitempty"`
+}
+
+// SigningKey 签名镜像使用的密钥，任务中只记录密钥 ID，私钥由 warpdrive 在任务运行时获取
+type SigningKey struct {
+	ID   string `yaml:"id"   bson:"id"   json:"id"`
+	Name string `yaml:"name" bson:"name" json:"name"`
 }
 
 type FileArchiveCtx struct {
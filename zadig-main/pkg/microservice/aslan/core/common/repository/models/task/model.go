@@ -80,10 +80,20 @@ type Task struct {
 	RwLock           sync.Mutex                   `bson:"-"                      json:"-"`
 	ResetImage       bool                         `bson:"resetImage"             json:"resetImage"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy"     json:"reset_image_policy"`
+	AutoRollback     bool                         `bson:"auto_rollback"          json:"auto_rollback"`
 	TriggerBy        *models.TriggerBy            `bson:"trigger_by,omitempty"   json:"trigger_by,omitempty"`
 	Features         []string                     `bson:"features"               json:"features"`
 	IsRestart        bool                         `bson:"is_restart"             json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"       json:"storage_endpoint"`
+	// VerifyImageSignature 部署和分发镜像前校验镜像签名
+	VerifyImageSignature bool `bson:"verify_image_signature" json:"verify_image_signature"`
+	// RetryFrom 从失败阶段重试时被重试的任务 ID
+	RetryFrom int64 `bson:"retry_from,omitempty"   json:"retry_from,omitempty"`
+	// RetryRoot 重试链路中最初的任务 ID
+	RetryRoot  int64 `bson:"retry_root,omitempty"   json:"retry_root,omitempty"`
+	RetryCount int   `bson:"retry_count,omitempty"  json:"retry_count,omitempty"`
+	// TraceContext 任务创建时生成的 trace 上下文, 随任务传递到 warpdrive 和 reaper
+	TraceContext map[string]string `bson:"trace_context,omitempty" json:"trace_context,omitempty"`
 }
 
 func (Task) TableName() string {
@@ -122,6 +132,19 @@ type TestSuite struct {
 	TestCases []TestCase `bson:"testcase"                json:"testcase"                 xml:"testcase"`
 	SuiteType string     `bson:"-"                       json:"-"                        xml:"-"`
 	Name      string     `bson:"name"                    json:"-"                        xml:"-"`
+	// Coverage 测试结果目录中的覆盖率报告汇总
+	Coverage *CoverageSummary `bson:"coverage,omitempty"      json:"coverage,omitempty"       xml:"coverage,omitempty"`
+}
+
+// CoverageSummary 覆盖率汇总，LineRate 和 BranchRate 取值范围 0~1
+type CoverageSummary struct {
+	Format          string  `bson:"format"                  json:"format"                   xml:"format,attr"`
+	LineRate        float64 `bson:"line_rate"               json:"line_rate"                xml:"line-rate,attr"`
+	LinesCovered    int     `bson:"lines_covered"           json:"lines_covered"            xml:"lines-covered,attr"`
+	LinesValid      int     `bson:"lines_valid"             json:"lines_valid"              xml:"lines-valid,attr"`
+	BranchRate      float64 `bson:"branch_rate"             json:"branch_rate"              xml:"branch-rate,attr"`
+	BranchesCovered int     `bson:"branches_covered"        json:"branches_covered"         xml:"branches-covered,attr"`
+	BranchesValid   int     `bson:"branches_valid"          json:"branches_valid"           xml:"branches-valid,attr"`
 }
 
 type PerformanceTestSuite struct {
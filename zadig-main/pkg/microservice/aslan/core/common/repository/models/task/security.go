@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 )
 
 type Security struct {
@@ -34,6 +35,11 @@ type Security struct {
 	EndTime    int64           `bson:"end_time,omitempty"            json:"end_time,omitempty"`
 	LogFile    string          `bson:"log_file"                      json:"log_file"`
 	Summary    map[string]int  `bson:"summary"                       json:"summary"`
+	// Scanner 和 Policy 来自工作流的安全扫描配置，Violations 为超出门禁的漏洞，Warning 为仅告警时的提示
+	Scanner    string                 `bson:"scanner,omitempty"             json:"scanner,omitempty"`
+	Policy     *models.SecurityPolicy `bson:"policy,omitempty"              json:"policy,omitempty"`
+	Violations []string               `bson:"violations,omitempty"          json:"violations,omitempty"`
+	Warning    string                 `bson:"warning,omitempty"             json:"warning,omitempty"`
 }
 
 func (s *Security) SetImageName(imageName string) {
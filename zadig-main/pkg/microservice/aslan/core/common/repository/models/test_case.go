@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// TestCaseResult 测试用例在一次测试任务中的执行结果，用于分析不稳定的用例
+type TestCaseResult struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"   json:"id,omitempty"`
+	ProductName  string             `bson:"product_name"    json:"product_name"`
+	TestName     string             `bson:"test_name"       json:"test_name"`
+	ClassName    string             `bson:"class_name"      json:"class_name"`
+	CaseName     string             `bson:"case_name"       json:"case_name"`
+	PipelineName string             `bson:"pipeline_name"   json:"pipeline_name"`
+	TaskID       int64              `bson:"task_id"         json:"task_id"`
+	// Revision 测试代码的版本，多个代码库的 commit 拼接而成
+	Revision   string        `bson:"revision"        json:"revision"`
+	Status     config.Status `bson:"status"          json:"status"`
+	CreateTime int64         `bson:"create_time"     json:"create_time"`
+	// Time 用例执行耗时（秒），用于按历史耗时切分测试分片
+	Time float64 `bson:"time"            json:"time"`
+}
+
+func (TestCaseResult) TableName() string {
+	return "test_case_result"
+}
+
+// TestCaseQuarantine 被隔离的测试用例，失败时仍然会在报告中展示，但不会导致测试失败
+type TestCaseQuarantine struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"   json:"id,omitempty"`
+	ProductName string             `bson:"product_name"    json:"product_name"`
+	TestName    string             `bson:"test_name"       json:"test_name"`
+	ClassName   string             `bson:"class_name"      json:"class_name"`
+	CaseName    string             `bson:"case_name"       json:"case_name"`
+	Reason      string             `bson:"reason"          json:"reason"`
+	CreatedBy   string             `bson:"created_by"      json:"created_by"`
+	CreateTime  int64              `bson:"create_time"     json:"create_time"`
+}
+
+func (TestCaseQuarantine) TableName() string {
+	return "test_case_quarantine"
+}
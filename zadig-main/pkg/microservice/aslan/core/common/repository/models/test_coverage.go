@@ -0,0 +1,33 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// TestCoverage 测试在代码库某个分支上最近一次的覆盖率，PR 触发的测试以此计算覆盖率变化
+type TestCoverage struct {
+	TestName     string           `bson:"test_name"      json:"test_name"`
+	RepoOwner    string           `bson:"repo_owner"     json:"repo_owner"`
+	RepoName     string           `bson:"repo_name"      json:"repo_name"`
+	Branch       string           `bson:"branch"         json:"branch"`
+	PipelineName string           `bson:"pipeline_name"  json:"pipeline_name"`
+	TaskID       int64            `bson:"task_id"        json:"task_id"`
+	Coverage     *CoverageSummary `bson:"coverage"       json:"coverage"`
+	UpdateTime   int64            `bson:"update_time"    json:"update_time"`
+}
+
+func (TestCoverage) TableName() string {
+	return "test_coverage"
+}
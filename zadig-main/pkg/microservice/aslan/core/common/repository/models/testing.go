@@ -60,6 +60,11 @@ type Testing struct {
 	CacheEnable  bool               `bson:"cache_enable"        json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"      json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
+
+	// ShardCount 大于 1 时测试会被拆分为多个分片，在多个 Pod 中并行执行
+	ShardCount int `bson:"shard_count,omitempty"     json:"shard_count,omitempty"`
+	// ShardByTiming 按照历史用例耗时切分分片，否则由测试脚本根据分片序号自行选择用例
+	ShardByTiming bool `bson:"shard_by_timing,omitempty" json:"shard_by_timing,omitempty"`
 }
 
 type TestingHookCtrl struct {
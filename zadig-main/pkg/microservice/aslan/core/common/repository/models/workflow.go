@@ -48,6 +48,8 @@ type Workflow struct {
 	SecurityStage   *SecurityStage     `bson:"security_stage"               json:"security_stage"`
 	DistributeStage *DistributeStage   `bson:"distribute_stage"             json:"distribute_stage"`
 	ExtensionStage  *ExtensionStage    `bson:"extension_stage"              json:"extension_stage"`
+	PluginStage     *PluginStage       `bson:"plugin_stage,omitempty"       json:"plugin_stage,omitempty"`
+	ApprovalStage   *ApprovalStage     `bson:"approval_stage,omitempty"     json:"approval_stage,omitempty"`
 	NotifyCtl       *NotifyCtl         `bson:"notify_ctl,omitempty"         json:"notify_ctl,omitempty"`
 	HookCtl         *WorkflowHookCtrl  `bson:"hook_ctl"                     json:"hook_ctl"`
 	BaseName        string             `bson:"base_name" json:"base_name"`
@@ -55,8 +57,26 @@ type Workflow struct {
 	// ResetImage indicate whether reset image to original version after completion
 	ResetImage       bool                         `bson:"reset_image"                  json:"reset_image"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy,omitempty" json:"reset_image_policy,omitempty"`
+	// AutoRollback 部署或测试阶段失败时，将服务回滚到最近一次成功发布的版本
+	AutoRollback bool `bson:"auto_rollback"                json:"auto_rollback"`
+	// VerifyImageSignature 部署和分发镜像前校验镜像签名，未被签名密钥库中的密钥签名的镜像将被拒绝
+	VerifyImageSignature bool `bson:"verify_image_signature"       json:"verify_image_signature"`
 	// IsParallel 控制单一工作流的任务是否支持并行处理
 	IsParallel bool `json:"is_parallel" bson:"is_parallel"`
+	// CodeSource 工作流通过代码仓库中的文件导入时记录文件来源
+	CodeSource *WorkflowCodeSource `bson:"code_source,omitempty"        json:"code_source,omitempty"`
+}
+
+// WorkflowCodeSource 声明式工作流文件在代码仓库中的位置
+type WorkflowCodeSource struct {
+	CodehostID int    `bson:"codehost_id"              json:"codehost_id"`
+	RepoOwner  string `bson:"repo_owner"               json:"repo_owner"`
+	RepoName   string `bson:"repo_name"                json:"repo_name"`
+	Branch     string `bson:"branch"                   json:"branch"`
+	Path       string `bson:"path"                     json:"path"`
+	// Checksum 最近一次导入的文件内容的 sha256
+	Checksum string `bson:"checksum"                 json:"checksum"`
+	SyncTime int64  `bson:"sync_time"                json:"sync_time"`
 }
 
 type WorkflowHookCtrl struct {
@@ -247,10 +267,32 @@ type TestStage struct {
 type TestExecArgs struct {
 	Name string    `bson:"test_name"             json:"test_name"`
 	Envs []*KeyVal `bson:"envs"           json:"envs"`
+	// ShardCount 覆盖测试模块中配置的分片数量
+	ShardCount int `bson:"shard_count,omitempty" json:"shard_count,omitempty"`
 }
 
 type SecurityStage struct {
 	Enabled bool `bson:"enabled"                    json:"enabled"`
+	// Scanner 镜像扫描后端，为空时使用 clair
+	Scanner string `bson:"scanner,omitempty"          json:"scanner,omitempty"`
+	// Policy 漏洞门禁策略，为空时扫描结果仅作展示
+	Policy *SecurityPolicy `bson:"policy,omitempty"           json:"policy,omitempty"`
+}
+
+// SecurityPolicy 漏洞门禁策略，MaxCritical 和 MaxHigh 为允许的漏洞数上限，负数表示不限制
+type SecurityPolicy struct {
+	Action      string              `bson:"action"                json:"action"`
+	MaxCritical int                 `bson:"max_critical"          json:"max_critical"`
+	MaxHigh     int                 `bson:"max_high"              json:"max_high"`
+	FixableOnly bool                `bson:"fixable_only"          json:"fixable_only"`
+	Allowlist   []*CVEAllowlistItem `bson:"allowlist,omitempty"   json:"allowlist,omitempty"`
+}
+
+// CVEAllowlistItem 豁免的漏洞，ExpireTime 为 0 时永久豁免
+type CVEAllowlistItem struct {
+	CVE        string `bson:"cve"                   json:"cve"`
+	ExpireTime int64  `bson:"expire_time"           json:"expire_time"`
+	Reason     string `bson:"reason"                json:"reason"`
 }
 
 type DistributeStage struct {
@@ -273,6 +315,43 @@ type ExtensionStage struct {
 	Headers    []*KeyVal `bson:"headers"              json:"headers"`
 }
 
+// PluginStage runs the registered external task plugins after the extension stage
+type PluginStage struct {
+	Enabled bool                `bson:"enabled"              json:"enabled"`
+	Plugins []*PluginModuleArgs `bson:"plugins"              json:"plugins"`
+}
+
+type PluginModuleArgs struct {
+	Name   string    `bson:"name"                 json:"name"`
+	Params []*KeyVal `bson:"params"               json:"params"`
+}
+
+// ApprovalStage blocks the workflow before deploying until the approvers accept it
+type ApprovalStage struct {
+	Enabled     bool   `bson:"enabled"              json:"enabled"`
+	Description string `bson:"description"          json:"description"`
+	// Timeout 审批超时时间，单位分钟
+	Timeout int `bson:"timeout"              json:"timeout"`
+	// NeededApprovers 通过审批需要的同意人数，为0时需要一人同意
+	NeededApprovers int         `bson:"needed_approvers"     json:"needed_approvers"`
+	Approvers       []*Approver `bson:"approvers"            json:"approvers"`
+}
+
+type ApproverType string
+
+const (
+	ApproverTypeUser ApproverType = "user"
+	ApproverTypeRole ApproverType = "role"
+)
+
+// Approver is either a user or a project role defined in the policy service
+type Approver struct {
+	Type ApproverType `bson:"type"                 json:"type"`
+	// Name is the user name for user approvers and the role name for role approvers
+	Name string `bson:"name"                 json:"name"`
+	UID  string `bson:"uid,omitempty"        json:"uid,omitempty"`
+}
+
 type RepoImage struct {
 	RepoID    string `json:"repo_id" bson:"repo_id"`
 	Name      string `json:"name" bson:"name" yaml:"name"`
@@ -298,6 +377,16 @@ type NotifyCtl struct {
 	AtMobiles       []string `bson:"at_mobiles,omitempty"             json:"at_mobiles,omitempty"`
 	IsAtAll         bool     `bson:"is_at_all,omitempty"              json:"is_at_all,omitempty"`
 	NotifyTypes     []string `bson:"notify_type"                      json:"notify_type"`
+	// Slack、Teams 以及通用 webhook 通知渠道
+	SlackWebHook   string          `bson:"slack_webhook,omitempty"          json:"slack_webhook,omitempty"`
+	TeamsWebHook   string          `bson:"teams_webhook,omitempty"          json:"teams_webhook,omitempty"`
+	GenericWebHook *GenericWebHook `bson:"generic_webhook,omitempty"        json:"generic_webhook,omitempty"`
+}
+
+// GenericWebHook 以 JSON 格式推送通知，配置 Secret 时会对请求体进行 HMAC-SHA256 签名
+type GenericWebHook struct {
+	Address string `bson:"address"                          json:"address"`
+	Secret  string `bson:"secret,omitempty"                 json:"secret,omitempty"`
 }
 
 type TaskInfo struct {
@@ -319,6 +408,28 @@ type DeployArgs struct {
 	// 格式: {service name}-{timestamp}-{suffix}}.tar.gz
 	// timestamp format: 20060102150405
 	PackageFile string `json:"package_file"`
+	// 部署策略
+	Strategy *DeployStrategy `json:"strategy,omitempty"`
+}
+
+// DeployStrategy 部署策略，默认为原地滚动更新
+type DeployStrategy struct {
+	Type setting.DeployStrategyType `bson:"type"                          json:"type"`
+	// CanarySteps 灰度发布时逐步切换的流量比例
+	CanarySteps []*CanaryStep `bson:"canary_steps,omitempty"        json:"canary_steps,omitempty"`
+	// CanaryReplicas 灰度负载的副本数，默认为1
+	CanaryReplicas int32 `bson:"canary_replicas,omitempty"     json:"canary_replicas,omitempty"`
+	// IngressName 设置后通过 nginx ingress canary 注解按权重切分流量，否则按副本数比例切分 Service 流量
+	IngressName string `bson:"ingress_name,omitempty"        json:"ingress_name,omitempty"`
+	// K8sServiceName 蓝绿发布时切换 selector 的 Service，灰度发布时用于生成灰度 Service
+	K8sServiceName string `bson:"k8s_service_name,omitempty"    json:"k8s_service_name,omitempty"`
+}
+
+type CanaryStep struct {
+	// Weight 流量比例，取值 1-100
+	Weight int `bson:"weight"                        json:"weight"`
+	// Pause 当前比例验证通过后等待的时间，单位秒
+	Pause int `bson:"pause"                         json:"pause"`
 }
 
 type TestArgs struct {
@@ -326,6 +437,8 @@ type TestArgs struct {
 	TestModuleName string              `bson:"test_module_name" json:"test_module_name"`
 	Envs           []*KeyVal           `bson:"envs" json:"envs"`
 	Builds         []*types.Repository `bson:"builds" json:"builds"`
+	// ShardCount 覆盖测试模块中配置的分片数量
+	ShardCount int `bson:"shard_count,omitempty" json:"shard_count,omitempty"`
 }
 
 type HookPayload struct {
@@ -350,6 +463,8 @@ type TargetArgs struct {
 	Envs             []*KeyVal         `bson:"envs"                      json:"envs"`
 	HasBuild         bool              `bson:"has_build"                 json:"has_build"`
 	JenkinsBuildArgs *JenkinsBuildArgs `bson:"jenkins_build_args"        json:"jenkins_build_args"`
+	// DeployStrategy 该服务部署任务使用的部署策略
+	DeployStrategy *DeployStrategy `bson:"deploy_strategy,omitempty" json:"deploy_strategy,omitempty"`
 }
 
 type JenkinsBuildArgs struct {
@@ -376,6 +491,8 @@ type ArtifactArgs struct {
 	TaskID       int64       `bson:"task_id,omitempty"                   json:"task_id,omitempty"`
 	FileName     string      `bson:"file_name,omitempty"                 json:"file_name,omitempty"`
 	URL          string      `bson:"url,omitempty"                       json:"url,omitempty"`
+	// DeployStrategy 该服务部署任务使用的部署策略
+	DeployStrategy *DeployStrategy `bson:"deploy_strategy,omitempty"           json:"deploy_strategy,omitempty"`
 }
 
 type VersionArgs struct {
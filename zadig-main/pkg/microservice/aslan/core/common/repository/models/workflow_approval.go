@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// WorkflowApproval records the approval request raised by the approval stage of a workflow task
+type WorkflowApproval struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty"          json:"id,omitempty"`
+	PipelineName    string              `bson:"pipeline_name"          json:"pipeline_name"`
+	ProjectName     string              `bson:"project_name"           json:"project_name"`
+	TaskID          int64               `bson:"task_id"                json:"task_id"`
+	TaskCreator     string              `bson:"task_creator"           json:"task_creator"`
+	Description     string              `bson:"description"            json:"description"`
+	Approvers       []*Approver         `bson:"approvers"              json:"approvers"`
+	NeededApprovers int                 `bson:"needed_approvers"       json:"needed_approvers"`
+	Decisions       []*ApprovalDecision `bson:"decisions"              json:"decisions"`
+	Status          config.Status       `bson:"status"                 json:"status"`
+	// Timeout 审批超时时间，单位分钟
+	Timeout    int   `bson:"timeout"                json:"timeout"`
+	CreateTime int64 `bson:"create_time"            json:"create_time"`
+	UpdateTime int64 `bson:"update_time"            json:"update_time"`
+}
+
+type ApprovalDecision struct {
+	UserID   string `bson:"user_id"                json:"user_id"`
+	UserName string `bson:"user_name"              json:"user_name"`
+	Approved bool   `bson:"approved"               json:"approved"`
+	Comment  string `bson:"comment"                json:"comment"`
+	Time     int64  `bson:"time"                   json:"time"`
+}
+
+func (WorkflowApproval) TableName() string {
+	return "workflow_approval"
+}
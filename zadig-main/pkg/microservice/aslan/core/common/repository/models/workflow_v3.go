@@ -32,6 +32,7 @@ type WorkflowV3 struct {
 	CreateTime  int64                    `bson:"create_time"    json:"create_time"`
 	UpdatedBy   string                   `bson:"updated_by"     json:"updated_by"`
 	UpdateTime  int64                    `bson:"update_time"    json:"update_time"`
+	CodeSource  *WorkflowCodeSource      `bson:"code_source,omitempty" json:"code_source,omitempty"`
 }
 
 type ParameterSettingType string
@@ -41,6 +41,7 @@ type BuildListOption struct {
 	BuildOS      string
 	BasicImageID string
 	PrivateKeyID string
+	SigningKeyID string
 }
 
 // FindOption ...
@@ -142,6 +143,9 @@ func (c *BuildColl) List(opt *BuildListOption) ([]*models.Build, error) {
 	if len(opt.PrivateKeyID) != 0 {
 		query["ssh.id"] = opt.PrivateKeyID
 	}
+	if len(opt.SigningKeyID) != 0 {
+		query["post_build.docker_build.signing_key_id"] = opt.SigningKeyID
+	}
 
 	var resp []*models.Build
 	ctx := context.Background()
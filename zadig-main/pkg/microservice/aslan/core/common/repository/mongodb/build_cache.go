@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type BuildCacheColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewBuildCacheColl() *BuildCacheColl {
+	name := models.BuildCache{}.TableName()
+	return &BuildCacheColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *BuildCacheColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *BuildCacheColl) EnsureIndex(ctx context.Context) error {
+	mods := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"key": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"update_time": -1},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+	_, err := c.Indexes().CreateMany(ctx, mods)
+	return err
+}
+
+// Upsert 同一个 Key 只保留最新一次编译的结果
+func (c *BuildCacheColl) Upsert(args *models.BuildCache) error {
+	if args == nil {
+		return errors.New("nil build cache args")
+	}
+
+	now := time.Now().Unix()
+	query := bson.M{"key": args.Key}
+	change := bson.M{
+		"$set": bson.M{
+			"product_name":  args.ProductName,
+			"service":       args.Service,
+			"service_name":  args.ServiceName,
+			"pipeline_name": args.PipelineName,
+			"task_id":       args.TaskID,
+			"image":         args.Image,
+			"package_file":  args.PackageFile,
+			"update_time":   now,
+		},
+		"$setOnInsert": bson.M{
+			"hit_count":   0,
+			"create_time": now,
+		},
+	}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *BuildCacheColl) Find(key string) (*models.BuildCache, error) {
+	resp := new(models.BuildCache)
+	err := c.FindOne(context.TODO(), bson.M{"key": key}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Hit 记录一次缓存命中，同时刷新缓存的留存时间
+func (c *BuildCacheColl) Hit(key string) error {
+	query := bson.M{"key": key}
+	change := bson.M{
+		"$inc": bson.M{"hit_count": 1},
+		"$set": bson.M{"update_time": time.Now().Unix()},
+	}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+// DeleteBefore 删除在 updateTime 之前没有写入或命中过的缓存
+func (c *BuildCacheColl) DeleteBefore(updateTime int64) (int64, error) {
+	res, err := c.DeleteMany(context.TODO(), bson.M{"update_time": bson.M{"$lt": updateTime}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// DeleteExceeded 只保留最近使用的 maxItems 条缓存
+func (c *BuildCacheColl) DeleteExceeded(maxItems int) (int64, error) {
+	opt := options.Find().
+		SetSort(bson.D{{"update_time", -1}, {"_id", -1}}).
+		SetSkip(int64(maxItems)).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := c.Collection.Find(context.TODO(), bson.M{}, opt)
+	if err != nil {
+		return 0, err
+	}
+	caches := make([]*models.BuildCache, 0)
+	if err := cursor.All(context.TODO(), &caches); err != nil {
+		return 0, err
+	}
+	if len(caches) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]interface{}, 0, len(caches))
+	for _, cache := range caches {
+		ids = append(ids, cache.ID)
+	}
+	res, err := c.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvDriftColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvDriftColl() *EnvDriftColl {
+	name := models.EnvDrift{}.TableName()
+	return &EnvDriftColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *EnvDriftColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvDriftColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvDriftColl) Find(productName, envName string) (*models.EnvDrift, error) {
+	res := &models.EnvDrift{}
+	err := c.FindOne(context.TODO(), bson.M{"product_name": productName, "env_name": envName}).Decode(res)
+
+	return res, err
+}
+
+// Upsert 每个环境只保留最近一次的检测结果
+func (c *EnvDriftColl) Upsert(args *models.EnvDrift) error {
+	args.CheckTime = time.Now().Unix()
+	query := bson.M{"product_name": args.ProductName, "env_name": args.EnvName}
+	change := bson.M{"$set": bson.M{
+		"drifted":    args.Drifted,
+		"resources":  args.Resources,
+		"error":      args.Error,
+		"check_time": args.CheckTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+
+	return err
+}
+
+func (c *EnvDriftColl) Delete(productName, envName string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"product_name": productName, "env_name": envName})
+
+	return err
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvSnapshotListOption struct {
+	ProductName string
+	EnvName     string
+}
+
+type EnvSnapshotColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvSnapshotColl() *EnvSnapshotColl {
+	name := models.EnvSnapshot{}.TableName()
+	return &EnvSnapshotColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *EnvSnapshotColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvSnapshotColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *EnvSnapshotColl) Create(args *models.EnvSnapshot) error {
+	args.ID = primitive.NewObjectID()
+	args.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), args)
+
+	return err
+}
+
+func (c *EnvSnapshotColl) Find(id string) (*models.EnvSnapshot, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.EnvSnapshot{}
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(res)
+
+	return res, err
+}
+
+// List 不返回快照内容，只返回快照的基本信息
+func (c *EnvSnapshotColl) List(opt *EnvSnapshotListOption) ([]*models.EnvSnapshot, error) {
+	query := bson.M{}
+	if opt.ProductName != "" {
+		query["product_name"] = opt.ProductName
+	}
+	if opt.EnvName != "" {
+		query["env_name"] = opt.EnvName
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{"create_time", -1}}).
+		SetProjection(bson.M{"product": 0, "render_set": 0, "services": 0, "images": 0})
+
+	res := make([]*models.EnvSnapshot, 0)
+	cursor, err := c.Collection.Find(context.TODO(), query, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &res)
+
+	return res, err
+}
+
+func (c *EnvSnapshotColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+
+	return err
+}
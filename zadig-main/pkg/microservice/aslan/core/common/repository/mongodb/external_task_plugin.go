@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ExternalTaskPluginColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewExternalTaskPluginColl() *ExternalTaskPluginColl {
+	name := models.ExternalTaskPlugin{}.TableName()
+	return &ExternalTaskPluginColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ExternalTaskPluginColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ExternalTaskPluginColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.M{"name": 1},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *ExternalTaskPluginColl) Create(args *models.ExternalTaskPlugin) error {
+	if args == nil {
+		return errors.New("nil external task plugin args")
+	}
+
+	args.CreatedAt = time.Now().Unix()
+	args.UpdatedAt = time.Now().Unix()
+
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *ExternalTaskPluginColl) Find(name string) (*models.ExternalTaskPlugin, error) {
+	resp := new(models.ExternalTaskPlugin)
+	err := c.FindOne(context.TODO(), bson.M{"name": name}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ExternalTaskPluginColl) FindByID(id string) (*models.ExternalTaskPlugin, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.ExternalTaskPlugin)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ExternalTaskPluginColl) List() ([]*models.ExternalTaskPlugin, error) {
+	resp := make([]*models.ExternalTaskPlugin, 0)
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{"created_at", -1}})
+
+	cursor, err := c.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *ExternalTaskPluginColl) Update(id string, args *models.ExternalTaskPlugin) error {
+	if args == nil {
+		return errors.New("nil external task plugin args")
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": oid}
+	change := bson.M{"$set": bson.M{
+		"name":        args.Name,
+		"description": args.Description,
+		"address":     args.Address,
+		"headers":     args.Headers,
+		"params":      args.Params,
+		"timeout":     args.Timeout,
+		"update_by":   args.UpdateBy,
+		"updated_at":  time.Now().Unix(),
+	}}
+
+	_, err = c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ExternalTaskPluginColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type NotificationSubscriptionListOption struct {
+	UserID      string
+	Event       config.NotificationEvent
+	EnabledOnly bool
+}
+
+type NotificationSubscriptionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewNotificationSubscriptionColl() *NotificationSubscriptionColl {
+	name := models.NotificationSubscription{}.TableName()
+	return &NotificationSubscriptionColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *NotificationSubscriptionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *NotificationSubscriptionColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.M{"user_id": 1},
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "events", Value: 1},
+				bson.E{Key: "enabled", Value: 1},
+			},
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *NotificationSubscriptionColl) Create(args *models.NotificationSubscription) error {
+	args.ID = primitive.NewObjectID()
+	args.CreateTime = time.Now().Unix()
+	args.UpdateTime = args.CreateTime
+	_, err := c.InsertOne(context.TODO(), args)
+
+	return err
+}
+
+func (c *NotificationSubscriptionColl) Find(id string) (*models.NotificationSubscription, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.NotificationSubscription{}
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(res)
+
+	return res, err
+}
+
+func (c *NotificationSubscriptionColl) Update(id string, args *models.NotificationSubscription) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	args.ID = oid
+	args.UpdateTime = time.Now().Unix()
+	_, err = c.ReplaceOne(context.TODO(), bson.M{"_id": oid}, args)
+
+	return err
+}
+
+func (c *NotificationSubscriptionColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+
+	return err
+}
+
+func (c *NotificationSubscriptionColl) List(opt *NotificationSubscriptionListOption) ([]*models.NotificationSubscription, error) {
+	query := bson.M{}
+	if opt.UserID != "" {
+		query["user_id"] = opt.UserID
+	}
+	if opt.Event != "" {
+		query["events"] = opt.Event
+	}
+	if opt.EnabledOnly {
+		query["enabled"] = true
+	}
+
+	res := make([]*models.NotificationSubscription, 0)
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &res)
+
+	return res, err
+}
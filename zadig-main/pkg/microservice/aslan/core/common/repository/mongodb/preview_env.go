@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type PreviewEnvFindOption struct {
+	ProductName  string
+	WorkflowName string
+	Source       string
+	RepoOwner    string
+	RepoName     string
+	PrID         int
+}
+
+type PreviewEnvListOption struct {
+	ProductName string
+	Source      string
+	RepoOwner   string
+	RepoName    string
+	PrID        int
+}
+
+type PreviewEnvColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewPreviewEnvColl() *PreviewEnvColl {
+	name := models.PreviewEnv{}.TableName()
+	return &PreviewEnvColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *PreviewEnvColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *PreviewEnvColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "workflow_name", Value: 1},
+				bson.E{Key: "source", Value: 1},
+				bson.E{Key: "repo_owner", Value: 1},
+				bson.E{Key: "repo_name", Value: 1},
+				bson.E{Key: "pr_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+			},
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+
+	return err
+}
+
+func (c *PreviewEnvColl) Create(args *models.PreviewEnv) error {
+	args.ID = primitive.NewObjectID()
+	args.CreateTime = time.Now().Unix()
+	args.UpdateTime = args.CreateTime
+	_, err := c.InsertOne(context.TODO(), args)
+
+	return err
+}
+
+func (c *PreviewEnvColl) Find(opt *PreviewEnvFindOption) (*models.PreviewEnv, error) {
+	query := bson.M{
+		"product_name":  opt.ProductName,
+		"workflow_name": opt.WorkflowName,
+		"source":        opt.Source,
+		"repo_owner":    opt.RepoOwner,
+		"repo_name":     opt.RepoName,
+		"pr_id":         opt.PrID,
+	}
+
+	res := &models.PreviewEnv{}
+	err := c.FindOne(context.TODO(), query).Decode(res)
+
+	return res, err
+}
+
+func (c *PreviewEnvColl) List(opt *PreviewEnvListOption) ([]*models.PreviewEnv, error) {
+	query := bson.M{}
+	if opt.ProductName != "" {
+		query["product_name"] = opt.ProductName
+	}
+	if opt.Source != "" {
+		query["source"] = opt.Source
+	}
+	if opt.RepoOwner != "" {
+		query["repo_owner"] = opt.RepoOwner
+	}
+	if opt.RepoName != "" {
+		query["repo_name"] = opt.RepoName
+	}
+	if opt.PrID != 0 {
+		query["pr_id"] = opt.PrID
+	}
+
+	res := make([]*models.PreviewEnv, 0)
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &res)
+
+	return res, err
+}
+
+func (c *PreviewEnvColl) Update(args *models.PreviewEnv) error {
+	args.UpdateTime = time.Now().Unix()
+	_, err := c.ReplaceOne(context.TODO(), bson.M{"_id": args.ID}, args)
+
+	return err
+}
+
+func (c *PreviewEnvColl) Delete(id primitive.ObjectID) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"_id": id})
+
+	return err
+}
@@ -392,6 +392,63 @@ func (c *ProductColl) UpdateProductRecycleDay(envName, productName string, recyc
 	return err
 }
 
+func (c *ProductColl) UpdateSleepSchedule(envName, productName string, schedule *models.EnvSleepSchedule) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"sleep_schedule": schedule,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateQuotaSetting(envName, productName string, setting *models.EnvQuotaSetting) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"quota_setting": setting,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateSleepState(envName, productName string, state *models.EnvSleepState) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"sleep_state": state,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+// UpdateExpireTime 更新到期时间的同时重置到期提醒
+func (c *ProductColl) UpdateExpireTime(envName, productName string, expireTime int64) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"expire_time":   expireTime,
+		"expire_warned": false,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateExpireWarned(envName, productName string, warned bool) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"expire_warned": warned,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
 func (c *ProductColl) UpdateIsPublic(envName, productName string, isPublic bool) error {
 	query := bson.M{"env_name": envName, "product_name": productName}
 	change := bson.M{"$set": bson.M{
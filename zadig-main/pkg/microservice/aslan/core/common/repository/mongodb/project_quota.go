@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ProjectQuotaColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewProjectQuotaColl() *ProjectQuotaColl {
+	name := models.ProjectQuota{}.TableName()
+	return &ProjectQuotaColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *ProjectQuotaColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ProjectQuotaColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.M{"product_name": 1},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *ProjectQuotaColl) Find(productName string) (*models.ProjectQuota, error) {
+	res := &models.ProjectQuota{}
+	err := c.FindOne(context.TODO(), bson.M{"product_name": productName}).Decode(res)
+
+	return res, err
+}
+
+func (c *ProjectQuotaColl) Upsert(args *models.ProjectQuota) error {
+	args.UpdateTime = time.Now().Unix()
+	query := bson.M{"product_name": args.ProductName}
+	change := bson.M{"$set": bson.M{
+		"env_quota":   args.EnvQuota,
+		"price":       args.Price,
+		"update_by":   args.UpdateBy,
+		"update_time": args.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+
+	return err
+}
+
+func (c *ProjectQuotaColl) Delete(productName string) error {
+	_, err := c.DeleteOne(context.TODO(), bson.M{"product_name": productName})
+
+	return err
+}
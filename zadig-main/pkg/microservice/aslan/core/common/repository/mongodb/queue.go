@@ -128,12 +128,19 @@ func (c *QueueColl) Update(args *models.Queue) error {
 	return err
 }
 
+// UpdateAgent claims the queued task for the agent, it fails if the task has been claimed by another instance
 func (c *QueueColl) UpdateAgent(taskID int64, pipelineName string, createTime int64, agentID string) error {
 	query := bson.M{"task_id": taskID, "pipeline_name": pipelineName, "create_time": createTime, "agent_id": ""}
 	change := bson.M{"$set": bson.M{
 		"agent_id": agentID,
 	}}
 
-	_, err := c.UpdateOne(context.TODO(), query, change)
-	return err
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("task has been claimed by another agent")
+	}
+	return nil
 }
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ServiceReleaseListOption struct {
+	ProductName string
+	EnvName     string
+	ServiceName string
+	Limit       int
+}
+
+type ServiceReleaseColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewServiceReleaseColl() *ServiceReleaseColl {
+	name := models.ServiceRelease{}.TableName()
+	return &ServiceReleaseColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ServiceReleaseColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ServiceReleaseColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "service_name", Value: 1},
+			bson.E{Key: "create_time", Value: -1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *ServiceReleaseColl) Create(args *models.ServiceRelease) error {
+	if args == nil {
+		return errors.New("nil service release args")
+	}
+
+	args.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+// FindLatest 获取服务在环境中最近一次成功发布的记录
+func (c *ServiceReleaseColl) FindLatest(productName, envName, serviceName string) (*models.ServiceRelease, error) {
+	resp := new(models.ServiceRelease)
+	query := bson.M{"product_name": productName, "env_name": envName, "service_name": serviceName}
+	opt := options.FindOne().SetSort(bson.D{{"create_time", -1}, {"_id", -1}})
+	err := c.FindOne(context.TODO(), query, opt).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *ServiceReleaseColl) List(opt *ServiceReleaseListOption) ([]*models.ServiceRelease, error) {
+	if opt == nil {
+		return nil, errors.New("nil list option")
+	}
+
+	query := bson.M{"product_name": opt.ProductName, "env_name": opt.EnvName}
+	if opt.ServiceName != "" {
+		query["service_name"] = opt.ServiceName
+	}
+	findOpt := options.Find().SetSort(bson.D{{"create_time", -1}, {"_id", -1}})
+	if opt.Limit > 0 {
+		findOpt.SetLimit(int64(opt.Limit))
+	}
+
+	resp := make([]*models.ServiceRelease, 0)
+	cursor, err := c.Find(context.TODO(), query, findOpt)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(context.TODO(), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
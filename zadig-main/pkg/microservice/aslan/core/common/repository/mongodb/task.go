@@ -88,6 +88,7 @@ type ListAllTaskOption struct {
 	Type            config.PipelineType
 	CreateTime      int64
 	BeforeCreatTime bool
+	EndTime         int64
 	Limit           int
 	Skip            int
 }
@@ -537,6 +538,9 @@ func (c *TaskColl) ListAllTasks(option *ListAllTaskOption) ([]*task.Task, error)
 		}
 		query["create_time"] = bson.M{comparison: option.CreateTime}
 	}
+	if option.EndTime > 0 {
+		query["end_time"] = bson.M{"$gte": option.EndTime}
+	}
 	opt := &options.FindOptions{}
 	if option.Limit != 0 {
 		projection := bson.D{
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type TestCaseResultColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+type ListTestCaseResultOption struct {
+	ProductName string
+	TestName    string
+	// Since 只查询该时间之后的结果
+	Since int64
+}
+
+func NewTestCaseResultColl() *TestCaseResultColl {
+	name := models.TestCaseResult{}.TableName()
+	return &TestCaseResultColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *TestCaseResultColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TestCaseResultColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "product_name", Value: 1},
+				bson.E{Key: "create_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "test_name", Value: 1},
+				bson.E{Key: "create_time", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *TestCaseResultColl) BulkCreate(args []*models.TestCaseResult) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	var docs []interface{}
+	for _, arg := range args {
+		docs = append(docs, arg)
+	}
+	_, err := c.InsertMany(context.TODO(), docs)
+	return err
+}
+
+func (c *TestCaseResultColl) List(opt *ListTestCaseResultOption) ([]*models.TestCaseResult, error) {
+	query := bson.M{}
+	if opt.ProductName != "" {
+		query["product_name"] = opt.ProductName
+	}
+	if opt.TestName != "" {
+		query["test_name"] = opt.TestName
+	}
+	if opt.Since > 0 {
+		query["create_time"] = bson.M{"$gte": opt.Since}
+	}
+
+	resp := make([]*models.TestCaseResult, 0)
+	ctx := context.Background()
+	cursor, err := c.Collection.Find(ctx, query, options.Find().SetSort(bson.M{"create_time": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	return resp, err
+}
+
+type TestCaseQuarantineColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewTestCaseQuarantineColl() *TestCaseQuarantineColl {
+	name := models.TestCaseQuarantine{}.TableName()
+	return &TestCaseQuarantineColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *TestCaseQuarantineColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TestCaseQuarantineColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "test_name", Value: 1},
+			bson.E{Key: "class_name", Value: 1},
+			bson.E{Key: "case_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *TestCaseQuarantineColl) List(productName, testName string) ([]*models.TestCaseQuarantine, error) {
+	query := bson.M{}
+	if productName != "" {
+		query["product_name"] = productName
+	}
+	if testName != "" {
+		query["test_name"] = testName
+	}
+
+	resp := make([]*models.TestCaseQuarantine, 0)
+	ctx := context.Background()
+	cursor, err := c.Collection.Find(ctx, query, options.Find().SetSort(bson.M{"create_time": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	return resp, err
+}
+
+func (c *TestCaseQuarantineColl) Find(id string) (*models.TestCaseQuarantine, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.TestCaseQuarantine)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *TestCaseQuarantineColl) Create(args *models.TestCaseQuarantine) error {
+	if args == nil {
+		return errors.New("nil TestCaseQuarantine info")
+	}
+
+	args.CreateTime = time.Now().Unix()
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *TestCaseQuarantineColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type TestCoverageColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewTestCoverageColl() *TestCoverageColl {
+	name := models.TestCoverage{}.TableName()
+	return &TestCoverageColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *TestCoverageColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TestCoverageColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "test_name", Value: 1},
+			bson.E{Key: "repo_owner", Value: 1},
+			bson.E{Key: "repo_name", Value: 1},
+			bson.E{Key: "branch", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *TestCoverageColl) Find(testName, repoOwner, repoName, branch string) (*models.TestCoverage, error) {
+	query := bson.M{"test_name": testName, "repo_owner": repoOwner, "repo_name": repoName, "branch": branch}
+	res := new(models.TestCoverage)
+	if err := c.FindOne(context.TODO(), query).Decode(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Upsert 只保留每个分支最近一次的覆盖率
+func (c *TestCoverageColl) Upsert(args *models.TestCoverage) error {
+	query := bson.M{"test_name": args.TestName, "repo_owner": args.RepoOwner, "repo_name": args.RepoName, "branch": args.Branch}
+	args.UpdateTime = time.Now().Unix()
+	_, err := c.UpdateOne(context.TODO(), query, bson.M{"$set": args}, options.Update().SetUpsert(true))
+	return err
+}
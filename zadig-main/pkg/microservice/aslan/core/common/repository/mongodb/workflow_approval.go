@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type WorkflowApprovalColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewWorkflowApprovalColl() *WorkflowApprovalColl {
+	name := models.WorkflowApproval{}.TableName()
+	return &WorkflowApprovalColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *WorkflowApprovalColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *WorkflowApprovalColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "pipeline_name", Value: 1},
+			bson.E{Key: "task_id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+// Create 仅在审批记录不存在时创建，已有的审批结果和超时时间不会被覆盖，返回是否新建了记录
+func (c *WorkflowApprovalColl) Create(args *models.WorkflowApproval) (bool, error) {
+	if args == nil {
+		return false, errors.New("nil workflow approval args")
+	}
+
+	args.ID = primitive.NilObjectID
+	if args.Decisions == nil {
+		args.Decisions = make([]*models.ApprovalDecision, 0)
+	}
+	args.CreateTime = time.Now().Unix()
+	args.UpdateTime = time.Now().Unix()
+
+	query := bson.M{"pipeline_name": args.PipelineName, "task_id": args.TaskID}
+	change := bson.M{"$setOnInsert": args}
+	res, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// Delete 重启任务时删除上一次运行的审批记录
+func (c *WorkflowApprovalColl) Delete(pipelineName string, taskID int64) error {
+	query := bson.M{"pipeline_name": pipelineName, "task_id": taskID}
+	_, err := c.DeleteOne(context.TODO(), query)
+	return err
+}
+
+func (c *WorkflowApprovalColl) Find(pipelineName string, taskID int64) (*models.WorkflowApproval, error) {
+	resp := new(models.WorkflowApproval)
+	query := bson.M{"pipeline_name": pipelineName, "task_id": taskID}
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddDecision appends the decision only if the approval is still waiting and the user has not decided yet,
+// it returns false if nothing is changed
+func (c *WorkflowApprovalColl) AddDecision(pipelineName string, taskID int64, decision *models.ApprovalDecision) (bool, error) {
+	if decision == nil {
+		return false, errors.New("nil approval decision")
+	}
+
+	query := bson.M{
+		"pipeline_name":       pipelineName,
+		"task_id":             taskID,
+		"status":              config.StatusWaiting,
+		"decisions.user_name": bson.M{"$ne": decision.UserName},
+	}
+	change := bson.M{
+		"$push": bson.M{"decisions": decision},
+		"$set":  bson.M{"update_time": time.Now().Unix()},
+	}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (c *WorkflowApprovalColl) UpdateStatus(pipelineName string, taskID int64, status config.Status) error {
+	query := bson.M{"pipeline_name": pipelineName, "task_id": taskID, "status": config.StatusWaiting}
+	change := bson.M{"$set": bson.M{
+		"status":      status,
+		"update_time": time.Now().Unix(),
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
@@ -29,6 +29,7 @@ import (
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
 	s3service "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
@@ -122,6 +123,7 @@ func InsertDeliveryVersion(args *commonmodels.DeliveryVersion, log *zap.SugaredL
 		log.Errorf("insert deliveryVersion error: %v", err)
 		return e.ErrCreateDeliveryVersion
 	}
+	notification.Dispatch(notification.NewDeliveryVersionCreatedEvent(args))
 	return nil
 }
 
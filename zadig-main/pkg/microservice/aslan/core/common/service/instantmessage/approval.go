@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const approvalTitle = "工作流待审批"
+
+// SendApprovalMessage notifies the approvers of a waiting approval through the IM channel configured in the workflow
+func (w *Service) SendApprovalMessage(approval *models.WorkflowApproval) error {
+	resp, err := w.workflowColl.Find(approval.PipelineName)
+	if err != nil {
+		log.Errorf("Workflow find err :%s", err)
+		return err
+	}
+	if resp.NotifyCtl == nil || !resp.NotifyCtl.Enabled {
+		log.Infof("Workflow notifyCtl is not enabled!")
+		return nil
+	}
+
+	content := createApprovalBody(approval, resp.NotifyCtl.WebHookType)
+	switch resp.NotifyCtl.WebHookType {
+	case slackType, teamsType, genericWebHookType:
+		err = w.SendNotifyMessage(resp.NotifyCtl, NewApprovalNotifyMessage(approval))
+	case dingDingType:
+		err = w.sendDingDingMessage(resp.NotifyCtl.DingDingWebHook, approvalTitle, content, resp.NotifyCtl.AtMobiles)
+	case feiShuType:
+		err = w.sendFeishuMessageOfSingleType(approvalTitle, resp.NotifyCtl.FeiShuWebHook, content)
+	default:
+		err = w.SendWeChatWorkMessage(weChatTextTypeMarkdown, resp.NotifyCtl.WeChatWebHook, content)
+	}
+	if err != nil {
+		log.Errorf("send approval message of %s:%d err : %s", approval.PipelineName, approval.TaskID, err)
+		return err
+	}
+	return nil
+}
+
+func approvalURL(approval *models.WorkflowApproval) string {
+	return fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/%s/%s/%d",
+		configbase.SystemAddress(), approval.ProjectName, multiInfo, approval.PipelineName, approval.TaskID)
+}
+
+func approvalFields(approval *models.WorkflowApproval) []*NotifyField {
+	approvers := make([]string, 0, len(approval.Approvers))
+	for _, approver := range approval.Approvers {
+		if approver.Type == models.ApproverTypeRole {
+			approvers = append(approvers, fmt.Sprintf("角色 %s", approver.Name))
+			continue
+		}
+		approvers = append(approvers, approver.Name)
+	}
+	needed := approval.NeededApprovers
+	if needed <= 0 {
+		needed = 1
+	}
+
+	fields := []*NotifyField{
+		{Title: "创建人", Value: approval.TaskCreator},
+		{Title: "审批人", Value: strings.Join(approvers, ", ")},
+		{Title: "需要同意人数", Value: strconv.Itoa(needed)},
+	}
+	if approval.Timeout > 0 {
+		fields = append(fields, &NotifyField{Title: "超时时间", Value: fmt.Sprintf("%d 分钟", approval.Timeout)})
+	}
+	if approval.Description != "" {
+		fields = append(fields, &NotifyField{Title: "审批说明", Value: approval.Description})
+	}
+	return fields
+}
+
+func NewApprovalNotifyMessage(approval *models.WorkflowApproval) *NotifyMessage {
+	return &NotifyMessage{
+		Event:       NotifyEventApproval,
+		Title:       fmt.Sprintf("%s %s #%d", approvalTitle, approval.PipelineName, approval.TaskID),
+		Status:      approval.Status,
+		ProjectName: approval.ProjectName,
+		Name:        approval.PipelineName,
+		TaskID:      approval.TaskID,
+		TaskType:    config.WorkflowType,
+		Creator:     approval.TaskCreator,
+		URL:         approvalURL(approval),
+		Fields:      approvalFields(approval),
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
+func createApprovalBody(approval *models.WorkflowApproval, webHookType string) string {
+	url := approvalURL(approval)
+
+	lines := make([]string, 0)
+	if webHookType == feiShuType {
+		lines = append(lines, fmt.Sprintf("待审批的工作流: %s#%d", approval.PipelineName, approval.TaskID))
+	} else {
+		lines = append(lines, fmt.Sprintf("#### 待审批的工作流: [%s#%d](%s)", approval.PipelineName, approval.TaskID, url))
+	}
+	for _, field := range approvalFields(approval) {
+		lines = append(lines, fmt.Sprintf("- %s：%s", field.Title, field.Value))
+	}
+	if webHookType == feiShuType {
+		lines = append(lines, fmt.Sprintf("审批地址: %s", url))
+	}
+	return strings.Join(lines, " \n")
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const (
+	NotifyEventTask            = "task"
+	NotifyEventDeliveryVersion = "delivery_version"
+	NotifyEventApproval        = "approval"
+
+	notifyButtonText = "点击查看更多信息"
+)
+
+// Notifier 通知渠道，所有渠道共用同一份与渠道无关的通知内容
+type Notifier interface {
+	Send(msg *NotifyMessage) error
+}
+
+// NotifyMessage 通知内容，通用 webhook 会将其直接作为请求体
+type NotifyMessage struct {
+	Event       string              `json:"event"`
+	Title       string              `json:"title"`
+	Status      config.Status       `json:"status"`
+	ProjectName string              `json:"project_name"`
+	Name        string              `json:"name"`
+	TaskID      int64               `json:"task_id,omitempty"`
+	TaskType    config.PipelineType `json:"task_type,omitempty"`
+	Creator     string              `json:"creator"`
+	URL         string              `json:"url"`
+	Fields      []*NotifyField      `json:"fields"`
+	Timestamp   int64               `json:"timestamp"`
+}
+
+type NotifyField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// isLegacyWebHookType 钉钉、飞书和企业微信的任务通知仍然使用各自的模板
+func isLegacyWebHookType(webHookType string) bool {
+	return webHookType != slackType && webHookType != teamsType && webHookType != genericWebHookType
+}
+
+func (w *Service) newNotifier(notifyCtl *models.NotifyCtl) (Notifier, error) {
+	switch notifyCtl.WebHookType {
+	case slackType:
+		return &slackNotifier{service: w, uri: notifyCtl.SlackWebHook}, nil
+	case teamsType:
+		return &teamsNotifier{service: w, uri: notifyCtl.TeamsWebHook}, nil
+	case genericWebHookType:
+		if notifyCtl.GenericWebHook == nil || notifyCtl.GenericWebHook.Address == "" {
+			return nil, fmt.Errorf("generic webhook address is empty")
+		}
+		return &genericWebHookNotifier{service: w, address: notifyCtl.GenericWebHook.Address, secret: notifyCtl.GenericWebHook.Secret}, nil
+	case dingDingType:
+		return &dingDingNotifier{service: w, uri: notifyCtl.DingDingWebHook, atMobiles: notifyCtl.AtMobiles}, nil
+	case feiShuType:
+		return &feiShuNotifier{service: w, uri: notifyCtl.FeiShuWebHook}, nil
+	default:
+		return &weChatWorkNotifier{service: w, uri: notifyCtl.WeChatWebHook}, nil
+	}
+}
+
+// SendNotifyMessage 按照 notifyCtl 中配置的 webhook 类型发送通知
+func (w *Service) SendNotifyMessage(notifyCtl *models.NotifyCtl, msg *NotifyMessage) error {
+	notifier, err := w.newNotifier(notifyCtl)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(msg)
+}
+
+// SendDeliveryVersionMessage 版本交付结束后，按照创建版本的工作流的通知配置发送通知
+func (w *Service) SendDeliveryVersionMessage(version *models.DeliveryVersion, status config.Status) error {
+	if version.WorkflowName == "" {
+		return nil
+	}
+	resp, err := w.workflowColl.Find(version.WorkflowName)
+	if err != nil {
+		log.Errorf("Workflow find err :%s", err)
+		return err
+	}
+	if resp.NotifyCtl == nil || !resp.NotifyCtl.Enabled || !sets.NewString(resp.NotifyCtl.NotifyTypes...).Has(string(status)) {
+		return nil
+	}
+
+	return w.SendNotifyMessage(resp.NotifyCtl, newDeliveryVersionNotifyMessage(version, status))
+}
+
+func NewTaskNotifyMessage(task *task.Task, desc string) *NotifyMessage {
+	msg := &NotifyMessage{
+		Event:       NotifyEventTask,
+		Title:       fmt.Sprintf("工作流 %s #%d %s", task.PipelineName, task.TaskID, taskStatusText(task.Status)),
+		Status:      task.Status,
+		ProjectName: task.ProductName,
+		Name:        task.PipelineName,
+		TaskID:      task.TaskID,
+		TaskType:    task.Type,
+		Creator:     task.TaskCreator,
+		URL:         getTaskURL(task),
+		Timestamp:   time.Now().Unix(),
+	}
+
+	msg.AddField("执行用户", task.TaskCreator)
+	if task.Type == config.WorkflowType && task.WorkflowArgs != nil {
+		msg.AddField("环境信息", task.WorkflowArgs.Namespace)
+	}
+	if desc != "" {
+		msg.AddField("测试描述", desc)
+	}
+	msg.AddField("开始时间", time.Unix(task.StartTime, 0).Format("2006-01-02 15:04:05"))
+	msg.AddField("持续时间", (time.Duration(time.Now().Unix()-task.StartTime) * time.Second).String())
+
+	for _, stage := range task.Stages {
+		switch stage.TaskType {
+		case config.TaskBuild:
+			for _, subTask := range stage.SubTasks {
+				buildInfo, err := base.ToBuildTask(subTask)
+				if err != nil {
+					log.Errorf("parse buildInfo failed, err:%s", err)
+					continue
+				}
+				msg.AddField(fmt.Sprintf("服务 %s", buildInfo.ServiceName), buildText(buildInfo))
+			}
+		case config.TaskTestingV2:
+			for _, subTask := range stage.SubTasks {
+				testInfo, err := base.ToTestingTask(subTask)
+				if err != nil {
+					log.Errorf("parse testInfo failed, err:%s", err)
+					continue
+				}
+				msg.AddField(fmt.Sprintf("测试 %s", testInfo.TestModuleName), testResultText(testInfo, task.TestReports))
+			}
+		}
+	}
+	return msg
+}
+
+func newDeliveryVersionNotifyMessage(version *models.DeliveryVersion, status config.Status) *NotifyMessage {
+	result := "交付成功"
+	if status != config.StatusPassed {
+		result = "交付失败"
+	}
+	msg := &NotifyMessage{
+		Event:       NotifyEventDeliveryVersion,
+		Title:       fmt.Sprintf("版本 %s %s", version.Version, result),
+		Status:      status,
+		ProjectName: version.ProductName,
+		Name:        version.Version,
+		Creator:     version.CreatedBy,
+		URL:         fmt.Sprintf("%s/v1/projects/detail/%s/version", configbase.SystemAddress(), version.ProductName),
+		Timestamp:   time.Now().Unix(),
+	}
+
+	msg.AddField("创建人", version.CreatedBy)
+	msg.AddField("工作流", fmt.Sprintf("%s #%d", version.WorkflowName, version.TaskID))
+	if version.Desc != "" {
+		msg.AddField("版本描述", version.Desc)
+	}
+	if version.Error != "" {
+		msg.AddField("错误信息", version.Error)
+	}
+	return msg
+}
+
+func (m *NotifyMessage) AddField(title, value string) {
+	m.Fields = append(m.Fields, &NotifyField{Title: title, Value: value})
+}
+
+// markdown 钉钉和企业微信使用的 markdown 格式
+func (m *NotifyMessage) markdown() string {
+	lines := []string{fmt.Sprintf("#### %s", m.Title)}
+	for _, field := range m.Fields {
+		lines = append(lines, fmt.Sprintf("- **%s**：%s", field.Title, field.Value))
+	}
+	lines = append(lines, fmt.Sprintf("[%s](%s)", notifyButtonText, m.URL))
+	return strings.Join(lines, " \n")
+}
+
+// Text 飞书和站内信使用的纯文本格式
+func (m *NotifyMessage) Text() string {
+	lines := make([]string, 0, len(m.Fields)+1)
+	for _, field := range m.Fields {
+		lines = append(lines, fmt.Sprintf("%s：%s", field.Title, field.Value))
+	}
+	lines = append(lines, m.URL)
+	return strings.Join(lines, " \n")
+}
+
+func getTaskURL(task *task.Task) string {
+	if task.Type == config.TestType {
+		return fmt.Sprintf("%s/v1/projects/detail/%s/test/detail/function/%s/%d", configbase.SystemAddress(), task.ProductName, task.PipelineName, task.TaskID)
+	}
+	uiType := multiInfo
+	if task.Type == config.SingleType {
+		uiType = singleInfo
+	}
+	return fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/%s/%s/%d", configbase.SystemAddress(), task.ProductName, uiType, task.PipelineName, task.TaskID)
+}
+
+func taskStatusText(status config.Status) string {
+	if status == config.StatusPassed {
+		return "执行成功"
+	} else if status == config.StatusCancelled {
+		return "执行取消"
+	} else if status == config.StatusTimeout {
+		return "执行超时"
+	}
+	return "执行失败"
+}
+
+func buildText(buildInfo *task.Build) string {
+	for idx, buildRepo := range buildInfo.JobCtx.Builds {
+		if idx != 0 && !buildRepo.IsPrimary {
+			continue
+		}
+		ref := buildRepo.Branch
+		if buildRepo.Tag != "" {
+			ref = buildRepo.Tag
+		}
+		commitID := buildRepo.CommitID
+		if len(commitID) > 8 {
+			commitID = commitID[0:8]
+		}
+		return strings.TrimSpace(fmt.Sprintf("%s %s %s", buildInfo.JobCtx.Image, ref, commitID))
+	}
+	return buildInfo.JobCtx.Image
+}
+
+func testResultText(testInfo *task.Testing, testReports map[string]interface{}) string {
+	status := testInfo.TaskStatus
+	if status == "" {
+		status = config.StatusNotRun
+	}
+	report, ok := testReports[testInfo.TestModuleName]
+	if !ok || testInfo.JobCtx.TestType != setting.FunctionTest {
+		return string(status)
+	}
+
+	tr := &task.TestReport{}
+	if err := task.IToi(report, tr); err != nil || tr.FunctionTestSuite == nil {
+		return string(status)
+	}
+	failedNum := tr.FunctionTestSuite.Failures + tr.FunctionTestSuite.Errors
+	return fmt.Sprintf("%d(成功)%d(失败)%d(总数)", tr.FunctionTestSuite.Tests-failedNum, failedNum, tr.FunctionTestSuite.Tests+tr.FunctionTestSuite.Skips)
+}
+
+type dingDingNotifier struct {
+	service   *Service
+	uri       string
+	atMobiles []string
+}
+
+func (n *dingDingNotifier) Send(msg *NotifyMessage) error {
+	return n.service.sendDingDingMessage(n.uri, msg.Title, msg.markdown(), n.atMobiles)
+}
+
+type feiShuNotifier struct {
+	service *Service
+	uri     string
+}
+
+func (n *feiShuNotifier) Send(msg *NotifyMessage) error {
+	return n.service.sendFeishuMessageOfSingleType(msg.Title, n.uri, msg.Text())
+}
+
+type weChatWorkNotifier struct {
+	service *Service
+	uri     string
+}
+
+func (n *weChatWorkNotifier) Send(msg *NotifyMessage) error {
+	return n.service.SendWeChatWorkMessage(weChatTextTypeMarkdown, n.uri, msg.markdown())
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func newTestNotifyMessage(fieldNum int) *NotifyMessage {
+	msg := &NotifyMessage{
+		Event:  NotifyEventTask,
+		Title:  "工作流 demo #1 执行失败",
+		Status: config.StatusFailed,
+		URL:    "https://zadig.example.com/v1/projects/detail/demo/pipelines/multi/demo/1",
+	}
+	for i := 0; i < fieldNum; i++ {
+		msg.AddField(fmt.Sprintf("field-%d", i), "a<b")
+	}
+	return msg
+}
+
+func TestNewSlackMessage(t *testing.T) {
+	msg := newSlackMessage(newTestNotifyMessage(12))
+	assert.Equal(t, "工作流 demo #1 执行失败", msg.Text)
+	// header + 两个 section + actions
+	assert.Len(t, msg.Blocks, 4)
+	assert.Len(t, msg.Blocks[1].Fields, slackMaxSectionFields)
+	assert.Len(t, msg.Blocks[2].Fields, 2)
+	assert.Equal(t, "*field-0*\na&lt;b", msg.Blocks[1].Fields[0].Text)
+	assert.Equal(t, "danger", msg.Blocks[3].Elements[0].Style)
+}
+
+func TestNewTeamsMessage(t *testing.T) {
+	msg := newTeamsMessage(newTestNotifyMessage(2))
+	assert.Len(t, msg.Attachments, 1)
+	card := msg.Attachments[0].Content
+	assert.Equal(t, adaptiveCardContentType, msg.Attachments[0].ContentType)
+	assert.Equal(t, "Attention", card.Body[0].Color)
+	assert.Len(t, card.Body[1].Facts, 2)
+	assert.Equal(t, "https://zadig.example.com/v1/projects/detail/demo/pipelines/multi/demo/1", card.Actions[0].URL)
+}
+
+func TestSignWebHookPayload(t *testing.T) {
+	body := []byte(`{"event":"task"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1650000000." + string(body)))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signWebHookPayload("secret", "1650000000", body))
+	assert.NotEqual(t, signWebHookPayload("secret", "1650000000", body), signWebHookPayload("secret", "1650000001", body))
+}
+
+func TestNewNotifier(t *testing.T) {
+	w := &Service{}
+
+	notifier, err := w.newNotifier(&models.NotifyCtl{WebHookType: slackType, SlackWebHook: "https://hooks.slack.com/services/x"})
+	assert.NoError(t, err)
+	assert.IsType(t, &slackNotifier{}, notifier)
+
+	_, err = w.newNotifier(&models.NotifyCtl{WebHookType: genericWebHookType})
+	assert.Error(t, err)
+
+	notifier, err = w.newNotifier(&models.NotifyCtl{WebHookType: weChatWorkType})
+	assert.NoError(t, err)
+	assert.IsType(t, &weChatWorkNotifier{}, notifier)
+
+	assert.True(t, isLegacyWebHookType(feiShuType))
+	assert.False(t, isLegacyWebHookType(teamsType))
+}
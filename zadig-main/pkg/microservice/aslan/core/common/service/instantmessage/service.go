@@ -78,7 +78,7 @@ type wechatNotification struct {
 	IsAtAll     bool       `json:"is_at_all"`
 }
 
-func (w *Service) SendMessageRequest(uri string, message interface{}) ([]byte, error) {
+func (w *Service) SendMessageRequest(uri string, message interface{}, rfs ...httpclient.RequestFunc) ([]byte, error) {
 	c := httpclient.New()
 
 	// 使用代理
@@ -88,7 +88,7 @@ func (w *Service) SendMessageRequest(uri string, message interface{}) ([]byte, e
 		fmt.Printf("send message is using proxy:%s\n", proxies[0].GetProxyURL())
 	}
 
-	res, err := c.Post(uri, httpclient.SetBody(message))
+	res, err := c.Post(uri, append([]httpclient.RequestFunc{httpclient.SetBody(message)}, rfs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +117,9 @@ func (w *Service) SendInstantMessage(task *task.Task, testTaskStatusChanged bool
 			return nil
 		}
 		if resp.NotifyCtl.Enabled && sets.NewString(resp.NotifyCtl.NotifyTypes...).Has(string(task.Status)) {
+			if !isLegacyWebHookType(resp.NotifyCtl.WebHookType) {
+				return w.SendNotifyMessage(resp.NotifyCtl, NewTaskNotifyMessage(task, ""))
+			}
 			webHookType = resp.NotifyCtl.WebHookType
 			if webHookType == dingDingType {
 				uri = resp.NotifyCtl.DingDingWebHook
@@ -152,6 +155,9 @@ func (w *Service) SendInstantMessage(task *task.Task, testTaskStatusChanged bool
 			return nil
 		}
 		if resp.NotifyCtl.Enabled && sets.NewString(resp.NotifyCtl.NotifyTypes...).Has(string(task.Status)) {
+			if !isLegacyWebHookType(resp.NotifyCtl.WebHookType) {
+				return w.SendNotifyMessage(resp.NotifyCtl, NewTaskNotifyMessage(task, ""))
+			}
 			webHookType = resp.NotifyCtl.WebHookType
 			if webHookType == dingDingType {
 				uri = resp.NotifyCtl.DingDingWebHook
@@ -188,6 +194,9 @@ func (w *Service) SendInstantMessage(task *task.Task, testTaskStatusChanged bool
 		}
 		statusSets := sets.NewString(resp.NotifyCtl.NotifyTypes...)
 		if resp.NotifyCtl.Enabled && (statusSets.Has(string(task.Status)) || (testTaskStatusChanged && statusSets.Has(string(config.StatusChanged)))) {
+			if !isLegacyWebHookType(resp.NotifyCtl.WebHookType) {
+				return w.SendNotifyMessage(resp.NotifyCtl, NewTaskNotifyMessage(task, resp.Desc))
+			}
 			webHookType = resp.NotifyCtl.WebHookType
 			if webHookType == dingDingType {
 				uri = resp.NotifyCtl.DingDingWebHook
@@ -480,22 +489,8 @@ func getTplExec(tplcontent string, weChatNotification *wechatNotification) (stri
 			}
 			return multiInfo
 		},
-		"taskStatus": func(status config.Status) string {
-			if status == config.StatusPassed {
-				return "执行成功"
-			} else if status == config.StatusCancelled {
-				return "执行取消"
-			} else if status == config.StatusTimeout {
-				return "执行超时"
-			}
-			return "执行失败"
-		},
-		"getIcon": func(status config.Status) string {
-			if status == config.StatusPassed {
-				return "👍"
-			}
-			return "⚠️"
-		},
+		"taskStatus": taskStatusText,
+		"getIcon":    statusIcon,
 		"getStartTime": func(startTime int64) string {
 			return time.Unix(startTime, 0).Format("2006-01-02 15:04:05")
 		},
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+const (
+	slackType = "slack"
+
+	slackBlockHeader  = "header"
+	slackBlockSection = "section"
+	slackBlockActions = "actions"
+	slackTextPlain    = "plain_text"
+	slackTextMrkdwn   = "mrkdwn"
+	slackButton       = "button"
+	// slackMaxSectionFields Block Kit 中每个 section 最多包含 10 个 field
+	slackMaxSectionFields = 10
+)
+
+// SlackMessage Slack incoming webhook 消息，使用 Block Kit 展示
+type SlackMessage struct {
+	Text   string        `json:"text"`
+	Blocks []*SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type     string          `json:"type"`
+	Text     *SlackText      `json:"text,omitempty"`
+	Fields   []*SlackText    `json:"fields,omitempty"`
+	Elements []*SlackElement `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type SlackElement struct {
+	Type  string     `json:"type"`
+	Text  *SlackText `json:"text"`
+	URL   string     `json:"url"`
+	Style string     `json:"style,omitempty"`
+}
+
+type slackNotifier struct {
+	service *Service
+	uri     string
+}
+
+func (n *slackNotifier) Send(msg *NotifyMessage) error {
+	_, err := n.service.SendMessageRequest(n.uri, newSlackMessage(msg))
+	return err
+}
+
+func newSlackMessage(msg *NotifyMessage) *SlackMessage {
+	blocks := []*SlackBlock{
+		{
+			Type: slackBlockHeader,
+			Text: &SlackText{Type: slackTextPlain, Text: fmt.Sprintf("%s %s", statusIcon(msg.Status), msg.Title)},
+		},
+	}
+
+	fields := make([]*SlackText, 0, len(msg.Fields))
+	for _, field := range msg.Fields {
+		fields = append(fields, &SlackText{Type: slackTextMrkdwn, Text: fmt.Sprintf("*%s*\n%s", slackEscape(field.Title), slackEscape(field.Value))})
+	}
+	for start := 0; start < len(fields); start += slackMaxSectionFields {
+		end := start + slackMaxSectionFields
+		if end > len(fields) {
+			end = len(fields)
+		}
+		blocks = append(blocks, &SlackBlock{Type: slackBlockSection, Fields: fields[start:end]})
+	}
+
+	style := "primary"
+	if msg.Status != config.StatusPassed {
+		style = "danger"
+	}
+	blocks = append(blocks, &SlackBlock{
+		Type: slackBlockActions,
+		Elements: []*SlackElement{
+			{
+				Type:  slackButton,
+				Text:  &SlackText{Type: slackTextPlain, Text: notifyButtonText},
+				URL:   msg.URL,
+				Style: style,
+			},
+		},
+	})
+
+	return &SlackMessage{Text: msg.Title, Blocks: blocks}
+}
+
+// slackEscape https://api.slack.com/reference/surfaces/formatting#escaping
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func statusIcon(status config.Status) string {
+	if status == config.StatusPassed {
+		return "👍"
+	}
+	return "⚠️"
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+const (
+	teamsType = "teams"
+
+	teamsMessageType         = "message"
+	adaptiveCardContentType  = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema       = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardType         = "AdaptiveCard"
+	adaptiveCardVersion      = "1.4"
+	adaptiveElementTextBlock = "TextBlock"
+	adaptiveElementFactSet   = "FactSet"
+	adaptiveActionOpenURL    = "Action.OpenUrl"
+)
+
+// TeamsMessage Microsoft Teams incoming webhook 消息，使用 Adaptive Card 展示
+type TeamsMessage struct {
+	Type        string             `json:"type"`
+	Attachments []*TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string        `json:"contentType"`
+	Content     *AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string             `json:"$schema"`
+	Type    string             `json:"type"`
+	Version string             `json:"version"`
+	Body    []*AdaptiveElement `json:"body"`
+	Actions []*AdaptiveAction  `json:"actions,omitempty"`
+}
+
+type AdaptiveElement struct {
+	Type   string          `json:"type"`
+	Text   string          `json:"text,omitempty"`
+	Weight string          `json:"weight,omitempty"`
+	Size   string          `json:"size,omitempty"`
+	Color  string          `json:"color,omitempty"`
+	Wrap   bool            `json:"wrap,omitempty"`
+	Facts  []*AdaptiveFact `json:"facts,omitempty"`
+}
+
+type AdaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type AdaptiveAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type teamsNotifier struct {
+	service *Service
+	uri     string
+}
+
+func (n *teamsNotifier) Send(msg *NotifyMessage) error {
+	_, err := n.service.SendMessageRequest(n.uri, newTeamsMessage(msg))
+	return err
+}
+
+func newTeamsMessage(msg *NotifyMessage) *TeamsMessage {
+	color := "Good"
+	if msg.Status != config.StatusPassed {
+		color = "Attention"
+	}
+
+	facts := make([]*AdaptiveFact, 0, len(msg.Fields))
+	for _, field := range msg.Fields {
+		facts = append(facts, &AdaptiveFact{Title: field.Title, Value: field.Value})
+	}
+
+	card := &AdaptiveCard{
+		Schema:  adaptiveCardSchema,
+		Type:    adaptiveCardType,
+		Version: adaptiveCardVersion,
+		Body: []*AdaptiveElement{
+			{
+				Type:   adaptiveElementTextBlock,
+				Text:   msg.Title,
+				Weight: "Bolder",
+				Size:   "Medium",
+				Color:  color,
+				Wrap:   true,
+			},
+			{
+				Type:  adaptiveElementFactSet,
+				Facts: facts,
+			},
+		},
+		Actions: []*AdaptiveAction{
+			{
+				Type:  adaptiveActionOpenURL,
+				Title: notifyButtonText,
+				URL:   msg.URL,
+			},
+		},
+	}
+
+	return &TeamsMessage{
+		Type: teamsMessageType,
+		Attachments: []*TeamsAttachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content:     card,
+			},
+		},
+	}
+}
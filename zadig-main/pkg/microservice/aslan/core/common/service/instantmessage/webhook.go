@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	genericWebHookType = "webhook"
+
+	webHookEventHeader     = "X-Zadig-Event"
+	webHookTimestampHeader = "X-Zadig-Timestamp"
+	webHookSignatureHeader = "X-Zadig-Signature"
+)
+
+// genericWebHookNotifier 以 JSON 格式推送 NotifyMessage。
+// 配置 secret 时，签名为 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))，接收方可以据此校验来源并拒绝过期请求
+type genericWebHookNotifier struct {
+	service *Service
+	address string
+	secret  string
+}
+
+func (n *genericWebHookNotifier) Send(msg *NotifyMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(msg.Timestamp, 10)
+	rfs := []httpclient.RequestFunc{
+		httpclient.SetHeader("Content-Type", "application/json"),
+		httpclient.SetHeader(webHookEventHeader, msg.Event),
+		httpclient.SetHeader(webHookTimestampHeader, timestamp),
+	}
+	if n.secret != "" {
+		rfs = append(rfs, httpclient.SetHeader(webHookSignatureHeader, signWebHookPayload(n.secret, timestamp, body)))
+	}
+
+	_, err = n.service.SendMessageRequest(n.address, body, rfs...)
+	return err
+}
+
+func signWebHookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
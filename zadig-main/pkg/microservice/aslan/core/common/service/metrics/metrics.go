@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	toolmetrics "github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+const subsystem = "aslan"
+
+var (
+	queueTasks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: toolmetrics.Namespace,
+		Subsystem: subsystem,
+		Name:      "queue_tasks",
+		Help:      "Number of tasks in the task queue, partitioned by status.",
+	}, []string{"status"})
+
+	queueWaitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: toolmetrics.Namespace,
+		Subsystem: subsystem,
+		Name:      "queue_wait_seconds",
+		Help:      "Time tasks spent in the queue before being sent to warpdrive, partitioned by task type.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	}, []string{"type"})
+
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: toolmetrics.Namespace,
+		Subsystem: subsystem,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of finished tasks, partitioned by task type and status.",
+		Buckets:   prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"type", "status"})
+
+	nsqConsumeErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: toolmetrics.Namespace,
+		Subsystem: subsystem,
+		Name:      "nsq_consume_errors_total",
+		Help:      "Number of errors happened when consuming NSQ messages, partitioned by topic.",
+	}, []string{"topic"})
+
+	webhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: toolmetrics.Namespace,
+		Subsystem: subsystem,
+		Name:      "webhook_events_total",
+		Help:      "Number of handled code host webhook events, partitioned by code host and result.",
+	}, []string{"codehost", "result"})
+)
+
+// queueStatuses 队列中可能出现的状态，没有任务的状态也需要置 0
+var queueStatuses = []config.Status{
+	config.StatusWaiting,
+	config.StatusBlocked,
+	config.StatusQueued,
+	config.StatusRunning,
+}
+
+// SetQueueTasks 根据队列中任务的状态更新队列长度
+func SetQueueTasks(statuses []config.Status) {
+	counts := make(map[config.Status]int)
+	for _, status := range statuses {
+		counts[status]++
+	}
+	for _, status := range queueStatuses {
+		queueTasks.WithLabelValues(string(status)).Set(float64(counts[status]))
+	}
+}
+
+func ObserveQueueWait(taskType config.PipelineType, createTime int64) {
+	if createTime <= 0 {
+		return
+	}
+	queueWaitDuration.WithLabelValues(string(taskType)).Observe(time.Since(time.Unix(createTime, 0)).Seconds())
+}
+
+func ObserveTaskDuration(taskType config.PipelineType, status config.Status, startTime, endTime int64) {
+	if startTime <= 0 || endTime < startTime {
+		return
+	}
+	taskDuration.WithLabelValues(string(taskType), string(status)).Observe(float64(endTime - startTime))
+}
+
+func IncNSQConsumeError(topic string) {
+	nsqConsumeErrors.WithLabelValues(topic).Inc()
+}
+
+func IncWebhookEvent(codehost string, err error) {
+	webhookEvents.WithLabelValues(codehost, toolmetrics.Result(err)).Inc()
+}
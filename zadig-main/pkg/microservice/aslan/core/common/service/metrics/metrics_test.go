@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	toolmetrics "github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+func TestSetQueueTasks(t *testing.T) {
+	SetQueueTasks([]config.Status{config.StatusWaiting, config.StatusWaiting, config.StatusRunning})
+	assert.Equal(t, float64(2), testutil.ToFloat64(queueTasks.WithLabelValues(string(config.StatusWaiting))))
+	assert.Equal(t, float64(1), testutil.ToFloat64(queueTasks.WithLabelValues(string(config.StatusRunning))))
+
+	// 队列清空后所有状态归零
+	SetQueueTasks(nil)
+	for _, status := range queueStatuses {
+		assert.Equal(t, float64(0), testutil.ToFloat64(queueTasks.WithLabelValues(string(status))))
+	}
+}
+
+func TestIncWebhookEvent(t *testing.T) {
+	IncWebhookEvent("gitlab", nil)
+	IncWebhookEvent("gitlab", nil)
+	IncWebhookEvent("gitlab", errors.New("bad payload"))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(webhookEvents.WithLabelValues("gitlab", toolmetrics.ResultSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(webhookEvents.WithLabelValues("gitlab", toolmetrics.ResultFailure)))
+}
+
+func TestObserveTaskDurationIgnoresUnfinished(t *testing.T) {
+	ObserveTaskDuration(config.WorkflowType, config.StatusPassed, 0, 100)
+	ObserveTaskDuration(config.WorkflowType, config.StatusPassed, 200, 100)
+	assert.Equal(t, 0, testutil.CollectAndCount(taskDuration))
+
+	ObserveTaskDuration(config.WorkflowType, config.StatusPassed, 100, 160)
+	assert.Equal(t, 1, testutil.CollectAndCount(taskDuration))
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/mail"
+)
+
+// Event 需要分发给订阅者的事件，范围字段为空表示事件与之无关
+type Event struct {
+	Type         config.NotificationEvent
+	ProjectName  string
+	WorkflowName string
+	EnvName      string
+	// ProjectNames 事件同时属于多个项目时使用，例如集群断开
+	ProjectNames []string
+	Message      *instantmessage.NotifyMessage
+}
+
+type Dispatcher struct {
+	subscriptionColl *mongodb.NotificationSubscriptionColl
+	notifyColl       *mongodb.NotifyColl
+	imService        *instantmessage.Service
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		subscriptionColl: mongodb.NewNotificationSubscriptionColl(),
+		notifyColl:       mongodb.NewNotifyColl(),
+		imService:        instantmessage.NewWeChatClient(),
+	}
+}
+
+// Dispatch 异步分发事件，不阻塞调用方
+func Dispatch(event *Event) {
+	go func() {
+		if err := NewDispatcher().Dispatch(event); err != nil {
+			log.Errorf("failed to dispatch %s event: %s", event.Type, err)
+		}
+	}()
+}
+
+// Dispatch 将事件按照订阅的范围和渠道发送给所有订阅者，单个订阅发送失败不影响其他订阅
+func (d *Dispatcher) Dispatch(event *Event) error {
+	subs, err := d.subscriptionColl.List(&mongodb.NotificationSubscriptionListOption{
+		Event:       event.Type,
+		EnabledOnly: true,
+	})
+	if err != nil {
+		return fmt.Errorf("list subscriptions error: %s", err)
+	}
+
+	now := time.Now()
+	var email *systemconfig.Email
+	for _, sub := range subs {
+		if !matchScope(sub, event) {
+			continue
+		}
+		quiet := inQuietHours(sub.QuietHours, now)
+		for _, channel := range sub.Channels {
+			// 免打扰时段只屏蔽邮件和 IM，站内信照常保留
+			if quiet && channel != config.NotificationChannelInApp {
+				continue
+			}
+
+			switch channel {
+			case config.NotificationChannelInApp:
+				err = d.sendInApp(sub, event.Message)
+			case config.NotificationChannelEmail:
+				if email == nil {
+					if email, err = systemconfig.New().GetEmailHost(); err != nil {
+						email = nil
+						break
+					}
+				}
+				err = sendEmail(email, sub, event.Message)
+			case config.NotificationChannelIM:
+				if sub.IM == nil {
+					continue
+				}
+				err = d.imService.SendNotifyMessage(sub.IM, event.Message)
+			default:
+				continue
+			}
+			if err != nil {
+				log.Errorf("failed to send %s event to %s by %s: %s", event.Type, sub.UserName, channel, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) sendInApp(sub *models.NotificationSubscription, msg *instantmessage.NotifyMessage) error {
+	return d.notifyColl.Create(&models.Notify{
+		Type:     config.Message,
+		Receiver: sub.UserName,
+		Content: &models.MessageCtx{
+			Title:   msg.Title,
+			Content: msg.Text(),
+		},
+	})
+}
+
+func sendEmail(email *systemconfig.Email, sub *models.NotificationSubscription, msg *instantmessage.NotifyMessage) error {
+	if sub.Email == "" {
+		return fmt.Errorf("email address is empty")
+	}
+	return mail.SendEmail(&mail.EmailParams{
+		From:     email.UserName,
+		To:       sub.Email,
+		Subject:  msg.Title,
+		Host:     email.Name,
+		UserName: email.UserName,
+		Password: email.Password,
+		Port:     email.Port,
+		Body:     emailBody(msg),
+	})
+}
+
+func emailBody(msg *instantmessage.NotifyMessage) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<h3>%s</h3><ul>", html.EscapeString(msg.Title)))
+	for _, field := range msg.Fields {
+		b.WriteString(fmt.Sprintf("<li><b>%s</b>：%s</li>", html.EscapeString(field.Title), html.EscapeString(field.Value)))
+	}
+	b.WriteString("</ul>")
+	if msg.URL != "" {
+		b.WriteString(fmt.Sprintf(`<a href="%s">点击查看更多信息</a>`, html.EscapeString(msg.URL)))
+	}
+	return b.String()
+}
+
+func matchScope(sub *models.NotificationSubscription, event *Event) bool {
+	if sub.ProjectName != "" && sub.ProjectName != event.ProjectName && !containsString(event.ProjectNames, sub.ProjectName) {
+		return false
+	}
+	if sub.WorkflowName != "" && sub.WorkflowName != event.WorkflowName {
+		return false
+	}
+	if sub.EnvName != "" && sub.EnvName != event.EnvName {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// inQuietHours 判断 now 是否处于免打扰时段，Start 大于 End 时表示跨天，例如 22:00 - 08:00
+func inQuietHours(quietHours *models.QuietHours, now time.Time) bool {
+	if quietHours == nil {
+		return false
+	}
+	start, err := parseClock(quietHours.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(quietHours.End)
+	if err != nil || start == end {
+		return false
+	}
+
+	if quietHours.Timezone != "" {
+		if loc, err := time.LoadLocation(quietHours.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	current := now.Hour()*60 + now.Minute()
+	if start < end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+// ValidateQuietHours 校验免打扰时段的格式
+func ValidateQuietHours(quietHours *models.QuietHours) error {
+	if quietHours == nil {
+		return nil
+	}
+	if _, err := parseClock(quietHours.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(quietHours.End); err != nil {
+		return err
+	}
+	if quietHours.Timezone != "" {
+		if _, err := time.LoadLocation(quietHours.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %s", quietHours.Timezone)
+		}
+	}
+	return nil
+}
+
+// parseClock 将 HH:MM 转换为当天的分钟数
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func TestInQuietHours(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		quietHours *models.QuietHours
+		now        time.Time
+		expected   bool
+	}{
+		{
+			name:     "no quiet hours",
+			now:      time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:       "inside same day range",
+			quietHours: &models.QuietHours{Start: "12:00", End: "14:00", Timezone: "UTC"},
+			now:        time.Date(2022, 1, 1, 13, 30, 0, 0, time.UTC),
+			expected:   true,
+		},
+		{
+			name:       "end is exclusive",
+			quietHours: &models.QuietHours{Start: "12:00", End: "14:00", Timezone: "UTC"},
+			now:        time.Date(2022, 1, 1, 14, 0, 0, 0, time.UTC),
+			expected:   false,
+		},
+		{
+			name:       "overnight before midnight",
+			quietHours: &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"},
+			now:        time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC),
+			expected:   true,
+		},
+		{
+			name:       "overnight after midnight",
+			quietHours: &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"},
+			now:        time.Date(2022, 1, 1, 7, 59, 0, 0, time.UTC),
+			expected:   true,
+		},
+		{
+			name:       "overnight outside",
+			quietHours: &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"},
+			now:        time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+			expected:   false,
+		},
+		{
+			name:       "converted to subscriber timezone",
+			quietHours: &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"},
+			now:        time.Date(2022, 1, 1, 15, 0, 0, 0, time.UTC),
+			expected:   true,
+		},
+		{
+			name:       "invalid clock is ignored",
+			quietHours: &models.QuietHours{Start: "25:00", End: "08:00"},
+			now:        time.Date(2022, 1, 1, 23, 0, 0, 0, shanghai),
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, inQuietHours(tt.quietHours, tt.now))
+		})
+	}
+}
+
+func TestMatchScope(t *testing.T) {
+	event := &Event{
+		Type:         config.NotificationEventEnvDeployed,
+		ProjectName:  "demo",
+		WorkflowName: "demo-workflow-dev",
+		EnvName:      "dev",
+	}
+
+	assert.True(t, matchScope(&models.NotificationSubscription{}, event))
+	assert.True(t, matchScope(&models.NotificationSubscription{ProjectName: "demo"}, event))
+	assert.True(t, matchScope(&models.NotificationSubscription{ProjectName: "demo", EnvName: "dev"}, event))
+	assert.False(t, matchScope(&models.NotificationSubscription{ProjectName: "other"}, event))
+	assert.False(t, matchScope(&models.NotificationSubscription{ProjectName: "demo", WorkflowName: "demo-workflow-qa"}, event))
+	assert.False(t, matchScope(&models.NotificationSubscription{EnvName: "prod"}, event))
+
+	clusterEvent := &Event{
+		Type:         config.NotificationEventClusterDisconnected,
+		ProjectNames: []string{"demo", "other"},
+	}
+	assert.True(t, matchScope(&models.NotificationSubscription{ProjectName: "other"}, clusterEvent))
+	assert.False(t, matchScope(&models.NotificationSubscription{ProjectName: "third"}, clusterEvent))
+}
+
+func TestValidateQuietHours(t *testing.T) {
+	assert.NoError(t, ValidateQuietHours(nil))
+	assert.NoError(t, ValidateQuietHours(&models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"}))
+	assert.Error(t, ValidateQuietHours(&models.QuietHours{Start: "22", End: "08:00"}))
+	assert.Error(t, ValidateQuietHours(&models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Mars/Base"}))
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+)
+
+// NewTaskEvents 根据任务的最终状态生成任务失败和环境部署事件
+func NewTaskEvents(t *task.Task) []*Event {
+	var events []*Event
+	switch t.Status {
+	case config.StatusFailed, config.StatusTimeout:
+		events = append(events, newTaskEvent(config.NotificationEventTaskFailed, t))
+	case config.StatusPassed:
+		if t.Type == config.WorkflowType && hasDeployStage(t) {
+			events = append(events, newTaskEvent(config.NotificationEventEnvDeployed, t))
+		}
+	}
+	return events
+}
+
+func newTaskEvent(eventType config.NotificationEvent, t *task.Task) *Event {
+	msg := instantmessage.NewTaskNotifyMessage(t, "")
+	msg.Event = string(eventType)
+	event := &Event{
+		Type:         eventType,
+		ProjectName:  t.ProductName,
+		WorkflowName: t.PipelineName,
+		Message:      msg,
+	}
+	if t.WorkflowArgs != nil {
+		event.EnvName = t.WorkflowArgs.Namespace
+	}
+	return event
+}
+
+func hasDeployStage(t *task.Task) bool {
+	for _, stage := range t.Stages {
+		if stage.TaskType == config.TaskDeploy && stage.Status == config.StatusPassed {
+			return true
+		}
+	}
+	return false
+}
+
+func NewApprovalRequiredEvent(approval *models.WorkflowApproval) *Event {
+	msg := instantmessage.NewApprovalNotifyMessage(approval)
+	msg.Event = string(config.NotificationEventApprovalRequired)
+	return &Event{
+		Type:         config.NotificationEventApprovalRequired,
+		ProjectName:  approval.ProjectName,
+		WorkflowName: approval.PipelineName,
+		Message:      msg,
+	}
+}
+
+func NewDeliveryVersionCreatedEvent(version *models.DeliveryVersion) *Event {
+	msg := &instantmessage.NotifyMessage{
+		Event:       string(config.NotificationEventDeliveryVersionCreated),
+		Title:       fmt.Sprintf("版本 %s 已创建", version.Version),
+		ProjectName: version.ProductName,
+		Name:        version.Version,
+		Creator:     version.CreatedBy,
+		URL:         fmt.Sprintf("%s/v1/projects/detail/%s/version", configbase.SystemAddress(), version.ProductName),
+		Timestamp:   time.Now().Unix(),
+	}
+	msg.AddField("创建人", version.CreatedBy)
+	if version.WorkflowName != "" {
+		msg.AddField("工作流", fmt.Sprintf("%s #%d", version.WorkflowName, version.TaskID))
+	}
+	if version.Desc != "" {
+		msg.AddField("版本描述", version.Desc)
+	}
+
+	event := &Event{
+		Type:         config.NotificationEventDeliveryVersionCreated,
+		ProjectName:  version.ProductName,
+		WorkflowName: version.WorkflowName,
+		Message:      msg,
+	}
+	if version.ProductEnvInfo != nil {
+		event.EnvName = version.ProductEnvInfo.EnvName
+	}
+	return event
+}
+
+// NewClusterDisconnectedEvent projectNames 为使用该集群的项目
+func NewClusterDisconnectedEvent(cluster *models.K8SCluster, projectNames []string) *Event {
+	msg := &instantmessage.NotifyMessage{
+		Event:     string(config.NotificationEventClusterDisconnected),
+		Title:     fmt.Sprintf("集群 %s 连接断开", cluster.Name),
+		Name:      cluster.Name,
+		URL:       configbase.SystemAddress(),
+		Timestamp: time.Now().Unix(),
+	}
+	msg.AddField("集群", cluster.Name)
+	if cluster.Description != "" {
+		msg.AddField("描述", cluster.Description)
+	}
+	msg.AddField("断开时间", time.Now().Format("2006-01-02 15:04:05"))
+
+	return &Event{
+		Type:         config.NotificationEventClusterDisconnected,
+		ProjectNames: projectNames,
+		Message:      msg,
+	}
+}
+
+func NewEnvExpiringEvent(product *models.Product) *Event {
+	msg := &instantmessage.NotifyMessage{
+		Event:       string(config.NotificationEventEnvExpiring),
+		Title:       fmt.Sprintf("环境 %s 即将到期", product.EnvName),
+		ProjectName: product.ProductName,
+		Name:        product.EnvName,
+		URL:         fmt.Sprintf("%s/v1/projects/detail/%s/envs/detail?envName=%s", configbase.SystemAddress(), product.ProductName, product.EnvName),
+		Timestamp:   time.Now().Unix(),
+	}
+	msg.AddField("环境", product.EnvName)
+	msg.AddField("到期时间", time.Unix(product.ExpireTime, 0).Format("2006-01-02 15:04:05"))
+	msg.AddField("说明", "环境到期后将被自动删除，如需继续使用请延长有效期")
+
+	return &Event{
+		Type:        config.NotificationEventEnvExpiring,
+		ProjectName: product.ProductName,
+		EnvName:     product.EnvName,
+		Message:     msg,
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
@@ -240,6 +241,10 @@ func (c *client) ProccessNotify(notify *models.Notify) error {
 			return fmt.Errorf("SendInstantMessage err : %s", err)
 		}
 
+		for _, event := range notification.NewTaskEvents(task) {
+			notification.Dispatch(event)
+		}
+
 		for _, receiver := range receivers {
 			subs, err := c.subscriptionColl.List(notify.Receiver)
 			if err != nil {
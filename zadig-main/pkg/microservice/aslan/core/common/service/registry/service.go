@@ -161,6 +161,16 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 		return
 	}
 
+	repo, err = client.NewRepository(c.ctx, repoNameRef, c.endpointURL.String(), c.repositoryTransport(repoName))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// repositoryTransport returns the transport authorized to pull the repository
+func (c *authClient) repositoryTransport(repoName string) http.RoundTripper {
 	creds := registry.NewStaticCredentialStore(&types.AuthConfig{
 		Username:      c.endpoint.Ak,
 		Password:      c.endpoint.Sk,
@@ -183,14 +193,7 @@ func (c *authClient) getRepository(repoName string) (repo distribution.Repositor
 
 	tokenHandler := auth.NewTokenHandlerWithOptions(tokenHandlerOptions)
 	modifier := auth.NewAuthorizer(c.cm, tokenHandler, basicHandler)
-	tr := transport.NewTransport(c.tr, modifier)
-
-	repo, err = client.NewRepository(c.ctx, repoNameRef, c.endpointURL.String(), tr)
-	if err != nil {
-		return
-	}
-
-	return
+	return transport.NewTransport(c.tr, modifier)
 }
 
 func (c *authClient) listTags(repoName string) (tags []string, err error) {
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// cosign 将签名存放在镜像所在仓库中 tag 为 sha256-<hex>.sig 的镜像里, 每个 layer 为一个签名
+	cosignSignatureTagSuffix  = ".sig"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+)
+
+type CosignSignature struct {
+	// Payload simple signing 格式的签名内容, 包含被签名镜像的 digest
+	Payload []byte
+	// Signature base64 编码的签名
+	Signature string
+}
+
+type cosignSignatureManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// GetCosignSignatures returns the digest of the image and the cosign signatures attached to it
+func GetCosignSignatures(ep Endpoint, repoName, tag string, log *zap.SugaredLogger) (string, []*CosignSignature, error) {
+	cli, err := (&v2RegistryService{}).createClient(ep, log)
+	if err != nil {
+		return "", nil, err
+	}
+	tr := cli.repositoryTransport(repoName)
+
+	_, header, body, err := cli.get(tr, repoName, "manifests/"+tag,
+		schema2.MediaTypeManifest, manifestlist.MediaTypeManifestList, ociManifestMediaType, ociIndexMediaType)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get manifest of %s:%s", repoName, tag)
+	}
+	imageDigest := header.Get("Docker-Content-Digest")
+	if imageDigest == "" {
+		imageDigest = digest.FromBytes(body).String()
+	}
+
+	sigTag := strings.Replace(imageDigest, ":", "-", 1) + cosignSignatureTagSuffix
+	status, _, body, err := cli.get(tr, repoName, "manifests/"+sigTag, ociManifestMediaType, schema2.MediaTypeManifest)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get signature manifest of %s:%s", repoName, tag)
+	}
+	if status == http.StatusNotFound {
+		return imageDigest, nil, nil
+	}
+
+	manifest := new(cosignSignatureManifest)
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return "", nil, errors.Wrapf(err, "failed to parse signature manifest of %s:%s", repoName, tag)
+	}
+
+	signatures := make([]*CosignSignature, 0, len(manifest.Layers))
+	for _, layer := range manifest.Layers {
+		signature, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		_, _, payload, err := cli.get(tr, repoName, "blobs/"+layer.Digest)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to get signature payload %s", layer.Digest)
+		}
+		signatures = append(signatures, &CosignSignature{Payload: payload, Signature: signature})
+	}
+	return imageDigest, signatures, nil
+}
+
+// get requests the registry api of the repository, 404 is returned as the status instead of an error
+func (c *authClient) get(tr http.RoundTripper, repoName, path string, accepts ...string) (int, http.Header, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/%s/%s", strings.TrimSuffix(c.endpointURL.String(), "/"), repoName, path), nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for _, accept := range accepts {
+		req.Header.Add("Accept", accept)
+	}
+
+	resp, err := (&http.Client{Transport: tr}).Do(req.WithContext(c.ctx))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, resp.Header, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// ParseCosignPublicKey parses the public key generated by cosign generate-key-pair
+func ParseCosignPublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// VerifyCosignSignature checks the signature is signed by the key and it is the signature of the image digest
+func VerifyCosignSignature(publicKey *ecdsa.PublicKey, signature *CosignSignature, imageDigest string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature encoding")
+	}
+	sum := sha256.Sum256(signature.Payload)
+	if !ecdsa.VerifyASN1(publicKey, sum[:], sig) {
+		return errors.New("signature mismatch")
+	}
+
+	payload := new(cosignPayload)
+	if err := json.Unmarshal(signature.Payload, payload); err != nil {
+		return errors.Wrap(err, "invalid signature payload")
+	}
+	if payload.Critical.Image.DockerManifestDigest != imageDigest {
+		return fmt.Errorf("signature is for %s, not %s", payload.Critical.Image.DockerManifestDigest, imageDigest)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signCosignPayload(t *testing.T, key *ecdsa.PrivateKey, imageDigest string) *CosignSignature {
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"xxx.com/ns/app"},"image":{"docker-manifest-digest":"` + imageDigest + `"},"type":"cosign container image signature"},"optional":null}`)
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	assert.NoError(t, err)
+	return &CosignSignature{Payload: payload, Signature: base64.StdEncoding.EncodeToString(sig)}
+}
+
+func TestVerifyCosignSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	publicKey, err := ParseCosignPublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	assert.NoError(t, err)
+
+	imageDigest := "sha256:3b4b8e7a6e2b4b3f1d0b7f5b5a6c8d9e0f1a2b3c4d5e6f708192a3b4c5d6e7f8"
+	signature := signCosignPayload(t, key, imageDigest)
+	assert.NoError(t, VerifyCosignSignature(publicKey, signature, imageDigest))
+
+	// 签名的是其他镜像
+	assert.Error(t, VerifyCosignSignature(publicKey, signature, "sha256:0000"))
+
+	// 其他密钥的签名
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	assert.Error(t, VerifyCosignSignature(publicKey, signCosignPayload(t, otherKey, imageDigest), imageDigest))
+
+	_, err = ParseCosignPublicKey("not a key")
+	assert.Error(t, err)
+}
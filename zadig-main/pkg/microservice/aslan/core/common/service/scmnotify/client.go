@@ -17,10 +17,12 @@ limitations under the License.
 package scmnotify
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/google/go-github/v35/github"
 	"github.com/pkg/errors"
 	"github.com/xanzy/go-gitlab"
 	"go.uber.org/zap"
@@ -29,6 +31,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	"github.com/koderover/zadig/pkg/tool/gerrit"
+	githubtool "github.com/koderover/zadig/pkg/tool/git/github"
 	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
@@ -89,8 +92,43 @@ func (c *Client) Comment(notify *models.Notification) error {
 		if err != nil {
 			return fmt.Errorf("failed to comment gitlab due to %s/%d %v", notify.ProjectID, notify.PrID, err)
 		}
+	} else if strings.ToLower(codeHostDetail.Type) == systemconfig.GitHubProvider {
+		owner, repo := notify.ProjectID, ""
+		if items := strings.SplitN(notify.ProjectID, "/", 2); len(items) == 2 {
+			owner, repo = items[0], items[1]
+		}
+		cli := githubtool.NewClient(&githubtool.Config{AccessToken: codeHostDetail.AccessToken, Proxy: config.ProxyHTTPSAddr()})
+		if notify.CommentID == "" {
+			var ic *github.IssueComment
+			ic, err = cli.CreateIssueComment(context.Background(), owner, repo, notify.PrID, comment)
+			if err == nil {
+				notify.CommentID = strconv.FormatInt(ic.GetID(), 10)
+			}
+		} else {
+			commentID, _ := strconv.ParseInt(notify.CommentID, 10, 64)
+			_, err = cli.EditIssueComment(context.Background(), owner, repo, commentID, comment)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to comment github due to %s/%d %v", notify.ProjectID, notify.PrID, err)
+		}
 	} else if strings.ToLower(codeHostDetail.Type) == gerrit.CodehostTypeGerrit {
 		cli := gerrit.NewClient(codeHostDetail.Address, codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		// gerrit 不支持更新评论，预览环境地址只在生成后发送一次
+		if notify.PrTask != nil && len(notify.PrTask.PreviewURLs) > 0 && !notify.PrTask.PreviewCommented {
+			if e := cli.SetReview(
+				notify.ProjectID,
+				notify.PrID,
+				fmt.Sprintf("预览环境 %s 访问地址:\n%s", notify.PrTask.EnvName, strings.Join(notify.PrTask.PreviewURLs, "\n")),
+				notify.Label,
+				"0",
+				notify.Revision,
+			); e != nil {
+				c.logger.Warnf("failed to set review %v %v", notify, e)
+			} else {
+				notify.PrTask.PreviewCommented = true
+			}
+		}
 		for _, task := range notify.Tasks {
 			// create task created comment
 			if !task.FirstCommented && task.Status == config.TaskStatusReady {
@@ -155,7 +193,7 @@ func (c *Client) Comment(notify *models.Notification) error {
 			}
 		}
 	} else {
-		return fmt.Errorf("non gitlab source not supported to comment")
+		return fmt.Errorf("%s source not supported to comment", codeHostDetail.Type)
 	}
 
 	return nil
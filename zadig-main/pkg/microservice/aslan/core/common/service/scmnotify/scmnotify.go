@@ -29,10 +29,12 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -317,6 +319,7 @@ func DownloadTestReports(taskInfo *task.Task, logger *zap.SugaredLogger) ([]*mod
 			}
 			testReport = append(testReport, testRepo)
 		}
+		fillBaseCoverage(taskInfo, testReport, logger)
 		return testReport, nil
 	case config.TestType:
 		if taskInfo.TestArgs == nil {
@@ -330,12 +333,49 @@ func DownloadTestReports(taskInfo *task.Task, logger *zap.SugaredLogger) ([]*mod
 			return nil, err
 		}
 		testReport = append(testReport, testRepo)
+		fillBaseCoverage(taskInfo, testReport, logger)
 		return testReport, nil
 	}
 
 	return nil, nil
 }
 
+// fillBaseCoverage 为 PR 触发的测试补充目标分支上最近一次的覆盖率
+func fillBaseCoverage(taskInfo *task.Task, testReports []*models.TestSuite, logger *zap.SugaredLogger) {
+	builds := make(map[string][]*types.Repository)
+	for _, stage := range taskInfo.Stages {
+		if stage.TaskType != config.TaskTestingV2 {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			testInfo, err := base.ToTestingTask(subTask)
+			if err != nil {
+				logger.Errorf("failed to convert testing task of %s:%d, err: %s", taskInfo.PipelineName, taskInfo.TaskID, err)
+				continue
+			}
+			builds[testInfo.TestModuleName] = testInfo.JobCtx.Builds
+		}
+	}
+
+	for _, report := range testReports {
+		if report.Coverage == nil {
+			continue
+		}
+		for _, repo := range builds[report.Name] {
+			if repo.PR <= 0 {
+				continue
+			}
+			// 目标分支还没有覆盖率记录时不展示变化
+			baseCoverage, err := mongodb.NewTestCoverageColl().Find(report.Name, repo.RepoOwner, repo.RepoName, repo.Branch)
+			if err != nil {
+				continue
+			}
+			report.BaseCoverage = baseCoverage.Coverage
+			break
+		}
+	}
+}
+
 // UpdateWebhookCommentForTest update the test comment to codehost when task status changes
 func (s *Service) UpdateWebhookCommentForTest(task *task.Task, logger *zap.SugaredLogger) (err error) {
 	if task.TestArgs.NotificationID == "" {
@@ -493,20 +533,21 @@ func (s *Service) UpdateEnvAndTaskWebhookComment(workflowArgs *models.WorkflowTa
 	if notification.PrTask == nil {
 		shouldComment = true
 	} else {
-		shouldComment = prTaskInfo.EnvStatus != notification.PrTask.EnvStatus
+		shouldComment = prTaskInfo.EnvStatus != notification.PrTask.EnvStatus ||
+			strings.Join(prTaskInfo.PreviewURLs, ",") != strings.Join(notification.PrTask.PreviewURLs, ",")
+		prTaskInfo.PreviewCommented = notification.PrTask.PreviewCommented && len(prTaskInfo.PreviewURLs) > 0
 	}
 	//转换状态
 	if shouldComment {
 		prTaskInfo.EnvStatus = convertStatus(prTaskInfo.EnvStatus)
 		notification.PrTask = prTaskInfo
-		if err = s.Coll.Upsert(notification); err != nil {
-			logger.Errorf("UpdateEnvAndTaskWebhookComment can't upsert notification by id %s", notification.ID)
-			return
-		}
-
 		if err = s.Client.Comment(notification); err != nil {
 			logger.Errorf("UpdateEnvAndTaskWebhookComment failed to comment %s, %v", notification.ToString(), err)
+		}
 
+		if err = s.Coll.Upsert(notification); err != nil {
+			logger.Errorf("UpdateEnvAndTaskWebhookComment can't upsert notification by id %s", notification.ID)
+			return
 		}
 	} else {
 		logger.Infof("UpdateEnvAndTaskWebhookComment status not changed of env %s, skip to update comment", prTaskInfo.EnvName)
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	helmclient "github.com/mittwald/go-helm-client"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+// K8sServiceDeployer 按服务的版本、镜像及渲染集重新部署环境中的 k8s 服务，
+// 部署逻辑位于环境模块中，由其在启动时设置
+var K8sServiceDeployer func(prod *models.Product, service *models.ProductService, log *zap.SugaredLogger) error
+
+type ServiceReleaseArgs struct {
+	ProductName  string
+	EnvName      string
+	ServiceName  string
+	PipelineName string
+	TaskID       int64
+	CreateBy     string
+}
+
+// RecordServiceRelease 记录服务在环境中成功发布后的镜像、渲染及 helm values 信息，作为回滚时的版本
+func RecordServiceRelease(args *ServiceReleaseArgs, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: args.ProductName, EnvName: args.EnvName})
+	if err != nil {
+		return fmt.Errorf("failed to find env %s/%s: %s", args.ProductName, args.EnvName, err)
+	}
+	productService, ok := prod.GetServiceMap()[args.ServiceName]
+	if !ok {
+		return fmt.Errorf("service %s not found in env %s/%s", args.ServiceName, args.ProductName, args.EnvName)
+	}
+
+	release := &models.ServiceRelease{
+		ProductName:     prod.ProductName,
+		EnvName:         prod.EnvName,
+		Namespace:       prod.Namespace,
+		ServiceName:     args.ServiceName,
+		Type:            productService.Type,
+		ServiceRevision: productService.Revision,
+		Containers:      productService.Containers,
+		Render:          productService.Render,
+		PipelineName:    args.PipelineName,
+		TaskID:          args.TaskID,
+		CreateBy:        args.CreateBy,
+	}
+	if release.Render == nil {
+		release.Render = prod.Render
+	}
+
+	if productService.Type == setting.HelmDeployType {
+		renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: prod.Render.Name, Revision: prod.Render.Revision})
+		if err != nil {
+			return fmt.Errorf("failed to find renderset %s/%d: %s", prod.Render.Name, prod.Render.Revision, err)
+		}
+		for _, chartInfo := range renderSet.ChartInfos {
+			if chartInfo.ServiceName == args.ServiceName {
+				release.RenderChart = chartInfo
+				break
+			}
+		}
+
+		helmClient, err := newHelmClient(prod)
+		if err != nil {
+			return err
+		}
+		helmRelease, err := helmClient.GetRelease(util.GeneHelmReleaseName(prod.Namespace, args.ServiceName))
+		if err != nil {
+			return fmt.Errorf("failed to get helm release of service %s: %s", args.ServiceName, err)
+		}
+		release.HelmRevision = helmRelease.Version
+	}
+
+	if err := commonrepo.NewServiceReleaseColl().Create(release); err != nil {
+		return fmt.Errorf("failed to create service release: %s", err)
+	}
+	log.Infof("service release of %s/%s/%s recorded", prod.ProductName, prod.EnvName, args.ServiceName)
+	return nil
+}
+
+// RollbackServiceRelease 将环境中的服务回滚到最近一次成功发布的版本
+func RollbackServiceRelease(productName, envName, serviceName string, log *zap.SugaredLogger) (*models.ServiceRelease, error) {
+	release, err := commonrepo.NewServiceReleaseColl().FindLatest(productName, envName, serviceName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, e.ErrServiceReleaseNotFound
+		}
+		return nil, e.ErrRollbackServiceRelease.AddErr(err)
+	}
+
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrRollbackServiceRelease.AddErr(err)
+	}
+	productService, ok := prod.GetServiceMap()[serviceName]
+	if !ok {
+		return nil, e.ErrRollbackServiceRelease.AddDesc(fmt.Sprintf("服务 %s 不在环境中", serviceName))
+	}
+
+	switch productService.Type {
+	case setting.K8SDeployType:
+		err = rollbackK8sService(prod, productService, release, log)
+	case setting.HelmDeployType:
+		err = rollbackHelmService(prod, productService, release)
+	default:
+		return nil, e.ErrRollbackServiceRelease.AddDesc(fmt.Sprintf("不支持回滚 %s 类型的服务", productService.Type))
+	}
+	if err != nil {
+		log.Errorf("failed to rollback service %s/%s/%s: %s", productName, envName, serviceName, err)
+		return nil, e.ErrRollbackServiceRelease.AddErr(err)
+	}
+
+	productService.Containers = release.Containers
+	if err := commonrepo.NewProductColl().Update(prod); err != nil {
+		log.Errorf("failed to update env %s/%s after rollback: %s", productName, envName, err)
+		return nil, e.ErrRollbackServiceRelease.AddErr(err)
+	}
+	log.Infof("service %s/%s/%s rolled back to the release created at %d", productName, envName, serviceName, release.CreateTime)
+	return release, nil
+}
+
+// rollbackK8sService 按发布记录中的服务版本和渲染集重新部署服务，使配置和变量一并恢复；
+// 早期没有记录版本的发布只恢复工作负载中的容器镜像
+func rollbackK8sService(prod *models.Product, productService *models.ProductService, release *models.ServiceRelease, log *zap.SugaredLogger) error {
+	if release.ServiceRevision > 0 && release.Render != nil && K8sServiceDeployer != nil {
+		svc := *productService
+		svc.Revision = release.ServiceRevision
+		svc.Containers = release.Containers
+		svc.Render = release.Render
+		if err := K8sServiceDeployer(prod, &svc, log); err != nil {
+			return err
+		}
+		productService.Revision = release.ServiceRevision
+		productService.Render = release.Render
+		return nil
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	images := make(map[string]string)
+	for _, container := range release.Containers {
+		images[container.Name] = container.Image
+	}
+
+	selector := labels.Set{setting.ProductLabel: prod.ProductName, setting.ServiceLabel: release.ServiceName}.AsSelector()
+	return rollbackWorkloadImages(prod.Namespace, selector, images, kubeClient)
+}
+
+func rollbackWorkloadImages(namespace string, selector labels.Selector, images map[string]string, kubeClient client.Client) error {
+	deployments, err := getter.ListDeployments(namespace, selector, kubeClient)
+	if err != nil {
+		return err
+	}
+	for _, deploy := range deployments {
+		for _, container := range deploy.Spec.Template.Spec.Containers {
+			image, ok := images[container.Name]
+			if !ok || image == container.Image {
+				continue
+			}
+			if err := updater.UpdateDeploymentImage(namespace, deploy.Name, container.Name, image, kubeClient); err != nil {
+				return fmt.Errorf("failed to update container image in %s/deployments/%s/%s: %s", namespace, deploy.Name, container.Name, err)
+			}
+		}
+	}
+
+	statefulSets, err := getter.ListStatefulSets(namespace, selector, kubeClient)
+	if err != nil {
+		return err
+	}
+	for _, sts := range statefulSets {
+		for _, container := range sts.Spec.Template.Spec.Containers {
+			image, ok := images[container.Name]
+			if !ok || image == container.Image {
+				continue
+			}
+			if err := updater.UpdateStatefulSetImage(namespace, sts.Name, container.Name, image, kubeClient); err != nil {
+				return fmt.Errorf("failed to update container image in %s/statefulsets/%s/%s: %s", namespace, sts.Name, container.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackHelmService 通过 helm rollback 恢复 release，并将 renderset 中的 values 恢复为发布记录中的内容
+func rollbackHelmService(prod *models.Product, productService *models.ProductService, release *models.ServiceRelease) error {
+	if release.HelmRevision <= 0 {
+		return fmt.Errorf("helm revision of service %s is not recorded", release.ServiceName)
+	}
+
+	helmClient, err := newHelmClient(prod)
+	if err != nil {
+		return err
+	}
+	spec := &helmclient.ChartSpec{
+		ReleaseName: util.GeneHelmReleaseName(prod.Namespace, release.ServiceName),
+		Namespace:   prod.Namespace,
+	}
+	if err := helmClient.RollbackRelease(spec, release.HelmRevision); err != nil {
+		return fmt.Errorf("failed to rollback helm release %s to revision %d: %s", spec.ReleaseName, release.HelmRevision, err)
+	}
+
+	productService.Revision = release.ServiceRevision
+	if release.RenderChart == nil {
+		return nil
+	}
+	renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: prod.Render.Name, Revision: prod.Render.Revision})
+	if err != nil {
+		return fmt.Errorf("failed to find renderset %s/%d: %s", prod.Render.Name, prod.Render.Revision, err)
+	}
+	for i, chartInfo := range renderSet.ChartInfos {
+		if chartInfo.ServiceName == release.ServiceName {
+			renderSet.ChartInfos[i] = release.RenderChart
+			break
+		}
+	}
+	return commonrepo.NewRenderSetColl().Update(renderSet)
+}
+
+func newHelmClient(prod *models.Product) (helmclient.Client, error) {
+	restConfig, err := kube.GetRESTConfig(prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	return helmtool.NewClientFromRestConf(restConfig, prod.Namespace)
+}
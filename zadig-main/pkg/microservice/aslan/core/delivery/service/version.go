@@ -48,7 +48,9 @@ import (
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
@@ -1095,9 +1097,33 @@ func updateVersionStatus(versionName, projectName, status, errStr string) {
 		}
 	}
 
+	// 版本交付结束时通知，状态未变化时不重复发送
+	var finishedVersion *commonmodels.DeliveryVersion
+	if status == setting.DeliveryVersionStatusSuccess || status == setting.DeliveryVersionStatusFailed {
+		versionInfo, err := commonrepo.NewDeliveryVersionColl().Get(&commonrepo.DeliveryVersionArgs{
+			ProductName: projectName,
+			Version:     versionName,
+		})
+		if err == nil && versionInfo.Status != status {
+			finishedVersion = versionInfo
+		}
+	}
+
 	err := commonrepo.NewDeliveryVersionColl().UpdateStatusByName(versionName, projectName, status, errStr)
 	if err != nil {
 		log.Errorf("failed to update version status, name: %s, err: %s", versionName, err)
+		return
+	}
+
+	if finishedVersion != nil {
+		notifyStatus := config.StatusPassed
+		if status == setting.DeliveryVersionStatusFailed {
+			notifyStatus = config.StatusFailed
+		}
+		finishedVersion.Error = errStr
+		if err := instantmessage.NewWeChatClient().SendDeliveryVersionMessage(finishedVersion, notifyStatus); err != nil {
+			log.Errorf("failed to send notification of version: %s, err: %s", versionName, err)
+		}
 	}
 }
 
@@ -1268,6 +1294,7 @@ func CreateNewHelmDeliveryVersion(args *CreateHelmDeliveryVersionArgs, logger *z
 		logger.Errorf("failed to insert version data, err: %s", err)
 		return e.ErrCreateDeliveryVersion.AddErr(fmt.Errorf("failed to insert delivery version: %s", versionObj.Version))
 	}
+	notification.Dispatch(notification.NewDeliveryVersionCreatedEvent(versionObj))
 
 	err = buildDeliveryCharts(chartDataMap, versionObj, args.DeliveryVersionChartData, logger)
 	if err != nil {
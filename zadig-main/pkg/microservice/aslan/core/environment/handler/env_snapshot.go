@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func CreateEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	args := new(service.CreateEnvSnapshotArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "新增", "集成环境-快照", envName, "", ctx.Logger)
+
+	ctx.Resp, ctx.Err = service.CreateEnvSnapshot(envName, projectName, ctx.UserName, args, ctx.Logger)
+}
+
+func ListEnvSnapshots(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListEnvSnapshots(c.Param("name"), projectName, ctx.Logger)
+}
+
+func GetEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetEnvSnapshot(c.Param("id"), c.Query("projectName"), ctx.Logger)
+}
+
+func DeleteEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "删除", "集成环境-快照", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.DeleteEnvSnapshot(c.Param("id"), projectName, ctx.Logger)
+}
+
+func ExportEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	archive, fileName, err := service.ExportEnvSnapshot(c.Param("id"), c.Query("projectName"), ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/gzip", archive)
+}
+
+func ImportEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	reader, err := file.Open()
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	defer reader.Close()
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "导入", "集成环境-快照", file.Filename, "", ctx.Logger)
+
+	ctx.Resp, ctx.Err = service.ImportEnvSnapshot(projectName, ctx.UserName, reader, ctx.Logger)
+}
+
+func RestoreEnvSnapshot(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	args := new(service.RestoreEnvSnapshotArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "恢复", "集成环境-快照", args.EnvName, "", ctx.Logger)
+
+	ctx.Err = service.RestoreEnvSnapshot(c.Param("id"), projectName, ctx.UserName, ctx.RequestID, args, ctx.Logger)
+}
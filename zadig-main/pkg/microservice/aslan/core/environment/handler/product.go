@@ -30,6 +30,20 @@ func CleanProductCronJob(c *gin.Context) {
 	service.CleanProductCronJob(ctx.RequestID, ctx.Logger)
 }
 
+func EnvLifecycleCronJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	service.EnvLifecycleCronJob(ctx.RequestID, ctx.Logger)
+}
+
+func EnvDriftCronJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	service.EnvDriftCronJob(ctx.Logger)
+}
+
 func GetInitProduct(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
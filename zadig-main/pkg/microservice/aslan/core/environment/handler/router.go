@@ -41,6 +41,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	cron := router.Group("cron")
 	{
 		cron.GET("/cleanproduct", CleanProductCronJob)
+		cron.GET("/envlifecycle", EnvLifecycleCronJob)
+		cron.GET("/envdrift", EnvDriftCronJob)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -101,6 +103,16 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.POST("", gin2.UpdateOperationLogStatus, CreateProduct)
 		environments.GET("/:name", GetProduct)
 		environments.PUT("/:name/envRecycle", gin2.UpdateOperationLogStatus, UpdateProductRecycleDay)
+		environments.PUT("/:name/sleepSchedule", gin2.UpdateOperationLogStatus, UpdateEnvSleepSchedule)
+		environments.PUT("/:name/ttl", gin2.UpdateOperationLogStatus, UpdateEnvTTL)
+		environments.POST("/:name/sleep", gin2.UpdateOperationLogStatus, SleepEnv)
+		environments.POST("/:name/wake", gin2.UpdateOperationLogStatus, WakeEnv)
+		environments.PUT("/:name/quota", gin2.UpdateOperationLogStatus, UpdateEnvQuota)
+		environments.POST("/:name/snapshots", gin2.UpdateOperationLogStatus, CreateEnvSnapshot)
+		environments.GET("/:name/snapshots", ListEnvSnapshots)
+		environments.GET("/:name/drift", GetEnvDrift)
+		environments.POST("/:name/drift/reconcile", gin2.UpdateOperationLogStatus, ReconcileEnvDrift)
+		environments.POST("/:name/drift/adopt", gin2.UpdateOperationLogStatus, AdoptEnvDrift)
 		environments.POST("/:name/estimated-values", EstimatedValues)
 		environments.PUT("/:name/renderset", gin2.UpdateOperationLogStatus, UpdateHelmProductRenderset)
 		environments.GET("/:name/helmChartVersions", GetHelmChartVersions)
@@ -119,10 +131,35 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.POST("/:name/services/:serviceName/scale", gin2.UpdateOperationLogStatus, ScaleService)
 		environments.POST("/:name/services/:serviceName/scaleNew", gin2.UpdateOperationLogStatus, ScaleNewService)
 		environments.GET("/:name/services/:serviceName/containers/:container", GetServiceContainer)
+		environments.GET("/:name/services/:serviceName/releases", ListServiceReleases)
+		environments.POST("/:name/services/:serviceName/rollback", gin2.UpdateOperationLogStatus, RollbackService)
 
 		environments.GET("/:name/estimated-renderchart", GetEstimatedRenderCharts)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 环境快照接口
+	// ---------------------------------------------------------------------------------------
+	snapshots := router.Group("snapshots")
+	{
+		snapshots.POST("/import", gin2.UpdateOperationLogStatus, ImportEnvSnapshot)
+		snapshots.GET("/:id", GetEnvSnapshot)
+		snapshots.DELETE("/:id", gin2.UpdateOperationLogStatus, DeleteEnvSnapshot)
+		snapshots.GET("/:id/export", ExportEnvSnapshot)
+		snapshots.POST("/:id/restore", gin2.UpdateOperationLogStatus, RestoreEnvSnapshot)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// 项目资源配额和成本接口
+	// ---------------------------------------------------------------------------------------
+	quota := router.Group("quota")
+	{
+		quota.GET("", GetProjectQuota)
+		// 项目配额会应用到包括生产环境在内的所有环境，仅限项目管理员
+		quota.PUT("", gin2.UpdateOperationLogStatus, UpdateProjectQuota)
+		quota.GET("/report", GetProjectResourceReport)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// renderset相关接口
 	// ---------------------------------------------------------------------------------------
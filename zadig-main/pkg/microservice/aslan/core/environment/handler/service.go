@@ -53,6 +53,21 @@ func RestartService(c *gin.Context) {
 	ctx.Err = service.RestartService(args.EnvName, args, ctx.Logger)
 }
 
+func ListServiceReleases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListServiceReleases(c.Query("projectName"), c.Param("name"), c.Param("serviceName"), ctx.Logger)
+}
+
+func RollbackService(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.Query("projectName"), "回滚", "集成环境-服务", fmt.Sprintf("环境名称:%s,服务名称:%s", c.Param("name"), c.Param("serviceName")), "", ctx.Logger)
+	ctx.Resp, ctx.Err = service.RollbackService(c.Query("projectName"), c.Param("name"), c.Param("serviceName"), ctx.Logger)
+}
+
 func UpdateService(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
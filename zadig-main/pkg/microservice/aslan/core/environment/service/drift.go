@@ -0,0 +1,658 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	helmclient "github.com/mittwald/go-helm-client"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+	"github.com/koderover/zadig/pkg/tool/kube/serializer"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	kubeutil "github.com/koderover/zadig/pkg/tool/kube/util"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+// driftVariablePrefix 用于定位字段来自哪个服务变量的占位值
+const driftVariablePrefix = "__zadig_drift_var_"
+
+var (
+	// 由集群维护的字段不参与比较
+	driftIgnoredPrefixes = []string{
+		"status",
+		"metadata.namespace",
+		"metadata.uid",
+		"metadata.resourceVersion",
+		"metadata.generation",
+		"metadata.creationTimestamp",
+		"metadata.managedFields",
+		"metadata.selfLink",
+		"metadata.annotations." + corev1.LastAppliedConfigAnnotation,
+		"metadata.annotations.deployment.kubernetes.io/revision",
+	}
+	containerImagePath = regexp.MustCompile(`^spec\.template\.spec\.containers\[([^\]]+)\]\.image$`)
+)
+
+type EnvDriftActionArgs struct {
+	// ServiceNames 为空表示环境中所有存在漂移的服务
+	ServiceNames []string `json:"service_names"`
+}
+
+type serviceDrift struct {
+	service   *commonmodels.ProductService
+	desired   []*unstructured.Unstructured
+	resources []*commonmodels.ResourceDrift
+}
+
+type driftChecker struct {
+	prod       *commonmodels.Product
+	renderSet  *commonmodels.RenderSet
+	kubeClient client.Client
+	informer   informers.SharedInformerFactory
+	helmClient helmclient.Client
+	ignored    []string
+}
+
+// GetEnvDrift 返回最近一次的检测结果，refresh 为 true 或者从未检测过时立即检测
+func GetEnvDrift(envName, productName string, refresh bool, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	if !refresh {
+		drift, err := commonrepo.NewEnvDriftColl().Find(productName, envName)
+		if err == nil {
+			return drift, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, e.ErrCheckEnvDrift.AddErr(err)
+		}
+	}
+
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrCheckEnvDrift.AddErr(err)
+	}
+	drift, err := checkAndSaveEnvDrift(prod, log)
+	if err != nil {
+		return nil, e.ErrCheckEnvDrift.AddErr(err)
+	}
+	return drift, nil
+}
+
+// EnvDriftCronJob 定时检测所有容器环境的漂移情况
+func EnvDriftCronJob(log *zap.SugaredLogger) {
+	log.Info("[EnvDriftCronJob] started ...")
+	defer log.Info("[EnvDriftCronJob] end")
+
+	products, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{ExcludeStatus: setting.ProductStatusDeleting})
+	if err != nil {
+		log.Errorf("[Product.List] error: %v", err)
+		return
+	}
+	for _, prod := range products {
+		if !driftSupported(prod) {
+			continue
+		}
+		if _, err := checkAndSaveEnvDrift(prod, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to check drift: %s", prod.EnvName, prod.ProductName, err)
+		}
+	}
+}
+
+// ReconcileEnvDrift 按照渲染结果重新部署存在漂移的资源，覆盖线上的修改
+func ReconcileEnvDrift(envName, productName string, args *EnvDriftActionArgs, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrReconcileEnvDrift.AddErr(err)
+	}
+	checker, err := newDriftChecker(prod, log)
+	if err != nil {
+		return e.ErrReconcileEnvDrift.AddErr(err)
+	}
+	drifts, err := checker.check(sets.NewString(args.ServiceNames...), log)
+	if err != nil {
+		return e.ErrReconcileEnvDrift.AddErr(err)
+	}
+
+	for _, drift := range drifts {
+		if len(drift.resources) == 0 {
+			continue
+		}
+		if prod.Source == setting.HelmDeployType {
+			for _, u := range drift.desired {
+				u.SetNamespace(prod.Namespace)
+				if err := updater.CreateOrPatchUnstructured(u, checker.kubeClient); err != nil {
+					return e.ErrReconcileEnvDrift.AddErr(err)
+				}
+			}
+			continue
+		}
+		if _, err := upsertService(true, prod, drift.service, nil, checker.renderSet, checker.informer, checker.kubeClient, log); err != nil {
+			return e.ErrReconcileEnvDrift.AddErr(err)
+		}
+	}
+
+	if _, err := checkAndSaveEnvDrift(prod, log); err != nil {
+		log.Warnf("[%s][P:%s] failed to refresh drift after reconcile: %s", envName, productName, err)
+	}
+	return nil
+}
+
+// AdoptEnvDrift 将线上被修改的镜像和来自服务变量的字段写回环境配置，不重新部署
+func AdoptEnvDrift(envName, productName, username string, args *EnvDriftActionArgs, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	if prod.Source == setting.HelmDeployType {
+		return e.ErrAdoptEnvDrift.AddDesc("helm 环境请通过修改 values 采纳线上配置")
+	}
+	checker, err := newDriftChecker(prod, log)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	drifts, err := checker.check(sets.NewString(args.ServiceNames...), log)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+
+	variables := make(map[string]string)
+	imageChanged := false
+	for _, drift := range drifts {
+		for _, resource := range drift.resources {
+			for _, field := range resource.Fields {
+				if field.Reason != commonmodels.DriftReasonLiveModified || !isScalar(field.Live) {
+					continue
+				}
+				switch field.AdoptType {
+				case commonmodels.DriftAdoptVariable:
+					variables[field.VariableKey] = fmt.Sprint(field.Live)
+				case commonmodels.DriftAdoptImage:
+					for _, container := range drift.service.Containers {
+						if container.Name == field.Container {
+							container.Image = fmt.Sprint(field.Live)
+							imageChanged = true
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(variables) == 0 && !imageChanged {
+		return nil
+	}
+
+	if len(variables) > 0 {
+		renderSet := &commonmodels.RenderSet{
+			Name:        checker.renderSet.Name,
+			EnvName:     envName,
+			ProductTmpl: productName,
+			UpdateBy:    username,
+			KVs:         adoptRenderKVs(checker.renderSet.KVs, variables),
+		}
+		if err := commonservice.CreateRenderSet(renderSet, log); err != nil {
+			return e.ErrAdoptEnvDrift.AddErr(err)
+		}
+		prod.Render = &commonmodels.RenderInfo{Name: renderSet.Name, Revision: renderSet.Revision, ProductTmpl: productName}
+		setServiceRender(prod)
+	}
+	if err := commonrepo.NewProductColl().Update(prod); err != nil {
+		log.Errorf("[%s][P:%s] failed to update product: %s", envName, productName, err)
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+
+	if _, err := checkAndSaveEnvDrift(prod, log); err != nil {
+		log.Warnf("[%s][P:%s] failed to refresh drift after adopt: %s", envName, productName, err)
+	}
+	return nil
+}
+
+func driftSupported(prod *commonmodels.Product) bool {
+	return prod.Source != setting.PMDeployType && prod.Source != setting.SourceFromExternal
+}
+
+func checkAndSaveEnvDrift(prod *commonmodels.Product, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	result := &commonmodels.EnvDrift{ProductName: prod.ProductName, EnvName: prod.EnvName}
+
+	checker, err := newDriftChecker(prod, log)
+	if err == nil {
+		var drifts []*serviceDrift
+		drifts, err = checker.check(sets.NewString(), log)
+		for _, drift := range drifts {
+			result.Resources = append(result.Resources, drift.resources...)
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Drifted = len(result.Resources) > 0
+
+	if saveErr := commonrepo.NewEnvDriftColl().Upsert(result); saveErr != nil {
+		return nil, saveErr
+	}
+	return result, err
+}
+
+func newDriftChecker(prod *commonmodels.Product, log *zap.SugaredLogger) (*driftChecker, error) {
+	if !driftSupported(prod) {
+		return nil, fmt.Errorf("环境类型 %s 不支持漂移检测", prod.Source)
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	inf, err := informer.NewInformer(prod.ClusterID, prod.Namespace, cls)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &driftChecker{
+		prod:       prod,
+		kubeClient: kubeClient,
+		informer:   inf,
+		ignored:    driftIgnoredPrefixes,
+	}
+	// 休眠中的环境副本数被缩容为 0，不算作漂移
+	if prod.SleepState != nil && prod.SleepState.Sleeping {
+		checker.ignored = append(append([]string{}, driftIgnoredPrefixes...), "spec.replicas")
+	}
+
+	if prod.Source == setting.HelmDeployType {
+		restConfig, err := kube.GetRESTConfig(prod.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		checker.helmClient, err = helmtool.NewClientFromRestConf(restConfig, prod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return checker, nil
+	}
+
+	renderName, revision := "", int64(0)
+	if prod.Render != nil {
+		renderName, revision = prod.Render.Name, prod.Render.Revision
+	}
+	checker.renderSet, err = commonservice.GetRenderSet(renderName, revision, log)
+	if err != nil {
+		return nil, err
+	}
+	return checker, nil
+}
+
+// check 对比服务的渲染结果、上次部署的内容和线上资源，serviceNames 为空时检查所有服务
+func (c *driftChecker) check(serviceNames sets.String, log *zap.SugaredLogger) ([]*serviceDrift, error) {
+	var drifts []*serviceDrift
+	for _, group := range c.prod.Services {
+		for _, svc := range group {
+			if serviceNames.Len() > 0 && !serviceNames.Has(svc.ServiceName) {
+				continue
+			}
+			if svc.Type != setting.K8SDeployType && svc.Type != setting.HelmDeployType {
+				continue
+			}
+
+			drift, err := c.checkService(svc, log)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %s", svc.ServiceName, err)
+			}
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+func (c *driftChecker) checkService(svc *commonmodels.ProductService, log *zap.SugaredLogger) (*serviceDrift, error) {
+	var (
+		manifest  string
+		variables map[string]string
+	)
+	if c.helmClient != nil {
+		release, err := c.helmClient.GetRelease(util.GeneHelmReleaseName(c.prod.Namespace, svc.ServiceName))
+		if err != nil {
+			return nil, err
+		}
+		manifest = release.Manifest
+	} else {
+		parsedYaml, err := renderService(c.prod, c.renderSet, svc)
+		if err != nil {
+			return nil, err
+		}
+		manifest = *parsedYaml
+		variables = c.variablePaths(svc, log)
+	}
+
+	desired, err := splitUnstructured(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &serviceDrift{service: svc, desired: desired}
+	for _, u := range desired {
+		resource := &commonmodels.ResourceDrift{ServiceName: svc.ServiceName, Kind: u.GetKind(), Name: u.GetName()}
+
+		live, found, err := c.liveObject(u)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			resource.Missing = true
+			drift.resources = append(drift.resources, resource)
+			continue
+		}
+
+		var original *unstructured.Unstructured
+		if c.helmClient == nil {
+			original, err = lastAppliedObject(live)
+			if err != nil {
+				log.Warnf("failed to parse last applied configuration of %s/%s: %s", u.GetKind(), u.GetName(), err)
+			}
+		}
+
+		resource.Fields = diffDriftFields(u, original, live, c.ignored)
+		for _, field := range resource.Fields {
+			if key, ok := variables[resourcePathKey(u, field.Path)]; ok {
+				field.AdoptType = commonmodels.DriftAdoptVariable
+				field.VariableKey = key
+			} else if match := containerImagePath.FindStringSubmatch(field.Path); match != nil && c.helmClient == nil {
+				field.AdoptType = commonmodels.DriftAdoptImage
+				field.Container = match[1]
+			}
+		}
+		if len(resource.Fields) > 0 {
+			drift.resources = append(drift.resources, resource)
+		}
+	}
+	return drift, nil
+}
+
+// liveObject 工作负载和 Service 从 informer 缓存中获取，其他资源从 client 缓存中获取
+func (c *driftChecker) liveObject(u *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+	var (
+		obj runtime.Object
+		err error
+	)
+	switch u.GetKind() {
+	case setting.Deployment:
+		obj, err = c.informer.Apps().V1().Deployments().Lister().Deployments(c.prod.Namespace).Get(u.GetName())
+	case setting.StatefulSet:
+		obj, err = c.informer.Apps().V1().StatefulSets().Lister().StatefulSets(c.prod.Namespace).Get(u.GetName())
+	case setting.Service:
+		obj, err = c.informer.Core().V1().Services().Lister().Services(c.prod.Namespace).Get(u.GetName())
+	default:
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(u.GroupVersionKind())
+		found, err := getter.GetResourceInCache(c.prod.Namespace, u.GetName(), live, c.kubeClient)
+		return live, found, err
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	// lister 返回的对象没有 apiVersion 和 kind
+	live := &unstructured.Unstructured{Object: data}
+	live.SetGroupVersionKind(u.GroupVersionKind())
+	return live, true, nil
+}
+
+// variablePaths 使用占位值渲染服务模板，找出来自服务变量的字段
+func (c *driftChecker) variablePaths(svc *commonmodels.ProductService, log *zap.SugaredLogger) map[string]string {
+	tmpl, err := commonrepo.NewServiceColl().Find(&commonrepo.ServiceFindOption{
+		ServiceName: svc.ServiceName,
+		ProductName: svc.ProductName,
+		Type:        svc.Type,
+		Revision:    svc.Revision,
+	})
+	if err != nil {
+		log.Warnf("failed to find service template %s/%d: %s", svc.ServiceName, svc.Revision, err)
+		return nil
+	}
+
+	placeholders := &commonmodels.RenderSet{}
+	for _, kv := range c.renderSet.KVs {
+		placeholders.KVs = append(placeholders.KVs, &templatemodels.RenderKV{Key: kv.Key, Value: driftVariablePrefix + kv.Key})
+	}
+	parsedYaml := commonservice.RenderValueForString(tmpl.Yaml, placeholders)
+	parsedYaml = kube.ParseSysKeys(c.prod.Namespace, c.prod.EnvName, c.prod.ProductName, svc.ServiceName, parsedYaml)
+
+	resources, err := splitUnstructured(parsedYaml)
+	if err != nil {
+		return nil
+	}
+	res := make(map[string]string)
+	for _, u := range resources {
+		fields := make(map[string]interface{})
+		flattenObject("", u.Object, fields)
+		for path, value := range fields {
+			if s, ok := value.(string); ok && strings.HasPrefix(s, driftVariablePrefix) {
+				res[resourcePathKey(u, path)] = strings.TrimPrefix(s, driftVariablePrefix)
+			}
+		}
+	}
+	return res
+}
+
+func splitUnstructured(manifest string) ([]*unstructured.Unstructured, error) {
+	var res []*unstructured.Unstructured
+	for _, item := range releaseutil.SplitManifests(manifest) {
+		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
+func lastAppliedObject(live *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, err := kubeutil.GetOriginalConfiguration(live)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	original := &unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &original.Object); err != nil {
+		return nil, err
+	}
+	return original, nil
+}
+
+func resourcePathKey(u *unstructured.Unstructured, path string) string {
+	return fmt.Sprintf("%s/%s|%s", u.GetKind(), u.GetName(), path)
+}
+
+// diffDriftFields 三方对比：上次部署的字段在线上被修改视为漂移，渲染结果与上次部署不同视为待更新
+// original 为空时以渲染结果作为上次部署的内容
+func diffDriftFields(desired, original, live *unstructured.Unstructured, ignored []string) []*commonmodels.DriftField {
+	desiredFields := make(map[string]interface{})
+	flattenObject("", desired.Object, desiredFields)
+	liveFields := make(map[string]interface{})
+	flattenObject("", live.Object, liveFields)
+	originalFields := desiredFields
+	if original != nil {
+		originalFields = make(map[string]interface{})
+		flattenObject("", original.Object, originalFields)
+	}
+
+	reported := make(map[string]*commonmodels.DriftField)
+	for path, applied := range originalFields {
+		if isIgnoredDriftPath(path, ignored) {
+			continue
+		}
+		if liveValue, ok := liveFields[path]; ok && driftValueEqual(applied, liveValue) {
+			continue
+		}
+		reported[path] = &commonmodels.DriftField{
+			Path:        path,
+			Desired:     desiredFields[path],
+			Live:        liveFields[path],
+			LastApplied: applied,
+			Reason:      commonmodels.DriftReasonLiveModified,
+		}
+	}
+	for path, want := range desiredFields {
+		if _, ok := reported[path]; ok || isIgnoredDriftPath(path, ignored) {
+			continue
+		}
+		if applied, ok := originalFields[path]; ok && driftValueEqual(applied, want) {
+			continue
+		}
+		if liveValue, ok := liveFields[path]; ok && driftValueEqual(want, liveValue) {
+			continue
+		}
+		reported[path] = &commonmodels.DriftField{
+			Path:        path,
+			Desired:     want,
+			Live:        liveFields[path],
+			LastApplied: originalFields[path],
+			Reason:      commonmodels.DriftReasonPendingUpdate,
+		}
+	}
+
+	fields := make([]*commonmodels.DriftField, 0, len(reported))
+	for _, field := range reported {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
+
+// flattenObject 将对象展开为 路径->值，元素带有 name 字段的列表按照 name 展开，例如 spec.template.spec.containers[app].image
+func flattenObject(prefix string, v interface{}, out map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			out[prefix] = val
+			return
+		}
+		for k, child := range val {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flattenObject(path, child, out)
+		}
+	case []interface{}:
+		names, ok := namedListKeys(val)
+		if !ok {
+			out[prefix] = val
+			return
+		}
+		for i, item := range val {
+			flattenObject(fmt.Sprintf("%s[%s]", prefix, names[i]), item, out)
+		}
+	default:
+		out[prefix] = val
+	}
+}
+
+func namedListKeys(list []interface{}) ([]string, bool) {
+	if len(list) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(list))
+	seen := sets.NewString()
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := m["name"].(string)
+		if !ok || seen.Has(name) {
+			return nil, false
+		}
+		seen.Insert(name)
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func isIgnoredDriftPath(path string, ignored []string) bool {
+	for _, prefix := range ignored {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// driftValueEqual 不同来源的数字类型不一致，例如 int64 和 float64，统一按字符串比较
+func driftValueEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if isScalar(a) && isScalar(b) {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return false
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, int, int32, int64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func adoptRenderKVs(kvs []*templatemodels.RenderKV, variables map[string]string) []*templatemodels.RenderKV {
+	res := make([]*templatemodels.RenderKV, 0, len(kvs))
+	for _, kv := range kvs {
+		newKV := *kv
+		if value, ok := variables[kv.Key]; ok {
+			newKV.Value = value
+		}
+		res = append(res, &newKV)
+	}
+	return res
+}
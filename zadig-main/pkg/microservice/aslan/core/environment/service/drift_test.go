@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func newDriftDeployment(replicas interface{}, image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": image},
+					},
+				},
+			},
+		},
+	}}
+}
+
+var _ = Describe("Testing env drift", func() {
+
+	Describe("test flattenObject", func() {
+		It("should expand named lists by name", func() {
+			fields := make(map[string]interface{})
+			flattenObject("", newDriftDeployment(int64(1), "app:v1").Object, fields)
+			Expect(fields).To(HaveKeyWithValue("spec.template.spec.containers[app].image", "app:v1"))
+			Expect(fields).To(HaveKeyWithValue("spec.replicas", int64(1)))
+		})
+	})
+
+	Describe("test diffDriftFields", func() {
+		It("should report nothing when live matches", func() {
+			desired := newDriftDeployment(int64(1), "app:v1")
+			live := newDriftDeployment(int64(1), "app:v1")
+			live.Object["status"] = map[string]interface{}{"replicas": int64(1)}
+			Expect(diffDriftFields(desired, nil, live, driftIgnoredPrefixes)).To(BeEmpty())
+		})
+
+		It("should report live modification against last applied", func() {
+			desired := newDriftDeployment(int64(1), "app:v1")
+			original := newDriftDeployment(float64(1), "app:v1")
+			live := newDriftDeployment(int64(1), "app:v2")
+
+			fields := diffDriftFields(desired, original, live, driftIgnoredPrefixes)
+			Expect(fields).To(HaveLen(1))
+			Expect(fields[0].Path).To(Equal("spec.template.spec.containers[app].image"))
+			Expect(fields[0].Reason).To(Equal(commonmodels.DriftReasonLiveModified))
+			Expect(fields[0].Live).To(Equal("app:v2"))
+			Expect(containerImagePath.FindStringSubmatch(fields[0].Path)[1]).To(Equal("app"))
+		})
+
+		It("should report pending template update", func() {
+			desired := newDriftDeployment(int64(2), "app:v1")
+			original := newDriftDeployment(int64(1), "app:v1")
+			live := newDriftDeployment(int64(1), "app:v1")
+
+			fields := diffDriftFields(desired, original, live, driftIgnoredPrefixes)
+			Expect(fields).To(HaveLen(1))
+			Expect(fields[0].Path).To(Equal("spec.replicas"))
+			Expect(fields[0].Reason).To(Equal(commonmodels.DriftReasonPendingUpdate))
+		})
+
+		It("should skip ignored paths", func() {
+			desired := newDriftDeployment(int64(1), "app:v1")
+			live := newDriftDeployment(int64(0), "app:v1")
+			ignored := append(append([]string{}, driftIgnoredPrefixes...), "spec.replicas")
+			Expect(diffDriftFields(desired, nil, live, ignored)).To(BeEmpty())
+		})
+	})
+
+	Describe("test liveObject", func() {
+		It("should not report type meta of objects from the lister", func() {
+			replicas := int32(1)
+			informer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			// lister 中的对象没有 TypeMeta
+			err := informer.Apps().V1().Deployments().Informer().GetIndexer().Add(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "dev"},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			checker := &driftChecker{prod: &commonmodels.Product{Namespace: "dev"}, informer: informer}
+			desired := newDriftDeployment(int64(1), "app:v1")
+			live, found, err := checker.liveObject(desired)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(live.GetAPIVersion()).To(Equal("apps/v1"))
+			Expect(live.GetKind()).To(Equal("Deployment"))
+			Expect(diffDriftFields(desired, nil, live, driftIgnoredPrefixes)).To(BeEmpty())
+		})
+	})
+})
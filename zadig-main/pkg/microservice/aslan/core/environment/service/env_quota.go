@@ -0,0 +1,601 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/hashicorp/go-multierror"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+)
+
+// hoursPerMonth 按每月平均 730 小时估算月度成本
+const hoursPerMonth = 730
+
+const bytesPerGiB = 1 << 30
+
+type ProjectResourceReport struct {
+	ProductName string                      `json:"product_name"`
+	Price       *commonmodels.ResourcePrice `json:"price"`
+	Envs        []*EnvResourceUsage         `json:"envs"`
+	Requests    *ResourceAmount             `json:"requests"`
+	Limits      *ResourceAmount             `json:"limits"`
+	Used        *ResourceAmount             `json:"used"`
+	HourlyCost  float64                     `json:"hourly_cost"`
+	MonthlyCost float64                     `json:"monthly_cost"`
+}
+
+type EnvResourceUsage struct {
+	EnvName   string                        `json:"env_name"`
+	Namespace string                        `json:"namespace"`
+	ClusterID string                        `json:"cluster_id"`
+	Quota     *commonmodels.EnvQuotaSetting `json:"quota"`
+	Pods      int                           `json:"pods"`
+	Requests  *ResourceAmount               `json:"requests"`
+	Limits    *ResourceAmount               `json:"limits"`
+	// Used 来自 metrics-server，集群未安装时为空
+	Used        *ResourceAmount `json:"used"`
+	HourlyCost  float64         `json:"hourly_cost"`
+	MonthlyCost float64         `json:"monthly_cost"`
+	Error       string          `json:"error"`
+}
+
+// ResourceAmount 中 CPU 单位为核，Memory 单位为 GiB
+type ResourceAmount struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// resourceTotals 计算过程中使用 milli core 和 byte，避免精度丢失
+type resourceTotals struct {
+	cpuMilli int64
+	memBytes int64
+}
+
+func (r *resourceTotals) add(o resourceTotals) {
+	r.cpuMilli += o.cpuMilli
+	r.memBytes += o.memBytes
+}
+
+func (r resourceTotals) amount() *ResourceAmount {
+	return &ResourceAmount{
+		CPU:    roundResource(float64(r.cpuMilli) / 1000),
+		Memory: roundResource(float64(r.memBytes) / bytesPerGiB),
+	}
+}
+
+func GetProjectQuota(productName string, log *zap.SugaredLogger) (*commonmodels.ProjectQuota, error) {
+	quota, err := commonrepo.NewProjectQuotaColl().Find(productName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &commonmodels.ProjectQuota{ProductName: productName}, nil
+		}
+		log.Errorf("failed to find quota of project %s: %s", productName, err)
+		return nil, e.ErrGetProjectQuota.AddErr(err)
+	}
+	return quota, nil
+}
+
+// UpdateProjectQuota 保存项目配额后同步到项目下所有未单独设置配额的环境
+func UpdateProjectQuota(productName, username string, args *commonmodels.ProjectQuota, log *zap.SugaredLogger) error {
+	if err := validateEnvQuota(args.EnvQuota); err != nil {
+		return e.ErrUpdateProjectQuota.AddErr(err)
+	}
+	if args.Price != nil && (args.Price.CPUCoreHour < 0 || args.Price.MemoryGiBHour < 0) {
+		return e.ErrUpdateProjectQuota.AddDesc("price must not be negative")
+	}
+
+	args.ProductName = productName
+	args.UpdateBy = username
+	if err := commonrepo.NewProjectQuotaColl().Upsert(args); err != nil {
+		log.Errorf("failed to update quota of project %s: %s", productName, err)
+		return e.ErrUpdateProjectQuota.AddErr(err)
+	}
+
+	if !projectUsesKube(productName) {
+		return nil
+	}
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: productName})
+	if err != nil {
+		return e.ErrApplyEnvQuota.AddErr(err)
+	}
+
+	errList := new(multierror.Error)
+	for _, env := range envs {
+		quota := mergeEnvQuota(env.QuotaSetting, args.EnvQuota)
+		if err := applyEnvQuotaToProduct(env, quota); err != nil {
+			log.Errorf("failed to apply quota to env %s/%s: %s", productName, env.EnvName, err)
+			errList = multierror.Append(errList, fmt.Errorf("env %s: %s", env.EnvName, err))
+		}
+	}
+	if err := errList.ErrorOrNil(); err != nil {
+		return e.ErrApplyEnvQuota.AddErr(err)
+	}
+	return nil
+}
+
+// UpdateEnvQuota 设置环境级别的配额，args 为空时恢复使用项目级别的配置
+func UpdateEnvQuota(envName, productName string, args *commonmodels.EnvQuotaSetting, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrUpdateEnvQuota.AddErr(err)
+	}
+	if err := validateEnvQuota(args); err != nil {
+		return e.ErrUpdateEnvQuota.AddErr(err)
+	}
+	if args != nil && args.ResourceQuota == nil && args.LimitRange == nil {
+		args = nil
+	}
+
+	if err := commonrepo.NewProductColl().UpdateQuotaSetting(envName, productName, args); err != nil {
+		log.Errorf("failed to update quota of env %s/%s: %s", productName, envName, err)
+		return e.ErrUpdateEnvQuota.AddErr(err)
+	}
+
+	if !projectUsesKube(productName) {
+		return nil
+	}
+	quota, err := envQuotaSetting(productName, args)
+	if err != nil {
+		return e.ErrApplyEnvQuota.AddErr(err)
+	}
+	if err := applyEnvQuotaToProduct(prod, quota); err != nil {
+		log.Errorf("failed to apply quota to env %s/%s: %s", productName, envName, err)
+		return e.ErrApplyEnvQuota.AddErr(err)
+	}
+	return nil
+}
+
+// GetProjectResourceReport 汇总项目下每个环境 Pod 申请和实际使用的资源，并按照项目配置的单价估算成本
+func GetProjectResourceReport(productName string, log *zap.SugaredLogger) (*ProjectResourceReport, error) {
+	projectQuota, err := GetProjectQuota(productName, log)
+	if err != nil {
+		return nil, e.ErrGetResourceReport.AddErr(err)
+	}
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{Name: productName})
+	if err != nil {
+		return nil, e.ErrGetResourceReport.AddErr(err)
+	}
+
+	report := &ProjectResourceReport{
+		ProductName: productName,
+		Price:       projectQuota.Price,
+		Envs:        make([]*EnvResourceUsage, 0, len(envs)),
+	}
+	var requests, limits, used resourceTotals
+	usedAvailable := false
+	for _, env := range envs {
+		usage := &EnvResourceUsage{
+			EnvName:   env.EnvName,
+			Namespace: env.Namespace,
+			ClusterID: env.ClusterID,
+			Quota:     mergeEnvQuota(env.QuotaSetting, projectQuota.EnvQuota),
+		}
+		report.Envs = append(report.Envs, usage)
+
+		envRequests, envLimits, envUsed, err := collectEnvResources(env, log)
+		if err != nil {
+			log.Warnf("failed to collect resources of env %s/%s: %s", productName, env.EnvName, err)
+			usage.Error = err.Error()
+			continue
+		}
+		usage.Pods = envRequests.pods
+		usage.Requests = envRequests.totals.amount()
+		usage.Limits = envLimits.amount()
+		usage.HourlyCost = resourceCost(envRequests.totals, projectQuota.Price)
+		usage.MonthlyCost = roundResource(usage.HourlyCost * hoursPerMonth)
+		requests.add(envRequests.totals)
+		limits.add(envLimits)
+		if envUsed != nil {
+			usage.Used = envUsed.amount()
+			used.add(*envUsed)
+			usedAvailable = true
+		}
+	}
+
+	report.Requests = requests.amount()
+	report.Limits = limits.amount()
+	if usedAvailable {
+		report.Used = used.amount()
+	}
+	report.HourlyCost = resourceCost(requests, projectQuota.Price)
+	report.MonthlyCost = roundResource(report.HourlyCost * hoursPerMonth)
+	return report, nil
+}
+
+// envQuotaSetting 返回环境实际生效的配额
+func envQuotaSetting(productName string, envQuota *commonmodels.EnvQuotaSetting) (*commonmodels.EnvQuotaSetting, error) {
+	projectQuota, err := commonrepo.NewProjectQuotaColl().Find(productName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return mergeEnvQuota(envQuota, nil), nil
+		}
+		return nil, err
+	}
+	return mergeEnvQuota(envQuota, projectQuota.EnvQuota), nil
+}
+
+// mergeEnvQuota ResourceQuota 和 LimitRange 分别以环境级别的配置优先
+func mergeEnvQuota(envQuota, projectQuota *commonmodels.EnvQuotaSetting) *commonmodels.EnvQuotaSetting {
+	res := &commonmodels.EnvQuotaSetting{}
+	if projectQuota != nil {
+		res.ResourceQuota = projectQuota.ResourceQuota
+		res.LimitRange = projectQuota.LimitRange
+	}
+	if envQuota != nil {
+		if envQuota.ResourceQuota != nil {
+			res.ResourceQuota = envQuota.ResourceQuota
+		}
+		if envQuota.LimitRange != nil {
+			res.LimitRange = envQuota.LimitRange
+		}
+	}
+	return res
+}
+
+// kubeEnvQuota 用于创建和更新环境时应用配额，读取配置失败时不影响环境本身
+// 未设置任何配额时返回 nil，已有配额的删除由 UpdateEnvQuota 和 UpdateProjectQuota 处理
+func kubeEnvQuota(productName string, envQuota *commonmodels.EnvQuotaSetting, log *zap.SugaredLogger) *commonmodels.EnvQuotaSetting {
+	quota, err := envQuotaSetting(productName, envQuota)
+	if err != nil {
+		log.Warnf("failed to get quota of project %s: %s", productName, err)
+		return nil
+	}
+	if quota.ResourceQuota == nil && quota.LimitRange == nil {
+		return nil
+	}
+	return quota
+}
+
+func projectUsesKube(productName string) bool {
+	productTmpl, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return true
+	}
+	return preCreateNSAndSecret(productTmpl.ProductFeature)
+}
+
+func applyEnvQuotaToProduct(prod *commonmodels.Product, quota *commonmodels.EnvQuotaSetting) error {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	return applyEnvQuota(prod.Namespace, quota, kubeClient)
+}
+
+// applyEnvQuota 创建或更新 namespace 中的 ResourceQuota 和 LimitRange，未设置的部分会被删除
+func applyEnvQuota(namespace string, quota *commonmodels.EnvQuotaSetting, kubeClient client.Client) error {
+	if quota == nil {
+		quota = &commonmodels.EnvQuotaSetting{}
+	}
+
+	rq, err := buildResourceQuota(namespace, quota.ResourceQuota)
+	if err != nil {
+		return err
+	}
+	if rq != nil {
+		err = updater.CreateOrPatchResourceQuota(rq, kubeClient)
+	} else {
+		err = updater.DeleteResourceQuota(namespace, setting.EnvResourceQuotaName, kubeClient)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to apply resource quota: %s", err)
+	}
+
+	lr, err := buildLimitRange(namespace, quota.LimitRange)
+	if err != nil {
+		return err
+	}
+	if lr != nil {
+		err = updater.CreateOrPatchLimitRange(lr, kubeClient)
+	} else {
+		err = updater.DeleteLimitRange(namespace, setting.EnvLimitRangeName, kubeClient)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to apply limit range: %s", err)
+	}
+	return nil
+}
+
+func validateEnvQuota(quota *commonmodels.EnvQuotaSetting) error {
+	if quota == nil {
+		return nil
+	}
+
+	rq, err := buildResourceQuota("", quota.ResourceQuota)
+	if err != nil {
+		return err
+	}
+	if rq != nil {
+		if err := checkNotGreater(rq.Spec.Hard, corev1.ResourceRequestsCPU, corev1.ResourceLimitsCPU); err != nil {
+			return err
+		}
+		if err := checkNotGreater(rq.Spec.Hard, corev1.ResourceRequestsMemory, corev1.ResourceLimitsMemory); err != nil {
+			return err
+		}
+	}
+
+	lr, err := buildLimitRange("", quota.LimitRange)
+	if err != nil {
+		return err
+	}
+	if lr != nil {
+		item := lr.Spec.Limits[0]
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := item.DefaultRequest[name]
+			limit, hasLimit := item.Default[name]
+			if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+				return fmt.Errorf("default request of %s is greater than default limit", name)
+			}
+		}
+	}
+	return nil
+}
+
+func checkNotGreater(list corev1.ResourceList, small, big corev1.ResourceName) error {
+	s, ok := list[small]
+	if !ok {
+		return nil
+	}
+	b, ok := list[big]
+	if !ok {
+		return nil
+	}
+	if s.Cmp(b) > 0 {
+		return fmt.Errorf("%s is greater than %s", small, big)
+	}
+	return nil
+}
+
+func buildResourceQuota(namespace string, args *commonmodels.ResourceQuotaSetting) (*corev1.ResourceQuota, error) {
+	if args == nil {
+		return nil, nil
+	}
+	if args.Pods < 0 {
+		return nil, fmt.Errorf("pods must not be negative")
+	}
+
+	hard := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:    args.RequestsCPU,
+		corev1.ResourceRequestsMemory: args.RequestsMemory,
+		corev1.ResourceLimitsCPU:      args.LimitsCPU,
+		corev1.ResourceLimitsMemory:   args.LimitsMemory,
+	} {
+		if err := setQuantity(hard, name, value); err != nil {
+			return nil, err
+		}
+	}
+	if args.Pods > 0 {
+		hard[corev1.ResourcePods] = *resource.NewQuantity(args.Pods, resource.DecimalSI)
+	}
+	if len(hard) == 0 {
+		return nil, nil
+	}
+
+	return &corev1.ResourceQuota{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ResourceQuota",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      setting.EnvResourceQuotaName,
+		},
+		Spec: corev1.ResourceQuotaSpec{Hard: hard},
+	}, nil
+}
+
+func buildLimitRange(namespace string, args *commonmodels.LimitRangeSetting) (*corev1.LimitRange, error) {
+	if args == nil {
+		return nil, nil
+	}
+
+	defaultRequest, defaultLimit := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, item := range []struct {
+		list  corev1.ResourceList
+		name  corev1.ResourceName
+		value string
+	}{
+		{defaultRequest, corev1.ResourceCPU, args.DefaultRequestCPU},
+		{defaultRequest, corev1.ResourceMemory, args.DefaultRequestMemory},
+		{defaultLimit, corev1.ResourceCPU, args.DefaultLimitCPU},
+		{defaultLimit, corev1.ResourceMemory, args.DefaultLimitMemory},
+	} {
+		if err := setQuantity(item.list, item.name, item.value); err != nil {
+			return nil, err
+		}
+	}
+	if len(defaultRequest) == 0 && len(defaultLimit) == 0 {
+		return nil, nil
+	}
+
+	return &corev1.LimitRange{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "LimitRange",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      setting.EnvLimitRangeName,
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:           corev1.LimitTypeContainer,
+				DefaultRequest: defaultRequest,
+				Default:        defaultLimit,
+			}},
+		},
+	}, nil
+}
+
+func setQuantity(list corev1.ResourceList, name corev1.ResourceName, value string) error {
+	if value == "" {
+		return nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %s", name, value, err)
+	}
+	if q.Sign() < 0 {
+		return fmt.Errorf("%s must not be negative", name)
+	}
+	list[name] = q
+	return nil
+}
+
+type podRequests struct {
+	pods   int
+	totals resourceTotals
+}
+
+// collectEnvResources 返回环境中运行的 Pod 申请和限制的资源，以及 metrics-server 中的实际用量
+func collectEnvResources(prod *commonmodels.Product, log *zap.SugaredLogger) (*podRequests, resourceTotals, *resourceTotals, error) {
+	var limits resourceTotals
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, limits, nil, err
+	}
+	pods, err := getter.ListPods(prod.Namespace, envWorkloadSelector(prod), kubeClient)
+	if err != nil {
+		return nil, limits, nil, err
+	}
+
+	requests := &podRequests{}
+	podNames := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podNames[pod.Name] = true
+		requests.pods++
+		requests.totals.add(podResourceTotals(&pod.Spec, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Requests }))
+		limits.add(podResourceTotals(&pod.Spec, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Limits }))
+	}
+
+	used, err := podMetricsUsage(prod.ClusterID, prod.Namespace, podNames)
+	if err != nil {
+		log.Debugf("metrics of namespace %s are unavailable: %s", prod.Namespace, err)
+		return requests, limits, nil, nil
+	}
+	return requests, limits, used, nil
+}
+
+// podResourceTotals 与调度器的计算方式一致：业务容器之和与最大的 init 容器取较大值
+func podResourceTotals(spec *corev1.PodSpec, resources func(corev1.ResourceRequirements) corev1.ResourceList) resourceTotals {
+	var res resourceTotals
+	for _, c := range spec.Containers {
+		res.add(resourceListTotals(resources(c.Resources)))
+	}
+	for _, c := range spec.InitContainers {
+		initTotals := resourceListTotals(resources(c.Resources))
+		if initTotals.cpuMilli > res.cpuMilli {
+			res.cpuMilli = initTotals.cpuMilli
+		}
+		if initTotals.memBytes > res.memBytes {
+			res.memBytes = initTotals.memBytes
+		}
+	}
+	return res
+}
+
+func resourceListTotals(list corev1.ResourceList) resourceTotals {
+	var res resourceTotals
+	if cpu, ok := list[corev1.ResourceCPU]; ok {
+		res.cpuMilli = cpu.MilliValue()
+	}
+	if mem, ok := list[corev1.ResourceMemory]; ok {
+		res.memBytes = mem.Value()
+	}
+	return res
+}
+
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Containers []struct {
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// podMetricsUsage 通过 metrics.k8s.io 获取 Pod 的实际用量，集群未安装 metrics-server 时返回错误
+func podMetricsUsage(clusterID, namespace string, podNames map[string]bool) (*resourceTotals, error) {
+	clientset, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := clientset.Discovery().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		DoRaw(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	return sumPodMetrics(data, podNames)
+}
+
+func sumPodMetrics(data []byte, podNames map[string]bool) (*resourceTotals, error) {
+	metrics := &podMetricsList{}
+	if err := json.Unmarshal(data, metrics); err != nil {
+		return nil, err
+	}
+
+	res := &resourceTotals{}
+	for _, item := range metrics.Items {
+		if !podNames[item.Metadata.Name] {
+			continue
+		}
+		for _, c := range item.Containers {
+			res.add(resourceListTotals(c.Usage))
+		}
+	}
+	return res, nil
+}
+
+// resourceCost 按申请的资源估算每小时成本
+func resourceCost(requests resourceTotals, price *commonmodels.ResourcePrice) float64 {
+	if price == nil {
+		return 0
+	}
+	cost := float64(requests.cpuMilli)/1000*price.CPUCoreHour + float64(requests.memBytes)/bytesPerGiB*price.MemoryGiBHour
+	return roundResource(cost)
+}
+
+func roundResource(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func containerWithResources(cpu, memory string) corev1.Container {
+	return corev1.Container{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+var _ = Describe("Testing env quota", func() {
+
+	Describe("test buildResourceQuota", func() {
+		It("should build hard limits in namespace", func() {
+			rq, err := buildResourceQuota("ns", &commonmodels.ResourceQuotaSetting{
+				RequestsCPU:  "2",
+				LimitsMemory: "4Gi",
+				Pods:         10,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rq.Namespace).To(Equal("ns"))
+			Expect(rq.Name).To(Equal(setting.EnvResourceQuotaName))
+			Expect(rq.Spec.Hard).To(HaveLen(3))
+			Expect(rq.Spec.Hard.Pods().Value()).To(Equal(int64(10)))
+		})
+
+		It("should return nil when nothing is set", func() {
+			rq, err := buildResourceQuota("ns", &commonmodels.ResourceQuotaSetting{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rq).To(BeNil())
+		})
+	})
+
+	DescribeTable("test validateEnvQuota",
+		func(quota *commonmodels.EnvQuotaSetting, valid bool) {
+			err := validateEnvQuota(quota)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("empty", nil, true),
+		Entry("valid quota", &commonmodels.EnvQuotaSetting{
+			ResourceQuota: &commonmodels.ResourceQuotaSetting{RequestsCPU: "500m", LimitsCPU: "1"},
+			LimitRange:    &commonmodels.LimitRangeSetting{DefaultRequestMemory: "128Mi", DefaultLimitMemory: "256Mi"},
+		}, true),
+		Entry("invalid quantity", &commonmodels.EnvQuotaSetting{
+			ResourceQuota: &commonmodels.ResourceQuotaSetting{RequestsCPU: "two"},
+		}, false),
+		Entry("requests greater than limits", &commonmodels.EnvQuotaSetting{
+			ResourceQuota: &commonmodels.ResourceQuotaSetting{RequestsMemory: "2Gi", LimitsMemory: "1Gi"},
+		}, false),
+		Entry("default request greater than default limit", &commonmodels.EnvQuotaSetting{
+			LimitRange: &commonmodels.LimitRangeSetting{DefaultRequestCPU: "2", DefaultLimitCPU: "1"},
+		}, false),
+	)
+
+	Describe("test mergeEnvQuota", func() {
+		It("should prefer env setting for each part", func() {
+			projectQuota := &commonmodels.EnvQuotaSetting{
+				ResourceQuota: &commonmodels.ResourceQuotaSetting{RequestsCPU: "4"},
+				LimitRange:    &commonmodels.LimitRangeSetting{DefaultRequestCPU: "100m"},
+			}
+			envQuota := &commonmodels.EnvQuotaSetting{
+				ResourceQuota: &commonmodels.ResourceQuotaSetting{RequestsCPU: "8"},
+			}
+
+			quota := mergeEnvQuota(envQuota, projectQuota)
+			Expect(quota.ResourceQuota.RequestsCPU).To(Equal("8"))
+			Expect(quota.LimitRange.DefaultRequestCPU).To(Equal("100m"))
+		})
+	})
+
+	Describe("test podResourceTotals", func() {
+		It("should take the larger of containers and init containers", func() {
+			spec := &corev1.PodSpec{
+				Containers: []corev1.Container{
+					containerWithResources("250m", "256Mi"),
+					containerWithResources("250m", "256Mi"),
+				},
+				InitContainers: []corev1.Container{
+					containerWithResources("1", "128Mi"),
+				},
+			}
+
+			totals := podResourceTotals(spec, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Requests })
+			Expect(totals.cpuMilli).To(Equal(int64(1000)))
+			Expect(totals.memBytes).To(Equal(int64(512 << 20)))
+		})
+	})
+
+	Describe("test sumPodMetrics", func() {
+		It("should only count pods of the env", func() {
+			data := []byte(`{"items": [
+				{"metadata": {"name": "a"}, "containers": [{"usage": {"cpu": "100m", "memory": "1Gi"}}]},
+				{"metadata": {"name": "b"}, "containers": [{"usage": {"cpu": "300m", "memory": "1Gi"}}]}
+			]}`)
+
+			used, err := sumPodMetrics(data, map[string]bool{"a": true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(used.amount()).To(Equal(&ResourceAmount{CPU: 0.1, Memory: 1}))
+		})
+	})
+
+	Describe("test resourceCost", func() {
+		It("should price requested cpu and memory", func() {
+			requests := resourceTotals{cpuMilli: 1500, memBytes: 2 << 30}
+			price := &commonmodels.ResourcePrice{CPUCoreHour: 0.2, MemoryGiBHour: 0.05}
+			Expect(resourceCost(requests, price)).To(Equal(0.4))
+			Expect(resourceCost(requests, nil)).To(Equal(float64(0)))
+		})
+	})
+})
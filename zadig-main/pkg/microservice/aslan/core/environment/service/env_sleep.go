@@ -0,0 +1,366 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+// envExpireWarningDuration 环境到期前多久发送提醒
+const envExpireWarningDuration = 24 * time.Hour
+
+const envLifecycleOperator = "system"
+
+type EnvTTLArgs struct {
+	// TTL 从现在开始的有效期，单位为小时，0 表示永不过期
+	TTL int64 `json:"ttl"`
+}
+
+func SleepEnv(envName, productName, username string, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrSleepEnv.AddErr(err)
+	}
+	if err := sleepEnv(prod, username, log); err != nil {
+		return e.ErrSleepEnv.AddErr(err)
+	}
+	return nil
+}
+
+func WakeEnv(envName, productName, username string, log *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrWakeEnv.AddErr(err)
+	}
+	if err := wakeEnv(prod, username, log); err != nil {
+		return e.ErrWakeEnv.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateEnvSleepSchedule(envName, productName string, schedule *commonmodels.EnvSleepSchedule, log *zap.SugaredLogger) error {
+	if err := validateSleepSchedule(schedule); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName}); err != nil {
+		return e.ErrUpdateSleepSchedule.AddErr(err)
+	}
+	if err := commonrepo.NewProductColl().UpdateSleepSchedule(envName, productName, schedule); err != nil {
+		log.Errorf("[%s][P:%s] failed to update sleep schedule: %s", envName, productName, err)
+		return e.ErrUpdateSleepSchedule.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateEnvTTL(envName, productName string, args *EnvTTLArgs, log *zap.SugaredLogger) error {
+	if args.TTL < 0 {
+		return e.ErrInvalidParam.AddDesc("ttl不能为负数")
+	}
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName}); err != nil {
+		return e.ErrUpdateEnvTTL.AddErr(err)
+	}
+
+	var expireTime int64
+	if args.TTL > 0 {
+		expireTime = time.Now().Add(time.Duration(args.TTL) * time.Hour).Unix()
+	}
+	if err := commonrepo.NewProductColl().UpdateExpireTime(envName, productName, expireTime); err != nil {
+		log.Errorf("[%s][P:%s] failed to update expire time: %s", envName, productName, err)
+		return e.ErrUpdateEnvTTL.AddErr(err)
+	}
+	return nil
+}
+
+// EnvLifecycleCronJob 按照休眠策略休眠/唤醒环境，并清理到期的环境
+func EnvLifecycleCronJob(requestID string, log *zap.SugaredLogger) {
+	log.Info("[EnvLifecycleCronJob] started ...")
+	defer log.Info("[EnvLifecycleCronJob] end")
+
+	products, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{ExcludeStatus: setting.ProductStatusDeleting})
+	if err != nil {
+		log.Errorf("[Product.List] error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, product := range products {
+		if product.ExpireTime > 0 {
+			if now.Unix() >= product.ExpireTime {
+				if err := commonservice.DeleteProduct("robot", product.EnvName, product.ProductName, requestID, log); err != nil {
+					log.Errorf("[%s][P:%s] delete expired product error: %v", product.EnvName, product.ProductName, err)
+				} else {
+					log.Warnf("[%s] expired product %s deleted", product.EnvName, product.ProductName)
+				}
+				continue
+			}
+			if !product.ExpireWarned && now.Add(envExpireWarningDuration).Unix() >= product.ExpireTime {
+				notification.Dispatch(notification.NewEnvExpiringEvent(product))
+				if err := commonrepo.NewProductColl().UpdateExpireWarned(product.EnvName, product.ProductName, true); err != nil {
+					log.Errorf("[%s][P:%s] failed to update expire warned: %s", product.EnvName, product.ProductName, err)
+				}
+			}
+		}
+
+		if product.SleepSchedule == nil || !product.SleepSchedule.Enabled {
+			continue
+		}
+		sleep, at, ok := lastScheduleTransition(product.SleepSchedule, now)
+		if !ok {
+			continue
+		}
+		// 手动休眠/唤醒之后，直到下一个策略时间点前都以手动操作为准
+		state := product.SleepState
+		if state != nil && state.UpdateTime >= at.Unix() {
+			continue
+		}
+		if sleeping := state != nil && state.Sleeping; sleeping == sleep {
+			continue
+		}
+
+		if sleep {
+			err = sleepEnv(product, envLifecycleOperator, log)
+		} else {
+			err = wakeEnv(product, envLifecycleOperator, log)
+		}
+		if err != nil {
+			log.Errorf("[%s][P:%s] scheduled sleep=%t error: %s", product.EnvName, product.ProductName, sleep, err)
+		}
+	}
+}
+
+func sleepEnv(prod *commonmodels.Product, username string, log *zap.SugaredLogger) error {
+	if prod.Source == setting.PMDeployType {
+		return fmt.Errorf("主机环境不支持休眠")
+	}
+	if prod.SleepState != nil && prod.SleepState.Sleeping {
+		return nil
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	selector := envWorkloadSelector(prod)
+	ds, err := getter.ListDeployments(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return err
+	}
+	ss, err := getter.ListStatefulSets(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return err
+	}
+
+	state := &commonmodels.EnvSleepState{
+		Sleeping:   true,
+		UpdateTime: time.Now().Unix(),
+		UpdateBy:   username,
+	}
+	for _, d := range ds {
+		if replicas := specReplicas(d.Spec.Replicas); replicas > 0 {
+			state.Replicas = append(state.Replicas, &commonmodels.WorkloadReplicas{Kind: setting.Deployment, Name: d.Name, Replicas: replicas})
+		}
+	}
+	for _, s := range ss {
+		if replicas := specReplicas(s.Spec.Replicas); replicas > 0 {
+			state.Replicas = append(state.Replicas, &commonmodels.WorkloadReplicas{Kind: setting.StatefulSet, Name: s.Name, Replicas: replicas})
+		}
+	}
+
+	// 先记录副本数再缩容，避免缩容中途失败后无法恢复
+	if err := commonrepo.NewProductColl().UpdateSleepState(prod.EnvName, prod.ProductName, state); err != nil {
+		return err
+	}
+
+	errList := new(multierror.Error)
+	for _, w := range state.Replicas {
+		if err := scaleWorkload(prod.Namespace, w.Kind, w.Name, 0, kubeClient); err != nil {
+			errList = multierror.Append(errList, err)
+		}
+	}
+	log.Infof("[%s][P:%s] env is put to sleep by %s, %d workloads scaled to zero", prod.EnvName, prod.ProductName, username, len(state.Replicas))
+
+	return errList.ErrorOrNil()
+}
+
+func wakeEnv(prod *commonmodels.Product, username string, log *zap.SugaredLogger) error {
+	if prod.SleepState == nil || !prod.SleepState.Sleeping {
+		return nil
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	errList := new(multierror.Error)
+	for _, w := range prod.SleepState.Replicas {
+		err := scaleWorkload(prod.Namespace, w.Kind, w.Name, w.Replicas, kubeClient)
+		// 休眠期间被删除的工作负载无需恢复
+		if err != nil && !apierrors.IsNotFound(err) {
+			errList = multierror.Append(errList, err)
+		}
+	}
+	if err := errList.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	state := &commonmodels.EnvSleepState{
+		Sleeping:   false,
+		UpdateTime: time.Now().Unix(),
+		UpdateBy:   username,
+	}
+	if err := commonrepo.NewProductColl().UpdateSleepState(prod.EnvName, prod.ProductName, state); err != nil {
+		return err
+	}
+	log.Infof("[%s][P:%s] env is woken up by %s", prod.EnvName, prod.ProductName, username)
+
+	return nil
+}
+
+// envWorkloadSelector 选择环境中的工作负载，helm 环境的命名空间可能被共享，只选择本环境 release 中的工作负载
+func envWorkloadSelector(prod *commonmodels.Product) labels.Selector {
+	if prod.Source != setting.HelmDeployType {
+		return labels.Set{setting.ProductLabel: prod.ProductName}.AsSelector()
+	}
+
+	releases := make([]string, 0)
+	for serviceName := range prod.GetServiceMap() {
+		releases = append(releases, util.GeneHelmReleaseName(prod.Namespace, serviceName))
+	}
+	if len(releases) == 0 {
+		return labels.Nothing()
+	}
+	sort.Strings(releases)
+	// chart 按照规范使用 app.kubernetes.io/instance 标记 release
+	req, err := labels.NewRequirement(setting.InstanceLabel, selection.In, releases)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return labels.NewSelector().Add(*req)
+}
+
+func specReplicas(replicas *int32) int {
+	if replicas == nil {
+		return 1
+	}
+	return int(*replicas)
+}
+
+func scaleWorkload(namespace, kind, name string, replicas int, kubeClient client.Client) error {
+	switch kind {
+	case setting.Deployment:
+		return updater.ScaleDeployment(namespace, name, replicas, kubeClient)
+	case setting.StatefulSet:
+		return updater.ScaleStatefulSet(namespace, name, replicas, kubeClient)
+	default:
+		return fmt.Errorf("unsupported workload kind %s", kind)
+	}
+}
+
+func validateSleepSchedule(schedule *commonmodels.EnvSleepSchedule) error {
+	if _, _, err := parseClock(schedule.SleepTime); err != nil {
+		return fmt.Errorf("invalid sleep_time: %s", err)
+	}
+	if _, _, err := parseClock(schedule.WakeTime); err != nil {
+		return fmt.Errorf("invalid wake_time: %s", err)
+	}
+	if schedule.SleepTime == schedule.WakeTime {
+		return fmt.Errorf("sleep_time and wake_time can't be the same")
+	}
+	for _, day := range schedule.Weekdays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid weekday %d", day)
+		}
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", err)
+	}
+	return nil
+}
+
+func parseClock(clock string) (int, int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// lastScheduleTransition 返回 now 之前最近一次策略时间点，以及该时间点环境应处于休眠还是唤醒
+func lastScheduleTransition(schedule *commonmodels.EnvSleepSchedule, now time.Time) (sleep bool, at time.Time, ok bool) {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return false, time.Time{}, false
+	}
+	sleepHour, sleepMin, err := parseClock(schedule.SleepTime)
+	if err != nil {
+		return false, time.Time{}, false
+	}
+	wakeHour, wakeMin, err := parseClock(schedule.WakeTime)
+	if err != nil {
+		return false, time.Time{}, false
+	}
+
+	weekdays := sets.NewInt(schedule.Weekdays...)
+	now = now.In(loc)
+	// 越近的日期时间点越晚，找到第一个有时间点早于 now 的日期即可
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, -i)
+		if weekdays.Len() > 0 && !weekdays.Has(int(day.Weekday())) {
+			continue
+		}
+		sleepAt := time.Date(day.Year(), day.Month(), day.Day(), sleepHour, sleepMin, 0, 0, loc)
+		wakeAt := time.Date(day.Year(), day.Month(), day.Day(), wakeHour, wakeMin, 0, 0, loc)
+		if sleepAt.After(now) && wakeAt.After(now) {
+			continue
+		}
+		switch {
+		case sleepAt.After(now):
+			return false, wakeAt, true
+		case wakeAt.After(now):
+			return true, sleepAt, true
+		case sleepAt.After(wakeAt):
+			return true, sleepAt, true
+		default:
+			return false, wakeAt, true
+		}
+	}
+	return false, time.Time{}, false
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing env sleep", func() {
+
+	schedule := &commonmodels.EnvSleepSchedule{
+		Enabled:   true,
+		SleepTime: "20:00",
+		WakeTime:  "09:00",
+		Weekdays:  []int{1, 2, 3, 4, 5},
+		Timezone:  "UTC",
+	}
+
+	DescribeTable("test lastScheduleTransition",
+		func(now time.Time, sleep bool, at time.Time) {
+			gotSleep, gotAt, ok := lastScheduleTransition(schedule, now)
+			Expect(ok).To(BeTrue())
+			Expect(gotSleep).To(Equal(sleep))
+			Expect(gotAt.Equal(at)).To(BeTrue())
+		},
+		// 2021-11-01 是周一
+		Entry("working hours", time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC), false, time.Date(2021, 11, 1, 9, 0, 0, 0, time.UTC)),
+		Entry("after sleep time", time.Date(2021, 11, 1, 21, 0, 0, 0, time.UTC), true, time.Date(2021, 11, 1, 20, 0, 0, 0, time.UTC)),
+		Entry("before wake time", time.Date(2021, 11, 2, 8, 0, 0, 0, time.UTC), true, time.Date(2021, 11, 1, 20, 0, 0, 0, time.UTC)),
+		Entry("weekend", time.Date(2021, 11, 7, 12, 0, 0, 0, time.UTC), true, time.Date(2021, 11, 5, 20, 0, 0, 0, time.UTC)),
+	)
+
+	Describe("test validateSleepSchedule", func() {
+		It("should accept a valid schedule", func() {
+			Expect(validateSleepSchedule(schedule)).To(Succeed())
+		})
+
+		It("should reject invalid clock and weekday", func() {
+			Expect(validateSleepSchedule(&commonmodels.EnvSleepSchedule{SleepTime: "25:00", WakeTime: "09:00"})).NotTo(Succeed())
+			Expect(validateSleepSchedule(&commonmodels.EnvSleepSchedule{SleepTime: "20:00", WakeTime: "09:00", Weekdays: []int{7}})).NotTo(Succeed())
+			Expect(validateSleepSchedule(&commonmodels.EnvSleepSchedule{SleepTime: "09:00", WakeTime: "09:00"})).NotTo(Succeed())
+		})
+	})
+
+	Describe("test envWorkloadSelector", func() {
+		It("should only select the releases of a helm env", func() {
+			prod := &commonmodels.Product{
+				Source:    setting.HelmDeployType,
+				Namespace: "shared",
+				Services:  [][]*commonmodels.ProductService{{{ServiceName: "a"}, {ServiceName: "b"}}},
+			}
+			selector := envWorkloadSelector(prod)
+			Expect(selector.Matches(labels.Set{setting.InstanceLabel: "shared-a"})).To(BeTrue())
+			Expect(selector.Matches(labels.Set{setting.InstanceLabel: "shared-c"})).To(BeFalse())
+			Expect(selector.Matches(labels.Set{})).To(BeFalse())
+		})
+
+		It("should select nothing for a helm env without services", func() {
+			prod := &commonmodels.Product{Source: setting.HelmDeployType, Namespace: "shared"}
+			Expect(envWorkloadSelector(prod).Empty()).To(BeFalse())
+			Expect(envWorkloadSelector(prod).Matches(labels.Set{})).To(BeFalse())
+		})
+	})
+})
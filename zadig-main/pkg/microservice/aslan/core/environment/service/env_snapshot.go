@@ -0,0 +1,523 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+)
+
+const (
+	snapshotFileName     = "snapshot.json"
+	snapshotManifestsDir = "manifests"
+	// snapshotMaxSize 导入的快照内容大小上限
+	snapshotMaxSize = 64 << 20
+)
+
+type CreateEnvSnapshotArgs struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RestoreEnvSnapshotArgs 为空的字段沿用快照中的配置
+type RestoreEnvSnapshotArgs struct {
+	EnvName   string `json:"env_name"`
+	ClusterID string `json:"cluster_id"`
+	Namespace string `json:"namespace"`
+}
+
+func CreateEnvSnapshot(envName, productName, username string, args *CreateEnvSnapshotArgs, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+	}
+	// 快照中不包含 helm chart，无法在其他系统中恢复
+	if prod.Source == setting.HelmDeployType {
+		return nil, e.ErrCreateEnvSnapshot.AddDesc("暂不支持为 helm 环境创建快照")
+	}
+
+	snapshot := &commonmodels.EnvSnapshot{
+		Name:        args.Name,
+		ProductName: productName,
+		EnvName:     envName,
+		Description: args.Description,
+		Product:     prod,
+		CreateBy:    username,
+	}
+	if snapshot.Name == "" {
+		snapshot.Name = fmt.Sprintf("%s-%s", envName, time.Now().Format("20060102150405"))
+	}
+
+	if prod.Render != nil && prod.Render.Name != "" {
+		renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: prod.Render.Name, Revision: prod.Render.Revision})
+		if err != nil {
+			log.Errorf("[%s][P:%s] failed to find renderset %s/%d: %s", envName, productName, prod.Render.Name, prod.Render.Revision, err)
+			return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+		}
+		snapshot.RenderSet = renderSet
+	}
+
+	for _, group := range prod.Services {
+		for _, svc := range group {
+			svcSnapshot := &commonmodels.ServiceSnapshot{
+				ServiceName: svc.ServiceName,
+				ProductName: svc.ProductName,
+				Type:        svc.Type,
+				Revision:    svc.Revision,
+			}
+			if svc.Type == setting.K8SDeployType {
+				tmpl, err := commonrepo.NewServiceColl().Find(&commonrepo.ServiceFindOption{
+					ServiceName: svc.ServiceName,
+					ProductName: svc.ProductName,
+					Type:        svc.Type,
+					Revision:    svc.Revision,
+				})
+				if err != nil {
+					log.Warnf("[%s][P:%s] failed to find service %s/%d: %s", envName, productName, svc.ServiceName, svc.Revision, err)
+				} else {
+					svcSnapshot.Yaml = tmpl.Yaml
+					svcSnapshot.Source = tmpl.Source
+					svcSnapshot.Visibility = tmpl.Visibility
+					svcSnapshot.Containers = tmpl.Containers
+				}
+			}
+			if prod.Source != setting.PMDeployType && prod.Source != setting.HelmDeployType {
+				if yamls := ExportYaml(envName, productName, svc.ServiceName, log); len(yamls) > 0 {
+					svcSnapshot.Manifest = strings.Join(yamls, "\n---\n")
+				}
+			}
+			snapshot.Services = append(snapshot.Services, svcSnapshot)
+		}
+	}
+
+	if prod.Source != setting.PMDeployType {
+		images, err := listLiveImages(prod)
+		if err != nil {
+			// 集群不可用时仍然允许创建快照，恢复时使用环境记录中的镜像
+			log.Warnf("[%s][P:%s] failed to list live images: %s", envName, productName, err)
+		}
+		snapshot.Images = images
+	}
+
+	if err := commonrepo.NewEnvSnapshotColl().Create(snapshot); err != nil {
+		log.Errorf("[%s][P:%s] failed to create snapshot: %s", envName, productName, err)
+		return nil, e.ErrCreateEnvSnapshot.AddErr(err)
+	}
+	return snapshot, nil
+}
+
+func ListEnvSnapshots(envName, productName string, log *zap.SugaredLogger) ([]*commonmodels.EnvSnapshot, error) {
+	snapshots, err := commonrepo.NewEnvSnapshotColl().List(&commonrepo.EnvSnapshotListOption{ProductName: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to list snapshots of %s/%s: %s", productName, envName, err)
+		return nil, e.ErrListEnvSnapshots.AddErr(err)
+	}
+	return snapshots, nil
+}
+
+func GetEnvSnapshot(id, productName string, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := findEnvSnapshot(id, productName)
+	if err != nil {
+		log.Errorf("failed to find snapshot %s: %s", id, err)
+		return nil, e.ErrGetEnvSnapshot.AddErr(err)
+	}
+	return snapshot, nil
+}
+
+func DeleteEnvSnapshot(id, productName string, log *zap.SugaredLogger) error {
+	if _, err := findEnvSnapshot(id, productName); err != nil {
+		return e.ErrDeleteEnvSnapshot.AddErr(err)
+	}
+	if err := commonrepo.NewEnvSnapshotColl().Delete(id); err != nil {
+		log.Errorf("failed to delete snapshot %s: %s", id, err)
+		return e.ErrDeleteEnvSnapshot.AddErr(err)
+	}
+	return nil
+}
+
+// ExportEnvSnapshot 将快照打包为 tar.gz，包含快照内容和快照时 k8s 服务的资源 yaml
+func ExportEnvSnapshot(id, productName string, log *zap.SugaredLogger) ([]byte, string, error) {
+	snapshot, err := findEnvSnapshot(id, productName)
+	if err != nil {
+		return nil, "", e.ErrExportEnvSnapshot.AddErr(err)
+	}
+
+	files := map[string][]byte{}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, "", e.ErrExportEnvSnapshot.AddErr(err)
+	}
+	files[snapshotFileName] = data
+
+	for _, svc := range snapshot.Services {
+		if svc.Manifest == "" {
+			continue
+		}
+		files[fmt.Sprintf("%s/%s.yaml", snapshotManifestsDir, svc.ServiceName)] = []byte(svc.Manifest)
+	}
+
+	archive, err := packSnapshotArchive(files)
+	if err != nil {
+		log.Errorf("failed to pack snapshot %s: %s", id, err)
+		return nil, "", e.ErrExportEnvSnapshot.AddErr(err)
+	}
+	return archive, fmt.Sprintf("%s-%s.tar.gz", snapshot.ProductName, snapshot.Name), nil
+}
+
+// ImportEnvSnapshot 从导出的 tar.gz 中读取快照并保存为当前项目的快照
+func ImportEnvSnapshot(productName, username string, reader io.Reader, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := unpackSnapshotArchive(reader)
+	if err != nil {
+		return nil, e.ErrImportEnvSnapshot.AddErr(err)
+	}
+	if snapshot.Product == nil {
+		return nil, e.ErrImportEnvSnapshot.AddDesc("快照中缺少环境信息")
+	}
+	if snapshot.ProductName != productName {
+		return nil, e.ErrImportEnvSnapshot.AddDesc(fmt.Sprintf("快照属于项目 %s", snapshot.ProductName))
+	}
+	if snapshot.Product.Source == setting.HelmDeployType {
+		return nil, e.ErrImportEnvSnapshot.AddDesc("快照中不包含 helm chart，暂不支持导入 helm 环境的快照")
+	}
+
+	snapshot.CreateBy = username
+	if err := commonrepo.NewEnvSnapshotColl().Create(snapshot); err != nil {
+		log.Errorf("failed to import snapshot %s: %s", snapshot.Name, err)
+		return nil, e.ErrImportEnvSnapshot.AddErr(err)
+	}
+	return snapshot, nil
+}
+
+// RestoreEnvSnapshot 根据快照创建新环境，可以指定新的环境名、集群和命名空间
+func RestoreEnvSnapshot(id, productName, username, requestID string, args *RestoreEnvSnapshotArgs, log *zap.SugaredLogger) error {
+	snapshot, err := findEnvSnapshot(id, productName)
+	if err != nil {
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+
+	prod := snapshot.Product
+	if args.EnvName != "" {
+		prod.EnvName = args.EnvName
+	}
+	if _, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: prod.EnvName}); err == nil {
+		return e.ErrRestoreEnvSnapshot.AddDesc(fmt.Sprintf("环境 %s 已存在，请先删除或指定新的环境名称", prod.EnvName))
+	}
+	if err := ensureSnapshotServices(snapshot, username, log); err != nil {
+		return e.ErrRestoreEnvSnapshot.AddErr(err)
+	}
+
+	if args.ClusterID != "" {
+		prod.ClusterID = args.ClusterID
+	}
+	if prod.ClusterID != "" {
+		relations, err := commonrepo.NewProjectClusterRelationColl().List(&commonrepo.ProjectClusterRelationOption{
+			ProjectName: productName,
+			ClusterID:   prod.ClusterID,
+		})
+		if err != nil {
+			return e.ErrRestoreEnvSnapshot.AddErr(err)
+		}
+		if len(relations) == 0 {
+			return e.ErrRestoreEnvSnapshot.AddDesc(fmt.Sprintf("项目 %s 不能使用集群 %s", productName, prod.ClusterID))
+		}
+	}
+	prod.Namespace = commonservice.GetProductEnvNamespace(prod.EnvName, productName, args.Namespace)
+	resetRestoredProduct(prod, username)
+	applySnapshotImages(prod, snapshot.Images)
+
+	if snapshot.RenderSet != nil {
+		renderSet := snapshot.RenderSet
+		renderSet.Name = prod.Namespace
+		renderSet.EnvName = prod.EnvName
+		renderSet.UpdateBy = username
+		renderSet.IsDefault = false
+		if prod.Source == setting.HelmDeployType {
+			// helm 环境创建时按照命名空间查找预置的渲染集
+			err = commonservice.CreateHelmRenderSet(renderSet, log)
+			prod.ChartInfos = renderSet.ChartInfos
+			prod.Render = nil
+		} else {
+			err = commonservice.CreateRenderSet(renderSet, log)
+			prod.Render = &commonmodels.RenderInfo{Name: renderSet.Name, Revision: renderSet.Revision, ProductTmpl: productName}
+		}
+		if err != nil {
+			log.Errorf("[%s][P:%s] failed to restore renderset: %s", prod.EnvName, productName, err)
+			return e.ErrRestoreEnvSnapshot.AddErr(err)
+		}
+		for _, group := range prod.Services {
+			for _, svc := range group {
+				svc.Render = prod.Render
+			}
+		}
+	}
+
+	if err := CreateProduct(username, requestID, prod, log); err != nil {
+		log.Errorf("[%s][P:%s] failed to restore env from snapshot %s: %s", prod.EnvName, productName, id, err)
+		return err
+	}
+	return nil
+}
+
+func findEnvSnapshot(id, productName string) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := commonrepo.NewEnvSnapshotColl().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.ProductName != productName {
+		return nil, fmt.Errorf("snapshot %s not found in project %s", id, productName)
+	}
+	return snapshot, nil
+}
+
+// ensureSnapshotServices 恢复时服务模板版本必须存在，否则无法渲染。
+// 在其他系统中恢复时，缺少的 k8s 服务模板按照快照中保存的 yaml 重新创建，环境使用新创建的版本
+func ensureSnapshotServices(snapshot *commonmodels.EnvSnapshot, username string, log *zap.SugaredLogger) error {
+	var missing []string
+	revisions := make(map[string]int64)
+	for _, svc := range snapshot.Services {
+		_, err := commonrepo.NewServiceColl().Find(&commonrepo.ServiceFindOption{
+			ServiceName: svc.ServiceName,
+			ProductName: svc.ProductName,
+			Type:        svc.Type,
+			Revision:    svc.Revision,
+		})
+		if err == nil {
+			continue
+		}
+		if svc.Type != setting.K8SDeployType || svc.Yaml == "" {
+			missing = append(missing, fmt.Sprintf("%s(%d)", svc.ServiceName, svc.Revision))
+			continue
+		}
+
+		rev, err := commonrepo.NewCounterColl().GetNextSeq(fmt.Sprintf(setting.ServiceTemplateCounterName, svc.ServiceName, svc.ProductName))
+		if err != nil {
+			return fmt.Errorf("failed to get next revision of service %s: %s", svc.ServiceName, err)
+		}
+		tmpl := snapshotServiceTemplate(snapshot.Product, svc, rev, username)
+		if err := commonrepo.NewServiceColl().Create(tmpl); err != nil {
+			return fmt.Errorf("failed to recreate service %s: %s", svc.ServiceName, err)
+		}
+		log.Infof("service %s/%d not found, recreated as revision %d from the snapshot", svc.ServiceName, svc.Revision, rev)
+		revisions[snapshotServiceKey(svc.ProductName, svc.ServiceName)] = rev
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("service revisions not found: %s", strings.Join(missing, ", "))
+	}
+
+	for _, group := range snapshot.Product.Services {
+		for _, svc := range group {
+			if rev, ok := revisions[snapshotServiceKey(svc.ProductName, svc.ServiceName)]; ok {
+				svc.Revision = rev
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotServiceTemplate 根据快照中保存的内容构造服务模板，早期的快照未保存容器时使用环境中记录的容器
+func snapshotServiceTemplate(prod *commonmodels.Product, svc *commonmodels.ServiceSnapshot, revision int64, username string) *commonmodels.Service {
+	tmpl := &commonmodels.Service{
+		ServiceName: svc.ServiceName,
+		ProductName: svc.ProductName,
+		Type:        svc.Type,
+		Revision:    revision,
+		Source:      svc.Source,
+		Yaml:        svc.Yaml,
+		Visibility:  svc.Visibility,
+		Containers:  svc.Containers,
+		CreateBy:    username,
+	}
+	if tmpl.Source == "" {
+		tmpl.Source = setting.SourceFromZadig
+	}
+	if tmpl.Visibility == "" {
+		tmpl.Visibility = setting.PrivateVisibility
+	}
+	if len(tmpl.Containers) > 0 {
+		return tmpl
+	}
+	for _, group := range prod.Services {
+		for _, productSvc := range group {
+			if productSvc.ServiceName == svc.ServiceName && productSvc.ProductName == svc.ProductName {
+				tmpl.Containers = productSvc.Containers
+			}
+		}
+	}
+	return tmpl
+}
+
+func snapshotServiceKey(productName, serviceName string) string {
+	return productName + "/" + serviceName
+}
+
+func resetRestoredProduct(prod *commonmodels.Product, username string) {
+	prod.ID = primitive.NilObjectID
+	prod.Revision = 1
+	prod.UpdateBy = username
+	prod.Error = ""
+	prod.IsExisted = false
+	prod.SleepState = nil
+	prod.ExpireTime = 0
+	prod.ExpireWarned = false
+}
+
+// applySnapshotImages 使用快照时线上运行的镜像覆盖环境记录中的镜像
+func applySnapshotImages(prod *commonmodels.Product, images []*commonmodels.WorkloadImage) {
+	if prod.Source == setting.HelmDeployType {
+		// helm 环境的镜像由渲染集中的 values 决定
+		return
+	}
+
+	imageMap := make(map[string]string)
+	for _, image := range images {
+		imageMap[image.ServiceName+"/"+image.Container] = image.Image
+	}
+	for _, group := range prod.Services {
+		for _, svc := range group {
+			for _, container := range svc.Containers {
+				if image, ok := imageMap[svc.ServiceName+"/"+container.Name]; ok {
+					container.Image = image
+				}
+			}
+		}
+	}
+}
+
+func listLiveImages(prod *commonmodels.Product) ([]*commonmodels.WorkloadImage, error) {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := envWorkloadSelector(prod)
+	ds, err := getter.ListDeployments(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := getter.ListStatefulSets(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*commonmodels.WorkloadImage
+	for _, d := range ds {
+		images = append(images, workloadImages(setting.Deployment, d.Name, d.Labels[setting.ServiceLabel], d.Spec.Template.Spec.Containers)...)
+	}
+	for _, s := range ss {
+		images = append(images, workloadImages(setting.StatefulSet, s.Name, s.Labels[setting.ServiceLabel], s.Spec.Template.Spec.Containers)...)
+	}
+	return images, nil
+}
+
+func workloadImages(kind, name, serviceName string, containers []corev1.Container) []*commonmodels.WorkloadImage {
+	images := make([]*commonmodels.WorkloadImage, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, &commonmodels.WorkloadImage{
+			ServiceName: serviceName,
+			Kind:        kind,
+			Name:        name,
+			Container:   c.Name,
+			Image:       c.Image,
+		})
+	}
+	return images
+}
+
+func packSnapshotArchive(files map[string][]byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	now := time.Now()
+	for name, content := range files {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unpackSnapshotArchive(reader io.Reader) (*commonmodels.EnvSnapshot, error) {
+	gr, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name != snapshotFileName {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(tr, snapshotMaxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > snapshotMaxSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", snapshotFileName, snapshotMaxSize)
+		}
+		snapshot := &commonmodels.EnvSnapshot{}
+		if err := json.Unmarshal(data, snapshot); err != nil {
+			return nil, err
+		}
+		return snapshot, nil
+	}
+	return nil, fmt.Errorf("%s not found in archive", snapshotFileName)
+}
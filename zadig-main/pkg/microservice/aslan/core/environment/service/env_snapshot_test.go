@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing env snapshot", func() {
+
+	Describe("test snapshot archive", func() {
+		It("should read back the packed snapshot", func() {
+			archive, err := packSnapshotArchive(map[string][]byte{
+				"manifests/svc.yaml": []byte("kind: Deployment"),
+				snapshotFileName:     []byte(`{"name":"snap","product_name":"p","env_name":"dev","product":{"env_name":"dev"}}`),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			snapshot, err := unpackSnapshotArchive(bytes.NewReader(archive))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot.Name).To(Equal("snap"))
+			Expect(snapshot.ProductName).To(Equal("p"))
+			Expect(snapshot.Product.EnvName).To(Equal("dev"))
+		})
+
+		It("should fail without snapshot file", func() {
+			archive, err := packSnapshotArchive(map[string][]byte{"manifests/svc.yaml": []byte("kind: Deployment")})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = unpackSnapshotArchive(bytes.NewReader(archive))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject a snapshot file larger than the limit", func() {
+			archive, err := packSnapshotArchive(map[string][]byte{snapshotFileName: bytes.Repeat([]byte(" "), snapshotMaxSize+1)})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = unpackSnapshotArchive(bytes.NewReader(archive))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("test applySnapshotImages", func() {
+		It("should override container images with live images", func() {
+			prod := &commonmodels.Product{
+				Services: [][]*commonmodels.ProductService{{
+					{ServiceName: "a", Containers: []*commonmodels.Container{{Name: "c1", Image: "a:v1"}, {Name: "c2", Image: "b:v1"}}},
+				}},
+			}
+			applySnapshotImages(prod, []*commonmodels.WorkloadImage{
+				{ServiceName: "a", Kind: setting.Deployment, Name: "a", Container: "c1", Image: "a:v2"},
+				{ServiceName: "other", Kind: setting.Deployment, Name: "other", Container: "c2", Image: "b:v2"},
+			})
+			Expect(prod.Services[0][0].Containers[0].Image).To(Equal("a:v2"))
+			Expect(prod.Services[0][0].Containers[1].Image).To(Equal("b:v1"))
+		})
+	})
+
+	Describe("test snapshotServiceTemplate", func() {
+		prod := &commonmodels.Product{
+			Services: [][]*commonmodels.ProductService{{
+				{ServiceName: "a", ProductName: "p", Containers: []*commonmodels.Container{{Name: "c1", Image: "a:v1"}}},
+			}},
+		}
+
+		It("should recreate the template from the snapshot", func() {
+			svc := &commonmodels.ServiceSnapshot{
+				ServiceName: "a",
+				ProductName: "p",
+				Type:        setting.K8SDeployType,
+				Revision:    7,
+				Yaml:        "kind: Deployment",
+				Source:      setting.SourceFromGerrit,
+				Containers:  []*commonmodels.Container{{Name: "c1", Image: "a:v0"}},
+			}
+			tmpl := snapshotServiceTemplate(prod, svc, 2, "user")
+			Expect(tmpl.Revision).To(Equal(int64(2)))
+			Expect(tmpl.Yaml).To(Equal("kind: Deployment"))
+			Expect(tmpl.Source).To(Equal(setting.SourceFromGerrit))
+			Expect(tmpl.Visibility).To(Equal(setting.PrivateVisibility))
+			Expect(tmpl.Containers[0].Image).To(Equal("a:v0"))
+		})
+
+		It("should use the containers of the env for early snapshots", func() {
+			svc := &commonmodels.ServiceSnapshot{ServiceName: "a", ProductName: "p", Type: setting.K8SDeployType, Yaml: "kind: Deployment"}
+			tmpl := snapshotServiceTemplate(prod, svc, 1, "user")
+			Expect(tmpl.Source).To(Equal(setting.SourceFromZadig))
+			Expect(tmpl.Containers).To(HaveLen(1))
+			Expect(tmpl.Containers[0].Name).To(Equal("c1"))
+		})
+	})
+})
@@ -623,7 +623,7 @@ func UpdateProductRegistry(envName, productName, registryID string, log *zap.Sug
 	if err != nil {
 		return e.ErrUpdateEnv.AddErr(err)
 	}
-	err = ensureKubeEnv(exitedProd.Namespace, registryID, kubeClient, log)
+	err = ensureKubeEnv(exitedProd.Namespace, registryID, kubeEnvQuota(productName, exitedProd.QuotaSetting, log), kubeClient, log)
 
 	if err != nil {
 		log.Errorf("UpdateProductRegistry ensureKubeEnv by envName:%s,error: %v", envName, err)
@@ -657,7 +657,7 @@ func UpdateProductV2(envName, productName, user, requestID string, force bool, k
 		}
 	}
 
-	err = ensureKubeEnv(exitedProd.Namespace, exitedProd.RegistryID, kubeClient, log)
+	err = ensureKubeEnv(exitedProd.Namespace, exitedProd.RegistryID, kubeEnvQuota(productName, exitedProd.QuotaSetting, log), kubeClient, log)
 
 	if err != nil {
 		log.Errorf("[%s][P:%s] service.UpdateProductV2 create kubeEnv error: %v", envName, productName, err)
@@ -1366,7 +1366,7 @@ func UpdateHelmProductRenderset(productName, envName, userName, requestID string
 		log.Errorf("UpdateHelmProductRenderset GetKubeClient error, error msg:%s", err)
 		return err
 	}
-	return ensureKubeEnv(product.Namespace, product.RegistryID, kubeClient, log)
+	return ensureKubeEnv(product.Namespace, product.RegistryID, kubeEnvQuota(productName, product.QuotaSetting, log), kubeClient, log)
 }
 
 func UpdateHelmProductVariable(productName, envName, username, requestID string, updatedRcs []*templatemodels.RenderChart, renderset *commonmodels.RenderSet, log *zap.SugaredLogger) error {
@@ -1585,6 +1585,31 @@ func GetProductIngress(productName string, log *zap.SugaredLogger) ([]*ProductIn
 	return productIngressInfos, nil
 }
 
+// GetEnvIngressURLs 返回环境中所有 ingress 的访问地址
+func GetEnvIngressURLs(productName, envName string, log *zap.SugaredLogger) ([]string, error) {
+	serviceGroups, _, err := ListGroups("", envName, productName, 0, 0, log)
+	if err != nil {
+		log.Errorf("Failed to list services in env %s/%s, err: %s", productName, envName, err)
+		return nil, err
+	}
+
+	urls := make([]string, 0)
+	hosts := sets.NewString()
+	for _, serviceGroup := range serviceGroups {
+		if serviceGroup.Ingress == nil {
+			continue
+		}
+		for _, hostInfo := range serviceGroup.Ingress.HostInfo {
+			if hostInfo.Host == "" || hosts.Has(hostInfo.Host) {
+				continue
+			}
+			hosts.Insert(hostInfo.Host)
+			urls = append(urls, "http://"+hostInfo.Host)
+		}
+	}
+	return urls, nil
+}
+
 func GetHelmChartVersions(productName, envName string, log *zap.SugaredLogger) ([]*commonmodels.HelmVersions, error) {
 	var (
 		helmVersions = make([]*commonmodels.HelmVersions, 0)
@@ -2243,6 +2268,11 @@ func preCreateProduct(envName string, args *commonmodels.Product, kubeClient cli
 		renderSetName       = commonservice.GetProductEnvNamespace(envName, args.ProductName, args.Namespace)
 		err                 error
 	)
+	if err = validateEnvQuota(args.QuotaSetting); err != nil {
+		log.Errorf("[%s][P:%s] invalid quota setting: %v", envName, productTemplateName, err)
+		return e.ErrCreateEnv.AddErr(err)
+	}
+
 	// 如果 args.Render.Revision > 0 则该次操作是版本回溯
 	if args.Render != nil && args.Render.Revision > 0 {
 		renderSetName = args.Render.Name
@@ -2318,7 +2348,7 @@ func preCreateProduct(envName string, args *commonmodels.Product, kubeClient cli
 
 	args.Render = tmpRenderInfo
 	if preCreateNSAndSecret(productTmpl.ProductFeature) {
-		return ensureKubeEnv(args.Namespace, args.RegistryID, kubeClient, log)
+		return ensureKubeEnv(args.Namespace, args.RegistryID, kubeEnvQuota(args.ProductName, args.QuotaSetting, log), kubeClient, log)
 	}
 	return nil
 }
@@ -2401,7 +2431,7 @@ func applySystemImagePullSecrets(podSpec *corev1.PodSpec) {
 		})
 }
 
-func ensureKubeEnv(namespace string, registryId string, kubeClient client.Client, log *zap.SugaredLogger) error {
+func ensureKubeEnv(namespace string, registryId string, quota *commonmodels.EnvQuotaSetting, kubeClient client.Client, log *zap.SugaredLogger) error {
 	err := kube.CreateNamespace(namespace, kubeClient)
 	if err != nil {
 		log.Errorf("[%s] get or create namespace error: %v", namespace, err)
@@ -2414,6 +2444,14 @@ func ensureKubeEnv(namespace string, registryId string, kubeClient client.Client
 		return e.ErrCreateSecret.AddDesc(e.CreateDefaultRegistryErrMsg)
 	}
 
+	// 应用项目或环境的资源配额
+	if quota != nil {
+		if err := applyEnvQuota(namespace, quota, kubeClient); err != nil {
+			log.Errorf("[%s] apply resource quota error: %v", namespace, err)
+			return e.ErrApplyEnvQuota.AddErr(err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+)
+
+// defaultServiceReleaseLimit 默认返回的服务发布记录数量
+const defaultServiceReleaseLimit = 20
+
+func ListServiceReleases(productName, envName, serviceName string, log *zap.SugaredLogger) ([]*commonmodels.ServiceRelease, error) {
+	releases, err := commonrepo.NewServiceReleaseColl().List(&commonrepo.ServiceReleaseListOption{
+		ProductName: productName,
+		EnvName:     envName,
+		ServiceName: serviceName,
+		Limit:       defaultServiceReleaseLimit,
+	})
+	if err != nil {
+		log.Errorf("failed to list releases of service %s/%s/%s: %s", productName, envName, serviceName, err)
+		return nil, e.ErrListServiceReleases.AddErr(err)
+	}
+	return releases, nil
+}
+
+// RollbackService 将服务回滚到最近一次成功发布的版本
+func RollbackService(productName, envName, serviceName string, log *zap.SugaredLogger) (*commonmodels.ServiceRelease, error) {
+	return commonservice.RollbackServiceRelease(productName, envName, serviceName, log)
+}
+
+// DeployK8sService 按服务的版本、镜像及渲染集重新部署环境中的 k8s 服务，用于服务回滚
+func DeployK8sService(prod *commonmodels.Product, service *commonmodels.ProductService, log *zap.SugaredLogger) error {
+	renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: service.Render.Name, Revision: service.Render.Revision})
+	if err != nil {
+		return fmt.Errorf("failed to find renderset %s/%d: %s", service.Render.Name, service.Render.Revision, err)
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return err
+	}
+	inf, err := informer.NewInformer(prod.ClusterID, prod.Namespace, cls)
+	if err != nil {
+		return err
+	}
+
+	_, err = upsertService(true, prod, service, prod.GetServiceMap()[service.ServiceName], renderSet, inf, kubeClient, log)
+	return err
+}
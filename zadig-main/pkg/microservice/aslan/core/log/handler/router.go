@@ -34,6 +34,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		log.GET("/pipelines/:pipelineName/tasks/:taskId/tests/:testName", GetTestJobContainerLogs)
 		log.GET("/workflow/:pipelineName/tasks/:taskId/tests/:testName/service/:serviceName", GetWorkflowTestJobContainerLogs)
 		log.GET("/v3/workflow/:workflowName/tasks/:taskId", GetWorkflowBuildV3JobContainerLogs)
+
+		// 统一的任务日志接口：运行中读取容器日志，结束后读取归档日志
+		log.GET("/tasks/:pipelineType/:pipelineName/:taskId/:subTask", GetTaskLog)
+		log.GET("/tasks/:pipelineType/:pipelineName/:taskId/:subTask/download", DownloadTaskLog)
+		log.GET("/tasks/:pipelineType/:pipelineName/:taskId/:subTask/ws", StreamTaskLog)
 	}
 
 	sse := router.Group("sse")
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	logservice "github.com/koderover/zadig/pkg/microservice/aslan/core/log/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/util/ginzap"
+)
+
+func GetTaskLog(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	opts, err := taskLogOptions(c)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+
+	search := &logservice.LogSearchOptions{
+		Keyword:    c.Query("keyword"),
+		Regexp:     c.Query("regexp") == "true",
+		IgnoreCase: c.Query("ignoreCase") == "true",
+	}
+	if lines := c.Query("context"); lines != "" {
+		if search.Context, err = strconv.Atoi(lines); err != nil {
+			ctx.Err = e.ErrInvalidParam.AddDesc("invalid context")
+			return
+		}
+	}
+
+	ctx.Resp, ctx.Err = logservice.GetTaskLog(opts, search, ctx.Logger)
+}
+
+func DownloadTaskLog(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	opts, err := taskLogOptions(c)
+	if err != nil {
+		ctx.Err = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	content, fileName, err := logservice.DownloadTaskLog(opts, ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
+
+func StreamTaskLog(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	logger := ginzap.WithContext(c).Sugar()
+
+	opts, err := taskLogOptions(c)
+	if err != nil {
+		ctx.Err = err
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	internalhandler.WebSocketStream(c, func(ctx context.Context, streamChan chan interface{}) {
+		logservice.StreamTaskLog(ctx, streamChan, opts, logger)
+	}, logger)
+}
+
+func taskLogOptions(c *gin.Context) (*logservice.TaskLogOptions, error) {
+	taskID, err := strconv.ParseInt(c.Param("taskId"), 10, 64)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddDesc("invalid task id")
+	}
+
+	return &logservice.TaskLogOptions{
+		PipelineName: c.Param("pipelineName"),
+		PipelineType: config.PipelineType(c.Param("pipelineType")),
+		TaskID:       taskID,
+		SubTask:      c.Param("subTask"),
+		ServiceName:  c.Query("serviceName"),
+	}, nil
+}
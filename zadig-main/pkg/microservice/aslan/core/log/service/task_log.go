@@ -0,0 +1,498 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/containerlog"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+)
+
+const (
+	LogSourceLive    = "live"
+	LogSourceArchive = "archive"
+
+	TaskLogMessageLine  = "line"
+	TaskLogMessageEnd   = "end"
+	TaskLogMessageError = "error"
+
+	// 搜索结果上下文行数上限
+	maxSearchContext = 20
+)
+
+// 日志分段名称，对应 reaper 在各执行阶段输出的标记行
+const (
+	LogSectionPrepare     = "prepare"
+	LogSectionCache       = "cache"
+	LogSectionInstall     = "install"
+	LogSectionGitClone    = "git_clone"
+	LogSectionBuildScript = "build_script"
+	LogSectionDockerBuild = "docker_build"
+	LogSectionPostScripts = "post_scripts"
+)
+
+var logSectionMarkers = []struct {
+	marker  string
+	section string
+}{
+	{"Pulling Cache.", LogSectionCache},
+	{"Installing Dependency Packages.", LogSectionInstall},
+	{"Cloning Repository.", LogSectionGitClone},
+	{"Executing User Build Script.", LogSectionBuildScript},
+	{"Preparing Dockerfile.", LogSectionDockerBuild},
+	{"Executing Post Scripts.", LogSectionPostScripts},
+	{"Uploading Build Cache.", LogSectionCache},
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+type TaskLogOptions struct {
+	PipelineName string
+	PipelineType config.PipelineType
+	TaskID       int64
+	// SubTask 为子任务类型，如 buildv2、testingv2
+	SubTask     string
+	ServiceName string
+}
+
+type LogSearchOptions struct {
+	Keyword    string
+	Regexp     bool
+	IgnoreCase bool
+	// Context 为每个匹配行前后附带的行数
+	Context int
+}
+
+type LogLine struct {
+	Number  int    `json:"number"`
+	Content string `json:"content"`
+	Section string `json:"section"`
+}
+
+type LogSection struct {
+	Name      string `json:"name"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+type TaskLog struct {
+	Source     string        `json:"source"`
+	TotalLines int           `json:"total_lines"`
+	Sections   []*LogSection `json:"sections"`
+	Lines      []*LogLine    `json:"lines"`
+	// Matches 为命中搜索条件的行号，用于前端定位
+	Matches []int `json:"matches,omitempty"`
+}
+
+type TaskLogMessage struct {
+	Type   string   `json:"type"`
+	Source string   `json:"source,omitempty"`
+	Line   *LogLine `json:"line,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type taskLogTarget struct {
+	finished  bool
+	clusterID string
+	namespace string
+}
+
+func GetTaskLog(opts *TaskLogOptions, search *LogSearchOptions, log *zap.SugaredLogger) (*TaskLog, error) {
+	content, source, err := getTaskLogContent(opts, log)
+	if err != nil {
+		return nil, e.ErrGetTaskLog.AddErr(err)
+	}
+
+	taskLog, err := buildTaskLog(content, source, search)
+	if err != nil {
+		return nil, e.ErrSearchTaskLog.AddErr(err)
+	}
+	return taskLog, nil
+}
+
+func DownloadTaskLog(opts *TaskLogOptions, log *zap.SugaredLogger) ([]byte, string, error) {
+	content, _, err := getTaskLogContent(opts, log)
+	if err != nil {
+		return nil, "", e.ErrGetTaskLog.AddErr(err)
+	}
+	return []byte(content), taskLogFileName(opts) + ".log", nil
+}
+
+// StreamTaskLog 推送完整日志：子任务运行中时跟随容器日志，容器不存在或任务已结束时回退到归档日志
+func StreamTaskLog(ctx context.Context, streamChan chan interface{}, opts *TaskLogOptions, log *zap.SugaredLogger) {
+	target, err := findTaskLogTarget(opts)
+	if err != nil {
+		log.Errorf("Failed to find task %s-%d: %s", opts.PipelineName, opts.TaskID, err)
+		streamChan <- &TaskLogMessage{Type: TaskLogMessageError, Error: err.Error()}
+		return
+	}
+
+	tracker := newSectionTracker()
+	if !target.finished {
+		streamed, err := streamLiveTaskLog(ctx, streamChan, opts, target, tracker, log)
+		if err != nil {
+			streamChan <- &TaskLogMessage{Type: TaskLogMessageError, Error: err.Error()}
+			return
+		}
+		if streamed {
+			streamChan <- &TaskLogMessage{Type: TaskLogMessageEnd, Source: LogSourceLive}
+			return
+		}
+	}
+
+	content, err := getArchivedTaskLog(opts, log)
+	if err != nil {
+		streamChan <- &TaskLogMessage{Type: TaskLogMessageError, Error: err.Error()}
+		return
+	}
+	for i, line := range splitLogLines(content) {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		streamChan <- &TaskLogMessage{
+			Type:   TaskLogMessageLine,
+			Source: LogSourceArchive,
+			Line:   &LogLine{Number: i + 1, Content: line, Section: tracker.track(i+1, line)},
+		}
+	}
+	streamChan <- &TaskLogMessage{Type: TaskLogMessageEnd, Source: LogSourceArchive}
+}
+
+// streamLiveTaskLog 返回 false 表示未找到运行中的 pod，由调用方回退到归档日志
+func streamLiveTaskLog(ctx context.Context, streamChan chan interface{}, opts *TaskLogOptions, target *taskLogTarget, tracker *sectionTracker, log *zap.SugaredLogger) (bool, error) {
+	podName, err := findTaskPod(opts, target)
+	if err != nil || podName == "" {
+		return false, nil
+	}
+
+	clientSet, err := kubeclient.GetClientset(config.HubServerAddress(), target.clusterID)
+	if err != nil {
+		log.Errorf("Failed to get clientset for cluster %s: %s", target.clusterID, err)
+		return false, err
+	}
+
+	out, err := containerlog.GetContainerLogStream(ctx, target.namespace, podName, containerName(opts.SubTask), true, 0, clientSet)
+	if err != nil {
+		log.Infof("Failed to get log stream of pod %s, fallback to archived log: %s", podName, err)
+		return false, nil
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	buf := bufio.NewReader(out)
+	number := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		default:
+		}
+
+		line, err := buf.ReadString('\n')
+		if len(line) > 0 {
+			number++
+			line = strings.TrimRight(line, "\r\n")
+			streamChan <- &TaskLogMessage{
+				Type:   TaskLogMessageLine,
+				Source: LogSourceLive,
+				Line:   &LogLine{Number: number, Content: line, Section: tracker.track(number, line)},
+			}
+		}
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			log.Errorf("Failed to read log stream of pod %s: %s", podName, err)
+			return true, nil
+		}
+	}
+}
+
+func getTaskLogContent(opts *TaskLogOptions, log *zap.SugaredLogger) (string, string, error) {
+	target, err := findTaskLogTarget(opts)
+	if err != nil {
+		log.Errorf("Failed to find task %s-%d: %s", opts.PipelineName, opts.TaskID, err)
+		return "", "", err
+	}
+
+	if !target.finished {
+		if podName, err := findTaskPod(opts, target); err == nil && podName != "" {
+			clientSet, err := kubeclient.GetClientset(config.HubServerAddress(), target.clusterID)
+			if err == nil {
+				buf := new(bytes.Buffer)
+				err = containerlog.GetContainerLogs(target.namespace, podName, containerName(opts.SubTask), false, 0, buf, clientSet)
+				if err == nil {
+					return buf.String(), LogSourceLive, nil
+				}
+			}
+			log.Infof("Failed to get live log of pod %s, fallback to archived log: %s", podName, err)
+		}
+	}
+
+	content, err := getArchivedTaskLog(opts, log)
+	if err != nil {
+		return "", "", err
+	}
+	return content, LogSourceArchive, nil
+}
+
+func getArchivedTaskLog(opts *TaskLogOptions, log *zap.SugaredLogger) (string, error) {
+	return getContainerLogFromS3(strings.ToLower(opts.PipelineName), taskLogFileName(opts), opts.TaskID, log)
+}
+
+func taskLogFileName(opts *TaskLogOptions) string {
+	name := fmt.Sprintf("%s-%s-%d-%s", opts.PipelineType, opts.PipelineName, opts.TaskID, opts.SubTask)
+	if opts.ServiceName != "" {
+		name = fmt.Sprintf("%s-%s", name, opts.ServiceName)
+	}
+	return strings.Replace(strings.ToLower(name), "_", "-", -1)
+}
+
+func findTaskLogTarget(opts *TaskLogOptions) (*taskLogTarget, error) {
+	t, err := commonrepo.NewTaskColl().Find(opts.TaskID, opts.PipelineName, opts.PipelineType)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &taskLogTarget{clusterID: setting.LocalClusterID}
+	subTask := findSubTask(t.Stages, opts)
+	if subTask != nil {
+		status, _ := subTask["status"].(string)
+		target.finished = isFinishedStatus(config.Status(status))
+		if clusterID, ok := subTask["cluster_id"].(string); ok && clusterID != "" {
+			target.clusterID = clusterID
+		}
+	} else {
+		target.finished = isFinishedStatus(t.Status)
+	}
+
+	switch target.clusterID {
+	case setting.LocalClusterID:
+		target.namespace = config.Namespace()
+	default:
+		target.namespace = setting.AttachedClusterNamespace
+	}
+	return target, nil
+}
+
+func findSubTask(stages []*models.Stage, opts *TaskLogOptions) map[string]interface{} {
+	for _, stage := range stages {
+		if string(stage.TaskType) != opts.SubTask {
+			continue
+		}
+		for name, subTask := range stage.SubTasks {
+			if opts.ServiceName == "" || strings.EqualFold(name, opts.ServiceName) {
+				return subTask
+			}
+			if serviceName, ok := subTask["service_name"].(string); ok && strings.EqualFold(serviceName, opts.ServiceName) {
+				return subTask
+			}
+		}
+	}
+	return nil
+}
+
+func isFinishedStatus(status config.Status) bool {
+	switch status {
+	case config.StatusPassed, config.StatusFailed, config.StatusTimeout, config.StatusCancelled, config.StatusSkipped:
+		return true
+	}
+	return false
+}
+
+func findTaskPod(opts *TaskLogOptions, target *taskLogTarget) (string, error) {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), target.clusterID)
+	if err != nil {
+		return "", err
+	}
+
+	selector := getPipelineSelector(&GetContainerOptions{
+		PipelineName: opts.PipelineName,
+		PipelineType: string(opts.PipelineType),
+		TaskID:       opts.TaskID,
+		SubTask:      opts.SubTask,
+		ServiceName:  opts.ServiceName,
+	})
+	pods, err := getter.ListPods(target.namespace, selector, kubeClient)
+	if err != nil || len(pods) == 0 {
+		return "", err
+	}
+	return pods[0].Name, nil
+}
+
+// 与 getPipelineSelector 一致，容器名兼容之前的下划线
+func containerName(subTask string) string {
+	return strings.Replace(subTask, "_", "-", 1)
+}
+
+func buildTaskLog(content, source string, search *LogSearchOptions) (*TaskLog, error) {
+	matcher, err := newLogMatcher(search)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitLogLines(content)
+	tracker := newSectionTracker()
+	all := make([]*LogLine, 0, len(lines))
+	for i, line := range lines {
+		all = append(all, &LogLine{Number: i + 1, Content: line, Section: tracker.track(i+1, line)})
+	}
+
+	taskLog := &TaskLog{
+		Source:     source,
+		TotalLines: len(all),
+		Sections:   tracker.finish(len(all)),
+		Lines:      all,
+	}
+	if matcher == nil {
+		return taskLog, nil
+	}
+
+	ctxLines := search.Context
+	if ctxLines < 0 {
+		ctxLines = 0
+	}
+	if ctxLines > maxSearchContext {
+		ctxLines = maxSearchContext
+	}
+
+	keep := make([]bool, len(all))
+	taskLog.Matches = []int{}
+	for i, line := range all {
+		if !matcher(stripANSI(line.Content)) {
+			continue
+		}
+		taskLog.Matches = append(taskLog.Matches, line.Number)
+		for j := i - ctxLines; j <= i+ctxLines; j++ {
+			if j >= 0 && j < len(all) {
+				keep[j] = true
+			}
+		}
+	}
+
+	taskLog.Lines = make([]*LogLine, 0, len(taskLog.Matches))
+	for i, line := range all {
+		if keep[i] {
+			taskLog.Lines = append(taskLog.Lines, line)
+		}
+	}
+	return taskLog, nil
+}
+
+func newLogMatcher(search *LogSearchOptions) (func(string) bool, error) {
+	if search == nil || search.Keyword == "" {
+		return nil, nil
+	}
+
+	if search.Regexp {
+		expr := search.Keyword
+		if search.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	if search.IgnoreCase {
+		keyword := strings.ToLower(search.Keyword)
+		return func(s string) bool {
+			return strings.Contains(strings.ToLower(s), keyword)
+		}, nil
+	}
+	return func(s string) bool {
+		return strings.Contains(s, search.Keyword)
+	}, nil
+}
+
+// splitLogLines 按行拆分日志，保留 ANSI 颜色控制符
+func splitLogLines(content string) []string {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+type sectionTracker struct {
+	current  *LogSection
+	sections []*LogSection
+}
+
+func newSectionTracker() *sectionTracker {
+	return &sectionTracker{}
+}
+
+// track 记录第 number 行所属的分段并返回分段名称
+func (t *sectionTracker) track(number int, line string) string {
+	name := LogSectionPrepare
+	if t.current != nil {
+		name = t.current.Name
+	}
+
+	plain := stripANSI(line)
+	for _, m := range logSectionMarkers {
+		if strings.Contains(plain, m.marker) {
+			name = m.section
+			break
+		}
+	}
+
+	if t.current == nil || t.current.Name != name {
+		if t.current != nil {
+			t.current.EndLine = number - 1
+		}
+		t.current = &LogSection{Name: name, StartLine: number}
+		t.sections = append(t.sections, t.current)
+	}
+	return name
+}
+
+func (t *sectionTracker) finish(total int) []*LogSection {
+	if t.current != nil {
+		t.current.EndLine = total
+	}
+	return t.sections
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBuildLog = "preparing\n" +
+	"Installing Dependency Packages.\n" +
+	"go 1.16 installed\n" +
+	"Cloning Repository.\n" +
+	"\x1b[32mclone done\x1b[0m\n" +
+	"Executing User Build Script.\n" +
+	"make build\n" +
+	"\x1b[31mERROR: build failed\x1b[0m\r\n" +
+	"Executing Post Scripts.\n" +
+	"cleanup\n"
+
+func TestBuildTaskLogSections(t *testing.T) {
+	log, err := buildTaskLog(testBuildLog, LogSourceArchive, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, log.TotalLines)
+	assert.Len(t, log.Lines, 10)
+	assert.Nil(t, log.Matches)
+
+	assert.Equal(t, []*LogSection{
+		{Name: LogSectionPrepare, StartLine: 1, EndLine: 1},
+		{Name: LogSectionInstall, StartLine: 2, EndLine: 3},
+		{Name: LogSectionGitClone, StartLine: 4, EndLine: 5},
+		{Name: LogSectionBuildScript, StartLine: 6, EndLine: 8},
+		{Name: LogSectionPostScripts, StartLine: 9, EndLine: 10},
+	}, log.Sections)
+
+	// ANSI 颜色保留，仅去掉行尾的 \r
+	assert.Equal(t, "\x1b[31mERROR: build failed\x1b[0m", log.Lines[7].Content)
+	assert.Equal(t, LogSectionBuildScript, log.Lines[7].Section)
+}
+
+func TestBuildTaskLogSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		search  *LogSearchOptions
+		matches []int
+		lines   []int
+	}{
+		{
+			name:    "plain keyword",
+			search:  &LogSearchOptions{Keyword: "build"},
+			matches: []int{7, 8},
+			lines:   []int{7, 8},
+		},
+		{
+			name:    "ignore case with context",
+			search:  &LogSearchOptions{Keyword: "error", IgnoreCase: true, Context: 1},
+			matches: []int{8},
+			lines:   []int{7, 8, 9},
+		},
+		{
+			name:    "regexp ignores ansi codes",
+			search:  &LogSearchOptions{Keyword: "^clone", Regexp: true},
+			matches: []int{5},
+			lines:   []int{5},
+		},
+		{
+			name:    "no match",
+			search:  &LogSearchOptions{Keyword: "not-exist"},
+			matches: []int{},
+			lines:   []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := buildTaskLog(testBuildLog, LogSourceLive, tt.search)
+			assert.NoError(t, err)
+			assert.Equal(t, 10, log.TotalLines)
+			assert.Equal(t, tt.matches, log.Matches)
+
+			lines := make([]int, 0, len(log.Lines))
+			for _, line := range log.Lines {
+				lines = append(lines, line.Number)
+			}
+			assert.Equal(t, tt.lines, lines)
+		})
+	}
+}
+
+func TestBuildTaskLogInvalidRegexp(t *testing.T) {
+	_, err := buildTaskLog(testBuildLog, LogSourceLive, &LogSearchOptions{Keyword: "(", Regexp: true})
+	assert.Error(t, err)
+}
+
+func TestTaskLogFileName(t *testing.T) {
+	name := taskLogFileName(&TaskLogOptions{
+		PipelineName: "Demo_Workflow",
+		PipelineType: "workflow",
+		TaskID:       12,
+		SubTask:      "buildv2",
+		ServiceName:  "Svc_Module",
+	})
+	assert.Equal(t, "workflow-demo-workflow-12-buildv2-svc-module", name)
+}
@@ -110,6 +110,13 @@ func ReconnectCluster(c *gin.Context) {
 	ctx.Err = service.ReconnectCluster(ctx.UserName, c.Param("id"), ctx.Logger)
 }
 
+func NotifyClusterDisconnected(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Err = service.NotifyClusterDisconnected(c.Param("id"), ctx.Logger)
+}
+
 func ClusterConnectFromAgent(c *gin.Context) {
 	c.Request.URL.Path = strings.TrimPrefix(c.Request.URL.Path, "/api/hub")
 	service.ProxyAgent(c.Writer, c.Request)
@@ -39,6 +39,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		Cluster.DELETE("/:id", DeleteCluster)
 		Cluster.PUT("/:id/disconnect", DisconnectCluster)
 		Cluster.PUT("/:id/reconnect", ReconnectCluster)
+		// 供 hubserver 在集群异常断开时回调，仅限内部调用
+		Cluster.POST("/:id/disconnected", NotifyClusterDisconnected)
 	}
 
 	bundles := router.Group("bundle-resources")
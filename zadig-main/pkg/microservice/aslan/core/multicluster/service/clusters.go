@@ -37,6 +37,7 @@ import (
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
 	"github.com/koderover/zadig/pkg/setting"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
 	e "github.com/koderover/zadig/pkg/tool/errors"
@@ -351,6 +352,32 @@ func ReconnectCluster(username string, clusterID string, logger *zap.SugaredLogg
 	return s.ReconnectCluster(username, clusterID, logger)
 }
 
+// NotifyClusterDisconnected 由 hubserver 在集群连接异常断开时调用，通知订阅了该事件的用户
+func NotifyClusterDisconnected(clusterID string, logger *zap.SugaredLogger) error {
+	cluster, err := commonrepo.NewK8SClusterColl().Get(clusterID)
+	if err != nil {
+		logger.Errorf("failed to get cluster %s, err: %s", clusterID, err)
+		return e.ErrClusterNotFound.AddErr(err)
+	}
+	// 以数据库中记录的集群状态为准，避免误报
+	if cluster.Status != setting.Abnormal {
+		logger.Warnf("cluster %s is %s, skip the disconnected notification", clusterID, cluster.Status)
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("cluster %s is not disconnected", clusterID))
+	}
+
+	relations, err := commonrepo.NewProjectClusterRelationColl().List(&commonrepo.ProjectClusterRelationOption{ClusterID: clusterID})
+	if err != nil {
+		logger.Warnf("failed to list projects of cluster %s, err: %s", clusterID, err)
+	}
+	projectNames := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		projectNames = append(projectNames, relation.ProjectName)
+	}
+
+	notification.Dispatch(notification.NewClusterDisconnectedEvent(cluster, projectNames))
+	return nil
+}
+
 func ProxyAgent(writer gin.ResponseWriter, request *http.Request) {
 	s, _ := kube.NewService(config.HubServerAddress())
 
@@ -416,6 +416,10 @@ func DeleteProductTemplate(userName, productName, requestID string, log *zap.Sug
 		log.Errorf("DeleteProductTemplate Delete productName %s ProjectClusterRelation err: %s", productName, err)
 	}
 
+	if err = commonrepo.NewProjectQuotaColl().Delete(productName); err != nil {
+		log.Errorf("DeleteProductTemplate Delete productName %s ProjectQuota err: %s", productName, err)
+	}
+
 	// Delete freestyle workflow
 	cl := configclient.New(configbase.ConfigServiceAddress())
 	if enable, err := cl.CheckFeature(setting.ModernWorkflowType); err == nil && enable {
@@ -29,6 +29,7 @@ import (
 	modeMongodb "github.com/koderover/zadig/pkg/microservice/aslan/core/collaboration/repository/mongodb"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/webhook"
 	deliveryhandler "github.com/koderover/zadig/pkg/microservice/aslan/core/delivery/handler"
@@ -36,6 +37,7 @@ import (
 	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
 	labelMongodb "github.com/koderover/zadig/pkg/microservice/aslan/core/label/repository/mongodb"
 	projecthandler "github.com/koderover/zadig/pkg/microservice/aslan/core/project/handler"
+	statrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/mongodb"
 	systemrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/mongodb"
 	systemservice "github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
 	workflowhandler "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/handler"
@@ -115,6 +117,8 @@ func Start(ctx context.Context) {
 
 	systemservice.SetProxyConfig()
 
+	commonservice.K8sServiceDeployer = environmentservice.DeployK8sService
+
 	workflowservice.InitPipelineController()
 	// 如果集群环境所属的项目不存在，则删除此集群环境
 	environmentservice.CleanProducts()
@@ -211,6 +215,19 @@ func initDatabase() {
 		commonrepo.NewChartColl(),
 		commonrepo.NewDockerfileTemplateColl(),
 		commonrepo.NewProjectClusterRelationColl(),
+		commonrepo.NewExternalTaskPluginColl(),
+		commonrepo.NewWorkflowApprovalColl(),
+		commonrepo.NewServiceReleaseColl(),
+		commonrepo.NewBuildCacheColl(),
+		commonrepo.NewSigningKeyColl(),
+		commonrepo.NewTestCoverageColl(),
+		commonrepo.NewTestCaseResultColl(),
+		commonrepo.NewTestCaseQuarantineColl(),
+		commonrepo.NewNotificationSubscriptionColl(),
+		commonrepo.NewPreviewEnvColl(),
+		commonrepo.NewEnvSnapshotColl(),
+		commonrepo.NewEnvDriftColl(),
+		commonrepo.NewProjectQuotaColl(),
 
 		systemrepo.NewAnnouncementColl(),
 		systemrepo.NewOperationLogColl(),
@@ -218,6 +235,7 @@ func initDatabase() {
 		labelMongodb.NewLabelBindingColl(),
 		modeMongodb.NewCollaborationModeColl(),
 		modeMongodb.NewCollaborationInstanceColl(),
+		statrepo.NewDoraStatColl(),
 	} {
 		wg.Add(1)
 		go func(r indexer) {
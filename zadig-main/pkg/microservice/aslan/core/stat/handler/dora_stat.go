@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/stat/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type getDoraStatReq struct {
+	StartDate      int64    `json:"startDate,omitempty"`
+	EndDate        int64    `json:"endDate,omitempty"`
+	ProductNames   []string `json:"productNames"`
+	EnvNames       []string `json:"envNames"`
+	ProductionOnly bool     `json:"productionOnly"`
+}
+
+func InitDoraStat(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Err = service.InitDoraStat(ctx.Logger)
+}
+
+func GetDoraMeasure(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	//params validate
+	args := new(getDoraStatReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	ctx.Resp, ctx.Err = service.GetDoraMeasure(&models.DoraStatOption{
+		StartDate:      args.StartDate,
+		EndDate:        args.EndDate,
+		ProductNames:   args.ProductNames,
+		EnvNames:       args.EnvNames,
+		ProductionOnly: args.ProductionOnly,
+	}, ctx.Logger)
+}
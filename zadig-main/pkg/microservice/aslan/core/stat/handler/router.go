@@ -48,6 +48,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		quality.POST("/testDeliveryDeploy", GetTestDeliveryDeployMeasure)
 		quality.POST("/testHealthMeasure", GetTestHealthMeasure)
 		quality.POST("/testTrend", GetTestTrendMeasure)
+		quality.POST("/testCoverageMeasure", GetTestCoverageMeasure)
 		//deployStat
 		quality.POST("/initDeployStat", InitDeployStat)
 		quality.POST("/pipelineHealthMeasure", GetPipelineHealthMeasure)
@@ -55,5 +56,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		quality.POST("/deployWeeklyMeasure", GetDeployWeeklyMeasure)
 		quality.POST("/deployTopFiveHigherMeasure", GetDeployTopFiveHigherMeasure)
 		quality.POST("/deployTopFiveFailureMeasure", GetDeployTopFiveFailureMeasure)
+		//doraStat
+		quality.POST("/initDoraStat", InitDoraStat)
+		quality.POST("/doraMeasure", GetDoraMeasure)
 	}
 }
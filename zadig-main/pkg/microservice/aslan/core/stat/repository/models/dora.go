@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+type DoraStatOption struct {
+	StartDate      int64
+	EndDate        int64
+	ProductNames   []string
+	EnvNames       []string
+	ProductionOnly bool
+}
+
+// DoraStat 按项目、环境和日期汇总的交付效能数据，时长单位均为秒
+type DoraStat struct {
+	ProductName   string `bson:"product_name"             json:"productName"`
+	EnvName       string `bson:"env_name"                 json:"envName"`
+	Production    bool   `bson:"production"               json:"production"`
+	DeploySuccess int    `bson:"deploy_success"           json:"deploySuccess"`
+	DeployFailure int    `bson:"deploy_failure"           json:"deployFailure"`
+	// 代码提交到部署成功的耗时
+	LeadTimeTotal int64 `bson:"lead_time_total"          json:"leadTimeTotal"`
+	LeadTimeCount int   `bson:"lead_time_count"          json:"leadTimeCount"`
+	// 部署失败到下一次部署成功的耗时，计入恢复当天
+	RestoreTimeTotal int64  `bson:"restore_time_total"       json:"restoreTimeTotal"`
+	RestoreCount     int    `bson:"restore_count"            json:"restoreCount"`
+	Date             string `bson:"date"                     json:"date"`
+	CreateTime       int64  `bson:"create_time"              json:"createTime"`
+	UpdateTime       int64  `bson:"update_time"              json:"updateTime"`
+}
+
+func (DoraStat) TableName() string {
+	return "dora_stat"
+}
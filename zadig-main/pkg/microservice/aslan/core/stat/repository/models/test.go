@@ -28,6 +28,9 @@ type TestStat struct {
 	Date             string `bson:"date"                    json:"date"`
 	CreateTime       int64  `bson:"create_time"             json:"createTime"`
 	UpdateTime       int64  `bson:"update_time"             json:"updateTime"`
+	// 当天测试的覆盖行数和总行数，用于计算覆盖率
+	TotalCoveredLines int `bson:"total_covered_lines"     json:"totalCoveredLines"`
+	TotalValidLines   int `bson:"total_valid_lines"       json:"totalValidLines"`
 }
 
 func (TestStat) TableName() string {
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	models "github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type DoraStatColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewDoraStatColl() *DoraStatColl {
+	name := models.DoraStat{}.TableName()
+	return &DoraStatColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *DoraStatColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *DoraStatColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "date", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *DoraStatColl) FindCount() (int, error) {
+	count, err := c.CountDocuments(context.TODO(), bson.M{})
+	return int(count), err
+}
+
+func (c *DoraStatColl) Upsert(args *models.DoraStat) error {
+	if args == nil {
+		return errors.New("nil doraStat args")
+	}
+
+	query := bson.M{
+		"product_name": args.ProductName,
+		"env_name":     args.EnvName,
+		"date":         args.Date,
+	}
+	update := bson.M{"$set": args}
+	_, err := c.UpdateOne(context.TODO(), query, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *DoraStatColl) ListDoraStat(option *models.DoraStatOption) ([]*models.DoraStat, error) {
+	query := bson.M{}
+	if len(option.ProductNames) > 0 {
+		query["product_name"] = bson.M{"$in": option.ProductNames}
+	}
+	if len(option.EnvNames) > 0 {
+		query["env_name"] = bson.M{"$in": option.EnvNames}
+	}
+	if option.ProductionOnly {
+		query["production"] = true
+	}
+	if option.StartDate > 0 {
+		query["create_time"] = bson.M{
+			"$gte": option.StartDate,
+			"$lte": option.EndDate,
+		}
+	}
+
+	resp := make([]*models.DoraStat, 0)
+	opts := options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}})
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	return resp, err
+}
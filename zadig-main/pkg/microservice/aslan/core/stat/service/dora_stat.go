@@ -0,0 +1,351 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	taskmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonmongodb "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/mongodb"
+)
+
+const (
+	// doraLookbackDays 增量统计时重新计算的天数，保证跨天的失败恢复时间能被统计到
+	doraLookbackDays = 7
+	// doraRestoreLookbackDays 增量统计时额外回放的天数，只用于恢复统计起点之前未恢复的失败状态
+	doraRestoreLookbackDays = 30
+)
+
+func InitDoraStat(log *zap.SugaredLogger) error {
+	option := &commonmongodb.ListAllTaskOption{Type: config.WorkflowType}
+	var since int64
+	count, err := mongodb.NewDoraStatColl().FindCount()
+	if err != nil {
+		log.Errorf("doraStat FindCount err:%v", err)
+		return fmt.Errorf("doraStat FindCount err:%v", err)
+	}
+	if count > 0 {
+		// 按结束时间筛选任务，与统计数据按结束时间聚合保持一致
+		since = doraLookbackStart(time.Now())
+		option.EndTime = since - doraRestoreLookbackDays*24*60*60
+	}
+
+	allProducts, err := templaterepo.NewProductColl().List()
+	if err != nil {
+		log.Errorf("doraStat ProductTmpl List err:%v", err)
+		return fmt.Errorf("doraStat ProductTmpl List err:%v", err)
+	}
+	for _, product := range allProducts {
+		option.ProductNames = []string{product.ProductName}
+		allTasks, err := commonmongodb.NewTaskColl().ListAllTasks(option)
+		if err != nil {
+			log.Errorf("doraStat list tasks err:%v", err)
+			return fmt.Errorf("doraStat list tasks err:%v", err)
+		}
+
+		productionEnvs, err := getProductionEnvs(product.ProductName)
+		if err != nil {
+			log.Warnf("doraStat get production envs of %s err:%v", product.ProductName, err)
+		}
+		for _, doraStat := range computeDoraStats(product.ProductName, allTasks, productionEnvs, since, log) {
+			if err := mongodb.NewDoraStatColl().Upsert(doraStat); err != nil {
+				log.Errorf("doraStat Upsert err:%v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// doraLookbackStart 增量统计的起始时间，按天对齐，避免只用部分数据覆盖最早一天的统计
+func doraLookbackStart(now time.Time) int64 {
+	date := now.AddDate(0, 0, -doraLookbackDays).Format(config.Date)
+	start, _ := time.ParseInLocation(config.Date, date, time.Local)
+	return start.Unix()
+}
+
+// getProductionEnvs 部署在生产集群上的环境视为生产环境
+func getProductionEnvs(productName string) (map[string]bool, error) {
+	envs, err := commonmongodb.NewProductColl().List(&commonmongodb.ProductListOptions{Name: productName})
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := commonmongodb.NewK8SClusterColl().List(&commonmongodb.ClusterListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	productionClusters := make(map[string]bool)
+	for _, cluster := range clusters {
+		productionClusters[cluster.ID.Hex()] = cluster.Production
+	}
+
+	resp := make(map[string]bool)
+	for _, env := range envs {
+		resp[env.EnvName] = productionClusters[env.ClusterID]
+	}
+	return resp, nil
+}
+
+type doraDeploy struct {
+	envName     string
+	success     bool
+	endTime     int64
+	commitTimes []int64
+}
+
+// computeDoraStats 按结束时间依次回放部署记录，生成每个环境每天的统计数据
+// since 之前的部署只用于恢复失败状态，不生成统计数据，避免用部分数据覆盖已有的统计
+func computeDoraStats(productName string, tasks []*taskmodels.Task, productionEnvs map[string]bool, since int64, log *zap.SugaredLogger) []*models.DoraStat {
+	deploys := make([]*doraDeploy, 0)
+	for _, task := range tasks {
+		deploys = append(deploys, toDoraDeploys(task, log)...)
+	}
+	sort.SliceStable(deploys, func(i, j int) bool { return deploys[i].endTime < deploys[j].endTime })
+
+	resp := make([]*models.DoraStat, 0)
+	statMap := make(map[string]*models.DoraStat)
+	failingSince := make(map[string]int64)
+	for _, deploy := range deploys {
+		date := time.Unix(deploy.endTime, 0).Format(config.Date)
+		tt, _ := time.ParseInLocation(config.Date, date, time.Local)
+		if tt.Unix() < since {
+			if deploy.success {
+				failingSince[deploy.envName] = 0
+			} else if failingSince[deploy.envName] == 0 {
+				failingSince[deploy.envName] = deploy.endTime
+			}
+			continue
+		}
+
+		key := deploy.envName + "/" + date
+		doraStat, ok := statMap[key]
+		if !ok {
+			doraStat = &models.DoraStat{
+				ProductName: productName,
+				EnvName:     deploy.envName,
+				Production:  productionEnvs[deploy.envName],
+				Date:        date,
+				CreateTime:  tt.Unix(),
+				UpdateTime:  time.Now().Unix(),
+			}
+			statMap[key] = doraStat
+			resp = append(resp, doraStat)
+		}
+
+		if !deploy.success {
+			doraStat.DeployFailure++
+			if failingSince[deploy.envName] == 0 {
+				failingSince[deploy.envName] = deploy.endTime
+			}
+			continue
+		}
+
+		doraStat.DeploySuccess++
+		for _, commitTime := range deploy.commitTimes {
+			if commitTime <= deploy.endTime {
+				doraStat.LeadTimeTotal += deploy.endTime - commitTime
+				doraStat.LeadTimeCount++
+			}
+		}
+		if since := failingSince[deploy.envName]; since > 0 {
+			doraStat.RestoreTimeTotal += deploy.endTime - since
+			doraStat.RestoreCount++
+			failingSince[deploy.envName] = 0
+		}
+	}
+	return resp
+}
+
+// toDoraDeploys 只统计执行到部署阶段的工作流任务，部署之后的阶段失败也算作变更失败
+// 同时部署到多个环境时，每个环境各记一次部署
+// 变更前置时间依赖构建时记录的 commit 时间，代码源未返回 commit 时间时不统计前置时间
+func toDoraDeploys(task *taskmodels.Task, log *zap.SugaredLogger) []*doraDeploy {
+	if task.WorkflowArgs == nil || task.WorkflowArgs.Namespace == "" || task.EndTime == 0 {
+		return nil
+	}
+	if task.Status != config.StatusPassed && task.Status != config.StatusFailed && task.Status != config.StatusTimeout {
+		return nil
+	}
+
+	deployed := false
+	commitTimes := make([]int64, 0)
+	for _, stage := range task.Stages {
+		switch stage.TaskType {
+		case config.TaskDeploy:
+			if stage.Status == config.StatusPassed || stage.Status == config.StatusFailed || stage.Status == config.StatusTimeout {
+				deployed = true
+			}
+		case config.TaskBuild:
+			for _, subTask := range stage.SubTasks {
+				buildInfo, err := base.ToBuildTask(subTask)
+				if err != nil {
+					log.Errorf("doraStat ToBuildTask err:%v", err)
+					continue
+				}
+				if commitTime := primaryCommitTime(buildInfo); commitTime > 0 {
+					commitTimes = append(commitTimes, commitTime)
+				}
+			}
+		}
+	}
+	if !deployed {
+		return nil
+	}
+
+	resp := make([]*doraDeploy, 0)
+	envSet := make(map[string]bool)
+	for _, envName := range strings.Split(task.WorkflowArgs.Namespace, ",") {
+		envName = strings.TrimSpace(envName)
+		if envName == "" || envSet[envName] {
+			continue
+		}
+		envSet[envName] = true
+		resp = append(resp, &doraDeploy{
+			envName:     envName,
+			success:     task.Status == config.StatusPassed,
+			endTime:     task.EndTime,
+			commitTimes: commitTimes,
+		})
+	}
+	return resp
+}
+
+func primaryCommitTime(buildInfo *taskmodels.Build) int64 {
+	for _, repo := range buildInfo.JobCtx.Builds {
+		if repo.IsPrimary {
+			return repo.CommitTime
+		}
+	}
+	if len(buildInfo.JobCtx.Builds) > 0 {
+		return buildInfo.JobCtx.Builds[0].CommitTime
+	}
+	return 0
+}
+
+type doraMeasure struct {
+	ProductName   string `json:"productName,omitempty"`
+	EnvName       string `json:"envName,omitempty"`
+	Production    bool   `json:"production"`
+	DeploySuccess int    `json:"deploySuccess"`
+	DeployFailure int    `json:"deployFailure"`
+	// 平均每天成功部署的次数
+	DeploymentFrequency float64 `json:"deploymentFrequency"`
+	// 平均变更前置时间，单位秒
+	LeadTime          int64   `json:"leadTime"`
+	ChangeFailureRate float64 `json:"changeFailureRate"`
+	// 平均恢复时间，单位秒
+	MeanTimeToRestore int64 `json:"meanTimeToRestore"`
+
+	days             map[string]bool
+	leadTimeTotal    int64
+	leadTimeCount    int
+	restoreTimeTotal int64
+	restoreCount     int
+}
+
+type doraMeasureResp struct {
+	Total *doraMeasure   `json:"total"`
+	Envs  []*doraMeasure `json:"envs"`
+}
+
+func GetDoraMeasure(option *models.DoraStatOption, log *zap.SugaredLogger) (*doraMeasureResp, error) {
+	doraStats, err := mongodb.NewDoraStatColl().ListDoraStat(option)
+	if err != nil {
+		log.Errorf("ListDoraStat err:%v", err)
+		return nil, fmt.Errorf("ListDoraStat err:%v", err)
+	}
+
+	return summarizeDoraStats(doraStats, option.StartDate, option.EndDate), nil
+}
+
+func summarizeDoraStats(doraStats []*models.DoraStat, startDate, endDate int64) *doraMeasureResp {
+	total := &doraMeasure{days: make(map[string]bool)}
+	envMap := make(map[string]*doraMeasure)
+	envs := make([]*doraMeasure, 0)
+	for _, doraStat := range doraStats {
+		key := doraStat.ProductName + "/" + doraStat.EnvName
+		env, ok := envMap[key]
+		if !ok {
+			env = &doraMeasure{
+				ProductName: doraStat.ProductName,
+				EnvName:     doraStat.EnvName,
+				Production:  doraStat.Production,
+				days:        make(map[string]bool),
+			}
+			envMap[key] = env
+			envs = append(envs, env)
+		}
+		env.add(doraStat)
+		total.add(doraStat)
+	}
+
+	// 指定了时间范围时按照范围内的天数计算部署频率，否则按照有数据的天数计算
+	days := 0
+	if startDate > 0 && endDate > startDate {
+		days = int(math.Ceil(float64(endDate-startDate) / float64(24*60*60)))
+	}
+	total.finish(days)
+	for _, env := range envs {
+		env.finish(days)
+	}
+	sort.SliceStable(envs, func(i, j int) bool {
+		if envs[i].ProductName != envs[j].ProductName {
+			return envs[i].ProductName < envs[j].ProductName
+		}
+		return envs[i].EnvName < envs[j].EnvName
+	})
+
+	return &doraMeasureResp{Total: total, Envs: envs}
+}
+
+func (m *doraMeasure) add(doraStat *models.DoraStat) {
+	m.DeploySuccess += doraStat.DeploySuccess
+	m.DeployFailure += doraStat.DeployFailure
+	m.leadTimeTotal += doraStat.LeadTimeTotal
+	m.leadTimeCount += doraStat.LeadTimeCount
+	m.restoreTimeTotal += doraStat.RestoreTimeTotal
+	m.restoreCount += doraStat.RestoreCount
+	m.days[doraStat.Date] = true
+}
+
+func (m *doraMeasure) finish(days int) {
+	if days <= 0 {
+		days = len(m.days)
+	}
+	if days > 0 {
+		m.DeploymentFrequency = float64(m.DeploySuccess) / float64(days)
+	}
+	if m.leadTimeCount > 0 {
+		m.LeadTime = m.leadTimeTotal / int64(m.leadTimeCount)
+	}
+	if total := m.DeploySuccess + m.DeployFailure; total > 0 {
+		m.ChangeFailureRate = float64(m.DeployFailure) / float64(total)
+	}
+	if m.restoreCount > 0 {
+		m.MeanTimeToRestore = m.restoreTimeTotal / int64(m.restoreCount)
+	}
+}
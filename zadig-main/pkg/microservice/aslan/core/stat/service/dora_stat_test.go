@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	taskmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/stat/repository/models"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func newDeployTask(t *testing.T, env string, status config.Status, endTime, commitTime int64) *taskmodels.Task {
+	build := &taskmodels.Build{
+		JobCtx: taskmodels.JobCtx{
+			Builds: []*types.Repository{{IsPrimary: true, CommitTime: commitTime}},
+		},
+	}
+	subTask, err := build.ToSubTask()
+	assert.NoError(t, err)
+
+	deployStatus := config.StatusPassed
+	if status != config.StatusPassed {
+		deployStatus = config.StatusFailed
+	}
+	return &taskmodels.Task{
+		Status:       status,
+		EndTime:      endTime,
+		WorkflowArgs: &commonmodels.WorkflowTaskArgs{Namespace: env},
+		Stages: []*commonmodels.Stage{
+			{TaskType: config.TaskBuild, Status: config.StatusPassed, SubTasks: map[string]map[string]interface{}{"svc": subTask}},
+			{TaskType: config.TaskDeploy, Status: deployStatus},
+		},
+	}
+}
+
+func TestComputeDoraStats(t *testing.T) {
+	day := time.Date(2022, 3, 1, 10, 0, 0, 0, time.Local).Unix()
+	hour := int64(60 * 60)
+	tasks := []*taskmodels.Task{
+		newDeployTask(t, "prod", config.StatusPassed, day, day-2*hour),
+		newDeployTask(t, "prod", config.StatusFailed, day+hour, day),
+		newDeployTask(t, "prod", config.StatusFailed, day+2*hour, day),
+		// 跨天恢复，恢复时间从第一次失败开始计算
+		newDeployTask(t, "prod", config.StatusPassed, day+24*hour, day+23*hour),
+		newDeployTask(t, "dev", config.StatusPassed, day, 0),
+		// 没有执行到部署阶段的任务不统计
+		{Status: config.StatusFailed, EndTime: day, WorkflowArgs: &commonmodels.WorkflowTaskArgs{Namespace: "prod"}},
+		{Status: config.StatusCancelled, EndTime: day, WorkflowArgs: &commonmodels.WorkflowTaskArgs{Namespace: "prod"}},
+	}
+
+	stats := computeDoraStats("demo", tasks, map[string]bool{"prod": true}, 0, zap.NewNop().Sugar())
+	assert.Len(t, stats, 3)
+
+	statMap := make(map[string]*models.DoraStat)
+	for _, stat := range stats {
+		statMap[stat.EnvName+"/"+stat.Date] = stat
+	}
+
+	first := statMap["prod/2022-03-01"]
+	assert.True(t, first.Production)
+	assert.Equal(t, 1, first.DeploySuccess)
+	assert.Equal(t, 2, first.DeployFailure)
+	assert.Equal(t, 2*hour, first.LeadTimeTotal)
+	assert.Equal(t, 1, first.LeadTimeCount)
+	assert.Equal(t, 0, first.RestoreCount)
+
+	second := statMap["prod/2022-03-02"]
+	assert.Equal(t, 1, second.DeploySuccess)
+	assert.Equal(t, 1, second.RestoreCount)
+	assert.Equal(t, 23*hour, second.RestoreTimeTotal)
+
+	dev := statMap["dev/2022-03-01"]
+	assert.False(t, dev.Production)
+	assert.Equal(t, 1, dev.DeploySuccess)
+	assert.Equal(t, 0, dev.LeadTimeCount)
+}
+
+func TestComputeDoraStatsMultiEnv(t *testing.T) {
+	day := time.Date(2022, 3, 1, 10, 0, 0, 0, time.Local).Unix()
+	tasks := []*taskmodels.Task{
+		newDeployTask(t, "dev, prod", config.StatusPassed, day, 0),
+	}
+
+	stats := computeDoraStats("demo", tasks, map[string]bool{"prod": true}, 0, zap.NewNop().Sugar())
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "dev", stats[0].EnvName)
+	assert.False(t, stats[0].Production)
+	assert.Equal(t, "prod", stats[1].EnvName)
+	assert.True(t, stats[1].Production)
+	assert.Equal(t, 1, stats[1].DeploySuccess)
+}
+
+func TestComputeDoraStatsSince(t *testing.T) {
+	day := time.Date(2022, 3, 1, 10, 0, 0, 0, time.Local).Unix()
+	hour := int64(60 * 60)
+	since := time.Date(2022, 3, 2, 0, 0, 0, 0, time.Local).Unix()
+	tasks := []*taskmodels.Task{
+		newDeployTask(t, "prod", config.StatusFailed, day, 0),
+		newDeployTask(t, "prod", config.StatusFailed, day+hour, 0),
+		newDeployTask(t, "prod", config.StatusPassed, day+24*hour, 0),
+	}
+
+	// 统计起点之前的部署不生成统计数据，但失败状态会延续到起点之后
+	stats := computeDoraStats("demo", tasks, nil, since, zap.NewNop().Sugar())
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "2022-03-02", stats[0].Date)
+	assert.Equal(t, 1, stats[0].DeploySuccess)
+	assert.Equal(t, 0, stats[0].DeployFailure)
+	assert.Equal(t, 1, stats[0].RestoreCount)
+	assert.Equal(t, 24*hour, stats[0].RestoreTimeTotal)
+}
+
+func TestSummarizeDoraStats(t *testing.T) {
+	stats := []*models.DoraStat{
+		{ProductName: "demo", EnvName: "prod", Date: "2022-03-01", DeploySuccess: 3, DeployFailure: 1, LeadTimeTotal: 300, LeadTimeCount: 3, RestoreTimeTotal: 100, RestoreCount: 1},
+		{ProductName: "demo", EnvName: "prod", Date: "2022-03-02", DeploySuccess: 1, LeadTimeTotal: 100, LeadTimeCount: 1},
+		{ProductName: "demo", EnvName: "dev", Date: "2022-03-01", DeploySuccess: 4},
+	}
+
+	resp := summarizeDoraStats(stats, 0, 0)
+	assert.Equal(t, 8, resp.Total.DeploySuccess)
+	assert.Equal(t, 4.0, resp.Total.DeploymentFrequency)
+	assert.Equal(t, int64(100), resp.Total.LeadTime)
+	assert.InDelta(t, 1.0/9, resp.Total.ChangeFailureRate, 0.0001)
+	assert.Equal(t, int64(100), resp.Total.MeanTimeToRestore)
+
+	assert.Len(t, resp.Envs, 2)
+	assert.Equal(t, "dev", resp.Envs[0].EnvName)
+	assert.Equal(t, "prod", resp.Envs[1].EnvName)
+	assert.Equal(t, 2.0, resp.Envs[1].DeploymentFrequency)
+	assert.Equal(t, 0.2, resp.Envs[1].ChangeFailureRate)
+
+	// 指定时间范围时按照范围内的天数计算部署频率
+	resp = summarizeDoraStats(stats, 1, 1+4*24*60*60)
+	assert.Equal(t, 2.0, resp.Total.DeploymentFrequency)
+}
+
+func TestDoraLookbackStart(t *testing.T) {
+	now := time.Date(2022, 3, 10, 15, 30, 0, 0, time.Local)
+	start := doraLookbackStart(now)
+	assert.Equal(t, time.Date(2022, 3, 3, 0, 0, 0, 0, time.Local).Unix(), start)
+	// 与统计数据按天聚合的日期保持一致
+	assert.Equal(t, "2022-03-03", time.Unix(start, 0).Format(config.Date))
+}
@@ -100,6 +100,8 @@ func InitTestStat(log *zap.SugaredLogger) error {
 				totalDeployCount = 0
 				totalTestCount   = 0
 				totalTestCase    = 0
+				totalCovered     = 0
+				totalValid       = 0
 			)
 			//循环task任务获取需要的数据
 			for _, taskPreview := range taskDateMap[taskDate] {
@@ -169,6 +171,10 @@ func InitTestStat(log *zap.SugaredLogger) error {
 										return
 									}
 									totalTestCase += testReport.FunctionTestSuite.Tests
+									if coverage := testReport.FunctionTestSuite.Coverage; coverage != nil {
+										totalCovered += coverage.LinesCovered
+										totalValid += coverage.LinesValid
+									}
 								}()
 							}
 						}
@@ -187,6 +193,8 @@ func InitTestStat(log *zap.SugaredLogger) error {
 			testStat.TotalTestCount = totalTestCount
 			testStat.TotalDeployCount = totalDeployCount
 			testStat.TotalTestCase = totalTestCase
+			testStat.TotalCoveredLines = totalCovered
+			testStat.TotalValidLines = totalValid
 			testStat.Date = taskDate
 			tt, _ := time.ParseInLocation(config.Date, taskDate, time.Local)
 			testStat.CreateTime = tt.Unix()
@@ -250,6 +258,42 @@ func GetTestAverageMeasure(startDate, endDate int64, productNames []string, log
 	return testStatDailyArgs, nil
 }
 
+type testCoverageStat struct {
+	Date string `json:"date"`
+	// Coverage 当天所有测试的行覆盖率，单位百分比
+	Coverage float64 `json:"coverage"`
+}
+
+func GetTestCoverageMeasure(startDate, endDate int64, productNames []string, log *zap.SugaredLogger) ([]*testCoverageStat, error) {
+	testStats, err := mongodb.NewTestStatColl().ListTestStat(&mongodb.TestStatOption{StartDate: startDate, EndDate: endDate, IsAsc: true, ProductNames: productNames})
+	if err != nil {
+		log.Errorf("ListTestStat err:%v", err)
+		return nil, fmt.Errorf("ListTestStat err:%v", err)
+	}
+
+	dates := make([]string, 0)
+	coveredMap := make(map[string]int)
+	validMap := make(map[string]int)
+	for _, testStat := range testStats {
+		if _, ok := validMap[testStat.Date]; !ok {
+			dates = append(dates, testStat.Date)
+		}
+		coveredMap[testStat.Date] += testStat.TotalCoveredLines
+		validMap[testStat.Date] += testStat.TotalValidLines
+	}
+	sort.Strings(dates)
+
+	testCoverageStats := make([]*testCoverageStat, 0, len(dates))
+	for _, date := range dates {
+		stat := &testCoverageStat{Date: date}
+		if validMap[date] > 0 {
+			stat.Coverage = math.Floor(float64(coveredMap[date])/float64(validMap[date])*10000+0.5) / 100
+		}
+		testCoverageStats = append(testCoverageStats, stat)
+	}
+	return testCoverageStats, nil
+}
+
 type testCaseStat struct {
 	Day      int64 `json:"day"`
 	TestCase int   `json:"testCase"`
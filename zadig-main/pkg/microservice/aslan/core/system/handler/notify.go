@@ -116,3 +116,41 @@ func ListSubscriptions(c *gin.Context) {
 
 	ctx.Resp, ctx.Err = service.ListSubscriptions(ctx.UserName, ctx.Logger)
 }
+
+func ListNotificationSubscriptions(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListNotificationSubscriptions(ctx.UserID, ctx.Logger)
+}
+
+func CreateNotificationSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := new(commonmodels.NotificationSubscription)
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid subscription args")
+		return
+	}
+	ctx.Err = service.CreateNotificationSubscription(ctx.UserID, ctx.UserName, args, ctx.Logger)
+}
+
+func UpdateNotificationSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := new(commonmodels.NotificationSubscription)
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid subscription args")
+		return
+	}
+	ctx.Err = service.UpdateNotificationSubscription(ctx.UserID, ctx.UserName, c.Param("id"), args, ctx.Logger)
+}
+
+func DeleteNotificationSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Err = service.DeleteNotificationSubscription(ctx.UserID, c.Param("id"), ctx.Logger)
+}
@@ -169,6 +169,19 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		privateKey.DELETE("/:id", gin2.UpdateOperationLogStatus, DeletePrivateKey)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 镜像签名密钥管理接口
+	// ---------------------------------------------------------------------------------------
+	signingKey := router.Group("signingKey")
+	{
+		signingKey.GET("", ListSigningKeys)
+		signingKey.GET("/:id", GetSigningKey)
+		signingKey.POST("", gin2.UpdateOperationLogStatus, CreateSigningKey)
+		signingKey.PUT("/:id", gin2.UpdateOperationLogStatus, UpdateSigningKey)
+		signingKey.DELETE("/:id", gin2.UpdateOperationLogStatus, DeleteSigningKey)
+		signingKey.POST("/verify", VerifyImageSignature)
+	}
+
 	notification := router.Group("notification")
 	{
 		notification.GET("", PullNotify)
@@ -178,6 +191,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		notification.PUT("/subscribe/:type", UpdateSubscribe)
 		notification.DELETE("/unsubscribe/notifytype/:type", Unsubscribe)
 		notification.GET("/subscribe", ListSubscriptions)
+
+		notification.GET("/subscriptions", ListNotificationSubscriptions)
+		notification.POST("/subscriptions", CreateNotificationSubscription)
+		notification.PUT("/subscriptions/:id", UpdateNotificationSubscription)
+		notification.DELETE("/subscriptions/:id", DeleteNotificationSubscription)
 	}
 
 	announcement := router.Group("announcement")
@@ -218,4 +236,16 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		externalSystem.PUT("/:id", gin2.UpdateOperationLogStatus, UpdateExternalSystem)
 		externalSystem.DELETE("/:id", gin2.UpdateOperationLogStatus, DeleteExternalSystem)
 	}
+
+	// ---------------------------------------------------------------------------------------
+	// external task plugin API
+	// ---------------------------------------------------------------------------------------
+	taskPlugin := router.Group("taskPlugin")
+	{
+		taskPlugin.GET("", ListExternalTaskPlugins)
+		taskPlugin.GET("/:id", GetExternalTaskPlugin)
+		taskPlugin.POST("", gin2.UpdateOperationLogStatus, CreateExternalTaskPlugin)
+		taskPlugin.PUT("/:id", gin2.UpdateOperationLogStatus, UpdateExternalTaskPlugin)
+		taskPlugin.DELETE("/:id", gin2.UpdateOperationLogStatus, DeleteExternalTaskPlugin)
+	}
 }
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+func ListSigningKeys(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListSigningKeys(ctx.Logger)
+}
+
+// GetSigningKey is called by warpdrive to get the private key before the image is signed
+func GetSigningKey(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetSigningKey(c.Param("id"), ctx.Logger)
+}
+
+func CreateSigningKey(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.SigningKey)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("CreateSigningKey c.GetRawData() err : %v", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("CreateSigningKey json.Unmarshal err : %v", err)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "新增", "系统设置-签名密钥", fmt.Sprintf("name:%s", args.Name), "", ctx.Logger)
+
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid SigningKey args")
+		return
+	}
+	args.UpdateBy = ctx.UserName
+
+	ctx.Err = service.CreateSigningKey(args, ctx.Logger)
+}
+
+func UpdateSigningKey(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.SigningKey)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("UpdateSigningKey c.GetRawData() err : %v", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("UpdateSigningKey json.Unmarshal err : %v", err)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统设置-签名密钥", fmt.Sprintf("name:%s", args.Name), "", ctx.Logger)
+
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid SigningKey args")
+		return
+	}
+	args.UpdateBy = ctx.UserName
+
+	ctx.Err = service.UpdateSigningKey(c.Param("id"), args, ctx.Logger)
+}
+
+func DeleteSigningKey(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "删除", "系统设置-签名密钥", fmt.Sprintf("id:%s", c.Param("id")), "", ctx.Logger)
+	ctx.Err = service.DeleteSigningKey(c.Param("id"), ctx.Logger)
+}
+
+// VerifyImageSignature is called by warpdrive before the image is deployed or released
+func VerifyImageSignature(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.VerifyImageSignatureArgs)
+	if err := c.ShouldBindJSON(args); err != nil || args.Image == "" || args.SigningKeyID == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("image and signing_key_id are required")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.VerifyImageSignature(args.Image, args.SigningKeyID, ctx.Logger)
+}
@@ -33,7 +33,8 @@ import (
 )
 
 const (
-	defaultWorkflowMaxDays int = 365
+	defaultWorkflowMaxDays   int = 365
+	defaultBuildCacheMaxDays int = 30
 	//logTag                     = "SysCap"
 )
 
@@ -44,6 +45,13 @@ var defaultWorkflowTaskRetention = &commonmodels.CapacityStrategy{
 	},
 }
 
+var defaultBuildCacheRetention = &commonmodels.CapacityStrategy{
+	Target: commonmodels.BuildCacheRetention,
+	Retention: &commonmodels.RetentionConfig{
+		MaxDays: defaultBuildCacheMaxDays,
+	},
+}
+
 func UpdateSysCapStrategy(strategy *commonmodels.CapacityStrategy) error {
 	if err := validateStrategy(strategy); err != nil {
 		return err
@@ -55,15 +63,26 @@ func UpdateSysCapStrategy(strategy *commonmodels.CapacityStrategy) error {
 	}
 
 	// 更新成功后，立即按照新的配置清理数据
-	go handleWorkflowTaskRetentionCenter(strategy, false)
+	switch strategy.Target {
+	case commonmodels.BuildCacheRetention:
+		go handleBuildCacheRetention(strategy, false)
+	default:
+		go handleWorkflowTaskRetentionCenter(strategy, false)
+	}
 
 	return nil
 }
 
 func GetCapacityStrategy(target commonmodels.CapacityTarget) (*commonmodels.CapacityStrategy, error) {
 	result, err := commonrepo.NewStrategyColl().GetByTarget(target)
-	if err != nil && target == commonmodels.WorkflowTaskRetention {
-		return defaultWorkflowTaskRetention, nil // Return default setup
+	if err != nil {
+		// Return default setup
+		switch target {
+		case commonmodels.WorkflowTaskRetention:
+			return defaultWorkflowTaskRetention, nil
+		case commonmodels.BuildCacheRetention:
+			return defaultBuildCacheRetention, nil
+		}
 	}
 	return result, err
 }
@@ -77,10 +96,56 @@ func HandleSystemGC(dryRun bool) error {
 		return err
 	}
 
-	return handleWorkflowTaskRetentionCenter(strategy, dryRun)
+	if err := handleWorkflowTaskRetentionCenter(strategy, dryRun); err != nil {
+		return err
+	}
+
+	cacheStrategy, err := GetCapacityStrategy(commonmodels.BuildCacheRetention)
+	if err != nil {
+		return err
+	}
+	return handleBuildCacheRetention(cacheStrategy, dryRun)
+}
+
+// handleBuildCacheRetention 按照留存策略清理编译结果缓存的索引
+func handleBuildCacheRetention(strategy *commonmodels.CapacityStrategy, dryRun bool) error {
+	retention := strategy.Retention
+	if retention == nil {
+		return errors.New("no valid strategy for build cache retention")
+	}
+	if dryRun {
+		log.Infof("build cache will be cleaned up, max days: %d, max items: %d", retention.MaxDays, retention.MaxItems)
+		return nil
+	}
+
+	var (
+		count int64
+		err   error
+	)
+	cacheColl := commonrepo.NewBuildCacheColl()
+	switch {
+	case retention.MaxDays > 0:
+		count, err = cacheColl.DeleteBefore(time.Now().AddDate(0, 0, -retention.MaxDays).Unix())
+	case retention.MaxItems > 0:
+		count, err = cacheColl.DeleteExceeded(retention.MaxItems)
+	default:
+		return errors.New("no valid strategy for build cache retention")
+	}
+	if err != nil {
+		log.Errorf("failed to clean up build cache, err: %s", err)
+		return err
+	}
+
+	log.Infof("%d stale build caches are cleaned up", count)
+	return nil
 }
 
 func CleanCache() error {
+	// 编译结果缓存跟随每日的缓存清理一起按留存策略清理
+	if strategy, err := GetCapacityStrategy(commonmodels.BuildCacheRetention); err == nil {
+		_ = handleBuildCacheRetention(strategy, false)
+	}
+
 	workflowMap := make(map[string]int)
 
 	workflows, err := commonrepo.NewWorkflowColl().List(&commonrepo.ListWorkflowOption{})
@@ -325,10 +390,10 @@ func cleanStaleTasks(tasks []*task.Task, s3Server *s3.S3, dryRun bool) []string
 }
 
 func validateStrategy(strategy *commonmodels.CapacityStrategy) error {
-	if strategy.Target == commonmodels.WorkflowTaskRetention {
+	if strategy.Target == commonmodels.WorkflowTaskRetention || strategy.Target == commonmodels.BuildCacheRetention {
 		retention := strategy.Retention
 		if retention == nil {
-			return errors.New("SysCap strategy: nil retention config for WorkflowTaskRetention")
+			return fmt.Errorf("SysCap strategy: nil retention config for %s", strategy.Target)
 		}
 		if !(retention.MaxDays > 0 && retention.MaxItems == 0) &&
 			!(retention.MaxDays == 0 && retention.MaxItems > 0) {
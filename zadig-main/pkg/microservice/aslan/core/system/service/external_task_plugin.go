@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"net/url"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func validateExternalTaskPlugin(args *commonmodels.ExternalTaskPlugin) error {
+	if args.Name == "" || args.Address == "" {
+		return fmt.Errorf("name and address must be provided")
+	}
+	if u, err := url.Parse(args.Address); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid address: %s", args.Address)
+	}
+	keys := make(map[string]bool)
+	for _, param := range args.Params {
+		if param.Key == "" || keys[param.Key] {
+			return fmt.Errorf("param key is empty or duplicated: %s", param.Key)
+		}
+		keys[param.Key] = true
+	}
+	return nil
+}
+
+func ListExternalTaskPlugins(log *zap.SugaredLogger) ([]*commonmodels.ExternalTaskPlugin, error) {
+	plugins, err := commonrepo.NewExternalTaskPluginColl().List()
+	if err != nil {
+		log.Errorf("ListExternalTaskPlugins err:%v", err)
+		return nil, e.ErrListExternalTaskPlugin.AddErr(err)
+	}
+	// headers may contain credentials, they are only returned by the admin only detail API
+	for _, plugin := range plugins {
+		plugin.Headers = nil
+	}
+	return plugins, nil
+}
+
+func GetExternalTaskPlugin(id string, log *zap.SugaredLogger) (*commonmodels.ExternalTaskPlugin, error) {
+	plugin, err := commonrepo.NewExternalTaskPluginColl().FindByID(id)
+	if err != nil {
+		log.Errorf("GetExternalTaskPlugin %s err:%v", id, err)
+		return nil, e.ErrListExternalTaskPlugin.AddErr(err)
+	}
+	return plugin, nil
+}
+
+func CreateExternalTaskPlugin(args *commonmodels.ExternalTaskPlugin, log *zap.SugaredLogger) error {
+	if err := validateExternalTaskPlugin(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if err := commonrepo.NewExternalTaskPluginColl().Create(args); err != nil {
+		log.Errorf("CreateExternalTaskPlugin err:%v", err)
+		return e.ErrCreateExternalTaskPlugin.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateExternalTaskPlugin(id string, args *commonmodels.ExternalTaskPlugin, log *zap.SugaredLogger) error {
+	if err := validateExternalTaskPlugin(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if err := commonrepo.NewExternalTaskPluginColl().Update(id, args); err != nil {
+		log.Errorf("UpdateExternalTaskPlugin err:%v", err)
+		return e.ErrUpdateExternalTaskPlugin.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteExternalTaskPlugin(id string, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewExternalTaskPluginColl().Delete(id); err != nil {
+		log.Errorf("DeleteExternalTaskPlugin err:%v", err)
+		return e.ErrDeleteExternalTaskPlugin.AddErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+var (
+	notificationEvents = sets.NewString(
+		string(config.NotificationEventTaskFailed),
+		string(config.NotificationEventEnvDeployed),
+		string(config.NotificationEventDeliveryVersionCreated),
+		string(config.NotificationEventApprovalRequired),
+		string(config.NotificationEventClusterDisconnected),
+		string(config.NotificationEventEnvExpiring),
+	)
+	notificationChannels = sets.NewString(
+		string(config.NotificationChannelInApp),
+		string(config.NotificationChannelEmail),
+		string(config.NotificationChannelIM),
+	)
+)
+
+func ListNotificationSubscriptions(userID string, log *zap.SugaredLogger) ([]*commonmodels.NotificationSubscription, error) {
+	resp, err := commonrepo.NewNotificationSubscriptionColl().List(&commonrepo.NotificationSubscriptionListOption{UserID: userID})
+	if err != nil {
+		log.Errorf("failed to list notification subscriptions of %s, err: %s", userID, err)
+		return nil, e.ErrListNotificationSubscriptions.AddErr(err)
+	}
+	return resp, nil
+}
+
+func CreateNotificationSubscription(userID, userName string, args *commonmodels.NotificationSubscription, log *zap.SugaredLogger) error {
+	args.UserID = userID
+	args.UserName = userName
+	if err := validateNotificationSubscription(args); err != nil {
+		return e.ErrCreateNotificationSubscription.AddErr(err)
+	}
+
+	if err := commonrepo.NewNotificationSubscriptionColl().Create(args); err != nil {
+		log.Errorf("failed to create notification subscription, err: %s", err)
+		return e.ErrCreateNotificationSubscription.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateNotificationSubscription(userID, userName, id string, args *commonmodels.NotificationSubscription, log *zap.SugaredLogger) error {
+	coll := commonrepo.NewNotificationSubscriptionColl()
+	sub, err := coll.Find(id)
+	if err != nil || sub.UserID != userID {
+		return e.ErrUpdateNotificationSubscription.AddDesc("subscription not found")
+	}
+
+	args.UserID = userID
+	args.UserName = userName
+	args.CreateTime = sub.CreateTime
+	if err := validateNotificationSubscription(args); err != nil {
+		return e.ErrUpdateNotificationSubscription.AddErr(err)
+	}
+
+	if err := coll.Update(id, args); err != nil {
+		log.Errorf("failed to update notification subscription %s, err: %s", id, err)
+		return e.ErrUpdateNotificationSubscription.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteNotificationSubscription(userID, id string, log *zap.SugaredLogger) error {
+	coll := commonrepo.NewNotificationSubscriptionColl()
+	sub, err := coll.Find(id)
+	if err != nil || sub.UserID != userID {
+		return e.ErrDeleteNotificationSubscription.AddDesc("subscription not found")
+	}
+
+	if err := coll.Delete(id); err != nil {
+		log.Errorf("failed to delete notification subscription %s, err: %s", id, err)
+		return e.ErrDeleteNotificationSubscription.AddErr(err)
+	}
+	return nil
+}
+
+func validateNotificationSubscription(args *commonmodels.NotificationSubscription) error {
+	if len(args.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range args.Events {
+		if !notificationEvents.Has(string(event)) {
+			return fmt.Errorf("unknown event %s", event)
+		}
+	}
+	if err := checkNotificationScope(args); err != nil {
+		return err
+	}
+
+	if len(args.Channels) == 0 {
+		return fmt.Errorf("at least one channel is required")
+	}
+	for _, channel := range args.Channels {
+		if !notificationChannels.Has(string(channel)) {
+			return fmt.Errorf("unknown channel %s", channel)
+		}
+		switch channel {
+		case config.NotificationChannelEmail:
+			// 未指定邮箱时使用用户的邮箱
+			if args.Email == "" {
+				users, err := user.New().ListUsers(&user.SearchArgs{UIDs: []string{args.UserID}})
+				if err != nil || len(users) == 0 || users[0].Email == "" {
+					return fmt.Errorf("email address is required")
+				}
+				args.Email = users[0].Email
+			}
+		case config.NotificationChannelIM:
+			if args.IM == nil || args.IM.WebHookType == "" {
+				return fmt.Errorf("im webhook is required")
+			}
+		}
+	}
+
+	return notification.ValidateQuietHours(args.QuietHours)
+}
+
+// systemScope 系统级别角色绑定所在的命名空间
+const systemScope = "*"
+
+// checkNotificationScope 订阅的项目需要用户有权限访问，不指定项目时会收到所有项目的事件，只允许系统管理员订阅
+func checkNotificationScope(args *commonmodels.NotificationSubscription) error {
+	isAdmin, err := hasRoleBinding(systemScope, args.UserID, string(setting.SystemAdmin))
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %s", err)
+	}
+	if args.ProjectName == "" {
+		if !isAdmin {
+			return fmt.Errorf("project is required")
+		}
+		return nil
+	}
+	if isAdmin {
+		return nil
+	}
+
+	authorized, err := hasRoleBinding(args.ProjectName, args.UserID, "")
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %s", err)
+	}
+	if !authorized {
+		return fmt.Errorf("no permission to project %s", args.ProjectName)
+	}
+	return nil
+}
+
+// hasRoleBinding 判断用户在 projectName 下是否有角色绑定，role 为空时不限制角色，公开项目对所有用户可见
+func hasRoleBinding(projectName, userID, role string) (bool, error) {
+	roleBindings, err := policy.NewDefault().ListRoleBindings(projectName)
+	if err != nil {
+		return false, err
+	}
+	for _, roleBinding := range roleBindings {
+		if role != "" {
+			if roleBinding.UID == userID && roleBinding.Role == role {
+				return true, nil
+			}
+			continue
+		}
+		if roleBinding.UID == userID || roleBinding.UID == "*" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/registry"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// cosign generate-key-pair 生成的加密私钥
+var cosignPrivateKeyPEMTypes = []string{"ENCRYPTED COSIGN PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY"}
+
+func ListSigningKeys(log *zap.SugaredLogger) ([]*commonmodels.SigningKey, error) {
+	keys, err := commonrepo.NewSigningKeyColl().List()
+	if err != nil {
+		log.Errorf("SigningKey.List error: %s", err)
+		return nil, e.ErrListSigningKeys.AddErr(err)
+	}
+	// 私钥及其密码不返回给前端
+	for _, key := range keys {
+		key.PrivateKey = ""
+		key.Password = ""
+	}
+	return keys, nil
+}
+
+// GetSigningKey 返回包含私钥的完整密钥，仅供 warpdrive 在签名镜像前获取
+func GetSigningKey(id string, log *zap.SugaredLogger) (*commonmodels.SigningKey, error) {
+	key, err := commonrepo.NewSigningKeyColl().Find(id)
+	if err != nil {
+		log.Errorf("SigningKey.Find %s error: %s", id, err)
+		return nil, e.ErrGetSigningKey.AddErr(err)
+	}
+	return key, nil
+}
+
+func CreateSigningKey(args *commonmodels.SigningKey, log *zap.SugaredLogger) error {
+	if args.PrivateKey == "" {
+		return e.ErrCreateSigningKey.AddDesc("private key is required")
+	}
+	if err := validateSigningKey(args); err != nil {
+		return e.ErrCreateSigningKey.AddDesc(err.Error())
+	}
+
+	if err := commonrepo.NewSigningKeyColl().Create(args); err != nil {
+		log.Errorf("SigningKey.Create error: %s", err)
+		return e.ErrCreateSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateSigningKey(id string, args *commonmodels.SigningKey, log *zap.SugaredLogger) error {
+	coll := commonrepo.NewSigningKeyColl()
+	key, err := coll.Find(id)
+	if err != nil {
+		log.Errorf("SigningKey.Find %s error: %s", id, err)
+		return e.ErrUpdateSigningKey.AddErr(err)
+	}
+	// 未修改私钥时沿用原有的私钥和密码
+	if args.PrivateKey == "" {
+		args.PrivateKey = key.PrivateKey
+		args.Password = key.Password
+	}
+	if err := validateSigningKey(args); err != nil {
+		return e.ErrUpdateSigningKey.AddDesc(err.Error())
+	}
+
+	if err := coll.Update(id, args); err != nil {
+		log.Errorf("SigningKey.Update %s error: %s", id, err)
+		return e.ErrUpdateSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteSigningKey(id string, log *zap.SugaredLogger) error {
+	builds, err := commonrepo.NewBuildColl().List(&commonrepo.BuildListOption{SigningKeyID: id})
+	if err == nil && len(builds) != 0 {
+		log.Errorf("SigningKey has been used by build, signing key id:%s, product name:%s, build name:%s", id, builds[0].ProductName, builds[0].Name)
+		return e.ErrDeleteUsedSigningKey
+	}
+
+	if err := commonrepo.NewSigningKeyColl().Delete(id); err != nil {
+		log.Errorf("SigningKey.Delete %s error: %s", id, err)
+		return e.ErrDeleteSigningKey.AddErr(err)
+	}
+	return nil
+}
+
+func validateSigningKey(args *commonmodels.SigningKey) error {
+	if args.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, err := registry.ParseCosignPublicKey(args.PublicKey); err != nil {
+		return fmt.Errorf("invalid public key: %s", err)
+	}
+	for _, pemType := range cosignPrivateKeyPEMTypes {
+		if strings.Contains(args.PrivateKey, "BEGIN "+pemType) {
+			return nil
+		}
+	}
+	return errors.New("private key must be generated by cosign generate-key-pair")
+}
+
+type VerifyImageSignatureArgs struct {
+	Image        string `json:"image"`
+	SigningKeyID string `json:"signing_key_id"`
+}
+
+type ImageSignatureResult struct {
+	Image      string `json:"image"`
+	Digest     string `json:"digest"`
+	Signed     bool   `json:"signed"`
+	SigningKey string `json:"signing_key,omitempty"`
+}
+
+// VerifyImageSignature 检查镜像是否有构建中配置的签名密钥的有效签名
+func VerifyImageSignature(image, signingKeyID string, log *zap.SugaredLogger) (*ImageSignatureResult, error) {
+	key, err := commonrepo.NewSigningKeyColl().Find(signingKeyID)
+	if err != nil {
+		log.Errorf("SigningKey.Find %s error: %s", signingKeyID, err)
+		return nil, e.ErrVerifyImageSignature.AddErr(err)
+	}
+	publicKey, err := registry.ParseCosignPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, e.ErrVerifyImageSignature.AddDesc(fmt.Sprintf("invalid public key of signing key %s: %s", key.Name, err))
+	}
+
+	regs, err := commonservice.ListRegistryNamespaces(true, log)
+	if err != nil {
+		log.Errorf("ListRegistryNamespaces error: %s", err)
+		return nil, e.ErrVerifyImageSignature.AddErr(err)
+	}
+	reg, repoName, tag, err := matchImageRegistry(image, regs)
+	if err != nil {
+		return nil, e.ErrVerifyImageSignature.AddDesc(err.Error())
+	}
+
+	imageDigest, signatures, err := registry.GetCosignSignatures(registry.Endpoint{
+		Addr:   reg.RegAddr,
+		Ak:     reg.AccessKey,
+		Sk:     reg.SecretKey,
+		Region: reg.Region,
+	}, repoName, tag, log)
+	if err != nil {
+		log.Errorf("GetCosignSignatures of %s error: %s", image, err)
+		return nil, e.ErrVerifyImageSignature.AddErr(err)
+	}
+
+	result := &ImageSignatureResult{Image: image, Digest: imageDigest}
+	if len(signatures) == 0 {
+		return result, nil
+	}
+
+	for _, signature := range signatures {
+		if registry.VerifyCosignSignature(publicKey, signature, imageDigest) == nil {
+			result.Signed = true
+			result.SigningKey = key.Name
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// matchImageRegistry 找到镜像所在的镜像仓库, 返回仓库中的镜像名和 tag
+func matchImageRegistry(image string, regs []*commonmodels.RegistryNamespace) (*commonmodels.RegistryNamespace, string, string, error) {
+	var matched *commonmodels.RegistryNamespace
+	var repoName string
+	for _, reg := range regs {
+		host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(reg.RegAddr, "https://"), "http://"), "/")
+		if !strings.HasPrefix(image, host+"/") {
+			continue
+		}
+		// 同一地址的多个仓库优先匹配 namespace
+		if matched != nil && (reg.Namespace == "" || !strings.HasPrefix(image, host+"/"+reg.Namespace+"/")) {
+			continue
+		}
+		matched = reg
+		repoName = strings.TrimPrefix(image, host+"/")
+	}
+	if matched == nil {
+		return nil, "", "", fmt.Errorf("registry of image %s is not found", image)
+	}
+
+	tag := "latest"
+	if i := strings.LastIndex(repoName, ":"); i > strings.LastIndex(repoName, "/") {
+		repoName, tag = repoName[:i], repoName[i+1:]
+	}
+	return matched, repoName, tag, nil
+}
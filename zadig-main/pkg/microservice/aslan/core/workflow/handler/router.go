@@ -114,6 +114,18 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflow.PUT("/old/:old/new/:new", CopyWorkflow)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 声明式工作流文件接口
+	// ---------------------------------------------------------------------------------------
+	workflowCode := router.Group("workflowcode")
+	{
+		workflowCode.POST("/validate", ValidateWorkflowCode)
+		workflowCode.POST("/import", gin2.UpdateOperationLogStatus, ImportWorkflowCode)
+		workflowCode.PUT("/import", gin2.UpdateOperationLogStatus, UpdateWorkflowFromCode)
+		workflowCode.GET("/export/:name", ExportWorkflowCode)
+		workflowCode.GET("/drift/:name", GetWorkflowCodeDrift)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// 产品工作流任务接口
 	// ---------------------------------------------------------------------------------------
@@ -128,8 +140,13 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflowtask.GET("/filters/pipelines/:name", GetFiltersPipeline)
 		workflowtask.GET("/id/:id/pipelines/:name", GetWorkflowTask)
 		workflowtask.POST("/id/:id/pipelines/:name/restart", gin2.UpdateOperationLogStatus, RestartWorkflowTask)
+		workflowtask.POST("/id/:id/pipelines/:name/retry", gin2.UpdateOperationLogStatus, RetryWorkflowTaskFromFailedStage)
 		workflowtask.DELETE("/id/:id/pipelines/:name", gin2.UpdateOperationLogStatus, CancelWorkflowTaskV2)
 		workflowtask.GET("/callback/id/:id/name/:name", GetWorkflowTaskCallback)
+		workflowtask.GET("/approval/id/:id/name/:name", GetWorkflowTaskApproval)
+		workflowtask.POST("/approval/id/:id/name/:name", StartWorkflowTaskApproval)
+		workflowtask.PUT("/approval/id/:id/name/:name", gin2.UpdateOperationLogStatus, ApproveWorkflowTask)
+		workflowtask.DELETE("/approval/id/:id/name/:name", CancelWorkflowTaskApproval)
 	}
 
 	serviceTask := router.Group("servicetask")
@@ -25,7 +25,9 @@ import (
 	"github.com/xanzy/go-gitlab"
 	"go.uber.org/zap"
 
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/metrics"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/webhook"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 	"github.com/koderover/zadig/pkg/tool/codehub"
 )
@@ -44,15 +46,21 @@ func ProcessWebHook(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
+	var codehost string
 	if github.WebHookType(c.Request) != "" {
+		codehost = systemconfig.GitHubProvider
 		ctx.Err = processGithub(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else if gitlab.HookEventType(c.Request) != "" {
+		codehost = systemconfig.GitLabProvider
 		ctx.Err = webhook.ProcessGitlabHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else if codehub.HookEventType(c.Request) != "" {
+		codehost = systemconfig.CodeHubProvider
 		ctx.Err = webhook.ProcessCodehubHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else {
+		codehost = systemconfig.GerritProvider
 		ctx.Err = webhook.ProcessGerritHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	}
+	metrics.IncWebhookEvent(codehost, ctx.Err)
 }
 
 func processGithub(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
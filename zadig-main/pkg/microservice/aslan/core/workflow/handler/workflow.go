@@ -141,7 +141,15 @@ func ListTestWorkflows(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	ctx.Resp, ctx.Err = workflow.ListTestWorkflows(c.Param("testName"), c.QueryArray("projects"), ctx.Logger)
+	workflows, err := workflow.ListTestWorkflows(c.Param("testName"), c.QueryArray("projects"), ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	for _, w := range workflows {
+		workflow.CleanWorkflow(w)
+	}
+	ctx.Resp = workflows
 }
 
 // FindWorkflow find a workflow
@@ -149,7 +157,13 @@ func FindWorkflow(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	ctx.Resp, ctx.Err = workflow.FindWorkflow(c.Param("name"), ctx.Logger)
+	resp, err := workflow.FindWorkflow(c.Param("name"), ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	workflow.CleanWorkflow(resp)
+	ctx.Resp = resp
 }
 
 func DeleteWorkflow(c *gin.Context) {
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// ValidateWorkflowCode 校验请求体中的工作流文件，返回带行号的 schema 错误
+func ValidateWorkflowCode(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	data, err := c.GetRawData()
+	if err != nil {
+		ctx.Err = e.ErrValidateWorkflowCode.AddErr(err)
+		return
+	}
+	ctx.Resp = workflow.ValidateWorkflowCode(data)
+}
+
+func ImportWorkflowCode(c *gin.Context) {
+	importWorkflowCode(c, false)
+}
+
+func UpdateWorkflowFromCode(c *gin.Context) {
+	importWorkflowCode(c, true)
+}
+
+func importWorkflowCode(c *gin.Context, update bool) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	args := new(workflow.ImportWorkflowCodeArgs)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("ImportWorkflowCode c.GetRawData() err : %v", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("ImportWorkflowCode json.Unmarshal err : %v", err)
+	}
+	function := "导入"
+	if update {
+		function = "更新"
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, function, "工作流", "", string(data), ctx.Logger)
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	ctx.Err = workflow.ImportWorkflowCode(projectName, update, args, ctx.UserName, ctx.Logger)
+}
+
+func ExportWorkflowCode(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() {
+		if ctx.Err != nil {
+			c.JSON(e.ErrorMessage(ctx.Err))
+			c.Abort()
+			return
+		}
+	}()
+
+	content, err := workflow.ExportWorkflowCode(c.Param("name"), workflow.WorkflowCodeKind(c.Query("kind")), ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	c.Data(http.StatusOK, "text/plain", content)
+	c.Abort()
+}
+
+func GetWorkflowCodeDrift(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = workflow.GetWorkflowCodeDrift(c.Param("name"), workflow.WorkflowCodeKind(c.Query("kind")), ctx.Logger)
+}
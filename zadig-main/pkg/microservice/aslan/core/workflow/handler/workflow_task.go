@@ -179,6 +179,21 @@ func RestartWorkflowTask(c *gin.Context) {
 	ctx.Err = workflow.RestartPipelineTaskV2(ctx.UserName, taskID, c.Param("name"), config.WorkflowType, ctx.Logger)
 }
 
+// RetryWorkflowTaskFromFailedStage 创建新任务，从失败的阶段开始重新运行
+func RetryWorkflowTaskFromFailedStage(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.GetString("productName"), "重试", "工作流-task", c.Param("name"), "", ctx.Logger)
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Resp, ctx.Err = workflow.RetryWorkflowTaskFromFailedStage(ctx.UserName, taskID, c.Param("name"), ctx.Logger)
+}
+
 func CancelWorkflowTaskV2(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -204,3 +219,73 @@ func GetWorkflowTaskCallback(c *gin.Context) {
 
 	ctx.Resp, ctx.Err = commonservice.GetWorkflowTaskCallback(taskID, c.Param("name"))
 }
+
+// StartWorkflowTaskApproval is called by warpdrive when the approval stage starts, it is privileged for system admins in the gateway
+func StartWorkflowTaskApproval(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Err = workflow.StartWorkflowTaskApproval(c.Param("name"), taskID, ctx.Logger)
+}
+
+// CancelWorkflowTaskApproval is called by warpdrive when the approval stage ends without a result, it is privileged for system admins in the gateway
+func CancelWorkflowTaskApproval(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Err = workflow.CancelWorkflowTaskApproval(c.Param("name"), taskID, ctx.Logger)
+}
+
+func GetWorkflowTaskApproval(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Resp, ctx.Err = workflow.GetWorkflowTaskApproval(c.Param("name"), taskID, ctx.Logger)
+}
+
+func ApproveWorkflowTask(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(workflow.ApproveWorkflowTaskArgs)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("ApproveWorkflowTask c.GetRawData() err : %v", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("ApproveWorkflowTask json.Unmarshal err : %v", err)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.GetString("productName"), "审批", "工作流-task", c.Param("name"), string(data), ctx.Logger)
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+
+	ctx.Err = workflow.ApproveWorkflowTask(c.Param("name"), taskID, ctx.UserID, ctx.UserName, args, ctx.Logger)
+}
@@ -74,6 +74,16 @@ func ProcessCodehubHook(payload []byte, req *http.Request, requestID string, log
 		}
 	}
 
+	if mergeEvent, ok := event.(*codehub.MergeEvent); ok && (mergeEvent.ObjectAttributes.State == "merged" || mergeEvent.ObjectAttributes.State == "closed") {
+		// PR 合并或关闭后回收预览环境
+		repoOwner, repoName := splitRepoPath(mergeEvent.ObjectAttributes.Target.PathWithNamespace)
+		go func(prID int) {
+			if err := RecyclePreviewEnvs(setting.SourceFromCodeHub, repoOwner, repoName, prID, requestID, log); err != nil {
+				log.Errorf("Failed to recycle preview envs, err: %s", err)
+			}
+		}(mergeEvent.ObjectAttributes.IID)
+	}
+
 	//产品工作流webhook
 	if err = TriggerWorkflowByCodehubEvent(event, baseURI, requestID, log); err != nil {
 		errorList = multierror.Append(errorList, err)
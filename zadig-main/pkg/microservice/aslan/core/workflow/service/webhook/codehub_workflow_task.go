@@ -18,7 +18,6 @@ package webhook
 
 import (
 	"strconv"
-	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"go.uber.org/zap"
@@ -165,8 +164,7 @@ func TriggerWorkflowByCodehubEvent(event interface{}, baseURI, requestID string,
 			}
 
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
-			namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
-			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
+			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: hookEnvName(item.WorkflowArgs)}
 			var prod *commonmodels.Product
 			if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
 				log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
@@ -174,7 +172,9 @@ func TriggerWorkflowByCodehubEvent(event interface{}, baseURI, requestID string,
 			}
 
 			var mergeRequestID, commitID string
+			prID := 0
 			if ev, isPr := event.(*codehub.MergeEvent); isPr {
+				prID = ev.ObjectAttributes.IID
 				// 如果是merge request，且该webhook触发器配置了自动取消，
 				// 则需要确认该merge request在本次commit之前的commit触发的任务是否处理完，没有处理完则取消掉。
 				mergeRequestID = strconv.Itoa(ev.ObjectAttributes.IID)
@@ -202,7 +202,18 @@ func TriggerWorkflowByCodehubEvent(event interface{}, baseURI, requestID string,
 			args.RepoName = item.MainRepo.RepoName
 			args.Committer = item.MainRepo.Committer
 			// 3. create task with args
-			if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+			if args.BaseNamespace != "" {
+				if prID == 0 {
+					log.Warnf("It's not a PR event,BaseNamespace:%s", args.BaseNamespace)
+					continue
+				}
+				// 预览环境的创建和任务执行耗时较长，不阻塞 webhook 请求
+				go func(args *commonmodels.WorkflowTaskArgs, prID int) {
+					if err := CreateEnvAndTaskByPR(args, prID, baseURI, requestID, log); err != nil {
+						log.Errorf("CreateEnvAndTaskByPR err:%v", err)
+					}
+				}(args, prID)
+			} else if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
 				log.Errorf("failed to create workflow task when receive push event %v due to %v ", event, err)
 				mErr = multierror.Append(mErr, err)
 			} else {
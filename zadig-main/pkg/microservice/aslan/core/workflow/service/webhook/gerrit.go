@@ -48,6 +48,7 @@ import (
 
 const (
 	changeMergedEventType    = "change-merged"
+	changeAbandonedEventType = "change-abandoned"
 	patchsetCreatedEventType = "patchset-created"
 )
 
@@ -76,6 +77,20 @@ func ProcessGerritHook(payload []byte, req *http.Request, requestID string, log
 		}
 	}
 
+	if gerritTypeEventObj.Type == changeMergedEventType || gerritTypeEventObj.Type == changeAbandonedEventType {
+		// change 合并或放弃后回收预览环境，gerrit 没有 repo owner
+		ev := new(changeMergedEvent)
+		if err := json.Unmarshal(payload, ev); err != nil {
+			log.Errorf("processGerritHook json.Unmarshal err : %v", err)
+		} else {
+			go func() {
+				if err := RecyclePreviewEnvs(setting.SourceFromGerrit, "", ev.Change.Project, ev.Change.Number, requestID, log); err != nil {
+					log.Errorf("Failed to recycle preview envs, err: %s", err)
+				}
+			}()
+		}
+	}
+
 	return TriggerWorkflowByGerritEvent(gerritTypeEventObj, payload, req.RequestURI, baseURI, req.Header.Get("X-Forwarded-Host"), requestID, log)
 }
 
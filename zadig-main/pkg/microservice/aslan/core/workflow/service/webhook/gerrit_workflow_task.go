@@ -322,8 +322,7 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 						errorList = multierror.Append(errorList, err)
 					} else if isMatch {
 						log.Infof("TriggerWorkflowByGerritEvent event match hook %v %v of %s", event, item.MainRepo, workflow.Name)
-						namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
-						opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
+						opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: hookEnvName(item.WorkflowArgs)}
 						var prod *commonmodels.Product
 						if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
 							log.Warnf("TriggerWorkflowByGerritEvent can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
@@ -334,7 +333,9 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 						addWebHookUser(matcher, domain)
 
 						var mergeRequestID, commitID string
+						prID := 0
 						if m, ok := matcher.(*gerritPatchsetCreatedEventMatcher); ok {
+							prID = m.Event.Change.Number
 							mergeRequestID = strconv.Itoa(m.Event.Change.Number)
 							commitID = strconv.Itoa(m.Event.PatchSet.Number)
 
@@ -383,7 +384,18 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 						workflowArgs.RepoOwner = item.MainRepo.RepoOwner
 						workflowArgs.RepoName = item.MainRepo.RepoName
 						workflowArgs.Committer = item.MainRepo.Committer
-						if resp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log); err != nil {
+						if workflowArgs.BaseNamespace != "" {
+							if prID == 0 {
+								log.Warnf("It's not a PR event,BaseNamespace:%s", workflowArgs.BaseNamespace)
+								continue
+							}
+							// 预览环境的创建和任务执行耗时较长，不阻塞 webhook 请求
+							go func(args *commonmodels.WorkflowTaskArgs, prID int) {
+								if err := CreateEnvAndTaskByPR(args, prID, baseURI, requestID, log); err != nil {
+									log.Errorf("CreateEnvAndTaskByPR err:%v", err)
+								}
+							}(workflowArgs, prID)
+						} else if resp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log); err != nil {
 							log.Errorf("TriggerWorkflowByGerritEvent failed to create workflow task when receive push event %v due to %v ", event, err)
 							errorList = multierror.Append(errorList, err)
 						} else {
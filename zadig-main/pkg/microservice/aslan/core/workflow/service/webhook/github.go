@@ -434,6 +434,15 @@ func ProcessGithubWebHook(payload []byte, req *http.Request, requestID string, l
 
 	switch et := event.(type) {
 	case *github.PullRequestEvent:
+		if *et.Action == "closed" {
+			// PR 合并或关闭后回收预览环境
+			go func() {
+				if err := RecyclePreviewEnvs(setting.SourceFromGithub, et.GetRepo().GetOwner().GetLogin(), et.GetRepo().GetName(), et.GetNumber(), requestID, log); err != nil {
+					log.Errorf("Failed to recycle preview envs, err: %s", err)
+				}
+			}()
+			return nil
+		}
 		if *et.Action != "opened" && *et.Action != "synchronize" {
 			return nil
 		}
@@ -339,8 +339,7 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
 					log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
-					namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
-					opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
+					opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: hookEnvName(item.WorkflowArgs)}
 					var prod *commonmodels.Product
 					if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
 						log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
@@ -349,7 +348,9 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 
 					var mergeRequestID, commitID string
 					var hookPayload *commonmodels.HookPayload
+					prID := 0
 					if ev, isPr := event.(*github.PullRequestEvent); isPr {
+						prID = ev.GetNumber()
 						// 如果是merge request，且该webhook触发器配置了自动取消，
 						// 则需要确认该merge request在本次commit之前的commit触发的任务是否处理完，没有处理完则取消掉。
 						if ev.PullRequest != nil && ev.PullRequest.Number != nil && ev.PullRequest.Head != nil && ev.PullRequest.Head.SHA != nil {
@@ -390,7 +391,18 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 					args.HookPayload = hookPayload
 
 					// 3. create task with args
-					if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+					if args.BaseNamespace != "" {
+						if prID == 0 {
+							log.Warnf("It's not a PR event,BaseNamespace:%s", args.BaseNamespace)
+							continue
+						}
+						// 预览环境的创建和任务执行耗时较长，不阻塞 webhook 请求
+						go func(args *commonmodels.WorkflowTaskArgs, prID int) {
+							if err := CreateEnvAndTaskByPR(args, prID, baseURI, requestID, log); err != nil {
+								log.Errorf("CreateEnvAndTaskByPR err:%v", err)
+							}
+						}(args, prID)
+					} else if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
 						log.Errorf("failed to create workflow task when receive push event due to %v ", err)
 						mErr = multierror.Append(mErr, err)
 					} else {
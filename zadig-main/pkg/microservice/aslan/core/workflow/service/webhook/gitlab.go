@@ -151,6 +151,16 @@ func ProcessGitlabHook(payload []byte, req *http.Request, requestID string, log
 		}()
 	}
 
+	if mergeEvent != nil && (mergeEvent.ObjectAttributes.State == "merged" || mergeEvent.ObjectAttributes.State == "closed") {
+		// PR 合并或关闭后回收预览环境
+		repoOwner, repoName := splitRepoPath(mergeEvent.ObjectAttributes.Target.PathWithNamespace)
+		go func(prID int) {
+			if err := RecyclePreviewEnvs(setting.SourceFromGitlab, repoOwner, repoName, prID, requestID, log); err != nil {
+				log.Errorf("Failed to recycle preview envs, err: %s", err)
+			}
+		}(mergeEvent.ObjectAttributes.IID)
+	}
+
 	if mergeEvent != nil {
 		//多服务工作流webhook
 		wg.Add(1)
@@ -22,7 +22,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -33,7 +32,6 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
-	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
 	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
@@ -43,7 +41,6 @@ import (
 	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
-	"github.com/koderover/zadig/pkg/util"
 )
 
 type gitlabMergeRequestDiffFunc func(event *gitlab.MergeEvent, id int) ([]string, error)
@@ -591,8 +588,7 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 				continue
 			}
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
-			namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
-			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
+			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: hookEnvName(item.WorkflowArgs)}
 			var prod *commonmodels.Product
 			if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
 				log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
@@ -658,9 +654,12 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 					log.Infof("succeed to create task %v", resp)
 				}
 			} else if item.WorkflowArgs.BaseNamespace != "" && isMergeRequest {
-				if err = CreateEnvAndTaskByPR(args, prID, requestID, log); err != nil {
-					log.Infof("CreateRandomEnv err:%v", err)
-				}
+				// 预览环境的创建和任务执行耗时较长，不阻塞 webhook 请求
+				go func(args *commonmodels.WorkflowTaskArgs, prID int) {
+					if err := CreateEnvAndTaskByPR(args, prID, baseURI, requestID, log); err != nil {
+						log.Errorf("CreateEnvAndTaskByPR err:%v", err)
+					}
+				}(args, prID)
 			} else {
 				log.Warnf("It's not a PR event,BaseNamespace:%s", item.WorkflowArgs.BaseNamespace)
 			}
@@ -685,81 +684,6 @@ func findChangedFilesOfMergeRequest(event *gitlab.MergeEvent, codehostID int) ([
 	return client.ListChangedFiles(event)
 }
 
-var mutex sync.Mutex
-
-// CreateEnvAndTaskByPR 根据pr触发创建环境、使用工作流更新该创建的环境、根据环境删除策略删除环境
-func CreateEnvAndTaskByPR(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, requestID string, log *zap.SugaredLogger) error {
-	//获取基准环境的详细信息
-	opt := &commonrepo.ProductFindOptions{Name: workflowArgs.ProductTmplName, EnvName: workflowArgs.BaseNamespace}
-	baseProduct, err := commonrepo.NewProductColl().Find(opt)
-	if err != nil {
-		return fmt.Errorf("CreateEnvAndTaskByPR Product Find err:%v", err)
-	}
-
-	mutex.Lock()
-	defer func() {
-		mutex.Unlock()
-	}()
-	if baseProduct.Render != nil {
-		if renderSet, _ := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: baseProduct.Render.Name, Revision: baseProduct.Render.Revision}); renderSet != nil {
-			baseProduct.Vars = renderSet.KVs
-		}
-	}
-
-	envName := fmt.Sprintf("%s-%d-%s%s", "pr", prID, util.GetRandomNumString(3), util.GetRandomString(3))
-	util.Clear(&baseProduct.ID)
-	baseProduct.Namespace = commonservice.GetProductEnvNamespace(envName, workflowArgs.ProductTmplName, "")
-	baseProduct.UpdateBy = setting.SystemUser
-	baseProduct.EnvName = envName
-	err = environmentservice.CreateProduct(setting.SystemUser, requestID, baseProduct, log)
-	if err != nil {
-		return fmt.Errorf("CreateEnvAndTaskByPR CreateProduct err:%v", err)
-	}
-
-	timeoutSeconds := config.ServiceStartTimeout()
-	//等待环境创建
-	if err = WaitEnvCreate(timeoutSeconds, envName, workflowArgs, log); err != nil {
-		return err
-	}
-
-	workflowArgs.Namespace = envName
-	taskResp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log)
-	if err != nil {
-		return fmt.Errorf("CreateEnvAndTaskByPR CreateWorkflowTask err：%v ", err)
-	}
-
-	taskStatus := ""
-	for {
-		taskInfo, err := commonrepo.NewTaskColl().Find(taskResp.TaskID, taskResp.PipelineName, config.WorkflowType)
-		if err != nil {
-			log.Errorf("CreateEnvAndTaskByPR PipelineTask find err:%v ", err)
-			time.Sleep(time.Second)
-			continue
-		}
-
-		if taskInfo.Status == config.StatusFailed || taskInfo.Status == config.StatusPassed || taskInfo.Status == config.StatusTimeout || taskInfo.Status == config.StatusCancelled {
-			taskStatus = string(taskInfo.Status)
-			break
-		} else {
-			time.Sleep(time.Second)
-		}
-	}
-	//按照用户设置的环境回收策略进行环境回收
-	if workflowArgs.EnvRecyclePolicy == setting.EnvRecyclePolicyAlways || (workflowArgs.EnvRecyclePolicy == setting.EnvRecyclePolicyTaskStatus && taskStatus == string(config.StatusPassed)) {
-		err = commonservice.DeleteProduct(setting.SystemUser, envName, workflowArgs.ProductTmplName, requestID, log)
-		if err != nil {
-			log.Errorf("CreateEnvAndTaskByPR DeleteProduct err:%v ", err)
-			return err
-		}
-		//等待环境删除
-		if err = WaitEnvDelete(timeoutSeconds, envName, workflowArgs, log); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func WaitEnvCreate(timeoutSeconds int, envName string, workflowArgs *commonmodels.WorkflowTaskArgs, log *zap.SugaredLogger) error {
 	timeout := false
 	go func() {
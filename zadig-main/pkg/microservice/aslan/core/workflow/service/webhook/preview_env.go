@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+var mutex sync.Mutex
+
+// CreateEnvAndTaskByPR 在 PR 对应的预览环境中运行工作流：首次触发时基于基准环境创建预览环境，
+// 后续提交复用该环境并只更新变更的服务，PR 合并或关闭时由 RecyclePreviewEnvs 回收环境
+func CreateEnvAndTaskByPR(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, baseURI, requestID string, log *zap.SugaredLogger) error {
+	previewEnv, err := ensurePreviewEnv(workflowArgs, prID, baseURI, requestID, log)
+	if err != nil {
+		return err
+	}
+	workflowArgs.NotificationID = previewEnv.NotificationID
+
+	timeoutSeconds := config.ServiceStartTimeout()
+	//等待环境创建
+	if err = WaitEnvCreate(timeoutSeconds, previewEnv.EnvName, workflowArgs, log); err != nil {
+		return err
+	}
+
+	workflowArgs.Namespace = previewEnv.EnvName
+	taskResp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log)
+	if err != nil {
+		return fmt.Errorf("CreateEnvAndTaskByPR CreateWorkflowTask err：%v ", err)
+	}
+	previewEnv.LastTaskID = taskResp.TaskID
+	previewEnv.CommitID = workflowArgs.CommitID
+	if err = commonrepo.NewPreviewEnvColl().Update(previewEnv); err != nil {
+		log.Errorf("Failed to update preview env %s, err: %s", previewEnv.EnvName, err)
+	}
+
+	taskStatus := waitTaskFinished(taskResp.TaskID, taskResp.PipelineName, log)
+	notifyPreviewURLs(previewEnv, workflowArgs, log)
+
+	//按照用户设置的环境回收策略进行环境回收
+	if workflowArgs.EnvRecyclePolicy == setting.EnvRecyclePolicyAlways || (workflowArgs.EnvRecyclePolicy == setting.EnvRecyclePolicyTaskStatus && taskStatus == config.StatusPassed) {
+		return recyclePreviewEnv(previewEnv, workflowArgs, requestID, log)
+	}
+
+	return nil
+}
+
+// RecyclePreviewEnvs 删除 PR 对应的所有预览环境及其 namespace，repoOwner 为空时不做匹配（gerrit）
+func RecyclePreviewEnvs(source, repoOwner, repoName string, prID int, requestID string, log *zap.SugaredLogger) error {
+	previewEnvs, err := commonrepo.NewPreviewEnvColl().List(&commonrepo.PreviewEnvListOption{
+		Source:    source,
+		RepoOwner: repoOwner,
+		RepoName:  repoName,
+		PrID:      prID,
+	})
+	if err != nil {
+		log.Errorf("Failed to list preview envs of %s/%s#%d, err: %s", repoOwner, repoName, prID, err)
+		return err
+	}
+
+	for _, previewEnv := range previewEnvs {
+		log.Infof("PR %s/%s#%d is closed, recycle preview env %s/%s", repoOwner, repoName, prID, previewEnv.ProductName, previewEnv.EnvName)
+		workflowArgs := &commonmodels.WorkflowTaskArgs{
+			ProductTmplName: previewEnv.ProductName,
+			NotificationID:  previewEnv.NotificationID,
+		}
+		if err := recyclePreviewEnv(previewEnv, workflowArgs, requestID, log); err != nil {
+			log.Errorf("Failed to recycle preview env %s/%s, err: %s", previewEnv.ProductName, previewEnv.EnvName, err)
+		}
+	}
+	return nil
+}
+
+// hookEnvName 返回触发器用于匹配部署目标的环境，配置了基准环境时使用基准环境
+func hookEnvName(args *commonmodels.WorkflowTaskArgs) string {
+	if args.BaseNamespace != "" {
+		return args.BaseNamespace
+	}
+	return strings.Split(args.Namespace, ",")[0]
+}
+
+// splitRepoPath 将 group/subgroup/repo 形式的路径拆分为 repo owner 和 repo name
+func splitRepoPath(path string) (string, string) {
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
+
+func ensurePreviewEnv(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, baseURI, requestID string, log *zap.SugaredLogger) (*commonmodels.PreviewEnv, error) {
+	mutex.Lock()
+	defer func() {
+		mutex.Unlock()
+	}()
+
+	previewEnvColl := commonrepo.NewPreviewEnvColl()
+	previewEnv, err := previewEnvColl.Find(&commonrepo.PreviewEnvFindOption{
+		ProductName:  workflowArgs.ProductTmplName,
+		WorkflowName: workflowArgs.WorkflowName,
+		Source:       workflowArgs.Source,
+		RepoOwner:    workflowArgs.RepoOwner,
+		RepoName:     workflowArgs.RepoName,
+		PrID:         prID,
+	})
+	if err == nil {
+		opt := &commonrepo.ProductFindOptions{Name: previewEnv.ProductName, EnvName: previewEnv.EnvName}
+		if _, err := commonrepo.NewProductColl().Find(opt); err == nil {
+			return previewEnv, nil
+		}
+		// 预览环境已被手动删除，重新创建
+		if err := previewEnvColl.Delete(previewEnv.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete preview env record %s: %v", previewEnv.EnvName, err)
+		}
+	} else if !commonrepo.IsErrNoDocuments(err) {
+		return nil, fmt.Errorf("failed to find preview env of PR %d: %v", prID, err)
+	}
+
+	envName, err := createPreviewProduct(workflowArgs, prID, requestID, log)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationID := workflowArgs.NotificationID
+	if notificationID == "" {
+		mainRepo := &commonmodels.MainHookRepo{
+			CodehostID: workflowArgs.CodehostID,
+			RepoOwner:  workflowArgs.RepoOwner,
+			RepoName:   workflowArgs.RepoName,
+		}
+		if notification, err := scmnotify.NewService().SendInitWebhookComment(mainRepo, prID, baseURI, false, false, log); err == nil {
+			notificationID = notification.ID.Hex()
+		}
+	}
+
+	previewEnv = &commonmodels.PreviewEnv{
+		ProductName:    workflowArgs.ProductTmplName,
+		EnvName:        envName,
+		BaseEnvName:    workflowArgs.BaseNamespace,
+		Source:         workflowArgs.Source,
+		CodehostID:     workflowArgs.CodehostID,
+		RepoOwner:      workflowArgs.RepoOwner,
+		RepoName:       workflowArgs.RepoName,
+		PrID:           prID,
+		WorkflowName:   workflowArgs.WorkflowName,
+		NotificationID: notificationID,
+	}
+	if err = previewEnvColl.Create(previewEnv); err != nil {
+		return nil, fmt.Errorf("failed to save preview env %s: %v", envName, err)
+	}
+	return previewEnv, nil
+}
+
+// createPreviewProduct 基于基准环境创建新环境，返回新环境名称
+func createPreviewProduct(workflowArgs *commonmodels.WorkflowTaskArgs, prID int, requestID string, log *zap.SugaredLogger) (string, error) {
+	//获取基准环境的详细信息
+	opt := &commonrepo.ProductFindOptions{Name: workflowArgs.ProductTmplName, EnvName: workflowArgs.BaseNamespace}
+	baseProduct, err := commonrepo.NewProductColl().Find(opt)
+	if err != nil {
+		return "", fmt.Errorf("CreateEnvAndTaskByPR Product Find err:%v", err)
+	}
+
+	if baseProduct.Render != nil {
+		if renderSet, _ := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: baseProduct.Render.Name, Revision: baseProduct.Render.Revision}); renderSet != nil {
+			baseProduct.Vars = renderSet.KVs
+		}
+	}
+
+	envName := fmt.Sprintf("%s-%d-%s%s", "pr", prID, util.GetRandomNumString(3), util.GetRandomString(3))
+	util.Clear(&baseProduct.ID)
+	baseProduct.Namespace = commonservice.GetProductEnvNamespace(envName, workflowArgs.ProductTmplName, "")
+	baseProduct.UpdateBy = setting.SystemUser
+	baseProduct.EnvName = envName
+	baseProduct.BaseName = workflowArgs.BaseNamespace
+	// 预览环境由 PR 关闭事件回收，不参与按天回收
+	baseProduct.RecycleDay = 0
+	if err = environmentservice.CreateProduct(setting.SystemUser, requestID, baseProduct, log); err != nil {
+		return "", fmt.Errorf("CreateEnvAndTaskByPR CreateProduct err:%v", err)
+	}
+	return envName, nil
+}
+
+func waitTaskFinished(taskID int64, pipelineName string, log *zap.SugaredLogger) config.Status {
+	for {
+		taskInfo, err := commonrepo.NewTaskColl().Find(taskID, pipelineName, config.WorkflowType)
+		if err != nil {
+			log.Errorf("CreateEnvAndTaskByPR PipelineTask find err:%v ", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if taskInfo.Status == config.StatusFailed || taskInfo.Status == config.StatusPassed || taskInfo.Status == config.StatusTimeout || taskInfo.Status == config.StatusCancelled {
+			return taskInfo.Status
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// notifyPreviewURLs 将预览环境的 ingress 地址回写到 PR 评论
+func notifyPreviewURLs(previewEnv *commonmodels.PreviewEnv, workflowArgs *commonmodels.WorkflowTaskArgs, log *zap.SugaredLogger) {
+	urls, err := environmentservice.GetEnvIngressURLs(previewEnv.ProductName, previewEnv.EnvName, log)
+	if err != nil {
+		log.Errorf("Failed to get ingress of preview env %s, err: %s", previewEnv.EnvName, err)
+		return
+	}
+	previewEnv.URLs = urls
+	if err = commonrepo.NewPreviewEnvColl().Update(previewEnv); err != nil {
+		log.Errorf("Failed to update preview env %s, err: %s", previewEnv.EnvName, err)
+	}
+
+	prTaskInfo := &commonmodels.PrTaskInfo{
+		ProductName: previewEnv.ProductName,
+		EnvName:     previewEnv.EnvName,
+		EnvStatus:   setting.PodRunning,
+		PreviewURLs: urls,
+	}
+	if product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: previewEnv.ProductName, EnvName: previewEnv.EnvName}); err == nil {
+		prTaskInfo.EnvStatus = product.Status
+	}
+	if err = scmnotify.NewService().UpdateEnvAndTaskWebhookComment(workflowArgs, prTaskInfo, log); err != nil {
+		log.Errorf("Failed to comment preview urls of env %s, err: %s", previewEnv.EnvName, err)
+	}
+}
+
+func recyclePreviewEnv(previewEnv *commonmodels.PreviewEnv, workflowArgs *commonmodels.WorkflowTaskArgs, requestID string, log *zap.SugaredLogger) error {
+	opt := &commonrepo.ProductFindOptions{Name: previewEnv.ProductName, EnvName: previewEnv.EnvName}
+	if _, err := commonrepo.NewProductColl().Find(opt); err == nil {
+		if err = commonservice.DeleteProduct(setting.SystemUser, previewEnv.EnvName, previewEnv.ProductName, requestID, log); err != nil {
+			log.Errorf("CreateEnvAndTaskByPR DeleteProduct err:%v ", err)
+			return err
+		}
+	}
+	if err := commonrepo.NewPreviewEnvColl().Delete(previewEnv.ID); err != nil {
+		log.Errorf("Failed to delete preview env record %s, err: %s", previewEnv.EnvName, err)
+	}
+
+	//等待环境删除
+	return WaitEnvDelete(config.ServiceStartTimeout(), previewEnv.EnvName, workflowArgs, log)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var _ = Describe("Testing preview env", func() {
+
+	Context("test splitRepoPath", func() {
+		It("should split nested group path", func() {
+			owner, repo := splitRepoPath("group/sub/repo")
+			Expect(owner).To(Equal("group/sub"))
+			Expect(repo).To(Equal("repo"))
+		})
+
+		It("should work without owner", func() {
+			owner, repo := splitRepoPath("repo")
+			Expect(owner).To(BeEmpty())
+			Expect(repo).To(Equal("repo"))
+		})
+	})
+
+	Context("test hookEnvName", func() {
+		It("should prefer base env", func() {
+			Expect(hookEnvName(&commonmodels.WorkflowTaskArgs{Namespace: "dev,qa", BaseNamespace: "base"})).To(Equal("base"))
+		})
+
+		It("should use the first env without base env", func() {
+			Expect(hookEnvName(&commonmodels.WorkflowTaskArgs{Namespace: "dev,qa"})).To(Equal("dev"))
+		})
+	})
+})
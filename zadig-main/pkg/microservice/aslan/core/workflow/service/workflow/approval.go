@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notification"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// defaultApprovalTimeout 未设置超时时间时审批的默认超时时间，单位分钟
+const defaultApprovalTimeout = 24 * 60
+
+type ApproveWorkflowTaskArgs struct {
+	Approve bool   `json:"approve"`
+	Comment string `json:"comment"`
+}
+
+func addApprovalToSubTasks(stage *commonmodels.ApprovalStage) (map[string]interface{}, error) {
+	if len(stage.Approvers) == 0 {
+		return nil, fmt.Errorf("approvers of the approval stage are not set")
+	}
+	timeout := stage.Timeout
+	if timeout <= 0 {
+		timeout = defaultApprovalTimeout
+	}
+
+	approvalTask := task.Approval{
+		TaskType:        config.TaskApproval,
+		Enabled:         true,
+		Description:     stage.Description,
+		Approvers:       stage.Approvers,
+		NeededApprovers: stage.NeededApprovers,
+		Timeout:         timeout,
+	}
+	return approvalTask.ToSubTask()
+}
+
+// StartWorkflowTaskApproval is called by warpdrive when the approval stage of the task begins,
+// it records a waiting approval and notifies the approvers
+func StartWorkflowTaskApproval(pipelineName string, taskID int64, log *zap.SugaredLogger) error {
+	pt, err := commonrepo.NewTaskColl().Find(taskID, pipelineName, config.WorkflowType)
+	if err != nil {
+		log.Errorf("find workflow task %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrStartWorkflowApproval.AddErr(err)
+	}
+
+	var approvalTask *task.Approval
+	for _, stage := range pt.Stages {
+		if stage.TaskType != config.TaskApproval {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			approvalTask, err = base.ToApprovalTask(subTask)
+			if err != nil {
+				log.Errorf("convert approval task of %s:%d error: %s", pipelineName, taskID, err)
+				return e.ErrStartWorkflowApproval.AddErr(err)
+			}
+		}
+	}
+	if approvalTask == nil {
+		return e.ErrStartWorkflowApproval.AddDesc("approval stage not found")
+	}
+
+	approval := &commonmodels.WorkflowApproval{
+		PipelineName:    pipelineName,
+		ProjectName:     pt.ProductName,
+		TaskID:          taskID,
+		TaskCreator:     pt.TaskCreator,
+		Description:     approvalTask.Description,
+		Approvers:       approvalTask.Approvers,
+		NeededApprovers: approvalTask.NeededApprovers,
+		Status:          config.StatusWaiting,
+		Timeout:         approvalTask.Timeout,
+	}
+	created, err := commonrepo.NewWorkflowApprovalColl().Create(approval)
+	if err != nil {
+		log.Errorf("create approval of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrStartWorkflowApproval.AddErr(err)
+	}
+	// 审批已经开始，重复调用不会重置审批结果，也不会重复通知
+	if !created {
+		return nil
+	}
+
+	go func() {
+		if err := instantmessage.NewWeChatClient().SendApprovalMessage(approval); err != nil {
+			log.Errorf("send approval message of %s:%d error: %s", pipelineName, taskID, err)
+		}
+	}()
+	notification.Dispatch(notification.NewApprovalRequiredEvent(approval))
+	return nil
+}
+
+func GetWorkflowTaskApproval(pipelineName string, taskID int64, log *zap.SugaredLogger) (*commonmodels.WorkflowApproval, error) {
+	approval, err := commonrepo.NewWorkflowApprovalColl().Find(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("find approval of %s:%d error: %s", pipelineName, taskID, err)
+		return nil, e.ErrGetWorkflowApproval.AddErr(err)
+	}
+
+	if err := ensureApprovalTimeout(approval); err != nil {
+		log.Errorf("update approval status of %s:%d error: %s", pipelineName, taskID, err)
+		return nil, e.ErrGetWorkflowApproval.AddErr(err)
+	}
+	return approval, nil
+}
+
+// ApproveWorkflowTask records the decision of the user, any rejection fails the approval and
+// it passes once enough approvers accept it
+func ApproveWorkflowTask(pipelineName string, taskID int64, userID, userName string, args *ApproveWorkflowTaskArgs, log *zap.SugaredLogger) error {
+	approvalColl := commonrepo.NewWorkflowApprovalColl()
+	approval, err := approvalColl.Find(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("find approval of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	if err := ensureApprovalTimeout(approval); err != nil {
+		log.Errorf("update approval status of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	if approval.Status != config.StatusWaiting {
+		return e.ErrApproveWorkflowTask.AddDesc(fmt.Sprintf("审批已结束，当前状态: %s", approval.Status))
+	}
+
+	ok, err := isWorkflowApprover(approval, userID, userName)
+	if err != nil {
+		log.Errorf("check approver of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	if !ok {
+		return e.ErrNotWorkflowApprover
+	}
+
+	decision := &commonmodels.ApprovalDecision{
+		UserID:   userID,
+		UserName: userName,
+		Approved: args.Approve,
+		Comment:  args.Comment,
+		Time:     time.Now().Unix(),
+	}
+	changed, err := approvalColl.AddDecision(pipelineName, taskID, decision)
+	if err != nil {
+		log.Errorf("add decision to approval of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	if !changed {
+		return e.ErrApproveWorkflowTask.AddDesc("已审批或审批已结束")
+	}
+
+	// 重新查询，避免并发审批时漏算其他人的审批结果
+	approval, err = approvalColl.Find(pipelineName, taskID)
+	if err != nil {
+		log.Errorf("find approval of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	status := approvalStatus(approval)
+	if status == config.StatusWaiting {
+		return nil
+	}
+	if err := approvalColl.UpdateStatus(pipelineName, taskID, status); err != nil {
+		log.Errorf("update approval status of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrApproveWorkflowTask.AddErr(err)
+	}
+	return nil
+}
+
+// CancelWorkflowTaskApproval is called by warpdrive when the task is cancelled or the approval stage times out,
+// the waiting approval is closed so that it can not be approved any more
+func CancelWorkflowTaskApproval(pipelineName string, taskID int64, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewWorkflowApprovalColl().UpdateStatus(pipelineName, taskID, config.StatusCancelled); err != nil {
+		log.Errorf("cancel approval of %s:%d error: %s", pipelineName, taskID, err)
+		return e.ErrCancelWorkflowApproval.AddErr(err)
+	}
+	return nil
+}
+
+func approvalStatus(approval *commonmodels.WorkflowApproval) config.Status {
+	needed := approval.NeededApprovers
+	if needed <= 0 {
+		needed = 1
+	}
+
+	approved := 0
+	for _, decision := range approval.Decisions {
+		if !decision.Approved {
+			return config.StatusFailed
+		}
+		approved++
+	}
+	if approved >= needed {
+		return config.StatusPassed
+	}
+	return config.StatusWaiting
+}
+
+func ensureApprovalTimeout(approval *commonmodels.WorkflowApproval) error {
+	if approval.Status != config.StatusWaiting || approval.Timeout <= 0 {
+		return nil
+	}
+	if time.Now().Unix() < approval.CreateTime+int64(approval.Timeout*60) {
+		return nil
+	}
+
+	approval.Status = config.StatusTimeout
+	return commonrepo.NewWorkflowApprovalColl().UpdateStatus(approval.PipelineName, approval.TaskID, config.StatusTimeout)
+}
+
+func isWorkflowApprover(approval *commonmodels.WorkflowApproval, userID, userName string) (bool, error) {
+	roles := make(map[string]bool)
+	for _, approver := range approval.Approvers {
+		switch approver.Type {
+		case commonmodels.ApproverTypeRole:
+			roles[approver.Name] = true
+		default:
+			// 用户名可能重复，设置了 UID 时只按 UID 匹配
+			if approver.UID != "" {
+				if approver.UID == userID {
+					return true, nil
+				}
+				continue
+			}
+			if approver.Name == userName {
+				return true, nil
+			}
+		}
+	}
+	if len(roles) == 0 {
+		return false, nil
+	}
+
+	roleBindings, err := policy.NewDefault().ListRoleBindings(approval.ProjectName)
+	if err != nil {
+		return false, err
+	}
+	for _, roleBinding := range roleBindings {
+		if roleBinding.UID == userID && roles[roleBinding.Role] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var _ = Describe("Testing approval", func() {
+
+	Context("approvalStatus", func() {
+		It("should be waiting without decisions", func() {
+			approval := &commonmodels.WorkflowApproval{}
+			Expect(approvalStatus(approval)).To(Equal(config.StatusWaiting))
+		})
+		It("should be passed once one approver accepts by default", func() {
+			approval := &commonmodels.WorkflowApproval{
+				Decisions: []*commonmodels.ApprovalDecision{{UserName: "a", Approved: true}},
+			}
+			Expect(approvalStatus(approval)).To(Equal(config.StatusPassed))
+		})
+		It("should wait for the needed approvers", func() {
+			approval := &commonmodels.WorkflowApproval{
+				NeededApprovers: 2,
+				Decisions:       []*commonmodels.ApprovalDecision{{UserName: "a", Approved: true}},
+			}
+			Expect(approvalStatus(approval)).To(Equal(config.StatusWaiting))
+
+			approval.Decisions = append(approval.Decisions, &commonmodels.ApprovalDecision{UserName: "b", Approved: true})
+			Expect(approvalStatus(approval)).To(Equal(config.StatusPassed))
+		})
+		It("should be failed if anyone rejects", func() {
+			approval := &commonmodels.WorkflowApproval{
+				NeededApprovers: 2,
+				Decisions: []*commonmodels.ApprovalDecision{
+					{UserName: "a", Approved: true},
+					{UserName: "b", Approved: false},
+				},
+			}
+			Expect(approvalStatus(approval)).To(Equal(config.StatusFailed))
+		})
+	})
+
+	Context("isWorkflowApprover", func() {
+		approval := &commonmodels.WorkflowApproval{
+			Approvers: []*commonmodels.Approver{
+				{Type: commonmodels.ApproverTypeUser, Name: "alice", UID: "uid-alice"},
+				{Type: commonmodels.ApproverTypeUser, Name: "bob"},
+			},
+		}
+
+		It("should match the approver by uid", func() {
+			ok, err := isWorkflowApprover(approval, "uid-alice", "alice-renamed")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+		It("should not match a reused name when the uid is set", func() {
+			ok, err := isWorkflowApprover(approval, "uid-other", "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+		It("should match the approver by name without uid", func() {
+			ok, err := isWorkflowApprover(approval, "uid-bob", "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
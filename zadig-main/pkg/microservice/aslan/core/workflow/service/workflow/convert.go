@@ -70,10 +70,13 @@ func ConvertQueueToTask(queueTask *commonmodels.Queue) *task.Task {
 		RwLock:                  queueTask.RwLock,
 		ResetImage:              queueTask.ResetImage,
 		ResetImagePolicy:        queueTask.ResetImagePolicy,
+		AutoRollback:            queueTask.AutoRollback,
+		VerifyImageSignature:    queueTask.VerifyImageSignature,
 		TriggerBy:               queueTask.TriggerBy,
 		Features:                queueTask.Features,
 		IsRestart:               queueTask.IsRestart,
 		StorageEndpoint:         queueTask.StorageEndpoint,
+		TraceContext:            queueTask.TraceContext,
 	}
 }
 
@@ -117,10 +120,13 @@ func ConvertTaskToQueue(task *task.Task) *commonmodels.Queue {
 		RwLock:                  task.RwLock,
 		ResetImage:              task.ResetImage,
 		ResetImagePolicy:        task.ResetImagePolicy,
+		AutoRollback:            task.AutoRollback,
+		VerifyImageSignature:    task.VerifyImageSignature,
 		TriggerBy:               task.TriggerBy,
 		Features:                task.Features,
 		IsRestart:               task.IsRestart,
 		StorageEndpoint:         task.StorageEndpoint,
+		TraceContext:            task.TraceContext,
 	}
 }
 
@@ -41,6 +41,7 @@ import (
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
 	git "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/metrics"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/notify"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/registry"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
@@ -112,6 +113,7 @@ func (h *TaskAckHandler) handle(message *nsq.Message) error {
 	var pt *task.Task
 	if err := json.Unmarshal(message.Body, &pt); err != nil {
 		h.log.Errorf("unmarshal PipelineTaskV2 message error: %v", err)
+		metrics.IncNSQConsumeError(setting.TopicAck)
 		return nil
 	}
 
@@ -155,6 +157,7 @@ func (h *TaskAckHandler) handle(message *nsq.Message) error {
 	// 更新数据库未完成任务状态
 	if err := h.ptColl.UpdateUnfinishedTask(pt); err != nil {
 		h.log.Errorf("%s:%d UpdateUnfinishedTask error: %v", pt.PipelineName, pt.TaskID, err)
+		metrics.IncNSQConsumeError(setting.TopicAck)
 		return nil
 	}
 
@@ -162,6 +165,7 @@ func (h *TaskAckHandler) handle(message *nsq.Message) error {
 	if pt.Status == config.StatusPassed || pt.Status == config.StatusFailed || pt.Status == config.StatusTimeout {
 		h.log.Infof("%s:%d:%v task done", pt.PipelineName, pt.TaskID, pt.Status)
 		h.queue.Remove(pt)
+		metrics.ObserveTaskDuration(pt.Type, pt.Status, pt.StartTime, pt.EndTime)
 		go func() {
 			if err = h.uploadTaskData(pt); err != nil {
 				h.log.Errorf("uploadTaskData err: %v", err)
@@ -206,6 +210,19 @@ func (h *TaskAckHandler) handle(message *nsq.Message) error {
 		}
 	}
 
+	// 任务成功时记录服务的发布版本，部署或测试阶段失败时按配置回滚到最近一次成功发布的版本
+	switch pt.Status {
+	case config.StatusPassed:
+		if !pt.ResetImage {
+			go h.recordServiceReleases(pt, deploys)
+		}
+	case config.StatusFailed, config.StatusTimeout:
+		// warpdrive 回传的任务中不包含 auto_rollback，以数据库中的任务为准
+		if taskInColl.AutoRollback {
+			go h.rollbackServiceReleases(pt, deploys)
+		}
+	}
+
 	// 更新历史pipeline状态（默认留下前一百个）
 	if err = h.ptColl.ArchiveHistoryPipelineTask(pt.PipelineName, pt.Type, 100); err != nil {
 		h.log.Errorf("ArchiveHistoryPipelineTask error: %v", err)
@@ -339,8 +356,31 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 								for _, build := range buildInfo.JobCtx.Builds {
 									deliveryArtifact.DockerFile = h.getDockerfileContent(build, buildInfo.JobCtx.DockerBuildCtx)
 								}
+								if signingKey := buildInfo.JobCtx.DockerBuildCtx.SigningKey; signingKey != nil {
+									deliveryArtifact.Signed = true
+									deliveryArtifact.SigningKey = signingKey.Name
+								}
 							}
 							deliveryArtifactArray = append(deliveryArtifactArray, deliveryArtifact)
+
+							if buildInfo.JobCtx.DockerBuildCtx != nil && buildInfo.JobCtx.DockerBuildCtx.SBOMFormat != "" { // sbom
+								sbomArtifact := new(commonmodels.DeliveryArtifact)
+								sbomArtifact.CreatedBy = pt.TaskCreator
+								sbomArtifact.CreatedTime = time.Now().Unix()
+								sbomArtifact.Source = string(config.WorkflowType)
+								sbomArtifact.Name = imageName
+								sbomArtifact.Image = image
+								sbomArtifact.ImageTag = imageTag
+								sbomArtifact.ImageDigest = deliveryArtifact.ImageDigest
+								sbomArtifact.Type = string(config.SBOM)
+								sbomArtifact.SBOMFormat = buildInfo.JobCtx.DockerBuildCtx.SBOMFormat
+								if storageInfo, err := s3.NewS3StorageFromEncryptedURI(pt.StorageURI); err == nil {
+									sbomArtifact.PackageStorageURI = storageInfo.Endpoint + "/" + storageInfo.Bucket
+									sbomArtifact.PackageFileLocation = storageInfo.GetObjectPath(fmt.Sprintf("%s/%d/sbom/%s", pt.PipelineName, pt.TaskID, buildInfo.JobCtx.DockerBuildCtx.SBOMFile))
+								}
+
+								deliveryArtifactArray = append(deliveryArtifactArray, sbomArtifact)
+							}
 						}
 						for _, deliveryArtifact := range deliveryArtifactArray {
 							tempDeliveryArtifacts, _, _ := h.deliveryArtifactColl.List(&commonrepo.DeliveryArtifactArgs{Name: deliveryArtifact.Name, Type: deliveryArtifact.Type, ImageTag: deliveryArtifact.ImageTag})
@@ -501,6 +541,10 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 								testTaskStat.TestCaseNum = totalCaseNum
 							}
 							testTaskStat.TotalDuration += testInfo.EndTime - testInfo.StartTime
+							if taskStatus == config.StatusPassed {
+								h.saveTestCoverage(pt, testInfo, testReport.Coverage)
+							}
+							h.saveTestCaseResults(pt, testInfo, testReport)
 						}
 
 						if taskStatus == config.StatusPassed {
@@ -673,6 +717,10 @@ func (h *TaskAckHandler) uploadTaskData(pt *task.Task) error {
 							testTaskStat.TestCaseNum = totalCaseNum
 						}
 						testTaskStat.TotalDuration += testInfo.EndTime - testInfo.StartTime
+						if taskStatus == config.StatusPassed {
+							h.saveTestCoverage(pt, testInfo, testReport.Coverage)
+						}
+						h.saveTestCaseResults(pt, testInfo, testReport)
 
 						if taskStatus == config.StatusPassed {
 							testTaskStat.TotalSuccess++
@@ -845,6 +893,142 @@ func (h *TaskAckHandler) updateProductImageByNs(namespace, productName, serviceN
 	return nil
 }
 
+func (h *TaskAckHandler) recordServiceReleases(pt *task.Task, deploys []*task.Deploy) {
+	for _, deploy := range uniqueDeployedServices(deploys, false) {
+		prod, err := h.productColl.FindEnv(&commonrepo.ProductEnvFindOptions{Name: deploy.ProductName, Namespace: deploy.Namespace})
+		if err != nil {
+			h.log.Errorf("find env of namespace %s error: %v", deploy.Namespace, err)
+			continue
+		}
+		args := &commonservice.ServiceReleaseArgs{
+			ProductName:  prod.ProductName,
+			EnvName:      prod.EnvName,
+			ServiceName:  deploy.ServiceName,
+			PipelineName: pt.PipelineName,
+			TaskID:       pt.TaskID,
+			CreateBy:     pt.TaskCreator,
+		}
+		if err := commonservice.RecordServiceRelease(args, h.log); err != nil {
+			h.log.Errorf("record release of service %s/%s error: %v", prod.EnvName, deploy.ServiceName, err)
+		}
+	}
+}
+
+// saveTestCoverage 记录非 PR 触发的测试在各分支上最近一次的覆盖率，用于计算 PR 的覆盖率变化
+func (h *TaskAckHandler) saveTestCoverage(pt *task.Task, testInfo *task.Testing, coverage *commonmodels.CoverageSummary) {
+	if coverage == nil {
+		return
+	}
+
+	for _, repo := range testInfo.JobCtx.Builds {
+		if repo.PR > 0 || repo.Branch == "" {
+			continue
+		}
+		err := commonrepo.NewTestCoverageColl().Upsert(&commonmodels.TestCoverage{
+			TestName:     testInfo.TestModuleName,
+			RepoOwner:    repo.RepoOwner,
+			RepoName:     repo.RepoName,
+			Branch:       repo.Branch,
+			PipelineName: pt.PipelineName,
+			TaskID:       pt.TaskID,
+			Coverage:     coverage,
+		})
+		if err != nil {
+			h.log.Errorf("save coverage of test %s on %s/%s:%s error: %v", testInfo.TestModuleName, repo.RepoOwner, repo.RepoName, repo.Branch, err)
+		}
+	}
+}
+
+// saveTestCaseResults 记录每个用例的执行结果，用于分析不稳定的用例
+func (h *TaskAckHandler) saveTestCaseResults(pt *task.Task, testInfo *task.Testing, testReport *commonmodels.TestSuite) {
+	revisions := make([]string, 0, len(testInfo.JobCtx.Builds))
+	for _, repo := range testInfo.JobCtx.Builds {
+		revision := repo.CommitID
+		if revision == "" {
+			revision = fmt.Sprintf("%s-%d", repo.Branch, repo.PR)
+		}
+		revisions = append(revisions, fmt.Sprintf("%s/%s@%s", repo.RepoOwner, repo.RepoName, revision))
+	}
+
+	results := make([]*commonmodels.TestCaseResult, 0, len(testReport.TestCases))
+	for _, testCase := range testReport.TestCases {
+		status := config.StatusPassed
+		if testCase.Skipped != nil {
+			status = config.StatusSkipped
+		} else if testCase.Failure != nil || testCase.Error != nil {
+			status = config.StatusFailed
+		}
+		results = append(results, &commonmodels.TestCaseResult{
+			ProductName:  pt.ProductName,
+			TestName:     testInfo.TestModuleName,
+			ClassName:    testCase.ClassName,
+			CaseName:     testCase.Name,
+			PipelineName: pt.PipelineName,
+			TaskID:       pt.TaskID,
+			Revision:     strings.Join(revisions, ","),
+			Status:       status,
+			CreateTime:   time.Now().Unix(),
+			Time:         testCase.Time,
+		})
+	}
+	if err := commonrepo.NewTestCaseResultColl().BulkCreate(results); err != nil {
+		h.log.Errorf("save case results of test %s error: %v", testInfo.TestModuleName, err)
+	}
+}
+
+// rollbackServiceReleases 仅在部署或测试阶段失败时回滚本次任务部署过的服务
+func (h *TaskAckHandler) rollbackServiceReleases(pt *task.Task, deploys []*task.Deploy) {
+	if !isDeployOrTestingFailed(pt) {
+		return
+	}
+
+	for _, deploy := range uniqueDeployedServices(deploys, true) {
+		prod, err := h.productColl.FindEnv(&commonrepo.ProductEnvFindOptions{Name: deploy.ProductName, Namespace: deploy.Namespace})
+		if err != nil {
+			h.log.Errorf("find env of namespace %s error: %v", deploy.Namespace, err)
+			continue
+		}
+		if _, err := commonservice.RollbackServiceRelease(prod.ProductName, prod.EnvName, deploy.ServiceName, h.log); err != nil {
+			h.log.Errorf("%s:%d rollback service %s/%s error: %v", pt.PipelineName, pt.TaskID, prod.EnvName, deploy.ServiceName, err)
+			continue
+		}
+		h.log.Infof("%s:%d service %s/%s rolled back", pt.PipelineName, pt.TaskID, prod.EnvName, deploy.ServiceName)
+	}
+}
+
+func isDeployOrTestingFailed(pt *task.Task) bool {
+	for _, stage := range pt.Stages {
+		if stage.TaskType != config.TaskDeploy && stage.TaskType != config.TaskTestingV2 {
+			continue
+		}
+		if stage.Status == config.StatusFailed || stage.Status == config.StatusTimeout {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueDeployedServices 按环境和服务去重，includeFailed 为 false 时只返回部署成功的服务
+func uniqueDeployedServices(deploys []*task.Deploy, includeFailed bool) []*task.Deploy {
+	resp := make([]*task.Deploy, 0)
+	visited := make(map[string]bool)
+	for _, deploy := range deploys {
+		if !deploy.Enabled || deploy.TaskStatus == "" {
+			continue
+		}
+		if !includeFailed && deploy.TaskStatus != config.StatusPassed {
+			continue
+		}
+		key := deploy.Namespace + "/" + deploy.ServiceName
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		resp = append(resp, deploy)
+	}
+	return resp
+}
+
 type ItReportHandler struct {
 	itReportColl *commonrepo.ItReportColl
 	log          *zap.SugaredLogger
@@ -855,6 +1039,7 @@ func (h *ItReportHandler) HandleMessage(message *nsq.Message) error {
 	var report *commonmodels.ItReport
 	if err := json.Unmarshal(message.Body, &report); err != nil {
 		h.log.Errorf("unmarshal ItReport message error: %v", err)
+		metrics.IncNSQConsumeError(setting.TopicItReport)
 		return nil
 	}
 
@@ -862,6 +1047,7 @@ func (h *ItReportHandler) HandleMessage(message *nsq.Message) error {
 
 	if err := h.itReportColl.Upsert(report); err != nil {
 		h.log.Errorf("create ItReport error: %v", err)
+		metrics.IncNSQConsumeError(setting.TopicItReport)
 	}
 	return nil
 }
@@ -876,6 +1062,7 @@ func (h *TaskNotificationHandler) HandleMessage(message *nsq.Message) error {
 	var n *commonmodels.Notify
 	if err := json.Unmarshal(message.Body, &n); err != nil {
 		h.log.Errorf("unmarshal Notify message error: %v", err)
+		metrics.IncNSQConsumeError(setting.TopicNotification)
 		return nil
 	}
 
@@ -884,6 +1071,7 @@ func (h *TaskNotificationHandler) HandleMessage(message *nsq.Message) error {
 	notifyClient := notify.NewNotifyClient()
 	if err := notifyClient.ProccessNotify(n); err != nil {
 		h.log.Errorf("send notify error :%v", err)
+		metrics.IncNSQConsumeError(setting.TopicNotification)
 	}
 
 	return nil
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+)
+
+var _ = Describe("Testing service release", func() {
+
+	Context("uniqueDeployedServices", func() {
+		deploys := []*task.Deploy{
+			{Enabled: true, TaskStatus: config.StatusPassed, Namespace: "ns", ServiceName: "a", ContainerName: "a1"},
+			{Enabled: true, TaskStatus: config.StatusPassed, Namespace: "ns", ServiceName: "a", ContainerName: "a2"},
+			{Enabled: true, TaskStatus: config.StatusFailed, Namespace: "ns", ServiceName: "b"},
+			{Enabled: true, Namespace: "ns", ServiceName: "c"},
+			{Enabled: false, TaskStatus: config.StatusPassed, Namespace: "ns", ServiceName: "d"},
+		}
+
+		It("should only return passed services once", func() {
+			resp := uniqueDeployedServices(deploys, false)
+			Expect(resp).To(HaveLen(1))
+			Expect(resp[0].ServiceName).To(Equal("a"))
+		})
+		It("should include failed services when rolling back", func() {
+			resp := uniqueDeployedServices(deploys, true)
+			Expect(resp).To(HaveLen(2))
+			Expect(resp[1].ServiceName).To(Equal("b"))
+		})
+	})
+
+	Context("isDeployOrTestingFailed", func() {
+		It("should be true if the deploy stage fails", func() {
+			pt := &task.Task{Stages: []*commonmodels.Stage{
+				{TaskType: config.TaskBuild, Status: config.StatusPassed},
+				{TaskType: config.TaskDeploy, Status: config.StatusFailed},
+			}}
+			Expect(isDeployOrTestingFailed(pt)).To(BeTrue())
+		})
+		It("should be true if the testing stage times out", func() {
+			pt := &task.Task{Stages: []*commonmodels.Stage{
+				{TaskType: config.TaskDeploy, Status: config.StatusPassed},
+				{TaskType: config.TaskTestingV2, Status: config.StatusTimeout},
+			}}
+			Expect(isDeployOrTestingFailed(pt)).To(BeTrue())
+		})
+		It("should be false if other stages fail", func() {
+			pt := &task.Task{Stages: []*commonmodels.Stage{
+				{TaskType: config.TaskBuild, Status: config.StatusFailed},
+			}}
+			Expect(isDeployOrTestingFailed(pt)).To(BeFalse())
+		})
+	})
+})
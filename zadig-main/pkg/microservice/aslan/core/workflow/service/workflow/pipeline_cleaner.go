@@ -34,6 +34,13 @@ func Clean(task *task.Task) {
 	}
 }
 
+// CleanWorkflow 隐藏工作流中通用 webhook 的签名密钥
+func CleanWorkflow(workflow *commonmodels.Workflow) {
+	if workflow.NotifyCtl != nil && workflow.NotifyCtl.GenericWebHook != nil && workflow.NotifyCtl.GenericWebHook.Secret != "" {
+		workflow.NotifyCtl.GenericWebHook.Secret = setting.MaskValue
+	}
+}
+
 func ensureTestTask(subTask map[string]interface{}) (newSub map[string]interface{}, err error) {
 	t, err := base.ToTestingTask(subTask)
 
@@ -17,12 +17,21 @@ limitations under the License.
 package workflow
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	configbase "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
@@ -30,11 +39,13 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/metrics"
 	nsqservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
 	"github.com/koderover/zadig/pkg/setting"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/getter"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 func SubScribeNSQ() error {
@@ -215,8 +226,14 @@ type CancelMessage struct {
 
 // CreateTask 接受create task请求, 保存task到数据库, 发送task到queue
 func CreateTask(t *task.Task) error {
+	// 每个任务对应一条 trace, trace 上下文随任务保存并传递到 warpdrive 和 reaper
+	ctx, span := tracing.Start(context.Background(), "CreateTask", trace.WithAttributes(taskAttributes(t)...))
+	defer span.End()
+	t.TraceContext = tracing.Inject(ctx)
+
 	if err := commonrepo.NewTaskColl().Create(t); err != nil {
 		log.Errorf("create PipelineTaskV2 error: %v", err)
+		span.RecordError(err)
 		return err
 	}
 	t.Status = config.StatusWaiting
@@ -290,6 +307,7 @@ func PipelineTaskSender() {
 	for {
 		time.Sleep(time.Second * 3)
 
+		updateQueueMetrics()
 		//c.checkAgents()
 		if hasAgentAvaiable() {
 			t, err := NextWaitingTask()
@@ -331,6 +349,20 @@ func PipelineTaskSender() {
 	}
 }
 
+func updateQueueMetrics() {
+	queues, err := commonrepo.NewQueueColl().List(&commonrepo.ListQueueOption{})
+	if err != nil {
+		log.Errorf("pqColl.List error: %v", err)
+		return
+	}
+
+	statuses := make([]config.Status, 0, len(queues))
+	for _, queue := range queues {
+		statuses = append(statuses, queue.Status)
+	}
+	metrics.SetQueueTasks(statuses)
+}
+
 func hasAgentAvaiable() bool {
 	kubeClient := krkubeclient.Client()
 	deployment, _, err := getter.GetDeployment(config.Namespace(), configbase.WarpDriveServiceName(), kubeClient)
@@ -339,7 +371,117 @@ func hasAgentAvaiable() bool {
 		return false
 	}
 
-	return len(RunningAndQueuedTasks()) < int(deployment.Status.ReadyReplicas)
+	return len(RunningAndQueuedTasks()) < int(deployment.Status.ReadyReplicas)*warpDriveTaskConcurrency(deployment, kubeClient, log.SugaredLogger())
+}
+
+// warpDriveTaskConcurrency returns the number of pipeline tasks each warpdrive instance is able to run,
+// it is configured by the WD_TASK_CONCURRENCY env of the warpdrive container, either literally or
+// from a ConfigMap or Secret
+func warpDriveTaskConcurrency(deployment *appsv1.Deployment, kubeClient client.Client, logger *zap.SugaredLogger) int {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		value, found, err := containerEnvValue(deployment.Namespace, container, setting.WarpDriveTaskConcurrency, kubeClient)
+		if err != nil {
+			logger.Warnf("Failed to get %s of container %s: %s", setting.WarpDriveTaskConcurrency, container.Name, err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if concurrency, err := strconv.Atoi(value); err == nil && concurrency > 0 {
+			return concurrency
+		}
+	}
+
+	return 1
+}
+
+// containerEnvValue resolves the value of the env in the same order as kubelet, env entries override envFrom sources
+func containerEnvValue(namespace string, container corev1.Container, name string, kubeClient client.Client) (string, bool, error) {
+	for i := len(container.Env) - 1; i >= 0; i-- {
+		env := container.Env[i]
+		if env.Name != name {
+			continue
+		}
+		if env.ValueFrom == nil {
+			return env.Value, true, nil
+		}
+		switch {
+		case env.ValueFrom.ConfigMapKeyRef != nil:
+			ref := env.ValueFrom.ConfigMapKeyRef
+			data, err := configMapData(namespace, ref.Name, kubeClient)
+			if err != nil {
+				return "", false, err
+			}
+			value, ok := data[ref.Key]
+			return value, ok, nil
+		case env.ValueFrom.SecretKeyRef != nil:
+			ref := env.ValueFrom.SecretKeyRef
+			data, err := secretData(namespace, ref.Name, kubeClient)
+			if err != nil {
+				return "", false, err
+			}
+			value, ok := data[ref.Key]
+			return value, ok, nil
+		default:
+			return "", false, nil
+		}
+	}
+
+	for i := len(container.EnvFrom) - 1; i >= 0; i-- {
+		source := container.EnvFrom[i]
+		if !strings.HasPrefix(name, source.Prefix) {
+			continue
+		}
+		var (
+			data map[string]string
+			err  error
+		)
+		switch {
+		case source.ConfigMapRef != nil:
+			data, err = configMapData(namespace, source.ConfigMapRef.Name, kubeClient)
+		case source.SecretRef != nil:
+			data, err = secretData(namespace, source.SecretRef.Name, kubeClient)
+		default:
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if value, ok := data[strings.TrimPrefix(name, source.Prefix)]; ok {
+			return value, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func configMapData(namespace, name string, kubeClient client.Client) (map[string]string, error) {
+	cm, found, err := getter.GetConfigMap(namespace, name, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("configmap %s/%s not found", namespace, name)
+	}
+	return cm.Data, nil
+}
+
+func secretData(namespace, name string, kubeClient client.Client) (map[string]string, error) {
+	secret, found, err := getter.GetSecret(namespace, name, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s not found", namespace, name)
+	}
+	data := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		data[k] = v
+	}
+	return data, nil
 }
 
 func RunningAndQueuedTasks() []*task.Task {
@@ -503,6 +645,8 @@ func updateAgentAndQueue(t *task.Task) error {
 		log.Errorf("Publish %s:%d to nsq error: %v", t.PipelineName, t.TaskID, err)
 		return err
 	}
+	metrics.ObserveQueueWait(t.Type, t.CreateTime)
+	traceQueueWait(t)
 	// 更新当前任务状态为 TaskQueued
 	t.Status = config.StatusQueued
 	// 更新队列状态为TaskQueued
@@ -513,6 +657,25 @@ func updateAgentAndQueue(t *task.Task) error {
 	return nil
 }
 
+func taskAttributes(t *task.Task) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("zadig.pipeline", t.PipelineName),
+		attribute.Int64("zadig.task_id", t.TaskID),
+		attribute.String("zadig.task_type", string(t.Type)),
+		attribute.String("zadig.project", t.ProductName),
+	}
+}
+
+// traceQueueWait 记录任务从创建到发送至 warpdrive 之间的排队时间
+func traceQueueWait(t *task.Task) {
+	if t.CreateTime <= 0 {
+		return
+	}
+	ctx := tracing.Extract(context.Background(), t.TraceContext)
+	_, span := tracing.StartChild(ctx, "WaitInQueue", trace.WithTimestamp(time.Unix(t.CreateTime, 0)), trace.WithAttributes(taskAttributes(t)...))
+	span.End()
+}
+
 func UpdateQueue(task *task.Task) bool {
 	if err := commonrepo.NewQueueColl().Update(ConvertTaskToQueue(task)); err != nil {
 		return false
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing warpdrive task concurrency", func() {
+
+	newDeployment := func(container corev1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "warpdrive", Namespace: "zadig"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{container}}},
+			},
+		}
+	}
+	kubeClient := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "warpdrive-config", Namespace: "zadig"},
+			Data:       map[string]string{"concurrency": "3", "WD_TASK_CONCURRENCY": "4"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "warpdrive-secret", Namespace: "zadig"},
+			Data:       map[string][]byte{"concurrency": []byte("5")},
+		},
+	).Build()
+
+	It("should read the literal value", func() {
+		container := corev1.Container{Env: []corev1.EnvVar{{Name: setting.WarpDriveTaskConcurrency, Value: "2"}}}
+		Expect(warpDriveTaskConcurrency(newDeployment(container), kubeClient, zap.NewNop().Sugar())).To(Equal(2))
+	})
+	It("should read the value from a configmap or secret", func() {
+		container := corev1.Container{Env: []corev1.EnvVar{{
+			Name: setting.WarpDriveTaskConcurrency,
+			ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "warpdrive-config"},
+				Key:                  "concurrency",
+			}},
+		}}}
+		Expect(warpDriveTaskConcurrency(newDeployment(container), kubeClient, zap.NewNop().Sugar())).To(Equal(3))
+
+		container.Env[0].ValueFrom = &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "warpdrive-secret"},
+			Key:                  "concurrency",
+		}}
+		Expect(warpDriveTaskConcurrency(newDeployment(container), kubeClient, zap.NewNop().Sugar())).To(Equal(5))
+	})
+	It("should read the value from envFrom", func() {
+		container := corev1.Container{EnvFrom: []corev1.EnvFromSource{{
+			ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "warpdrive-config"}},
+		}}}
+		Expect(warpDriveTaskConcurrency(newDeployment(container), kubeClient, zap.NewNop().Sugar())).To(Equal(4))
+	})
+	It("should fall back to 1 when the value can not be resolved", func() {
+		container := corev1.Container{Env: []corev1.EnvVar{{
+			Name: setting.WarpDriveTaskConcurrency,
+			ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+				Key:                  "concurrency",
+			}},
+		}}}
+		Expect(warpDriveTaskConcurrency(newDeployment(container), kubeClient, zap.NewNop().Sugar())).To(Equal(1))
+		Expect(warpDriveTaskConcurrency(newDeployment(corev1.Container{}), kubeClient, zap.NewNop().Sugar())).To(Equal(1))
+	})
+})
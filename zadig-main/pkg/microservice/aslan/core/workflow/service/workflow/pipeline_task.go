@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/labels"
@@ -469,6 +470,14 @@ func RestartPipelineTaskV2(userName string, taskID int64, pipelineName string, t
 		return e.ErrRestartTask.AddDesc(e.RestartPassedTaskErrMsg)
 	}
 
+	// 重启后审批阶段需要重新审批
+	if t.Type == config.WorkflowType {
+		if err := commonrepo.NewWorkflowApprovalColl().Delete(pipelineName, taskID); err != nil {
+			log.Errorf("[%d:%s] delete approval error: %v", taskID, pipelineName, err)
+			return e.ErrRestartTask.AddErr(err)
+		}
+	}
+
 	//更新测试的相关信息
 	if t.Type == config.TestType {
 		stages := make([]*commonmodels.Stage, 0)
@@ -527,6 +536,13 @@ func RestartPipelineTaskV2(userName string, taskID int64, pipelineName string, t
 									DockerFile: newBuildInfo.PostBuild.DockerBuild.DockerFile,
 									BuildArgs:  newBuildInfo.PostBuild.DockerBuild.BuildArgs,
 									ImageName:  buildInfo.JobCtx.Image,
+
+									EnableBuildkit: newBuildInfo.PostBuild.DockerBuild.EnableBuildkit,
+									Platforms:      newBuildInfo.PostBuild.DockerBuild.Platforms,
+									RemoteCache:    newBuildInfo.PostBuild.DockerBuild.RemoteCache,
+								}
+								if err := setDockerBuildSigning(buildInfo.JobCtx.DockerBuildCtx, newBuildInfo.PostBuild.DockerBuild, buildInfo.ServiceName); err != nil {
+									log.Errorf("setDockerBuildSigning error: %v", err)
 								}
 							}
 
@@ -710,6 +726,10 @@ func TestArgsToTestSubtask(args *commonmodels.TestTaskArgs, pt *task.Task, log *
 	testTask.JobCtx.TestThreshold = testModule.Threshold
 	testTask.JobCtx.Caches = testModule.Caches
 	testTask.JobCtx.ArtifactPaths = testModule.ArtifactPaths
+	testTask.QuarantinedCases = getQuarantinedTestCases(testModule.ProductName, testModule.Name, log)
+	if err := setTestShards(testTask, testModule, 0, log); err != nil {
+		return resp, e.ErrCreateTask.AddDesc(err.Error())
+	}
 	if testTask.Registries == nil {
 		registries, err := commonservice.ListRegistryNamespaces(true, log)
 		if err != nil {
@@ -757,6 +777,120 @@ func TestArgsToTestSubtask(args *commonmodels.TestTaskArgs, pt *task.Task, log *
 	return testTask, nil
 }
 
+// getQuarantinedTestCases 查询失败时仅记录日志，不影响测试任务的创建
+func getQuarantinedTestCases(productName, testName string, log *zap.SugaredLogger) []*task.QuarantinedTestCase {
+	quarantines, err := commonrepo.NewTestCaseQuarantineColl().List(productName, testName)
+	if err != nil {
+		log.Errorf("list quarantined cases of test %s/%s error: %v", productName, testName, err)
+		return nil
+	}
+
+	resp := make([]*task.QuarantinedTestCase, 0, len(quarantines))
+	for _, quarantine := range quarantines {
+		resp = append(resp, &task.QuarantinedTestCase{ClassName: quarantine.ClassName, CaseName: quarantine.CaseName})
+	}
+	return resp
+}
+
+// shardTimingDays 按耗时切分分片时参考最近 14 天的用例执行结果
+const shardTimingDays = 14
+
+// maxShardCount 单个测试最多同时启动的分片数量
+const maxShardCount = 20
+
+// CheckTestShardCount 检查测试分片数量，测试模块和工作流中的配置都需要满足
+func CheckTestShardCount(shardCount int, testType string) error {
+	if shardCount < 0 || shardCount > maxShardCount {
+		return fmt.Errorf("shard count should be between 0 and %d", maxShardCount)
+	}
+	// 性能测试的结果无法按分片合并
+	if shardCount > 1 && testType != "" && testType != setting.FunctionTest {
+		return fmt.Errorf("only function test can be sharded")
+	}
+	return nil
+}
+
+// setTestShards 设置测试分片，shardCount 大于 0 时覆盖测试模块中的配置
+func setTestShards(testTask *task.Testing, testModule *commonmodels.Testing, shardCount int, log *zap.SugaredLogger) error {
+	if shardCount <= 0 {
+		shardCount = testModule.ShardCount
+	}
+	if err := CheckTestShardCount(shardCount, testModule.TestType); err != nil {
+		return err
+	}
+	if shardCount <= 1 {
+		return nil
+	}
+	testTask.ShardCount = shardCount
+	if !testModule.ShardByTiming {
+		return nil
+	}
+
+	results, err := commonrepo.NewTestCaseResultColl().List(&commonrepo.ListTestCaseResultOption{
+		ProductName: testModule.ProductName,
+		TestName:    testModule.Name,
+		Since:       time.Now().AddDate(0, 0, -shardTimingDays).Unix(),
+	})
+	if err != nil {
+		log.Errorf("list case results of test %s error: %v", testModule.Name, err)
+		return nil
+	}
+	testTask.ShardCases = splitTestCasesByTiming(results, shardCount)
+	return nil
+}
+
+// splitTestCasesByTiming 以测试类（没有测试类时为用例）为单位，按照最近一次的耗时从大到小依次分配给总耗时最少的分片
+func splitTestCasesByTiming(results []*commonmodels.TestCaseResult, shardCount int) [][]string {
+	if len(results) == 0 || shardCount <= 1 {
+		return nil
+	}
+
+	// results 按照 create_time 升序，后出现的结果覆盖之前的耗时
+	type caseKey struct {
+		group, name string
+	}
+	caseTimes := make(map[caseKey]float64)
+	for _, result := range results {
+		group := result.ClassName
+		if group == "" {
+			group = result.CaseName
+		}
+		caseTimes[caseKey{group: group, name: result.CaseName}] = result.Time
+	}
+	groupTimes := make(map[string]float64)
+	for key, t := range caseTimes {
+		groupTimes[key.group] += t
+	}
+
+	groups := make([]string, 0, len(groupTimes))
+	for group := range groupTimes {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groupTimes[groups[i]] == groupTimes[groups[j]] {
+			return groups[i] < groups[j]
+		}
+		return groupTimes[groups[i]] > groupTimes[groups[j]]
+	})
+
+	shards := make([][]string, shardCount)
+	shardTimes := make([]float64, shardCount)
+	for _, group := range groups {
+		idx := 0
+		for i := 1; i < shardCount; i++ {
+			if shardTimes[i] < shardTimes[idx] {
+				idx = i
+			}
+		}
+		shards[idx] = append(shards[idx], group)
+		shardTimes[idx] += groupTimes[group]
+	}
+	for _, shard := range shards {
+		sort.Strings(shard)
+	}
+	return shards
+}
+
 func EnsureTaskResp(mt *commonmodels.Testing) {
 	if len(mt.Repos) == 0 {
 		mt.Repos = make([]*types.Repository, 0)
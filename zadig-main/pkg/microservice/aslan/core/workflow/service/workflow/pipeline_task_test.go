@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing test shards", func() {
+
+	Context("splitTestCasesByTiming", func() {
+		results := []*commonmodels.TestCaseResult{
+			{ClassName: "pkg/a", CaseName: "TestA1", Time: 100},
+			{ClassName: "pkg/a", CaseName: "TestA2", Time: 20},
+			{ClassName: "pkg/b", CaseName: "TestB1", Time: 60},
+			{ClassName: "pkg/c", CaseName: "TestC1", Time: 50},
+			{ClassName: "", CaseName: "TestD1", Time: 10},
+			// 后出现的结果覆盖之前的耗时
+			{ClassName: "pkg/a", CaseName: "TestA2", Time: 0},
+		}
+
+		It("should assign the slowest groups to the least loaded shard", func() {
+			shards := splitTestCasesByTiming(results, 2)
+			Expect(shards).To(Equal([][]string{
+				{"TestD1", "pkg/a"},
+				{"pkg/b", "pkg/c"},
+			}))
+		})
+
+		It("should leave the extra shards empty", func() {
+			shards := splitTestCasesByTiming(results, 6)
+			Expect(shards).To(HaveLen(6))
+			Expect(shards[5]).To(BeEmpty())
+		})
+
+		It("should return nil without history or sharding", func() {
+			Expect(splitTestCasesByTiming(nil, 2)).To(BeNil())
+			Expect(splitTestCasesByTiming(results, 1)).To(BeNil())
+		})
+	})
+
+	Context("setTestShards", func() {
+		It("should reject a shard count override out of range", func() {
+			testModule := &commonmodels.Testing{Name: "test", TestType: setting.FunctionTest, ShardCount: 2}
+			err := setTestShards(&task.Testing{}, testModule, maxShardCount+1, zap.NewNop().Sugar())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject sharding a performance test from the workflow", func() {
+			testModule := &commonmodels.Testing{Name: "test", TestType: setting.PerformanceTest}
+			err := setTestShards(&task.Testing{}, testModule, 2, zap.NewNop().Sugar())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should use the override within range", func() {
+			testTask := &task.Testing{}
+			testModule := &commonmodels.Testing{Name: "test", TestType: setting.FunctionTest}
+			Expect(setTestShards(testTask, testModule, 3, zap.NewNop().Sugar())).To(Succeed())
+			Expect(testTask.ShardCount).To(Equal(3))
+		})
+	})
+})
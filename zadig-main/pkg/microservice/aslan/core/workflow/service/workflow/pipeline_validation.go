@@ -323,6 +323,7 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 						ID:         pr.ID,
 						Message:    pr.Title,
 						AuthorName: pr.AuthorName,
+						CreatedAt:  pr.CreatedAt,
 					}
 					build.CheckoutRef = pr.CheckoutRef
 				} else {
@@ -341,6 +342,9 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 			build.CommitID = commit.ID
 			build.CommitMessage = commit.Message
 			build.AuthorName = commit.AuthorName
+			if commit.CreatedAt != nil {
+				build.CommitTime = commit.CreatedAt.Unix()
+			}
 		}
 	} else if codeHostInfo.Type == systemconfig.CodeHubProvider {
 		codeHubClient := codehub.NewClient(codeHostInfo.AccessKey, codeHostInfo.SecretKey, codeHostInfo.Region, config.ProxyHTTPSAddr(), codeHostInfo.EnableProxy)
@@ -351,6 +355,9 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 					build.CommitID = branchInfo.Commit.ID
 					build.CommitMessage = branchInfo.Commit.Message
 					build.AuthorName = branchInfo.Commit.AuthorName
+					if committedDate, err := time.Parse(time.RFC3339, branchInfo.Commit.CommittedDate); err == nil {
+						build.CommitTime = committedDate.Unix()
+					}
 					return
 				}
 			}
@@ -369,14 +376,13 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 				for _, tag := range tags {
 					if *tag.Name == build.Tag {
 						build.CommitID = tag.Commit.GetSHA()
-						if tag.Commit.Message == nil {
-							commitInfo, _, err := gitCli.Repositories.GetCommit(context.Background(), build.RepoOwner, build.RepoName, build.CommitID)
-							if err != nil {
-								log.Errorf("failed to github GetCommit %s err:%s", tag.Commit.GetURL(), err)
-								return
-							}
-							build.CommitMessage = commitInfo.GetCommit().GetMessage()
+						commitInfo, _, err := gitCli.Repositories.GetCommit(context.Background(), build.RepoOwner, build.RepoName, build.CommitID)
+						if err != nil {
+							log.Errorf("failed to github GetCommit %s err:%s", tag.Commit.GetURL(), err)
+							return
 						}
+						build.CommitMessage = commitInfo.GetCommit().GetMessage()
+						build.CommitTime = commitInfo.GetCommit().GetCommitter().GetDate().Unix()
 						build.AuthorName = tag.Commit.GetAuthor().GetName()
 						return
 					}
@@ -387,6 +393,7 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 					build.CommitID = *branch.Commit.SHA
 					build.CommitMessage = *branch.Commit.Commit.Message
 					build.AuthorName = *branch.Commit.Commit.Author.Name
+					build.CommitTime = branch.Commit.Commit.GetCommitter().GetDate().Unix()
 				}
 			} else if build.PR > 0 {
 				opt := &github.ListOptions{Page: 1, PerPage: 100}
@@ -399,6 +406,7 @@ func setBuildInfo(build *types.Repository, log *zap.SugaredLogger) {
 						build.CommitID = *commit.SHA
 						build.CommitMessage = *commit.Commit.Message
 						build.AuthorName = *commit.Commit.Author.Name
+						build.CommitTime = commit.Commit.GetCommitter().GetDate().Unix()
 						return
 					}
 				}
@@ -460,6 +468,10 @@ func setBuildFromArg(build, buildArg *types.Repository) {
 		build.CommitMessage = buildArg.CommitMessage
 	}
 
+	if buildArg.CommitTime > 0 {
+		build.CommitTime = buildArg.CommitTime
+	}
+
 	if buildArg.IsPrimary {
 		build.IsPrimary = true
 	}
@@ -690,6 +702,79 @@ func SetCandidateRegistry(payload *commonmodels.ConfigPayload, log *zap.SugaredL
 	return nil
 }
 
+// validateDeployStrategy 校验部署策略，灰度和蓝绿发布只支持 K8s YAML 服务
+func validateDeployStrategy(strategy *commonmodels.DeployStrategy, serviceType string) error {
+	if strategy == nil || strategy.Type == setting.DeployStrategyRolling {
+		return nil
+	}
+	if serviceType == setting.HelmDeployType {
+		return fmt.Errorf("deploy strategy %s is not supported by helm services", strategy.Type)
+	}
+
+	switch strategy.Type {
+	case setting.DeployStrategyCanary:
+		if len(strategy.CanarySteps) == 0 {
+			return fmt.Errorf("canary steps are not set")
+		}
+		lastWeight := 0
+		for _, step := range strategy.CanarySteps {
+			if step.Weight <= lastWeight || step.Weight > 100 {
+				return fmt.Errorf("canary weights must be increasing between 1 and 100")
+			}
+			if step.Pause < 0 {
+				return fmt.Errorf("pause of canary step must not be negative")
+			}
+			lastWeight = step.Weight
+		}
+		if strategy.IngressName != "" && strategy.K8sServiceName == "" {
+			return fmt.Errorf("k8s service is required to split traffic by ingress")
+		}
+	case setting.DeployStrategyBlueGreen:
+		if strategy.K8sServiceName == "" {
+			return fmt.Errorf("k8s service is required by blue-green deployment")
+		}
+	default:
+		return fmt.Errorf("unknown deploy strategy %s", strategy.Type)
+	}
+	return nil
+}
+
+// validateWorkflowDeployStrategies 校验每个服务的部署策略，不同服务不能切换同一个 Service 或 Ingress 的流量
+func validateWorkflowDeployStrategies(args *commonmodels.WorkflowTaskArgs) error {
+	type serviceStrategy struct {
+		name        string
+		serviceType string
+		strategy    *commonmodels.DeployStrategy
+	}
+	strategies := make([]*serviceStrategy, 0)
+	for _, target := range args.Target {
+		strategies = append(strategies, &serviceStrategy{name: target.ServiceName, serviceType: target.ServiceType, strategy: target.DeployStrategy})
+	}
+	for _, artifact := range args.Artifact {
+		strategies = append(strategies, &serviceStrategy{name: artifact.ServiceName, strategy: artifact.DeployStrategy})
+	}
+
+	k8sServices := make(map[string]string)
+	ingresses := make(map[string]string)
+	for _, svc := range strategies {
+		if err := validateDeployStrategy(svc.strategy, svc.serviceType); err != nil {
+			return fmt.Errorf("service %s: %s", svc.name, err)
+		}
+		if svc.strategy == nil || svc.strategy.Type == setting.DeployStrategyRolling {
+			continue
+		}
+		if other, ok := k8sServices[svc.strategy.K8sServiceName]; ok && svc.strategy.K8sServiceName != "" && other != svc.name {
+			return fmt.Errorf("k8s service %s is used by the deploy strategies of both %s and %s", svc.strategy.K8sServiceName, other, svc.name)
+		}
+		k8sServices[svc.strategy.K8sServiceName] = svc.name
+		if other, ok := ingresses[svc.strategy.IngressName]; ok && svc.strategy.IngressName != "" && other != svc.name {
+			return fmt.Errorf("ingress %s is used by the deploy strategies of both %s and %s", svc.strategy.IngressName, other, svc.name)
+		}
+		ingresses[svc.strategy.IngressName] = svc.name
+	}
+	return nil
+}
+
 // validateServiceContainer validate container with envName like dev
 func validateServiceContainer(envName, productName, serviceName, container string) (string, error) {
 	product, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing pipeline validation", func() {
+
+	Context("validateDeployStrategy", func() {
+		It("should be passed for rolling update", func() {
+			Expect(validateDeployStrategy(nil, setting.HelmDeployType)).ShouldNot(HaveOccurred())
+			Expect(validateDeployStrategy(&commonmodels.DeployStrategy{}, setting.HelmDeployType)).ShouldNot(HaveOccurred())
+		})
+		It("should raise error for helm services", func() {
+			strategy := &commonmodels.DeployStrategy{Type: setting.DeployStrategyBlueGreen, K8sServiceName: "svc"}
+			Expect(validateDeployStrategy(strategy, setting.HelmDeployType)).Should(HaveOccurred())
+			Expect(validateDeployStrategy(strategy, setting.K8SDeployType)).ShouldNot(HaveOccurred())
+		})
+		It("should raise error for blue-green without service", func() {
+			strategy := &commonmodels.DeployStrategy{Type: setting.DeployStrategyBlueGreen}
+			Expect(validateDeployStrategy(strategy, setting.K8SDeployType)).Should(HaveOccurred())
+		})
+		It("should require increasing canary weights", func() {
+			strategy := &commonmodels.DeployStrategy{
+				Type:        setting.DeployStrategyCanary,
+				CanarySteps: []*commonmodels.CanaryStep{{Weight: 10}, {Weight: 50, Pause: 60}},
+			}
+			Expect(validateDeployStrategy(strategy, setting.K8SDeployType)).ShouldNot(HaveOccurred())
+
+			strategy.CanarySteps = append(strategy.CanarySteps, &commonmodels.CanaryStep{Weight: 50})
+			Expect(validateDeployStrategy(strategy, setting.K8SDeployType)).Should(HaveOccurred())
+
+			strategy.CanarySteps = nil
+			Expect(validateDeployStrategy(strategy, setting.K8SDeployType)).Should(HaveOccurred())
+		})
+	})
+
+	Context("validateWorkflowDeployStrategies", func() {
+		blueGreen := func(k8sService string) *commonmodels.DeployStrategy {
+			return &commonmodels.DeployStrategy{Type: setting.DeployStrategyBlueGreen, K8sServiceName: k8sService}
+		}
+
+		It("should validate the strategy of each service", func() {
+			args := &commonmodels.WorkflowTaskArgs{Target: []*commonmodels.TargetArgs{
+				{ServiceName: "a", ServiceType: setting.K8SDeployType, DeployStrategy: blueGreen("a")},
+				{ServiceName: "b", ServiceType: setting.HelmDeployType, DeployStrategy: blueGreen("b")},
+			}}
+			Expect(validateWorkflowDeployStrategies(args)).Should(HaveOccurred())
+		})
+		It("should raise error when services switch the same k8s service", func() {
+			args := &commonmodels.WorkflowTaskArgs{Target: []*commonmodels.TargetArgs{
+				{ServiceName: "a", ServiceType: setting.K8SDeployType, DeployStrategy: blueGreen("svc")},
+				{ServiceName: "b", ServiceType: setting.K8SDeployType, DeployStrategy: blueGreen("svc")},
+			}}
+			Expect(validateWorkflowDeployStrategies(args)).Should(HaveOccurred())
+
+			args.Target[1].DeployStrategy = blueGreen("svc-b")
+			Expect(validateWorkflowDeployStrategies(args)).ShouldNot(HaveOccurred())
+		})
+		It("should allow services without strategy", func() {
+			args := &commonmodels.WorkflowTaskArgs{Target: []*commonmodels.TargetArgs{
+				{ServiceName: "a", ServiceType: setting.K8SDeployType},
+				{ServiceName: "b", ServiceType: setting.K8SDeployType},
+			}}
+			Expect(validateWorkflowDeployStrategies(args)).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -391,6 +391,10 @@ func CreateWorkflow(workflow *commonmodels.Workflow, log *zap.SugaredLogger) err
 		return e.ErrUpsertWorkflow.AddDesc(err.Error())
 	}
 
+	if err := validateSecurityStage(workflow.SecurityStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
 	err = commonservice.ProcessWebhook(workflow.HookCtl.Items, nil, webhook.WorkflowPrefix+workflow.Name, log)
 	if err != nil {
 		log.Errorf("Failed to process webhook, err: %s", err)
@@ -459,6 +463,17 @@ func UpdateWorkflow(workflow *commonmodels.Workflow, log *zap.SugaredLogger) err
 		return e.ErrUpsertWorkflow.AddDesc(err.Error())
 	}
 
+	if err := validateSecurityStage(workflow.SecurityStage); err != nil {
+		return e.ErrUpsertWorkflow.AddDesc(err.Error())
+	}
+
+	// 页面编辑时保留工作流文件的来源，用于检测与文件的差异
+	if workflow.CodeSource == nil {
+		workflow.CodeSource = currentWorkflow.CodeSource
+	}
+	// 查询时签名密钥已被隐藏，未修改时保留原有的值
+	restoreWorkflowSecrets(workflow, currentWorkflow)
+
 	err = commonservice.ProcessWebhook(workflow.HookCtl.Items, currentWorkflow.HookCtl.Items, webhook.WorkflowPrefix+workflow.Name, log)
 	if err != nil {
 		log.Errorf("Failed to process webhook, err: %s", err)
@@ -503,6 +518,35 @@ func validateWorkflowHookNames(w *commonmodels.Workflow) error {
 	return validateHookNames(names)
 }
 
+// validateSecurityStage 校验安全扫描的后端和漏洞门禁策略
+func validateSecurityStage(stage *commonmodels.SecurityStage) error {
+	if stage == nil || !stage.Enabled {
+		return nil
+	}
+	switch stage.Scanner {
+	case "", config.ScannerClair:
+	default:
+		return fmt.Errorf("不支持的安全扫描后端: %s", stage.Scanner)
+	}
+
+	policy := stage.Policy
+	if policy == nil {
+		return nil
+	}
+	switch policy.Action {
+	case config.SecurityPolicyActionFail, config.SecurityPolicyActionWarn:
+	default:
+		return fmt.Errorf("漏洞门禁的处理方式只能是 %s 或 %s", config.SecurityPolicyActionFail, config.SecurityPolicyActionWarn)
+	}
+	for _, item := range policy.Allowlist {
+		if item == nil || strings.TrimSpace(item.CVE) == "" {
+			return fmt.Errorf("漏洞豁免列表中的 CVE 不能为空")
+		}
+		item.CVE = strings.TrimSpace(item.CVE)
+	}
+	return nil
+}
+
 func ListWorkflows(projects []string, userID string, names []string, log *zap.SugaredLogger) ([]*Workflow, error) {
 	existingProjects, err := template.NewProductColl().ListNames(projects)
 	if err != nil {
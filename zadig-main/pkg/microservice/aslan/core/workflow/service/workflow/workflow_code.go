@@ -0,0 +1,733 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// WorkflowCodeVersion 声明式工作流文件的 schema 版本
+const WorkflowCodeVersion = "v1"
+
+type WorkflowCodeKind string
+
+const (
+	WorkflowCodeKindWorkflow   WorkflowCodeKind = "workflow"
+	WorkflowCodeKindWorkflowV3 WorkflowCodeKind = "workflow_v3"
+)
+
+// WorkflowCode 代码仓库中声明式工作流文件的内容，workflow 与 workflow_v3 覆盖对应模型的全部字段
+type WorkflowCode struct {
+	Version    string                   `json:"version"`
+	Kind       WorkflowCodeKind         `json:"kind"`
+	Workflow   *commonmodels.Workflow   `json:"workflow,omitempty"`
+	WorkflowV3 *commonmodels.WorkflowV3 `json:"workflow_v3,omitempty"`
+}
+
+type WorkflowCodeError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (err *WorkflowCodeError) Error() string {
+	if err.Path == "" {
+		return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", err.Line, err.Path, err.Message)
+}
+
+type WorkflowCodeValidation struct {
+	Valid  bool                 `json:"valid"`
+	Errors []*WorkflowCodeError `json:"errors"`
+}
+
+type ImportWorkflowCodeArgs struct {
+	// Content 工作流文件内容，为空时从 Source 指定的代码仓库中读取
+	Content string                           `json:"content"`
+	Source  *commonmodels.WorkflowCodeSource `json:"source"`
+}
+
+type WorkflowCodeDiff struct {
+	Path    string      `json:"path"`
+	File    interface{} `json:"file"`
+	Current interface{} `json:"current"`
+}
+
+type WorkflowCodeDrift struct {
+	Source *commonmodels.WorkflowCodeSource `json:"source"`
+	// FileChanged 最近一次导入后代码仓库中的文件是否有修改
+	FileChanged bool `json:"file_changed"`
+	// Drifted 当前工作流配置是否与代码仓库中的文件不一致
+	Drifted bool                `json:"drifted"`
+	Diffs   []*WorkflowCodeDiff `json:"diffs"`
+}
+
+var yamlErrorLineRegex = regexp.MustCompile(`line (\d+):`)
+
+func ValidateWorkflowCode(content []byte) *WorkflowCodeValidation {
+	_, errs := parseWorkflowCode(content)
+	return &WorkflowCodeValidation{
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	}
+}
+
+func ExportWorkflowCode(name string, kind WorkflowCodeKind, log *zap.SugaredLogger) ([]byte, error) {
+	code := &WorkflowCode{Version: WorkflowCodeVersion, Kind: kind}
+	switch kind {
+	case WorkflowCodeKindWorkflowV3:
+		workflow, err := commonrepo.NewWorkflowV3Coll().Find(name)
+		if err != nil {
+			log.Errorf("Failed to find workflow v3 %s, err: %s", name, err)
+			return nil, e.ErrExportWorkflowCode.AddErr(err)
+		}
+		cleanWorkflowV3Code(workflow)
+		code.WorkflowV3 = workflow
+	case WorkflowCodeKindWorkflow, "":
+		workflow, err := FindWorkflow(name, log)
+		if err != nil {
+			return nil, e.ErrExportWorkflowCode.AddErr(err)
+		}
+		cleanWorkflowCode(workflow)
+		maskWorkflowCodeSecrets(workflow, setting.MaskValue)
+		code.Kind = WorkflowCodeKindWorkflow
+		code.Workflow = workflow
+	default:
+		return nil, e.ErrExportWorkflowCode.AddDesc(fmt.Sprintf("不支持的工作流类型: %s", kind))
+	}
+
+	out, err := yaml.Marshal(code)
+	if err != nil {
+		log.Errorf("Failed to marshal workflow %s, err: %s", name, err)
+		return nil, e.ErrExportWorkflowCode.AddErr(err)
+	}
+	return out, nil
+}
+
+// ImportWorkflowCode 根据工作流文件创建工作流，update 为 true 时以文件内容为准更新已存在的工作流，
+// 文件中的项目必须与调用方有权限的项目一致
+func ImportWorkflowCode(projectName string, update bool, args *ImportWorkflowCodeArgs, user string, log *zap.SugaredLogger) error {
+	content := []byte(args.Content)
+	if len(content) == 0 {
+		if args.Source == nil {
+			return e.ErrImportWorkflowCode.AddDesc("工作流文件内容和来源不能同时为空")
+		}
+		var err error
+		content, err = getWorkflowCodeContent(args.Source)
+		if err != nil {
+			log.Errorf("Failed to get workflow file %s, err: %s", args.Source.Path, err)
+			return e.ErrImportWorkflowCode.AddErr(err)
+		}
+	}
+
+	code, errs := parseWorkflowCode(content)
+	if len(errs) > 0 {
+		return e.ErrImportWorkflowCode.AddDesc(joinWorkflowCodeErrors(errs))
+	}
+
+	var source *commonmodels.WorkflowCodeSource
+	if args.Source != nil {
+		source = args.Source
+		source.Checksum = workflowCodeChecksum(content)
+		source.SyncTime = time.Now().Unix()
+	}
+
+	switch code.Kind {
+	case WorkflowCodeKindWorkflowV3:
+		workflow := code.WorkflowV3
+		if workflow.ProjectName != projectName {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流文件中的项目 %s 与当前项目 %s 不一致", workflow.ProjectName, projectName))
+		}
+		cleanWorkflowV3Code(workflow)
+		workflow.CodeSource = source
+		current, err := commonrepo.NewWorkflowV3Coll().Find(workflow.Name)
+		if err != nil {
+			if update {
+				return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 不存在", workflow.Name))
+			}
+			_, err = CreateWorkflowV3(user, workflow, log)
+			return err
+		}
+		if current.ProjectName != projectName {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 已存在于项目 %s 中", workflow.Name, current.ProjectName))
+		}
+		if !update {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 已存在", workflow.Name))
+		}
+		workflow.CreatedBy = current.CreatedBy
+		workflow.CreateTime = current.CreateTime
+		return UpdateWorkflowV3(current.ID.Hex(), user, workflow, log)
+	default:
+		workflow := code.Workflow
+		if workflow.ProductTmplName != projectName {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流文件中的项目 %s 与当前项目 %s 不一致", workflow.ProductTmplName, projectName))
+		}
+		cleanWorkflowCode(workflow)
+		workflow.CodeSource = source
+		workflow.UpdateBy = user
+		if workflow.HookCtl == nil {
+			workflow.HookCtl = &commonmodels.WorkflowHookCtrl{}
+		}
+		current, err := commonrepo.NewWorkflowColl().Find(workflow.Name)
+		if err != nil {
+			if update {
+				return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 不存在", workflow.Name))
+			}
+			workflow.CreateBy = user
+			restoreWorkflowSecrets(workflow, nil)
+			return CreateWorkflow(workflow, log)
+		}
+		if current.ProductTmplName != projectName {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 已存在于项目 %s 中", workflow.Name, current.ProductTmplName))
+		}
+		if !update {
+			return e.ErrImportWorkflowCode.AddDesc(fmt.Sprintf("工作流 %s 已存在", workflow.Name))
+		}
+		workflow.ID = current.ID
+		workflow.CreateBy = current.CreateBy
+		workflow.CreateTime = current.CreateTime
+		restoreWorkflowSecrets(workflow, current)
+		return UpdateWorkflow(workflow, log)
+	}
+}
+
+// GetWorkflowCodeDrift 对比工作流当前的配置与代码仓库中的文件
+func GetWorkflowCodeDrift(name string, kind WorkflowCodeKind, log *zap.SugaredLogger) (*WorkflowCodeDrift, error) {
+	var (
+		source  *commonmodels.WorkflowCodeSource
+		current interface{}
+	)
+	switch kind {
+	case WorkflowCodeKindWorkflowV3:
+		workflow, err := commonrepo.NewWorkflowV3Coll().Find(name)
+		if err != nil {
+			log.Errorf("Failed to find workflow v3 %s, err: %s", name, err)
+			return nil, e.ErrWorkflowCodeDrift.AddErr(err)
+		}
+		source = workflow.CodeSource
+		cleanWorkflowV3Code(workflow)
+		current = workflow
+	case WorkflowCodeKindWorkflow, "":
+		workflow, err := FindWorkflow(name, log)
+		if err != nil {
+			return nil, e.ErrWorkflowCodeDrift.AddErr(err)
+		}
+		source = workflow.CodeSource
+		cleanWorkflowCode(workflow)
+		// 凭据不会导出到文件中，对比时忽略这些字段
+		maskWorkflowCodeSecrets(workflow, "")
+		current = workflow
+	default:
+		return nil, e.ErrWorkflowCodeDrift.AddDesc(fmt.Sprintf("不支持的工作流类型: %s", kind))
+	}
+	if source == nil {
+		return nil, e.ErrWorkflowCodeDrift.AddDesc("工作流未关联代码仓库中的文件")
+	}
+
+	content, err := getWorkflowCodeContent(source)
+	if err != nil {
+		log.Errorf("Failed to get workflow file %s, err: %s", source.Path, err)
+		return nil, e.ErrWorkflowCodeDrift.AddErr(err)
+	}
+	code, errs := parseWorkflowCode(content)
+	if len(errs) > 0 {
+		return nil, e.ErrWorkflowCodeDrift.AddDesc(joinWorkflowCodeErrors(errs))
+	}
+
+	var file interface{}
+	switch kind {
+	case WorkflowCodeKindWorkflowV3:
+		if code.WorkflowV3 == nil {
+			return nil, e.ErrWorkflowCodeDrift.AddDesc("文件中的工作流类型不匹配")
+		}
+		cleanWorkflowV3Code(code.WorkflowV3)
+		file = code.WorkflowV3
+	default:
+		if code.Workflow == nil {
+			return nil, e.ErrWorkflowCodeDrift.AddDesc("文件中的工作流类型不匹配")
+		}
+		cleanWorkflowCode(code.Workflow)
+		maskWorkflowCodeSecrets(code.Workflow, "")
+		file = code.Workflow
+	}
+
+	diffs, err := diffWorkflowCode(file, current)
+	if err != nil {
+		return nil, e.ErrWorkflowCodeDrift.AddErr(err)
+	}
+	return &WorkflowCodeDrift{
+		Source:      source,
+		FileChanged: source.Checksum != workflowCodeChecksum(content),
+		Drifted:     len(diffs) > 0,
+		Diffs:       diffs,
+	}, nil
+}
+
+func getWorkflowCodeContent(source *commonmodels.WorkflowCodeSource) ([]byte, error) {
+	if source.Path == "" {
+		return nil, fmt.Errorf("path of the workflow file is empty")
+	}
+	return getRawFileContent(source.CodehostID, source.RepoName, source.RepoOwner, source.Branch, source.Path)
+}
+
+func workflowCodeChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// cleanWorkflowCode 清除工作流中由系统维护的字段，这些字段不属于工作流文件的内容
+func cleanWorkflowCode(workflow *commonmodels.Workflow) {
+	workflow.ID = primitive.NilObjectID
+	workflow.UpdateBy = ""
+	workflow.CreateBy = ""
+	workflow.UpdateTime = 0
+	workflow.CreateTime = 0
+	workflow.CodeSource = nil
+	if workflow.Schedules != nil {
+		for _, schedule := range workflow.Schedules.Items {
+			schedule.ID = primitive.NilObjectID
+		}
+	}
+}
+
+// maskWorkflowCodeSecrets 将通知 webhook 地址、通用 webhook 的签名密钥以及扩展阶段的请求头替换为 mask，
+// 避免凭据随工作流文件提交到代码仓库
+func maskWorkflowCodeSecrets(workflow *commonmodels.Workflow, mask string) {
+	if notify := workflow.NotifyCtl; notify != nil {
+		maskWorkflowCodeValue(&notify.WeChatWebHook, mask)
+		maskWorkflowCodeValue(&notify.DingDingWebHook, mask)
+		maskWorkflowCodeValue(&notify.FeiShuWebHook, mask)
+		maskWorkflowCodeValue(&notify.SlackWebHook, mask)
+		maskWorkflowCodeValue(&notify.TeamsWebHook, mask)
+		if notify.GenericWebHook != nil {
+			maskWorkflowCodeValue(&notify.GenericWebHook.Address, mask)
+			maskWorkflowCodeValue(&notify.GenericWebHook.Secret, mask)
+		}
+	}
+	if workflow.ExtensionStage != nil {
+		for _, header := range workflow.ExtensionStage.Headers {
+			maskWorkflowCodeValue(&header.Value, mask)
+		}
+	}
+}
+
+// restoreWorkflowSecrets 导入或更新时仍为掩码的字段沿用当前工作流中保存的值，current 为空时清空这些字段
+func restoreWorkflowSecrets(workflow, current *commonmodels.Workflow) {
+	storedNotify := &commonmodels.NotifyCtl{}
+	storedGeneric := &commonmodels.GenericWebHook{}
+	storedHeaders := make(map[string]string)
+	if current != nil {
+		if current.NotifyCtl != nil {
+			storedNotify = current.NotifyCtl
+			if current.NotifyCtl.GenericWebHook != nil {
+				storedGeneric = current.NotifyCtl.GenericWebHook
+			}
+		}
+		if current.ExtensionStage != nil {
+			for _, header := range current.ExtensionStage.Headers {
+				storedHeaders[header.Key] = header.Value
+			}
+		}
+	}
+
+	if notify := workflow.NotifyCtl; notify != nil {
+		restoreWorkflowSecretValue(&notify.WeChatWebHook, storedNotify.WeChatWebHook)
+		restoreWorkflowSecretValue(&notify.DingDingWebHook, storedNotify.DingDingWebHook)
+		restoreWorkflowSecretValue(&notify.FeiShuWebHook, storedNotify.FeiShuWebHook)
+		restoreWorkflowSecretValue(&notify.SlackWebHook, storedNotify.SlackWebHook)
+		restoreWorkflowSecretValue(&notify.TeamsWebHook, storedNotify.TeamsWebHook)
+		if notify.GenericWebHook != nil {
+			restoreWorkflowSecretValue(&notify.GenericWebHook.Address, storedGeneric.Address)
+			restoreWorkflowSecretValue(&notify.GenericWebHook.Secret, storedGeneric.Secret)
+		}
+	}
+	if workflow.ExtensionStage != nil {
+		for _, header := range workflow.ExtensionStage.Headers {
+			restoreWorkflowSecretValue(&header.Value, storedHeaders[header.Key])
+		}
+	}
+}
+
+func maskWorkflowCodeValue(value *string, mask string) {
+	if *value != "" {
+		*value = mask
+	}
+}
+
+func restoreWorkflowSecretValue(value *string, stored string) {
+	if *value == setting.MaskValue {
+		*value = stored
+	}
+}
+
+func cleanWorkflowV3Code(workflow *commonmodels.WorkflowV3) {
+	workflow.ID = primitive.NilObjectID
+	workflow.CreatedBy = ""
+	workflow.CreateTime = 0
+	workflow.UpdatedBy = ""
+	workflow.UpdateTime = 0
+	workflow.CodeSource = nil
+}
+
+func joinWorkflowCodeErrors(errs []*WorkflowCodeError) string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// parseWorkflowCode 解析工作流文件，所有 schema 错误都会带上所在的行号
+func parseWorkflowCode(content []byte) (*WorkflowCode, []*WorkflowCodeError) {
+	doc := new(yamlv3.Node)
+	if err := yamlv3.Unmarshal(content, doc); err != nil {
+		codeErr := &WorkflowCodeError{Message: err.Error()}
+		if match := yamlErrorLineRegex.FindStringSubmatch(err.Error()); len(match) == 2 {
+			codeErr.Line, _ = strconv.Atoi(match[1])
+		}
+		return nil, []*WorkflowCodeError{codeErr}
+	}
+	if len(doc.Content) == 0 {
+		return nil, []*WorkflowCodeError{{Message: "workflow file is empty"}}
+	}
+
+	v := &workflowCodeValidator{nodes: make(map[string]*yamlv3.Node)}
+	v.walk(doc.Content[0], reflect.TypeOf(WorkflowCode{}), "")
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+
+	code := new(WorkflowCode)
+	if err := yaml.Unmarshal(content, code); err != nil {
+		return nil, []*WorkflowCodeError{{Message: err.Error()}}
+	}
+	v.validate(code)
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+	return code, nil
+}
+
+type workflowCodeValidator struct {
+	errs []*WorkflowCodeError
+	// nodes 记录每个路径对应的节点，用于定位校验错误的行号
+	nodes map[string]*yamlv3.Node
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func (v *workflowCodeValidator) walk(node *yamlv3.Node, t reflect.Type, path string) {
+	if node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		v.walk(node, t.Elem(), path)
+		return
+	}
+	// 自定义了 json 解析的类型（如 ObjectID）交给解析时校验
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			v.addError(node, path, "expected an object")
+			return
+		}
+		fields := workflowCodeFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinWorkflowCodePath(path, key.Value)
+			v.nodes[fieldPath] = key
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				v.addError(key, fieldPath, "unknown field")
+				continue
+			}
+			v.walk(value, fieldType, fieldPath)
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			v.addError(node, path, "expected an object")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinWorkflowCodePath(path, key.Value)
+			v.nodes[fieldPath] = key
+			v.walk(value, t.Elem(), fieldPath)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yamlv3.SequenceNode {
+			v.addError(node, path, "expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			v.nodes[itemPath] = item
+			v.walk(item, t.Elem(), itemPath)
+		}
+	case reflect.String:
+		v.expectScalar(node, path, "a string", "!!str")
+	case reflect.Bool:
+		v.expectScalar(node, path, "a boolean", "!!bool")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.expectScalar(node, path, "an integer", "!!int")
+	case reflect.Float32, reflect.Float64:
+		v.expectScalar(node, path, "a number", "!!int", "!!float")
+	}
+}
+
+func (v *workflowCodeValidator) expectScalar(node *yamlv3.Node, path, expected string, tags ...string) {
+	if node.Kind == yamlv3.ScalarNode {
+		for _, tag := range tags {
+			if node.Tag == tag {
+				return
+			}
+		}
+	}
+	v.addError(node, path, fmt.Sprintf("expected %s", expected))
+}
+
+func (v *workflowCodeValidator) addError(node *yamlv3.Node, path, message string) {
+	v.errs = append(v.errs, &WorkflowCodeError{
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: message,
+	})
+}
+
+// addPathError 在 path 或其最近的上级节点所在行记录错误
+func (v *workflowCodeValidator) addPathError(path, message string) {
+	for p := path; ; {
+		if node, ok := v.nodes[p]; ok {
+			v.addError(node, path, message)
+			return
+		}
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	v.errs = append(v.errs, &WorkflowCodeError{Line: 1, Column: 1, Path: path, Message: message})
+}
+
+func (v *workflowCodeValidator) validate(code *WorkflowCode) {
+	if code.Version != WorkflowCodeVersion {
+		v.addPathError("version", fmt.Sprintf("version must be %s", WorkflowCodeVersion))
+	}
+
+	switch code.Kind {
+	case WorkflowCodeKindWorkflow:
+		if code.WorkflowV3 != nil {
+			v.addPathError("workflow_v3", "workflow_v3 must be empty when kind is workflow")
+		}
+		if code.Workflow == nil {
+			v.addPathError("workflow", "workflow is required")
+			return
+		}
+		if !defaultNameRegex.MatchString(code.Workflow.Name) {
+			v.addPathError("workflow.name", fmt.Sprintf("name must match %s", defaultNameRegexString))
+		}
+		if code.Workflow.ProductTmplName == "" {
+			v.addPathError("workflow.product_tmpl_name", "product_tmpl_name is required")
+		}
+		if !checkWorkflowSubModule(code.Workflow) {
+			v.addPathError("workflow", "at least one stage must be enabled")
+		}
+		if err := validateWorkflowHookNames(code.Workflow); err != nil {
+			v.addPathError("workflow.hook_ctl", err.Error())
+		}
+	case WorkflowCodeKindWorkflowV3:
+		if code.Workflow != nil {
+			v.addPathError("workflow", "workflow must be empty when kind is workflow_v3")
+		}
+		if code.WorkflowV3 == nil {
+			v.addPathError("workflow_v3", "workflow_v3 is required")
+			return
+		}
+		if !defaultNameRegex.MatchString(code.WorkflowV3.Name) {
+			v.addPathError("workflow_v3.name", fmt.Sprintf("name must match %s", defaultNameRegexString))
+		}
+		if code.WorkflowV3.ProjectName == "" {
+			v.addPathError("workflow_v3.project_name", "project_name is required")
+		}
+		if !checkWorkflowSubModules(code.WorkflowV3) {
+			v.addPathError("workflow_v3.sub_tasks", "at least one sub task must be enabled")
+		}
+	default:
+		v.addPathError("kind", fmt.Sprintf("kind must be %s or %s", WorkflowCodeKindWorkflow, WorkflowCodeKindWorkflowV3))
+	}
+}
+
+// workflowCodeFields 按 json tag 返回结构体可以出现在工作流文件中的字段
+func workflowCodeFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range workflowCodeFields(embedded) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func joinWorkflowCodePath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// diffWorkflowCode 比较文件与当前配置，空值（null、空字符串、空列表等）视为相同
+func diffWorkflowCode(file, current interface{}) ([]*WorkflowCodeDiff, error) {
+	fileValue, err := toGenericValue(file)
+	if err != nil {
+		return nil, err
+	}
+	currentValue, err := toGenericValue(current)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*WorkflowCodeDiff, 0)
+	collectWorkflowCodeDiffs("", fileValue, currentValue, &diffs)
+	return diffs, nil
+}
+
+func toGenericValue(obj interface{}) (interface{}, error) {
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var resp interface{}
+	err = json.Unmarshal(out, &resp)
+	return resp, err
+}
+
+func collectWorkflowCodeDiffs(path string, file, current interface{}, diffs *[]*WorkflowCodeDiff) {
+	if isEmptyWorkflowCodeValue(file) && isEmptyWorkflowCodeValue(current) {
+		return
+	}
+
+	fileMap, fileIsMap := file.(map[string]interface{})
+	currentMap, currentIsMap := current.(map[string]interface{})
+	if fileIsMap && currentIsMap {
+		keys := make(map[string]bool)
+		for k := range fileMap {
+			keys[k] = true
+		}
+		for k := range currentMap {
+			keys[k] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for k := range keys {
+			sortedKeys = append(sortedKeys, k)
+		}
+		sort.Strings(sortedKeys)
+		for _, k := range sortedKeys {
+			collectWorkflowCodeDiffs(joinWorkflowCodePath(path, k), fileMap[k], currentMap[k], diffs)
+		}
+		return
+	}
+
+	fileList, fileIsList := file.([]interface{})
+	currentList, currentIsList := current.([]interface{})
+	if fileIsList && currentIsList && len(fileList) == len(currentList) {
+		for i := range fileList {
+			collectWorkflowCodeDiffs(fmt.Sprintf("%s[%d]", path, i), fileList[i], currentList[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(file, current) {
+		*diffs = append(*diffs, &WorkflowCodeDiff{Path: path, File: file, Current: current})
+	}
+}
+
+func isEmptyWorkflowCodeValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isEmptyWorkflowCodeValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
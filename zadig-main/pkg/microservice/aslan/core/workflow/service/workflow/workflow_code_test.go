@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+const validWorkflowCode = `version: v1
+kind: workflow
+workflow:
+  name: demo-workflow
+  product_tmpl_name: demo
+  build_stage:
+    enabled: true
+    modules:
+    - target:
+        service_name: svc
+        service_module: svc
+  hook_ctl:
+    enabled: false
+`
+
+var _ = Describe("Testing workflow code", func() {
+
+	Context("parseWorkflowCode", func() {
+		It("should parse a valid workflow file", func() {
+			code, errs := parseWorkflowCode([]byte(validWorkflowCode))
+			Expect(errs).To(BeEmpty())
+			Expect(code.Workflow.Name).To(Equal("demo-workflow"))
+			Expect(code.Workflow.BuildStage.Modules).To(HaveLen(1))
+		})
+		It("should report yaml syntax errors with line numbers", func() {
+			_, errs := parseWorkflowCode([]byte("version: v1\nkind: workflow\nworkflow: [\n"))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Line).To(BeNumerically(">", 0))
+		})
+		It("should report unknown fields with line numbers", func() {
+			content := validWorkflowCode + "  unknown_stage:\n    enabled: true\n"
+			_, errs := parseWorkflowCode([]byte(content))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Path).To(Equal("workflow.unknown_stage"))
+			Expect(errs[0].Line).To(Equal(14))
+		})
+		It("should report type errors with line numbers", func() {
+			content := "version: v1\nkind: workflow\nworkflow:\n  name: demo\n  is_parallel: yes-please\n"
+			_, errs := parseWorkflowCode([]byte(content))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Path).To(Equal("workflow.is_parallel"))
+			Expect(errs[0].Line).To(Equal(5))
+		})
+		It("should report semantic errors at the line of the field", func() {
+			content := "version: v2\nkind: workflow\nworkflow:\n  name: demo\n  build_stage:\n    enabled: true\n"
+			_, errs := parseWorkflowCode([]byte(content))
+			paths := make([]string, 0)
+			for _, err := range errs {
+				paths = append(paths, err.Path)
+				if err.Path == "version" {
+					Expect(err.Line).To(Equal(1))
+				}
+				if err.Path == "workflow.product_tmpl_name" {
+					Expect(err.Line).To(Equal(3))
+				}
+			}
+			Expect(paths).To(ContainElements("version", "workflow.product_tmpl_name"))
+		})
+	})
+
+	Context("diffWorkflowCode", func() {
+		It("should ignore empty values", func() {
+			file := &commonmodels.Workflow{Name: "demo", BuildStage: &commonmodels.BuildStage{Enabled: true}}
+			current := &commonmodels.Workflow{Name: "demo", BuildStage: &commonmodels.BuildStage{Enabled: true, Modules: []*commonmodels.BuildModule{}}}
+			diffs, err := diffWorkflowCode(file, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffs).To(BeEmpty())
+		})
+		It("should report changed fields", func() {
+			file := &commonmodels.Workflow{Name: "demo", Description: "from file"}
+			current := &commonmodels.Workflow{Name: "demo", Description: "from ui", IsParallel: true}
+			diffs, err := diffWorkflowCode(file, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffs).To(HaveLen(2))
+			Expect(diffs[0].Path).To(Equal("description"))
+			Expect(diffs[1].Path).To(Equal("is_parallel"))
+		})
+	})
+
+	Context("maskWorkflowCodeSecrets", func() {
+		newWorkflow := func() *commonmodels.Workflow {
+			return &commonmodels.Workflow{
+				Name: "demo",
+				NotifyCtl: &commonmodels.NotifyCtl{
+					Enabled:        true,
+					FeiShuWebHook:  "https://open.feishu.cn/hook/token",
+					GenericWebHook: &commonmodels.GenericWebHook{Address: "https://example.com/hook", Secret: "secret"},
+				},
+				ExtensionStage: &commonmodels.ExtensionStage{
+					Enabled: true,
+					Headers: []*commonmodels.KeyVal{{Key: "Authorization", Value: "Bearer token"}},
+				},
+			}
+		}
+
+		It("should mask credentials on export", func() {
+			workflow := newWorkflow()
+			maskWorkflowCodeSecrets(workflow, setting.MaskValue)
+			Expect(workflow.NotifyCtl.FeiShuWebHook).To(Equal(setting.MaskValue))
+			Expect(workflow.NotifyCtl.DingDingWebHook).To(BeEmpty())
+			Expect(workflow.NotifyCtl.GenericWebHook.Address).To(Equal(setting.MaskValue))
+			Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(Equal(setting.MaskValue))
+			Expect(workflow.ExtensionStage.Headers[0].Value).To(Equal(setting.MaskValue))
+		})
+		It("should keep the stored credentials when the mask is imported", func() {
+			workflow := newWorkflow()
+			maskWorkflowCodeSecrets(workflow, setting.MaskValue)
+			workflow.NotifyCtl.GenericWebHook.Secret = "new-secret"
+			restoreWorkflowSecrets(workflow, newWorkflow())
+			Expect(workflow.NotifyCtl.FeiShuWebHook).To(Equal("https://open.feishu.cn/hook/token"))
+			Expect(workflow.NotifyCtl.GenericWebHook.Address).To(Equal("https://example.com/hook"))
+			Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(Equal("new-secret"))
+			Expect(workflow.ExtensionStage.Headers[0].Value).To(Equal("Bearer token"))
+		})
+		It("should not store the mask for a new workflow", func() {
+			workflow := newWorkflow()
+			maskWorkflowCodeSecrets(workflow, setting.MaskValue)
+			restoreWorkflowSecrets(workflow, nil)
+			Expect(workflow.NotifyCtl.FeiShuWebHook).To(BeEmpty())
+			Expect(workflow.ExtensionStage.Headers[0].Value).To(BeEmpty())
+		})
+		It("should ignore credentials in the drift comparison", func() {
+			file := newWorkflow()
+			maskWorkflowCodeSecrets(file, setting.MaskValue)
+			current := newWorkflow()
+			maskWorkflowCodeSecrets(file, "")
+			maskWorkflowCodeSecrets(current, "")
+			diffs, err := diffWorkflowCode(file, current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffs).To(BeEmpty())
+		})
+	})
+
+	Context("ImportWorkflowCode", func() {
+		It("should reject a workflow file of another project", func() {
+			args := &ImportWorkflowCodeArgs{Content: validWorkflowCode}
+			err := ImportWorkflowCode("other", false, args, "user", zap.NewNop().Sugar())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("demo"))
+		})
+	})
+})
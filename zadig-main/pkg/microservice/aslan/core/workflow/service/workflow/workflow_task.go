@@ -27,6 +27,7 @@ import (
 	"strings"
 	"sync"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -439,6 +440,7 @@ func PresetWorkflowArgs(namespace, workflowName string, log *zap.SugaredLogger)
 					}
 				}
 				testArg.TestModuleName = workflowTestArgs.Name
+				testArg.ShardCount = workflowTestArgs.ShardCount
 				testArgs = append(testArgs, testArg)
 			}
 		}
@@ -550,6 +552,11 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		return nil, e.ErrCreateTask.AddErr(err)
 	}
 
+	if err := validateWorkflowDeployStrategies(args); err != nil {
+		log.Errorf("validate deploy strategies of workflow %s err: %v", args.WorkflowName, err)
+		return nil, e.ErrCreateTask.AddDesc(err.Error())
+	}
+
 	stages := make([]*commonmodels.Stage, 0)
 	serviceInfos := make([]*taskmodels.ServiceInfo, 0)
 	for _, target := range args.Target {
@@ -647,7 +654,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		}
 
 		if workflow.SecurityStage != nil && workflow.SecurityStage.Enabled {
-			securityTask, err := addSecurityToSubTasks()
+			securityTask, err := addSecurityToSubTasks(workflow.SecurityStage)
 			if err != nil {
 				log.Errorf("add security task error: %v", err)
 				return nil, e.ErrCreateTask.AddErr(err)
@@ -702,6 +709,15 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 			AddSubtaskToStage(&stages, stask, target.Name+"_"+target.ServiceName)
 		}
 	}
+	// add approval to stage
+	if workflow.ApprovalStage != nil && workflow.ApprovalStage.Enabled {
+		approvalTask, err := addApprovalToSubTasks(workflow.ApprovalStage)
+		if err != nil {
+			log.Errorf("add approval task error: %s", err)
+			return nil, e.ErrCreateTask.AddErr(err)
+		}
+		AddSubtaskToStage(&stages, approvalTask, string(config.TaskApproval))
+	}
 	// add extension to stage
 	if workflow.ExtensionStage != nil && workflow.ExtensionStage.Enabled {
 		extensionTask, err := addExtensionToSubTasks(workflow.ExtensionStage, serviceInfos)
@@ -711,6 +727,17 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		}
 		AddSubtaskToStage(&stages, extensionTask, string(config.TaskExtension))
 	}
+	// add external plugins to stage
+	if workflow.PluginStage != nil && workflow.PluginStage.Enabled {
+		for _, pluginArgs := range workflow.PluginStage.Plugins {
+			pluginTask, err := addExternalPluginToSubTasks(pluginArgs, serviceInfos)
+			if err != nil {
+				log.Errorf("add external plugin %s task error: %s", pluginArgs.Name, err)
+				return nil, e.ErrCreateTask.AddErr(err)
+			}
+			AddSubtaskToStage(&stages, pluginTask, pluginArgs.Name)
+		}
+	}
 
 	testTask := &taskmodels.Task{
 		TaskID:       nextTaskID,
@@ -764,7 +791,10 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		StorageURI:       defaultS3StoreURL,
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
+		AutoRollback:     workflow.AutoRollback,
 		TriggerBy:        triggerBy,
+
+		VerifyImageSignature: workflow.VerifyImageSignature,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -801,6 +831,88 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 	return resp, nil
 }
 
+// RetryWorkflowTaskFromFailedStage 基于失败的任务创建新任务，保留已通过的子任务及其产物，只重新运行失败或取消的子任务
+func RetryWorkflowTaskFromFailedStage(userName string, taskID int64, workflowName string, log *zap.SugaredLogger) (*CreateTaskResp, error) {
+	t, err := commonrepo.NewTaskColl().Find(taskID, workflowName, config.WorkflowType)
+	if err != nil {
+		log.Errorf("[%d:%s] find workflow task error: %s", taskID, workflowName, err)
+		return nil, e.ErrRestartTask.AddDesc(e.FindPipelineTaskErrMsg)
+	}
+	if t.Status != config.StatusFailed && t.Status != config.StatusTimeout && t.Status != config.StatusCancelled {
+		log.Errorf("cannot retry task of status %s", t.Status)
+		return nil, e.ErrRestartTask.AddDesc("只能重试失败、超时或取消的任务")
+	}
+
+	nextTaskID, err := generateNextTaskID(workflowName)
+	if err != nil {
+		return nil, err
+	}
+	resetTaskForRetry(t, nextTaskID, userName)
+
+	if err := CreateTask(t); err != nil {
+		log.Errorf("workflow Create retry task:[%d] err:%v", nextTaskID, err)
+		return nil, e.ErrCreateTask
+	}
+
+	_ = scmnotify.NewService().UpdateWebhookComment(t, log)
+	return &CreateTaskResp{
+		ProjectName:  t.ProductName,
+		PipelineName: workflowName,
+		TaskID:       nextTaskID,
+	}, nil
+}
+
+// resetTaskForRetry 将任务转换为重试任务：记录重试链路，已通过的子任务保持不变，其余子任务重置为待运行
+func resetTaskForRetry(t *task.Task, nextTaskID int64, userName string) {
+	t.RetryFrom = t.TaskID
+	if t.RetryRoot == 0 {
+		t.RetryRoot = t.TaskID
+	}
+	t.RetryCount++
+
+	t.ID = primitive.NilObjectID
+	t.TaskID = nextTaskID
+	t.Status = config.StatusCreated
+	t.TaskCreator = userName
+	t.TaskRevoker = ""
+	t.Error = ""
+	t.IsArchived = false
+	t.IsRestart = false
+
+	for _, stage := range t.Stages {
+		stagePassed := true
+		for _, subTask := range stage.SubTasks {
+			if !resetSubTaskForRetry(subTask) {
+				stagePassed = false
+			}
+		}
+		if !stagePassed {
+			stage.Status = ""
+		}
+	}
+}
+
+// resetSubTaskForRetry 重置未通过的子任务，返回子任务是否可以跳过
+func resetSubTaskForRetry(subTask map[string]interface{}) bool {
+	status, _ := subTask["status"].(string)
+	if config.Status(status) == config.StatusPassed {
+		return true
+	}
+	if enabled, _ := subTask["enabled"].(bool); !enabled {
+		return true
+	}
+
+	// 已经运行过的子任务超时时间已经换算成秒，需要以重试的方式运行
+	if startTime, ok := subTask["start_time"]; ok && startTime != nil && fmt.Sprint(startTime) != "0" {
+		subTask["is_restart"] = true
+	}
+	subTask["status"] = ""
+	subTask["start_time"] = int64(0)
+	subTask["end_time"] = int64(0)
+	delete(subTask, "error")
+	return false
+}
+
 func generateNextTaskID(workflowName string) (int64, error) {
 	nextTaskID, err := commonrepo.NewCounterColl().GetNextSeq(fmt.Sprintf(setting.WorkflowTaskFmt, workflowName))
 	if err != nil {
@@ -952,6 +1064,7 @@ func AddDataToArgsOrCreateReleaseImageTask(args *commonmodels.WorkflowTaskArgs,
 					TestModuleName: testEntity.Name,
 					Namespace:      args.Namespace,
 					Builds:         moduleTest.Repos,
+					ShardCount:     testEntity.ShardCount,
 				}
 				tests = append(tests, test)
 			}
@@ -1410,8 +1523,13 @@ func AddJiraSubTask(moduleName, target, serviceName, productName string, log *za
 	return jira.ToSubTask()
 }
 
-func addSecurityToSubTasks() (map[string]interface{}, error) {
-	securityTask := taskmodels.Security{TaskType: config.TaskSecurity, Enabled: true}
+func addSecurityToSubTasks(stage *commonmodels.SecurityStage) (map[string]interface{}, error) {
+	securityTask := taskmodels.Security{
+		TaskType: config.TaskSecurity,
+		Enabled:  true,
+		Scanner:  stage.Scanner,
+		Policy:   stage.Policy,
+	}
 	return securityTask.ToSubTask()
 }
 
@@ -1429,6 +1547,40 @@ func addExtensionToSubTasks(stage *commonmodels.ExtensionStage, serviceInfos []*
 	return extensionTask.ToSubTask()
 }
 
+func addExternalPluginToSubTasks(args *commonmodels.PluginModuleArgs, serviceInfos []*taskmodels.ServiceInfo) (map[string]interface{}, error) {
+	plugin, err := commonrepo.NewExternalTaskPluginColl().Find(args.Name)
+	if err != nil {
+		return nil, fmt.Errorf("find external plugin %s error: %s", args.Name, err)
+	}
+
+	values := make(map[string]string)
+	for _, kv := range args.Params {
+		values[kv.Key] = kv.Value
+	}
+	params := make([]*commonmodels.KeyVal, 0, len(plugin.Params))
+	for _, param := range plugin.Params {
+		value, ok := values[param.Key]
+		if !ok || value == "" {
+			value = param.DefaultValue
+		}
+		if value == "" && param.Required {
+			return nil, fmt.Errorf("param %s of external plugin %s is required", param.Key, plugin.Name)
+		}
+		params = append(params, &commonmodels.KeyVal{Key: param.Key, Value: value})
+	}
+
+	pluginTask := taskmodels.ExternalPlugin{
+		TaskType:     config.TaskExternalPlugin,
+		Enabled:      true,
+		PluginID:     plugin.ID.Hex(),
+		PluginName:   plugin.Name,
+		Params:       params,
+		Timeout:      plugin.Timeout,
+		ServiceInfos: serviceInfos,
+	}
+	return pluginTask.ToSubTask()
+}
+
 func workFlowArgsToTaskArgs(target string, workflowArgs *commonmodels.WorkflowTaskArgs) *commonmodels.TaskArgs {
 	resp := &commonmodels.TaskArgs{PipelineName: workflowArgs.WorkflowName, TaskCreator: workflowArgs.WorkflowTaskCreator}
 	for _, build := range workflowArgs.Target {
@@ -1436,6 +1588,12 @@ func workFlowArgsToTaskArgs(target string, workflowArgs *commonmodels.WorkflowTa
 			if build.Build != nil {
 				resp.Builds = build.Build.Repos
 			}
+			resp.Deploy.Strategy = build.DeployStrategy
+		}
+	}
+	for _, artifact := range workflowArgs.Artifact {
+		if artifact.Name == target {
+			resp.Deploy.Strategy = artifact.DeployStrategy
 		}
 	}
 	return resp
@@ -1503,6 +1661,11 @@ func testArgsToSubtask(args *commonmodels.WorkflowTaskArgs, pt *taskmodels.Task,
 		testTask.JobCtx.Caches = testModule.Caches
 		testTask.JobCtx.TestResultPath = testModule.TestResultPath
 		testTask.JobCtx.TestReportPath = testModule.TestReportPath
+		testTask.QuarantinedCases = getQuarantinedTestCases(testModule.ProductName, testModule.Name, log)
+		if err := setTestShards(testTask, testModule, testArg.ShardCount, log); err != nil {
+			log.Errorf("[%s]set test shards error: %v", testArg.TestModuleName, err)
+			return resp, e.ErrCreateTask.AddDesc(err.Error())
+		}
 
 		if testTask.Registries == nil {
 			testTask.Registries = registries
@@ -1625,6 +1788,11 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		return nil, err
 	}
 
+	if err := validateWorkflowDeployStrategies(args); err != nil {
+		log.Errorf("validate deploy strategies of workflow %s err: %v", args.WorkflowName, err)
+		return nil, e.ErrCreateTask.AddDesc(err.Error())
+	}
+
 	stages := make([]*commonmodels.Stage, 0)
 	for _, artifact := range args.Artifact {
 		subTasks := make([]map[string]interface{}, 0)
@@ -1710,7 +1878,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		}
 
 		if workflow.SecurityStage != nil && workflow.SecurityStage.Enabled {
-			securityTask, err := addSecurityToSubTasks()
+			securityTask, err := addSecurityToSubTasks(workflow.SecurityStage)
 			if err != nil {
 				log.Errorf("add security task error: %v", err)
 				return nil, err
@@ -1763,6 +1931,15 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		}
 	}
 
+	if workflow.ApprovalStage != nil && workflow.ApprovalStage.Enabled {
+		approvalTask, err := addApprovalToSubTasks(workflow.ApprovalStage)
+		if err != nil {
+			log.Errorf("add approval task error: %s", err)
+			return nil, e.ErrCreateTask.AddErr(err)
+		}
+		AddSubtaskToStage(&stages, approvalTask, string(config.TaskApproval))
+	}
+
 	testTask := &taskmodels.Task{
 		TaskID:       nextTaskID,
 		PipelineName: args.WorkflowName,
@@ -1812,7 +1989,10 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		StorageURI:       defaultS3StoreURL,
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
+		AutoRollback:     workflow.AutoRollback,
 		TriggerBy:        triggerBy,
+
+		VerifyImageSignature: workflow.VerifyImageSignature,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -1894,6 +2074,8 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 			ProductName:  args.ProductName,
 			Namespace:    module.PreBuild.Namespace,
 			ClusterID:    module.PreBuild.ClusterID,
+
+			ResultCacheEnable: module.ResultCacheEnable,
 		}
 
 		// In some old build configurations, the `pre_build.cluster_id` field is empty indicating that's a local cluster.
@@ -2043,6 +2225,12 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 				DockerFile:            module.PostBuild.DockerBuild.DockerFile,
 				BuildArgs:             module.PostBuild.DockerBuild.BuildArgs,
 				DockerTemplateContent: dockerTemplateContent,
+				EnableBuildkit:        module.PostBuild.DockerBuild.EnableBuildkit,
+				Platforms:             module.PostBuild.DockerBuild.Platforms,
+				RemoteCache:           module.PostBuild.DockerBuild.RemoteCache,
+			}
+			if err := setDockerBuildSigning(build.JobCtx.DockerBuildCtx, module.PostBuild.DockerBuild, build.ServiceName); err != nil {
+				return subTasks, e.ErrConvertSubTasks.AddErr(err)
 			}
 		}
 
@@ -2077,6 +2265,25 @@ func BuildModuleToSubTasks(args *commonmodels.BuildModuleArgs, log *zap.SugaredL
 	return subTasks, nil
 }
 
+// setDockerBuildSigning 设置推送镜像后生成 SBOM 和签名镜像所需的参数
+func setDockerBuildSigning(ctx *taskmodels.DockerBuildCtx, dockerBuild *commonmodels.DockerBuild, serviceName string) error {
+	if dockerBuild.SBOMFormat != "" {
+		ctx.SBOMFormat = dockerBuild.SBOMFormat
+		ctx.SBOMFile = fmt.Sprintf("%s-sbom.json", serviceName)
+	}
+	if dockerBuild.SigningKeyID != "" {
+		key, err := commonrepo.NewSigningKeyColl().Find(dockerBuild.SigningKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to find signing key %s: %s", dockerBuild.SigningKeyID, err)
+		}
+		ctx.SigningKey = &taskmodels.SigningKey{
+			ID:   key.ID.Hex(),
+			Name: key.Name,
+		}
+	}
+	return nil
+}
+
 func extractHostIPs(privateKeys []*commonmodels.PrivateKey, ips sets.String) sets.String {
 	for _, privateKey := range privateKeys {
 		ips.Insert(privateKey.IP)
@@ -2401,6 +2608,13 @@ func ensurePipelineTask(taskOpt *taskmodels.TaskOpt, log *zap.SugaredLogger) err
 				// 从创建任务payload设置容器部署
 				t.SetImage(taskOpt.Task.TaskArgs.Deploy.Image)
 				t.SetNamespace(taskOpt.Task.TaskArgs.Deploy.Namespace)
+				if t.Strategy == nil {
+					t.Strategy = taskOpt.Task.TaskArgs.Deploy.Strategy
+				}
+				if err := validateDeployStrategy(t.Strategy, t.ServiceType); err != nil {
+					log.Error(err)
+					return err
+				}
 
 				containerName := t.ContainerName
 				if taskOpt.IsWorkflowTask {
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/task"
+)
+
+var _ = Describe("Testing workflow task retry", func() {
+
+	Context("resetTaskForRetry", func() {
+		var t *task.Task
+
+		BeforeEach(func() {
+			t = &task.Task{
+				TaskID:      3,
+				Status:      config.StatusFailed,
+				TaskCreator: "alice",
+				Stages: []*commonmodels.Stage{
+					{
+						TaskType: config.TaskBuild,
+						Status:   config.StatusPassed,
+						SubTasks: map[string]map[string]interface{}{
+							"svc": {"enabled": true, "status": "passed", "start_time": int64(10), "end_time": int64(20)},
+						},
+					},
+					{
+						TaskType: config.TaskDeploy,
+						Status:   config.StatusFailed,
+						SubTasks: map[string]map[string]interface{}{
+							"a": {"enabled": true, "status": "passed", "image": "a:1"},
+							"b": {"enabled": true, "status": "failed", "start_time": int64(30), "error": "boom"},
+						},
+					},
+					{
+						TaskType: config.TaskTestingV2,
+						SubTasks: map[string]map[string]interface{}{
+							"test": {"enabled": true, "status": "", "start_time": int64(0)},
+						},
+					},
+				},
+			}
+		})
+
+		It("should keep passed sub tasks and reset the others", func() {
+			resetTaskForRetry(t, 5, "bob")
+
+			Expect(t.TaskID).To(Equal(int64(5)))
+			Expect(t.Status).To(Equal(config.StatusCreated))
+			Expect(t.TaskCreator).To(Equal("bob"))
+
+			Expect(t.Stages[0].Status).To(Equal(config.StatusPassed))
+			Expect(t.Stages[0].SubTasks["svc"]["status"]).To(Equal("passed"))
+
+			Expect(t.Stages[1].Status).To(BeEmpty())
+			Expect(t.Stages[1].SubTasks["a"]["status"]).To(Equal("passed"))
+			Expect(t.Stages[1].SubTasks["a"]["image"]).To(Equal("a:1"))
+			Expect(t.Stages[1].SubTasks["b"]["status"]).To(Equal(""))
+			Expect(t.Stages[1].SubTasks["b"]).NotTo(HaveKey("error"))
+			Expect(t.Stages[1].SubTasks["b"]["is_restart"]).To(BeTrue())
+
+			Expect(t.Stages[2].SubTasks["test"]).NotTo(HaveKey("is_restart"))
+		})
+
+		It("should record the retry lineage", func() {
+			resetTaskForRetry(t, 5, "bob")
+			Expect(t.RetryFrom).To(Equal(int64(3)))
+			Expect(t.RetryRoot).To(Equal(int64(3)))
+			Expect(t.RetryCount).To(Equal(1))
+
+			resetTaskForRetry(t, 8, "bob")
+			Expect(t.RetryFrom).To(Equal(int64(5)))
+			Expect(t.RetryRoot).To(Equal(int64(3)))
+			Expect(t.RetryCount).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+var _ = Describe("Testing workflow security stage", func() {
+
+	Context("validateSecurityStage", func() {
+		It("should skip the disabled stage", func() {
+			Expect(validateSecurityStage(nil)).To(Succeed())
+			Expect(validateSecurityStage(&commonmodels.SecurityStage{Scanner: "unknown"})).To(Succeed())
+		})
+		It("should accept the stage without a policy", func() {
+			Expect(validateSecurityStage(&commonmodels.SecurityStage{Enabled: true})).To(Succeed())
+		})
+		It("should reject an unknown scanner", func() {
+			Expect(validateSecurityStage(&commonmodels.SecurityStage{Enabled: true, Scanner: "unknown"})).NotTo(Succeed())
+		})
+		It("should reject an unknown action", func() {
+			stage := &commonmodels.SecurityStage{Enabled: true, Policy: &commonmodels.SecurityPolicy{Action: "ignore"}}
+			Expect(validateSecurityStage(stage)).NotTo(Succeed())
+		})
+		It("should reject an empty cve in the allowlist and trim the others", func() {
+			stage := &commonmodels.SecurityStage{
+				Enabled: true,
+				Scanner: config.ScannerClair,
+				Policy: &commonmodels.SecurityPolicy{
+					Action:    config.SecurityPolicyActionFail,
+					Allowlist: []*commonmodels.CVEAllowlistItem{{CVE: " CVE-2021-44228 "}},
+				},
+			}
+			Expect(validateSecurityStage(stage)).To(Succeed())
+			Expect(stage.Policy.Allowlist[0].CVE).To(Equal("CVE-2021-44228"))
+
+			stage.Policy.Allowlist = append(stage.Policy.Allowlist, &commonmodels.CVEAllowlistItem{CVE: " "})
+			Expect(validateSecurityStage(stage)).NotTo(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Testing workflow generic webhook secret", func() {
+
+	newWorkflow := func(secret string) *commonmodels.Workflow {
+		return &commonmodels.Workflow{
+			Name: "demo",
+			NotifyCtl: &commonmodels.NotifyCtl{
+				Enabled:        true,
+				GenericWebHook: &commonmodels.GenericWebHook{Address: "https://example.com/hook", Secret: secret},
+			},
+		}
+	}
+
+	It("should mask the secret in responses", func() {
+		workflow := newWorkflow("secret")
+		CleanWorkflow(workflow)
+		Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(Equal(setting.MaskValue))
+		Expect(workflow.NotifyCtl.GenericWebHook.Address).To(Equal("https://example.com/hook"))
+
+		workflow = newWorkflow("")
+		CleanWorkflow(workflow)
+		Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(BeEmpty())
+	})
+	It("should keep the stored secret when an update sends the mask back", func() {
+		workflow := newWorkflow(setting.MaskValue)
+		restoreWorkflowSecrets(workflow, newWorkflow("secret"))
+		Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(Equal("secret"))
+
+		workflow = newWorkflow("changed")
+		restoreWorkflowSecrets(workflow, newWorkflow("secret"))
+		Expect(workflow.NotifyCtl.GenericWebHook.Secret).To(Equal("changed"))
+	})
+})
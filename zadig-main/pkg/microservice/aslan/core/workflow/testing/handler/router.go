@@ -64,6 +64,17 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		testDetail.GET("", ListDetailTestModules)
 	}
 
+	// ---------------------------------------------------------------------------------------
+	// 不稳定用例分析及隔离接口
+	// ---------------------------------------------------------------------------------------
+	testCase := router.Group("testcase")
+	{
+		testCase.GET("/flaky", ListFlakyTestCases)
+		testCase.GET("/quarantine", ListQuarantinedTestCases)
+		testCase.POST("/quarantine", gin2.UpdateOperationLogStatus, QuarantineTestCase)
+		testCase.DELETE("/quarantine/:id", gin2.UpdateOperationLogStatus, DeleteTestCaseQuarantine)
+	}
+
 	// ---------------------------------------------------------------------------------------
 	// test 任务接口
 	// ---------------------------------------------------------------------------------------
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/testing/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+func ListFlakyTestCases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	days, _ := strconv.Atoi(c.Query("days"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	ctx.Resp, ctx.Err = service.ListFlakyTestCases(c.Query("projectName"), c.Query("testName"), days, limit, ctx.Logger)
+}
+
+func ListQuarantinedTestCases(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListQuarantinedTestCases(c.Query("projectName"), c.Query("testName"), ctx.Logger)
+}
+
+func QuarantineTestCase(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	args := new(service.QuarantineTestCaseArgs)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("QuarantineTestCase c.GetRawData() err : %v", err)
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("QuarantineTestCase json.Unmarshal err : %v", err)
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "新增", "项目管理-测试-隔离用例", args.TestName+"/"+args.CaseName, string(data), ctx.Logger)
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid quarantine args")
+		return
+	}
+
+	args.ProductName = projectName
+
+	ctx.Err = service.QuarantineTestCase(args, ctx.UserName, ctx.Logger)
+}
+
+func DeleteTestCaseQuarantine(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, projectName, "删除", "项目管理-测试-隔离用例", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.DeleteTestCaseQuarantine(projectName, c.Param("id"), ctx.Logger)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+const (
+	// defaultFlakyDays 默认分析最近 14 天的用例执行结果
+	defaultFlakyDays  = 14
+	defaultFlakyLimit = 20
+)
+
+type FlakyTestCase struct {
+	TestName  string `json:"test_name"`
+	ClassName string `json:"class_name"`
+	CaseName  string `json:"case_name"`
+	Runs      int    `json:"runs"`
+	Failures  int    `json:"failures"`
+	// Flips 同一代码版本上执行结果在成功和失败之间切换的次数
+	Flips int `json:"flips"`
+	// FlakyScore 切换次数与同一代码版本上重复执行次数的比值，取值范围 0~1
+	FlakyScore     float64 `json:"flaky_score"`
+	LastFailedTime int64   `json:"last_failed_time"`
+	Quarantined    bool    `json:"quarantined"`
+	QuarantineID   string  `json:"quarantine_id,omitempty"`
+}
+
+type QuarantineTestCaseArgs struct {
+	ProductName string `json:"product_name"`
+	TestName    string `json:"test_name"`
+	ClassName   string `json:"class_name"`
+	CaseName    string `json:"case_name"`
+	Reason      string `json:"reason"`
+}
+
+// ListFlakyTestCases 按不稳定程度从高到低列出项目中的测试用例
+func ListFlakyTestCases(productName, testName string, days, limit int, log *zap.SugaredLogger) ([]*FlakyTestCase, error) {
+	if days <= 0 {
+		days = defaultFlakyDays
+	}
+	if limit <= 0 {
+		limit = defaultFlakyLimit
+	}
+
+	results, err := commonrepo.NewTestCaseResultColl().List(&commonrepo.ListTestCaseResultOption{
+		ProductName: productName,
+		TestName:    testName,
+		Since:       time.Now().AddDate(0, 0, -days).Unix(),
+	})
+	if err != nil {
+		log.Errorf("list test case results of %s error: %s", productName, err)
+		return nil, e.ErrListFlakyTestCases.AddErr(err)
+	}
+
+	quarantines, err := commonrepo.NewTestCaseQuarantineColl().List(productName, testName)
+	if err != nil {
+		log.Errorf("list quarantined test cases of %s error: %s", productName, err)
+		return nil, e.ErrListFlakyTestCases.AddErr(err)
+	}
+	quarantineMap := make(map[string]*commonmodels.TestCaseQuarantine)
+	for _, quarantine := range quarantines {
+		quarantineMap[testCaseKey(quarantine.TestName, quarantine.ClassName, quarantine.CaseName)] = quarantine
+	}
+
+	flakyCases := calculateFlakyTestCases(results)
+	if len(flakyCases) > limit {
+		flakyCases = flakyCases[:limit]
+	}
+	for _, flakyCase := range flakyCases {
+		if quarantine, ok := quarantineMap[testCaseKey(flakyCase.TestName, flakyCase.ClassName, flakyCase.CaseName)]; ok {
+			flakyCase.Quarantined = true
+			flakyCase.QuarantineID = quarantine.ID.Hex()
+		}
+	}
+	return flakyCases, nil
+}
+
+// calculateFlakyTestCases 统计每个用例在同一代码版本上的结果切换次数，结果按时间升序排列，跳过的结果不参与计算
+func calculateFlakyTestCases(results []*commonmodels.TestCaseResult) []*FlakyTestCase {
+	var (
+		keys       []string
+		flakyMap   = make(map[string]*FlakyTestCase)
+		revisions  = make(map[string]map[string]config.Status)
+		repeatRuns = make(map[string]int)
+	)
+	for _, result := range results {
+		if result.Status != config.StatusPassed && result.Status != config.StatusFailed {
+			continue
+		}
+
+		key := testCaseKey(result.TestName, result.ClassName, result.CaseName)
+		flakyCase, ok := flakyMap[key]
+		if !ok {
+			flakyCase = &FlakyTestCase{TestName: result.TestName, ClassName: result.ClassName, CaseName: result.CaseName}
+			flakyMap[key] = flakyCase
+			revisions[key] = make(map[string]config.Status)
+			keys = append(keys, key)
+		}
+		flakyCase.Runs++
+		if result.Status == config.StatusFailed {
+			flakyCase.Failures++
+			flakyCase.LastFailedTime = result.CreateTime
+		}
+
+		last, ok := revisions[key][result.Revision]
+		revisions[key][result.Revision] = result.Status
+		if !ok {
+			continue
+		}
+		repeatRuns[key]++
+		if last != result.Status {
+			flakyCase.Flips++
+		}
+	}
+
+	resp := make([]*FlakyTestCase, 0)
+	for _, key := range keys {
+		flakyCase := flakyMap[key]
+		if flakyCase.Flips == 0 {
+			continue
+		}
+		flakyCase.FlakyScore = float64(flakyCase.Flips) / float64(repeatRuns[key])
+		resp = append(resp, flakyCase)
+	}
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].FlakyScore != resp[j].FlakyScore {
+			return resp[i].FlakyScore > resp[j].FlakyScore
+		}
+		return resp[i].Flips > resp[j].Flips
+	})
+	return resp
+}
+
+func testCaseKey(testName, className, caseName string) string {
+	return fmt.Sprintf("%s/%s/%s", testName, className, caseName)
+}
+
+func ListQuarantinedTestCases(productName, testName string, log *zap.SugaredLogger) ([]*commonmodels.TestCaseQuarantine, error) {
+	resp, err := commonrepo.NewTestCaseQuarantineColl().List(productName, testName)
+	if err != nil {
+		log.Errorf("list quarantined test cases of %s error: %s", productName, err)
+		return nil, e.ErrListQuarantinedTestCases.AddErr(err)
+	}
+	return resp, nil
+}
+
+// QuarantineTestCase 隔离用例，之后创建的测试任务中该用例失败不会导致测试失败
+func QuarantineTestCase(args *QuarantineTestCaseArgs, userName string, log *zap.SugaredLogger) error {
+	if args.TestName == "" || args.CaseName == "" {
+		return e.ErrQuarantineTestCase.AddDesc("test name and case name can not be empty")
+	}
+	if _, err := commonrepo.NewTestingColl().Find(args.TestName, args.ProductName); err != nil {
+		log.Errorf("find test %s of %s error: %s", args.TestName, args.ProductName, err)
+		return e.ErrQuarantineTestCase.AddDesc(fmt.Sprintf("test %s not found", args.TestName))
+	}
+
+	err := commonrepo.NewTestCaseQuarantineColl().Create(&commonmodels.TestCaseQuarantine{
+		ProductName: args.ProductName,
+		TestName:    args.TestName,
+		ClassName:   args.ClassName,
+		CaseName:    args.CaseName,
+		Reason:      args.Reason,
+		CreatedBy:   userName,
+	})
+	if err != nil {
+		log.Errorf("quarantine test case %s of %s error: %s", args.CaseName, args.TestName, err)
+		return e.ErrQuarantineTestCase.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteTestCaseQuarantine(productName, id string, log *zap.SugaredLogger) error {
+	quarantine, err := commonrepo.NewTestCaseQuarantineColl().Find(id)
+	if err != nil {
+		log.Errorf("find test case quarantine %s error: %s", id, err)
+		return e.ErrDeleteTestCaseQuarantine.AddErr(err)
+	}
+	if quarantine.ProductName != productName {
+		return e.ErrDeleteTestCaseQuarantine.AddDesc(fmt.Sprintf("quarantine %s does not belong to project %s", id, productName))
+	}
+
+	if err := commonrepo.NewTestCaseQuarantineColl().Delete(id); err != nil {
+		log.Errorf("delete test case quarantine %s error: %s", id, err)
+		return e.ErrDeleteTestCaseQuarantine.AddErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func TestCalculateFlakyTestCases(t *testing.T) {
+	result := func(caseName, revision string, status config.Status, createTime int64) *commonmodels.TestCaseResult {
+		return &commonmodels.TestCaseResult{TestName: "unit", ClassName: "pkg", CaseName: caseName, Revision: revision, Status: status, CreateTime: createTime}
+	}
+	results := []*commonmodels.TestCaseResult{
+		// 同一版本上成功失败交替出现
+		result("flaky", "a", config.StatusPassed, 1),
+		result("flaky", "a", config.StatusFailed, 2),
+		result("flaky", "a", config.StatusPassed, 3),
+		// 失败稳定出现，且在新版本上修复，不算不稳定
+		result("broken", "a", config.StatusFailed, 1),
+		result("broken", "a", config.StatusFailed, 2),
+		result("broken", "b", config.StatusPassed, 3),
+		// 偶尔失败
+		result("sometimes", "a", config.StatusPassed, 1),
+		result("sometimes", "a", config.StatusPassed, 2),
+		result("sometimes", "a", config.StatusSkipped, 3),
+		result("sometimes", "a", config.StatusPassed, 4),
+		result("sometimes", "a", config.StatusFailed, 5),
+	}
+
+	flakyCases := calculateFlakyTestCases(results)
+	assert.Len(t, flakyCases, 2)
+
+	assert.Equal(t, "flaky", flakyCases[0].CaseName)
+	assert.Equal(t, 3, flakyCases[0].Runs)
+	assert.Equal(t, 1, flakyCases[0].Failures)
+	assert.Equal(t, 2, flakyCases[0].Flips)
+	assert.Equal(t, 1.0, flakyCases[0].FlakyScore)
+	assert.Equal(t, int64(2), flakyCases[0].LastFailedTime)
+
+	assert.Equal(t, "sometimes", flakyCases[1].CaseName)
+	assert.Equal(t, 4, flakyCases[1].Runs)
+	assert.Equal(t, 1, flakyCases[1].Flips)
+	assert.InDelta(t, 1.0/3, flakyCases[1].FlakyScore, 0.0001)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/koderover/zadig/pkg/config"
 	ginmiddleware "github.com/koderover/zadig/pkg/middleware/gin"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 type engine struct {
@@ -54,6 +55,7 @@ func (s *engine) injectMiddlewares() {
 	}
 	g.Use(ginmiddleware.Response())
 	g.Use(ginmiddleware.RequestID())
+	g.Use(tracing.Middleware())
 	g.Use(ginmiddleware.RequestLog(log.NewFileLogger(config.RequestLogFile())))
 	g.Use(ginmiddleware.GetCollaborationNew())
 	g.Use(gin.Recovery())
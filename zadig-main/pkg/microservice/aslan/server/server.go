@@ -22,13 +22,28 @@ import (
 	_ "net/http/pprof"
 	"time"
 
+	commonconfig "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core"
 	"github.com/koderover/zadig/pkg/microservice/aslan/server/rest"
 	"github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 func Serve(ctx context.Context) error {
+	metrics.RegisterKubeClientMetrics()
+
+	shutdownTracing, err := tracing.Init("aslan", commonconfig.OTLPEndpoint())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Errorf("Failed to flush spans, error: %s", err)
+		}
+	}()
+
 	go func() {
 		if err := client.Start(ctx); err != nil {
 			panic(err)
@@ -58,6 +73,8 @@ func Serve(ctx context.Context) error {
 	}()
 
 	// pprof service, you can access it by {your_ip}:8888/debug/pprof
+	// metrics are served on the same internal port, they are not exposed through the gateway
+	http.Handle("/metrics", metrics.Handler())
 	go func() {
 		err := http.ListenAndServe("0.0.0.0:8888", nil)
 		if err != nil {
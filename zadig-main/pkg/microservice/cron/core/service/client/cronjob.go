@@ -66,6 +66,27 @@ func (c *Client) TriggerCleanProducts(log *zap.SugaredLogger) error {
 	return err
 }
 
+// TriggerEnvLifecycle 按照休眠策略休眠/唤醒环境，并清理到期的环境
+func (c *Client) TriggerEnvLifecycle(log *zap.SugaredLogger) error {
+	url := fmt.Sprintf("%s/environment/cron/envlifecycle", c.APIBase)
+	err := c.sendRequest(url)
+	if err != nil {
+		log.Errorf("trigger env lifecycle error :%v", err)
+	}
+	return err
+}
+
+// TriggerEnvDrift 检测环境中的资源是否与渲染结果一致
+func (c *Client) TriggerEnvDrift(log *zap.SugaredLogger) error {
+	url := fmt.Sprintf("%s/environment/cron/envdrift", c.APIBase)
+	log.Info("start check env drift..")
+	err := c.sendRequest(url)
+	if err != nil {
+		log.Errorf("trigger env drift error :%v", err)
+	}
+	return err
+}
+
 // TriggerCleanCIResources trigger clean CollaborationInstance Resources
 func (c *Client) TriggerCleanCIResources(log *zap.SugaredLogger) error {
 	url := fmt.Sprintf("%s/collaboration/collaborations/cron/clean", c.APIBase)
@@ -46,6 +46,13 @@ func (c *Client) InitStatData(log *zap.SugaredLogger) error {
 	if err != nil {
 		log.Errorf("trigger init deployStat error :%v", err)
 	}
+	//dora
+	url = fmt.Sprintf("%s/api/stat/quality/initDoraStat", configbase.AslanServiceAddress())
+	log.Info("start init doraStat..")
+	_, err = c.sendPostRequest(url, nil, log)
+	if err != nil {
+		log.Errorf("trigger init doraStat error :%v", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var (
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cron",
+		Name:      "job_runs_total",
+		Help:      "Number of cron scheduler job runs, partitioned by job and result.",
+	}, []string{"job", "result"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cron",
+		Name:      "job_duration_seconds",
+		Help:      "Duration of cron scheduler job runs, partitioned by job.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"job"})
+)
+
+// observeJob wraps a scheduler job to record its runs and duration
+func observeJob(job string, fn func(*zap.SugaredLogger) error) func(*zap.SugaredLogger) {
+	return func(log *zap.SugaredLogger) {
+		start := time.Now()
+		err := fn(log)
+		jobRuns.WithLabelValues(job, metrics.Result(err)).Inc()
+		jobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	}
+}
+
+// noError adapts a job which handles its errors internally
+func noError(fn func(*zap.SugaredLogger)) func(*zap.SugaredLogger) error {
+	return func(log *zap.SugaredLogger) error {
+		fn(log)
+		return nil
+	}
+}
@@ -60,6 +60,10 @@ const (
 
 	CleanProductScheduler = "CleanProductScheduler"
 
+	EnvLifecycleScheduler = "EnvLifecycleScheduler"
+
+	EnvDriftScheduler = "EnvDriftScheduler"
+
 	CleanCIResourcesScheduler = "CleanCIResourcesScheduler"
 
 	InitStatScheduler = "InitStatScheduler"
@@ -145,6 +149,10 @@ func (c *CronClient) Init() {
 
 	// 定时清理环境
 	c.InitCleanProductScheduler()
+	// 定时休眠/唤醒环境，清理到期环境
+	c.InitEnvLifecycleScheduler()
+	// 定时检测环境配置漂移
+	c.InitEnvDriftScheduler()
 	// clean collaboration instance resource every 5 minutes
 	c.InitCleanCIResourcesScheduler()
 	// 定时初始化构建数据
@@ -161,7 +169,7 @@ func (c *CronClient) InitCleanJobScheduler() {
 
 	c.Schedulers[CleanJobScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[CleanJobScheduler].Every(1).Day().At("01:00").Do(c.AslanCli.TriggerCleanjobs, c.log)
+	c.Schedulers[CleanJobScheduler].Every(1).Day().At("01:00").Do(observeJob(CleanJobScheduler, c.AslanCli.TriggerCleanjobs), c.log)
 
 	c.Schedulers[CleanJobScheduler].Start()
 }
@@ -170,16 +178,34 @@ func (c *CronClient) InitCleanProductScheduler() {
 
 	c.Schedulers[CleanProductScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[CleanProductScheduler].Every(5).Minutes().Do(c.AslanCli.TriggerCleanProducts, c.log)
+	c.Schedulers[CleanProductScheduler].Every(5).Minutes().Do(observeJob(CleanProductScheduler, c.AslanCli.TriggerCleanProducts), c.log)
 
 	c.Schedulers[CleanProductScheduler].Start()
 }
 
+func (c *CronClient) InitEnvLifecycleScheduler() {
+
+	c.Schedulers[EnvLifecycleScheduler] = gocron.NewScheduler()
+
+	c.Schedulers[EnvLifecycleScheduler].Every(1).Minutes().Do(observeJob(EnvLifecycleScheduler, c.AslanCli.TriggerEnvLifecycle), c.log)
+
+	c.Schedulers[EnvLifecycleScheduler].Start()
+}
+
+func (c *CronClient) InitEnvDriftScheduler() {
+
+	c.Schedulers[EnvDriftScheduler] = gocron.NewScheduler()
+
+	c.Schedulers[EnvDriftScheduler].Every(30).Minutes().Do(observeJob(EnvDriftScheduler, c.AslanCli.TriggerEnvDrift), c.log)
+
+	c.Schedulers[EnvDriftScheduler].Start()
+}
+
 func (c *CronClient) InitCleanCIResourcesScheduler() {
 
 	c.Schedulers[CleanCIResourcesScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[CleanCIResourcesScheduler].Every(5).Minutes().Do(c.AslanCli.TriggerCleanCIResources, c.log)
+	c.Schedulers[CleanCIResourcesScheduler].Every(5).Minutes().Do(observeJob(CleanCIResourcesScheduler, c.AslanCli.TriggerCleanCIResources), c.log)
 
 	c.Schedulers[CleanCIResourcesScheduler].Start()
 }
@@ -188,7 +214,7 @@ func (c *CronClient) InitJobScheduler() {
 
 	c.Schedulers[UpsertWorkflowScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[UpsertWorkflowScheduler].Every(1).Minutes().Do(c.UpsertWorkflowScheduler, c.log)
+	c.Schedulers[UpsertWorkflowScheduler].Every(1).Minutes().Do(observeJob(UpsertWorkflowScheduler, noError(c.UpsertWorkflowScheduler)), c.log)
 
 	c.Schedulers[UpsertWorkflowScheduler].Start()
 }
@@ -197,7 +223,7 @@ func (c *CronClient) InitTestScheduler() {
 
 	c.Schedulers[UpsertTestScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[UpsertTestScheduler].Every(1).Minutes().Do(c.UpsertTestScheduler, c.log)
+	c.Schedulers[UpsertTestScheduler].Every(1).Minutes().Do(observeJob(UpsertTestScheduler, noError(c.UpsertTestScheduler)), c.log)
 
 	c.Schedulers[UpsertTestScheduler].Start()
 }
@@ -206,7 +232,7 @@ func (c *CronClient) InitColliePipelineScheduler() {
 
 	c.Schedulers[UpsertColliePipelineScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[UpsertColliePipelineScheduler].Every(1).Minutes().Do(c.UpsertColliePipelineScheduler, c.log)
+	c.Schedulers[UpsertColliePipelineScheduler].Every(1).Minutes().Do(observeJob(UpsertColliePipelineScheduler, noError(c.UpsertColliePipelineScheduler)), c.log)
 
 	c.Schedulers[UpsertColliePipelineScheduler].Start()
 }
@@ -214,7 +240,7 @@ func (c *CronClient) InitColliePipelineScheduler() {
 func (c *CronClient) InitBuildStatScheduler() {
 	c.Schedulers[InitStatScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[InitStatScheduler].Every(1).Day().At("01:00").Do(c.AslanCli.InitStatData, c.log)
+	c.Schedulers[InitStatScheduler].Every(1).Day().At("01:00").Do(observeJob(InitStatScheduler, c.AslanCli.InitStatData), c.log)
 
 	c.Schedulers[InitStatScheduler].Start()
 }
@@ -223,7 +249,7 @@ func (c *CronClient) InitOperationStatScheduler() {
 
 	c.Schedulers[InitOperationStatScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[InitOperationStatScheduler].Every(1).Hour().Do(c.AslanCli.InitOperationStatData, c.log)
+	c.Schedulers[InitOperationStatScheduler].Every(1).Hour().Do(observeJob(InitOperationStatScheduler, c.AslanCli.InitOperationStatData), c.log)
 
 	c.Schedulers[InitOperationStatScheduler].Start()
 }
@@ -232,7 +258,7 @@ func (c *CronClient) InitPullSonarStatScheduler() {
 
 	c.Schedulers[InitPullSonarStatScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[InitPullSonarStatScheduler].Every(10).Minutes().Do(c.AslanCli.InitPullSonarStatScheduler, c.log)
+	c.Schedulers[InitPullSonarStatScheduler].Every(10).Minutes().Do(observeJob(InitPullSonarStatScheduler, c.AslanCli.InitPullSonarStatScheduler), c.log)
 
 	c.Schedulers[InitPullSonarStatScheduler].Start()
 }
@@ -241,7 +267,7 @@ func (c *CronClient) InitSystemCapacityGCScheduler() {
 
 	c.Schedulers[SystemCapacityGC] = gocron.NewScheduler()
 
-	c.Schedulers[SystemCapacityGC].Every(1).Day().At("02:00").Do(c.AslanCli.TriggerCleanCache, c.log)
+	c.Schedulers[SystemCapacityGC].Every(1).Day().At("02:00").Do(observeJob(SystemCapacityGC, c.AslanCli.TriggerCleanCache), c.log)
 
 	c.Schedulers[SystemCapacityGC].Start()
 }
@@ -250,7 +276,7 @@ func (c *CronClient) InitHealthCheckScheduler() {
 
 	c.Schedulers[InitHealthCheckScheduler] = gocron.NewScheduler()
 
-	c.Schedulers[InitHealthCheckScheduler].Every(10).Seconds().Do(c.UpsertEnvServiceScheduler, c.log)
+	c.Schedulers[InitHealthCheckScheduler].Every(10).Seconds().Do(observeJob(InitHealthCheckScheduler, noError(c.UpsertEnvServiceScheduler)), c.log)
 
 	c.Schedulers[InitHealthCheckScheduler].Start()
 }
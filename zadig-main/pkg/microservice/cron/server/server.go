@@ -25,6 +25,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/cron/core/service/scheduler"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/metrics"
 )
 
 func Serve(ctx context.Context) error {
@@ -40,6 +41,7 @@ func Serve(ctx context.Context) error {
 	cronClient.Init()
 
 	http.HandleFunc("/ping", ping)
+	http.Handle("/metrics", metrics.Handler())
 	server := &http.Server{Addr: ":8091", Handler: nil}
 
 	stopChan := make(chan struct{})
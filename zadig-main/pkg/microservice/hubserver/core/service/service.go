@@ -29,10 +29,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/proxy"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	configbase "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/hubserver/config"
 	"github.com/koderover/zadig/pkg/microservice/hubserver/core/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/hubserver/core/repository/mongodb"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/aslan"
 	"github.com/koderover/zadig/pkg/tool/crypto"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/tool/remotedialer"
@@ -284,6 +286,8 @@ func Sync(server *remotedialer.Server, stopCh <-chan struct{}) {
 						err := mongodb.NewK8sClusterColl().UpdateStatus(cluster)
 						if err != nil {
 							log.Errorf("failed to update clusters status %s %v", cluster.Name, err)
+						} else if cluster.Status == config.Abnormal {
+							go notifyClusterDisconnected(cluster.ID.Hex())
 						}
 					}
 				}
@@ -294,6 +298,13 @@ func Sync(server *remotedialer.Server, stopCh <-chan struct{}) {
 	}
 }
 
+// notifyClusterDisconnected 由 aslan 将集群断开事件分发给订阅者
+func notifyClusterDisconnected(clusterID string) {
+	if err := aslan.New(configbase.AslanServiceAddress()).NotifyClusterDisconnected(clusterID); err != nil {
+		log.Errorf("failed to notify cluster %s disconnected: %s", clusterID, err)
+	}
+}
+
 func HasSession(handler *remotedialer.Server, w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clientKey := vars["id"]
@@ -124,6 +124,10 @@ var systemAdminURLs = []*policyRule{
 		Methods:   []string{"PUT"},
 		Endpoints: []string{"api/aslan/cluster/clusters/?*/reconnect"},
 	},
+	{
+		Methods:   []string{"POST"},
+		Endpoints: []string{"api/aslan/cluster/clusters/?*/disconnected"},
+	},
 	{
 		Methods:   []string{"GET", "POST", "PUT", "DELETE"},
 		Endpoints: []string{"api/collaboration/collaborations"},
@@ -204,6 +208,34 @@ var systemAdminURLs = []*policyRule{
 		Methods:   []string{"PUT", "DELETE"},
 		Endpoints: []string{"api/aslan/system/privateKey/?*"},
 	},
+	{
+		Methods:   []string{"POST"},
+		Endpoints: []string{"api/aslan/system/taskPlugin"},
+	},
+	{
+		Methods:   []string{"GET", "PUT", "DELETE"},
+		Endpoints: []string{"api/aslan/system/taskPlugin/?*"},
+	},
+	{
+		Methods:   []string{"POST", "DELETE"},
+		Endpoints: []string{"api/aslan/workflow/workflowtask/approval/id/?*/name/?*"},
+	},
+	{
+		Methods:   []string{"GET", "POST"},
+		Endpoints: []string{"api/aslan/system/signingKey"},
+	},
+	{
+		Methods:   []string{"GET", "POST", "PUT", "DELETE"},
+		Endpoints: []string{"api/aslan/system/signingKey/?*"},
+	},
+	{
+		Methods:   []string{"POST"},
+		Endpoints: []string{"api/aslan/build/buildcache"},
+	},
+	{
+		Methods:   []string{"GET"},
+		Endpoints: []string{"api/aslan/build/buildcache/?*"},
+	},
 	{
 		Methods:   []string{"POST"},
 		Endpoints: []string{"api/aslan/system/announcement"},
@@ -324,6 +356,10 @@ var projectAdminURLs = []*policyRule{
 		Methods:   []string{"PUT"},
 		Endpoints: []string{"api/aslan/project/products"},
 	},
+	{
+		Methods:   []string{"PUT"},
+		Endpoints: []string{"api/aslan/environment/quota"},
+	},
 	{
 		Methods:   []string{"PUT", "DELETE"},
 		Endpoints: []string{"api/v1/picket/projects/?*"},
@@ -19,6 +19,8 @@ package service
 import (
 	"os/exec"
 	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/buildx"
 )
 
 const dockerExe = "/usr/local/bin/docker"
@@ -49,6 +51,26 @@ func dockerBuild(dockerfile, fullImage, buildArgs string) *exec.Cmd {
 	return exec.Command(dockerExe, args...)
 }
 
+// dockerBuildx returns buildx build command, the image is pushed by buildx directly
+//
+// e.g. docker buildx build --push --platform linux/amd64,linux/arm64 --cache-from type=registry,ref=name:buildcache -t name:tag -f Dockerfile .
+func dockerBuildx(buildCtx *DockerBuildCtx) *exec.Cmd {
+	args := []string{"buildx", "build", "--rm=true", "--push"}
+	if len(buildCtx.Platforms) > 0 {
+		args = append(args, "--platform", strings.Join(buildCtx.Platforms, ","))
+	}
+	if buildCtx.RemoteCache {
+		cacheRef := buildx.CacheRef(buildCtx.ImageName)
+		args = append(args,
+			"--cache-from", "type=registry,ref="+cacheRef,
+			"--cache-to", "type=registry,ref="+cacheRef+",mode=max",
+		)
+	}
+	args = append(args, strings.Fields(buildCtx.BuildArgs)...)
+	args = append(args, []string{"-t", buildCtx.ImageName, "-f", buildCtx.GetDockerFile(), "."}...)
+	return exec.Command(dockerExe, args...)
+}
+
 func dockerPull(image string) *exec.Cmd {
 	args := []string{"pull", image}
 	return exec.Command(dockerExe, args...)
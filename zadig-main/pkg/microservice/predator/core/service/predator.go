@@ -45,6 +45,10 @@ type DockerBuildCtx struct {
 	ImageName       string `yaml:"image_name"`
 	BuildArgs       string `yaml:"build_args"`
 	ImageReleaseTag string `yaml:"image_release_tag,omitempty"`
+	// buildx 相关参数
+	EnableBuildkit bool     `yaml:"enable_buildkit"`
+	Platforms      []string `yaml:"platforms,omitempty"`
+	RemoteCache    bool     `yaml:"remote_cache"`
 }
 
 //DockerRegistry  registry host/user/password
@@ -31,6 +31,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/predator/config"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/buildx"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
 
@@ -154,7 +155,13 @@ func (p *Predator) dockerCommands() []*exec.Cmd {
 	cmds := make([]*exec.Cmd, 0)
 	cmds = append(cmds, dockerVersion())
 
-	if p.Ctx.JobType == setting.BuildImageJob {
+	if p.Ctx.JobType == setting.BuildImageJob && p.Ctx.DockerBuildCtx.EnableBuildkit {
+		cmds = append(cmds, buildx.BuilderCmd())
+		if len(p.Ctx.DockerBuildCtx.Platforms) > 0 {
+			cmds = append(cmds, buildx.BinfmtInstallCmd())
+		}
+		cmds = append(cmds, dockerBuildx(p.Ctx.DockerBuildCtx))
+	} else if p.Ctx.JobType == setting.BuildImageJob {
 		cmds = append(cmds, dockerBuild(p.Ctx.DockerBuildCtx.GetDockerFile(), p.Ctx.DockerBuildCtx.ImageName, p.Ctx.DockerBuildCtx.BuildArgs))
 		p.Ctx.ReleaseImages = append(p.Ctx.ReleaseImages, RepoImage{
 			Name: p.Ctx.DockerBuildCtx.ImageName,
@@ -125,6 +125,10 @@ type Context struct {
 	Cache        types.Cache        `yaml:"cache"`
 	CacheDirType types.CacheDirType `yaml:"cache_dir_type"`
 	CacheUserDir string             `yaml:"cache_user_dir"`
+
+	// TraceContext 子任务 span 的 trace 上下文, OTLPEndpoint 为接收 span 的 collector 地址
+	TraceContext map[string]string `yaml:"trace_context,omitempty"`
+	OTLPEndpoint string            `yaml:"otlp_endpoint,omitempty"`
 }
 
 type ArtifactInfo struct {
@@ -140,13 +144,26 @@ type ArtifactInfo struct {
 // DockerFile: dockerfile名称, 默认为Dockerfile
 // ImageBuild: build image镜像全称, e.g. xxx.com/spock-release-candidates/image:tag
 type DockerBuildCtx struct {
-	Source                string `yaml:"source"      bson:"source"      json:"source"`
-	WorkDir               string `yaml:"work_dir"    bson:"work_dir"    json:"work_dir"`
-	DockerFile            string `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
-	ImageName             string `yaml:"image_name"  bson:"image_name"  json:"image_name"`
-	BuildArgs             string `yaml:"build_args"  bson:"build_args"  json:"build_args"`
-	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
-	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	Source                string      `yaml:"source"      bson:"source"      json:"source"`
+	WorkDir               string      `yaml:"work_dir"    bson:"work_dir"    json:"work_dir"`
+	DockerFile            string      `yaml:"docker_file" bson:"docker_file" json:"docker_file"`
+	ImageName             string      `yaml:"image_name"  bson:"image_name"  json:"image_name"`
+	BuildArgs             string      `yaml:"build_args"  bson:"build_args"  json:"build_args"`
+	ImageReleaseTag       string      `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
+	DockerTemplateContent string      `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	EnableBuildkit        bool        `yaml:"enable_buildkit" bson:"enable_buildkit" json:"enable_buildkit"`
+	Platforms             []string    `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	RemoteCache           bool        `yaml:"remote_cache" bson:"remote_cache" json:"remote_cache"`
+	SBOMFormat            string      `yaml:"sbom_format,omitempty" bson:"sbom_format,omitempty" json:"sbom_format,omitempty"`
+	SBOMFile              string      `yaml:"sbom_file,omitempty" bson:"sbom_file,omitempty" json:"sbom_file,omitempty"`
+	SigningKey            *SigningKey `yaml:"signing_key,omitempty" bson:"signing_key,omitempty" json:"signing_key,omitempty"`
+}
+
+// SigningKey cosign 格式的签名私钥及其密码
+type SigningKey struct {
+	Name       string `yaml:"name"        bson:"name"        json:"name"`
+	PrivateKey string `yaml:"private_key" bson:"private_key" json:"private_key"`
+	Password   string `yaml:"password"    bson:"password"    json:"password"`
 }
 
 func (c *DockerBuildCtx) GetDockerFile() string {
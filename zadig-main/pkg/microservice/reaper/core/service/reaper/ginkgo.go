@@ -39,6 +39,7 @@ func getSecondSince(startTime time.Time) float64 {
 	return float64(time.Since(startTime).Round(time.Millisecond).Nanoseconds()) / float64(time.Second)
 }
 
+// mergeGinkgoTestResults 合并测试结果目录下的 JUnit、go test -json、TAP 测试报告以及 Cobertura、LCOV 覆盖率报告
 func mergeGinkgoTestResults(testResultFile, testResultPath, testUploadPath string, startTime time.Time) error {
 	var (
 		err           error
@@ -63,14 +64,53 @@ func mergeGinkgoTestResults(testResultFile, testResultPath, testUploadPath strin
 		return files[i].ModTime().Before(files[j].ModTime())
 	})
 	for _, file := range files {
-		if filepath.Ext(file.Name()) == ".xml" {
-			filePath := path.Join(testResultPath, file.Name())
-			log.Infof("name %s mod time: %v", file.Name(), file.ModTime())
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		switch ext {
+		case ".xml", ".json", ".jsonl", ".tap", ".info", ".lcov":
+		default:
+			continue
+		}
+
+		filePath := path.Join(testResultPath, file.Name())
+		log.Infof("name %s mod time: %v", file.Name(), file.ModTime())
 
-			// 1. read file
-			xmlBytes, err2 := ioutil.ReadFile(filePath)
+		// 1. read file
+		xmlBytes, err2 := ioutil.ReadFile(filePath)
+		if err2 != nil {
+			log.Warningf("Read file [%s], error: %v", filePath, err2)
+			continue
+		}
+
+		switch ext {
+		case ".json", ".jsonl":
+			testCases, err2 := parseGoTestJSON(xmlBytes)
 			if err2 != nil {
-				log.Warningf("Read file [%s], error: %v", filePath, err2)
+				log.Warningf("Parse go test json file [%s], error: %v", filePath, err2)
+				continue
+			}
+			addTestCases(summaryResult, testCases)
+		case ".tap":
+			testCases, err2 := parseTAP(xmlBytes)
+			if err2 != nil {
+				log.Warningf("Parse tap file [%s], error: %v", filePath, err2)
+				continue
+			}
+			addTestCases(summaryResult, testCases)
+		case ".info", ".lcov":
+			coverage, err2 := parseLCOVCoverage(xmlBytes)
+			if err2 != nil {
+				log.Warningf("Parse lcov file [%s], error: %v", filePath, err2)
+				continue
+			}
+			summaryResult.Coverage = mergeCoverage(summaryResult.Coverage, coverage)
+		case ".xml":
+			if xmlRootElement(xmlBytes) == "coverage" {
+				coverage, err2 := parseCoberturaCoverage(xmlBytes)
+				if err2 != nil {
+					log.Warningf("Parse cobertura file [%s], error: %v", filePath, err2)
+					continue
+				}
+				summaryResult.Coverage = mergeCoverage(summaryResult.Coverage, coverage)
 				continue
 			}
 
@@ -103,6 +143,11 @@ func mergeGinkgoTestResults(testResultFile, testResultPath, testUploadPath strin
 					log.Warningf("Unmarshal xml file [%s], error: %v\n", filePath, err2)
 					continue
 				}
+				// 3. pytest、surefire 等报告的 tests 包含跳过的用例，或者缺少统计属性，按用例重新统计
+				if result.SkippedCount > 0 || (result.Tests == 0 && len(result.TestCases) > 0) {
+					addTestCases(summaryResult, result.TestCases)
+					continue
+				}
 				// 4. process summary result attribute
 				summaryResult.Tests += result.Tests
 				summaryResult.Failures += result.Failures
@@ -17,8 +17,16 @@ limitations under the License.
 package reaper
 
 import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
 )
 
 func TestGetSecondSince(t *testing.T) {
@@ -41,3 +49,41 @@ func TestGetSecondSince(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeTestResults(t *testing.T) {
+	resultPath, err := ioutil.TempDir("", "test-result")
+	assert.Nil(t, err)
+	defer os.RemoveAll(resultPath)
+	uploadPath, err := ioutil.TempDir("", "test-upload")
+	assert.Nil(t, err)
+	defer os.RemoveAll(uploadPath)
+
+	files := map[string]string{
+		"pytest.xml": `<testsuites><testsuite name="pytest" tests="3" failures="1" errors="0" skipped="1">
+<testcase classname="a" name="t1"/>
+<testcase classname="a" name="t2"><failure message="boom"/></testcase>
+<testcase classname="a" name="t3"><skipped/></testcase>
+</testsuite></testsuites>`,
+		"go.json":      `{"Action":"pass","Package":"pkg/a","Test":"TestA","Elapsed":0.1}`,
+		"coverage.xml": `<coverage line-rate="0.8" lines-covered="8" lines-valid="10"></coverage>`,
+		"lcov.info":    "LF:10\nLH:2\nend_of_record\n",
+	}
+	for name, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(resultPath, name), []byte(content), 0644))
+	}
+
+	assert.Nil(t, mergeGinkgoTestResults("result.xml", resultPath, uploadPath, time.Now()))
+
+	b, err := ioutil.ReadFile(filepath.Join(uploadPath, "result.xml"))
+	assert.Nil(t, err)
+	result := new(meta.TestSuite)
+	assert.Nil(t, xml.Unmarshal(b, result))
+	assert.Equal(t, 4, result.Tests)
+	assert.Equal(t, 1, result.Failures)
+	assert.Equal(t, 1, result.Skips)
+	assert.Equal(t, 2, result.Successes)
+	assert.NotNil(t, result.Coverage)
+	assert.Equal(t, 10, result.Coverage.LinesCovered)
+	assert.Equal(t, 20, result.Coverage.LinesValid)
+	assert.Equal(t, 0.5, result.Coverage.LineRate)
+}
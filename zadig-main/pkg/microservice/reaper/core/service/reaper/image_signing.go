@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/microservice/reaper/internal/s3"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+const (
+	syftExe   = "syft"
+	cosignExe = "cosign"
+
+	// sbomFolder SBOM 在对象存储中的目录, 与 file 和 test 同级
+	sbomFolder = "sbom"
+)
+
+// sbomCmd 直接从镜像仓库读取镜像生成 SBOM, buildx 构建的镜像不会被加载到本地
+func sbomCmd(image, format, output string) *exec.Cmd {
+	return exec.Command(syftExe, "registry:"+image, "-o", format, "--file", output)
+}
+
+// cosignSignCmd 使用 cosign 格式的私钥签名镜像, 签名推送到镜像所在仓库, 不上传透明日志
+func cosignSignCmd(image, keyFile string) *exec.Cmd {
+	return exec.Command(cosignExe, "sign", "--key", keyFile, "--tlog-upload=false", "--yes", image)
+}
+
+func (r *Reaper) runImageSupplyChain() error {
+	buildCtx := r.Ctx.DockerBuildCtx
+	if buildCtx.SBOMFormat != "" {
+		log.Info("Generating SBOM.")
+		startTime := time.Now()
+		if err := r.generateSBOM(buildCtx); err != nil {
+			return fmt.Errorf("failed to generate sbom: %s", err)
+		}
+		log.Infof("SBOM generation ended. Duration: %.2f seconds.", time.Since(startTime).Seconds())
+	}
+
+	if buildCtx.SigningKey != nil {
+		log.Info("Signing Image.")
+		startTime := time.Now()
+		if err := r.signImage(buildCtx); err != nil {
+			return fmt.Errorf("failed to sign image: %s", err)
+		}
+		log.Infof("Image signing ended. Duration: %.2f seconds.", time.Since(startTime).Seconds())
+	}
+	return nil
+}
+
+func (r *Reaper) generateSBOM(buildCtx *meta.DockerBuildCtx) error {
+	output := filepath.Join(os.TempDir(), buildCtx.SBOMFile)
+	cmd := sbomCmd(buildCtx.ImageName, buildCtx.SBOMFormat, output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = r.getUserEnvs()
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	if r.Ctx.StorageURI == "" {
+		log.Warning("storage is not configured, sbom is not uploaded")
+		return nil
+	}
+	store, err := s3.NewS3StorageFromEncryptedURI(r.Ctx.StorageURI, r.Ctx.AesKey)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 storage %s: %s", r.Ctx.StorageURI, err)
+	}
+	if store.Subfolder != "" {
+		store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, r.Ctx.PipelineName, r.Ctx.TaskID, sbomFolder)
+	} else {
+		store.Subfolder = fmt.Sprintf("%s/%d/%s", r.Ctx.PipelineName, r.Ctx.TaskID, sbomFolder)
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 client: %s", err)
+	}
+	return s3client.Upload(store.Bucket, output, store.GetObjectPath(buildCtx.SBOMFile))
+}
+
+func (r *Reaper) signImage(buildCtx *meta.DockerBuildCtx) error {
+	keyFile, err := ioutil.TempFile("", "cosign-*.key")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyFile.Name())
+
+	if _, err := keyFile.WriteString(buildCtx.SigningKey.PrivateKey); err != nil {
+		keyFile.Close()
+		return err
+	}
+	if err := keyFile.Close(); err != nil {
+		return err
+	}
+
+	cmd := cosignSignCmd(buildCtx.ImageName, keyFile.Name())
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(r.getUserEnvs(), "COSIGN_PASSWORD="+buildCtx.SigningKey.Password)
+	return cmd.Run()
+}
@@ -18,6 +18,8 @@ package reaper
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -33,7 +35,9 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/reaper/config"
 	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/buildx"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util/fs"
 )
@@ -43,12 +47,17 @@ const (
 	ReadmeFile       = "/tmp/README"
 )
 
+// ErrTestScriptFailed 测试任务的用户脚本执行失败，测试结果仍需要归档
+var ErrTestScriptFailed = errors.New("failed to execute user test script")
+
 type Reaper struct {
 	Ctx             *meta.Context
 	StartTime       time.Time
 	ActiveWorkspace string
 	UserEnvs        map[string]string
-	cm              CacheManager
+	// TraceCtx 带有当前构建 span 的上下文, 为空时不记录构建步骤
+	TraceCtx context.Context
+	cm       CacheManager
 }
 
 func NewReaper() (*Reaper, error) {
@@ -265,6 +274,35 @@ func dockerBuildCmd(dockerfile, fullImage, ctx, buildArgs string, ignoreCache bo
 	return exec.Command("sh", args...)
 }
 
+// dockerBuildxCmd builds the image with buildx and pushes it directly, since images of multiple platforms
+// can not be loaded into the docker daemon
+func dockerBuildxCmd(buildCtx *meta.DockerBuildCtx, ctx string, ignoreCache bool) *exec.Cmd {
+	args := []string{"-c"}
+	dockerCommand := "docker buildx build --rm=true --push"
+	if ignoreCache {
+		dockerCommand += " --no-cache"
+	}
+
+	if len(buildCtx.Platforms) > 0 {
+		dockerCommand += " --platform " + strings.Join(buildCtx.Platforms, ",")
+	}
+
+	if buildCtx.RemoteCache {
+		cacheRef := buildx.CacheRef(buildCtx.ImageName)
+		if !ignoreCache {
+			dockerCommand += " --cache-from type=registry,ref=" + cacheRef
+		}
+		dockerCommand += " --cache-to type=registry,ref=" + cacheRef + ",mode=max"
+	}
+
+	for _, val := range strings.Fields(buildCtx.BuildArgs) {
+		dockerCommand = dockerCommand + " " + val
+	}
+	dockerCommand = dockerCommand + " -t " + buildCtx.ImageName + " -f " + buildCtx.GetDockerFile() + " " + ctx
+	args = append(args, dockerCommand)
+	return exec.Command("sh", args...)
+}
+
 func (r *Reaper) setProxy(ctx *meta.DockerBuildCtx, cfg *meta.Proxy) {
 	if cfg.EnableRepoProxy && cfg.Type == "http" {
 		if !strings.Contains(strings.ToLower(ctx.BuildArgs), "--build-arg http_proxy=") {
@@ -278,6 +316,15 @@ func (r *Reaper) setProxy(ctx *meta.DockerBuildCtx, cfg *meta.Proxy) {
 
 func (r *Reaper) dockerCommands() []*exec.Cmd {
 	cmds := make([]*exec.Cmd, 0)
+	if r.Ctx.DockerBuildCtx.EnableBuildkit {
+		// 导出缓存和构建多架构镜像需要 docker-container 类型的 builder
+		cmds = append(cmds, buildx.BuilderCmd())
+		if len(r.Ctx.DockerBuildCtx.Platforms) > 0 {
+			cmds = append(cmds, buildx.BinfmtInstallCmd())
+		}
+		return append(cmds, dockerBuildxCmd(r.Ctx.DockerBuildCtx, r.Ctx.DockerBuildCtx.WorkDir, r.Ctx.IgnoreCache))
+	}
+
 	cmds = append(
 		cmds,
 		dockerBuildCmd(
@@ -323,7 +370,7 @@ func (r *Reaper) runDockerBuild() error {
 	}
 	log.Infof("Docker build ended. Duration: %.2f seconds.", time.Since(startTimeDockerBuild).Seconds())
 
-	return nil
+	return r.runImageSupplyChain()
 }
 
 func (r *Reaper) prepareDockerfile() error {
@@ -342,14 +389,14 @@ func (r *Reaper) prepareDockerfile() error {
 func (r *Reaper) Exec() error {
 	log.Info("Installing Dependency Packages.")
 	startTimeInstallDeps := time.Now()
-	if err := r.runIntallationScripts(); err != nil {
+	if err := r.TraceStep("InstallDependencies", r.runIntallationScripts); err != nil {
 		return fmt.Errorf("failed to install dependency packages: %s", err)
 	}
 	log.Infof("Install ended. Duration: %.2f seconds.", time.Since(startTimeInstallDeps).Seconds())
 
 	log.Info("Cloning Repository.")
 	startTimeCloneRepo := time.Now()
-	if err := r.runGitCmds(); err != nil {
+	if err := r.TraceStep("CloneRepository", r.runGitCmds); err != nil {
 		return fmt.Errorf("failed to clone repository: %s", err)
 	}
 	log.Infof("Clone ended. Duration: %.2f seconds.", time.Since(startTimeCloneRepo).Seconds())
@@ -360,16 +407,36 @@ func (r *Reaper) Exec() error {
 
 	log.Info("Executing User Build Script.")
 	startTimeRunBuildScript := time.Now()
-	if err := r.runScripts(); err != nil {
+	if err := r.TraceStep("RunScripts", r.runScripts); err != nil {
+		if r.HasTestResult() {
+			return fmt.Errorf("%w: %s", ErrTestScriptFailed, err)
+		}
 		return fmt.Errorf("failed to execute user build script: %s", err)
 	}
 	log.Infof("Execution ended. Duration: %.2f seconds.", time.Since(startTimeRunBuildScript).Seconds())
 
-	return r.runDockerBuild()
+	return r.TraceStep("DockerBuild", r.runDockerBuild)
+}
+
+// TraceStep 在 span 中执行构建步骤
+func (r *Reaper) TraceStep(name string, step func() error) error {
+	if r.TraceCtx == nil {
+		return step()
+	}
+
+	_, span := tracing.StartChild(r.TraceCtx, name)
+	err := step()
+	tracing.End(span, err)
+	return err
+}
+
+// HasTestResult 任务配置了测试结果目录
+func (r *Reaper) HasTestResult() bool {
+	return r.Ctx.GinkgoTest != nil && r.Ctx.GinkgoTest.ResultPath != ""
 }
 
 func (r *Reaper) AfterExec() error {
-	if r.Ctx.GinkgoTest != nil && r.Ctx.GinkgoTest.ResultPath != "" {
+	if r.HasTestResult() {
 		resultPath := r.Ctx.GinkgoTest.ResultPath
 		if !strings.HasPrefix(resultPath, "/") {
 			resultPath = filepath.Join(r.ActiveWorkspace, resultPath)
@@ -140,3 +140,31 @@ func TestReaperMaskSecretEnvs(t *testing.T) {
 	out := r.maskSecretEnvs(fmt.Sprintf("%s=%s", secretEnvKey, secretEnvVal))
 	assert.Equal(t, fmt.Sprintf("%s=%s", secretEnvKey, secretEnvMask), out)
 }
+
+func TestDockerBuildxCmd(t *testing.T) {
+	buildCtx := &meta.DockerBuildCtx{
+		ImageName:      "xxx.com/ns/app:20220101-master",
+		BuildArgs:      "--build-arg  A=1",
+		EnableBuildkit: true,
+		Platforms:      []string{"linux/amd64", "linux/arm64"},
+		RemoteCache:    true,
+	}
+
+	cmd := dockerBuildxCmd(buildCtx, ".", false)
+	assert.Equal(t, "docker buildx build --rm=true --push --platform linux/amd64,linux/arm64"+
+		" --cache-from type=registry,ref=xxx.com/ns/app:buildcache --cache-to type=registry,ref=xxx.com/ns/app:buildcache,mode=max"+
+		" --build-arg A=1 -t xxx.com/ns/app:20220101-master -f Dockerfile .", cmd.Args[2])
+
+	cmd = dockerBuildxCmd(buildCtx, ".", true)
+	assert.NotContains(t, cmd.Args[2], "--cache-from")
+	assert.Contains(t, cmd.Args[2], "--no-cache")
+	assert.Contains(t, cmd.Args[2], "--cache-to")
+}
+
+func TestImageSupplyChainCmds(t *testing.T) {
+	cmd := sbomCmd("xxx.com/ns/app:v1", "spdx-json", "/tmp/app-sbom.json")
+	assert.Equal(t, []string{syftExe, "registry:xxx.com/ns/app:v1", "-o", "spdx-json", "--file", "/tmp/app-sbom.json"}, cmd.Args)
+
+	cmd = cosignSignCmd("xxx.com/ns/app:v1", "/tmp/cosign.key")
+	assert.Equal(t, []string{cosignExe, "sign", "--key", "/tmp/cosign.key", "--tlog-upload=false", "--yes", "xxx.com/ns/app:v1"}, cmd.Args)
+}
@@ -280,6 +280,8 @@ func (r *Reaper) RunPostScripts() error {
 	if len(r.Ctx.PostScripts) == 0 {
 		return nil
 	}
+	log.Info("Executing Post Scripts.")
+
 	scripts := make([]string, 0, len(r.Ctx.PostScripts)+1)
 	scripts = append(scripts, "echo \"----------------------以下是Shell脚本执行日志----------------------\"\n")
 	scripts = append(scripts, r.Ctx.PostScripts...)
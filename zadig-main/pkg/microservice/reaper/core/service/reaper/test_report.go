@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+const (
+	CoverageFormatCobertura = "cobertura"
+	CoverageFormatLCOV      = "lcov"
+)
+
+// xmlRootElement 返回 xml 文档根节点名称，用于区分 JUnit 测试报告和 Cobertura 覆盖率报告
+func xmlRootElement(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// goTestEvent go test -json 输出的单行事件
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// parseGoTestJSON 解析 go test -json 的输出，只统计具体的测试用例，忽略 package 级别的事件
+func parseGoTestJSON(data []byte) ([]meta.TestCase, error) {
+	var (
+		testCases []meta.TestCase
+		indexes   = make(map[string]int)
+		outputs   = make(map[string]*strings.Builder)
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		event := new(goTestEvent)
+		if err := json.Unmarshal(line, event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal go test event: %s", err)
+		}
+		if event.Test == "" {
+			continue
+		}
+
+		key := event.Package + "/" + event.Test
+		if _, ok := indexes[key]; !ok {
+			indexes[key] = len(testCases)
+			outputs[key] = &strings.Builder{}
+			testCases = append(testCases, meta.TestCase{Name: event.Test, ClassName: event.Package})
+		}
+		testCase := &testCases[indexes[key]]
+
+		switch event.Action {
+		case "output":
+			outputs[key].WriteString(event.Output)
+		case "pass":
+			testCase.Time = event.Elapsed
+		case "skip":
+			testCase.Time = event.Elapsed
+			testCase.Skipped = &meta.Skipped{}
+		case "fail":
+			testCase.Time = event.Elapsed
+			testCase.Failure = &meta.Failure{Message: "Failed", Text: outputs[key].String()}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return testCases, nil
+}
+
+var tapResultRegexp = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*-?\s*([^#]*)(#\s*(.*))?$`)
+
+// parseTAP 解析 TAP(Test Anything Protocol) 格式的测试结果，SKIP 和 TODO 指令的用例视为跳过
+func parseTAP(data []byte) ([]meta.TestCase, error) {
+	var (
+		testCases  []meta.TestCase
+		inYAML     bool
+		diagnostic strings.Builder
+	)
+
+	// 失败用例后面的 yaml 诊断信息追加到失败详情中
+	flushDiagnostic := func() {
+		if len(testCases) > 0 && testCases[len(testCases)-1].Failure != nil && diagnostic.Len() > 0 {
+			testCases[len(testCases)-1].Failure.Text = diagnostic.String()
+		}
+		diagnostic.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+				flushDiagnostic()
+				continue
+			}
+			diagnostic.WriteString(trimmed)
+			diagnostic.WriteString("\n")
+			continue
+		}
+		if trimmed == "---" && len(testCases) > 0 {
+			inYAML = true
+			continue
+		}
+
+		matches := tapResultRegexp.FindStringSubmatch(trimmed)
+		if matches == nil {
+			continue
+		}
+
+		testCase := meta.TestCase{Name: strings.TrimSpace(matches[3])}
+		if testCase.Name == "" {
+			testCase.Name = "test " + matches[2]
+		}
+		directive := strings.ToUpper(strings.TrimSpace(matches[5]))
+		switch {
+		case strings.HasPrefix(directive, "SKIP"), strings.HasPrefix(directive, "TODO"):
+			testCase.Skipped = &meta.Skipped{}
+		case matches[1] == "not ok":
+			testCase.Failure = &meta.Failure{Message: "not ok", Text: trimmed}
+		}
+		testCases = append(testCases, testCase)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flushDiagnostic()
+
+	return testCases, nil
+}
+
+// coberturaReport Cobertura 报告，旧版本的报告根节点没有行数统计，需要从 line 节点计算
+type coberturaReport struct {
+	LineRate        float64 `xml:"line-rate,attr"`
+	LinesCovered    int     `xml:"lines-covered,attr"`
+	LinesValid      int     `xml:"lines-valid,attr"`
+	BranchRate      float64 `xml:"branch-rate,attr"`
+	BranchesCovered int     `xml:"branches-covered,attr"`
+	BranchesValid   int     `xml:"branches-valid,attr"`
+	Packages        []struct {
+		Classes []struct {
+			Lines []struct {
+				Hits              int    `xml:"hits,attr"`
+				Branch            bool   `xml:"branch,attr"`
+				ConditionCoverage string `xml:"condition-coverage,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+var conditionCoverageRegexp = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+func parseCoberturaCoverage(data []byte) (*meta.CoverageSummary, error) {
+	report := new(coberturaReport)
+	if err := xml.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+
+	summary := &meta.CoverageSummary{
+		Format:          CoverageFormatCobertura,
+		LinesCovered:    report.LinesCovered,
+		LinesValid:      report.LinesValid,
+		BranchesCovered: report.BranchesCovered,
+		BranchesValid:   report.BranchesValid,
+	}
+	if summary.LinesValid == 0 {
+		for _, pkg := range report.Packages {
+			for _, class := range pkg.Classes {
+				for _, line := range class.Lines {
+					summary.LinesValid++
+					if line.Hits > 0 {
+						summary.LinesCovered++
+					}
+					if !line.Branch {
+						continue
+					}
+					// condition-coverage="50% (1/2)"
+					if matches := conditionCoverageRegexp.FindStringSubmatch(line.ConditionCoverage); matches != nil {
+						covered, _ := strconv.Atoi(matches[1])
+						valid, _ := strconv.Atoi(matches[2])
+						summary.BranchesCovered += covered
+						summary.BranchesValid += valid
+					}
+				}
+			}
+		}
+	}
+	calculateCoverageRate(summary)
+	if summary.LinesValid == 0 {
+		// 没有任何行数信息时只能使用报告中的覆盖率
+		summary.LineRate = report.LineRate
+		summary.BranchRate = report.BranchRate
+	}
+
+	return summary, nil
+}
+
+// parseLCOVCoverage 汇总 lcov tracefile 中所有文件的 LF/LH/BRF/BRH 记录
+func parseLCOVCoverage(data []byte) (*meta.CoverageSummary, error) {
+	summary := &meta.CoverageSummary{Format: CoverageFormatLCOV}
+
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, ":"); idx > 0 {
+			value, convErr := strconv.Atoi(line[idx+1:])
+			if convErr == nil {
+				switch line[:idx] {
+				case "LF":
+					summary.LinesValid += value
+				case "LH":
+					summary.LinesCovered += value
+				case "BRF":
+					summary.BranchesValid += value
+				case "BRH":
+					summary.BranchesCovered += value
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	calculateCoverageRate(summary)
+
+	return summary, nil
+}
+
+func calculateCoverageRate(summary *meta.CoverageSummary) {
+	if summary.LinesValid > 0 {
+		summary.LineRate = float64(summary.LinesCovered) / float64(summary.LinesValid)
+	}
+	if summary.BranchesValid > 0 {
+		summary.BranchRate = float64(summary.BranchesCovered) / float64(summary.BranchesValid)
+	}
+}
+
+// mergeCoverage 合并多个覆盖率报告，按行数重新计算覆盖率
+func mergeCoverage(dst, src *meta.CoverageSummary) *meta.CoverageSummary {
+	if dst == nil {
+		return src
+	}
+	if src == nil {
+		return dst
+	}
+
+	if dst.Format != src.Format && !strings.Contains(dst.Format, src.Format) {
+		dst.Format = dst.Format + "," + src.Format
+	}
+	dst.LinesCovered += src.LinesCovered
+	dst.LinesValid += src.LinesValid
+	dst.BranchesCovered += src.BranchesCovered
+	dst.BranchesValid += src.BranchesValid
+	calculateCoverageRate(dst)
+	return dst
+}
+
+// addTestCases 按照 testsuites 的统计口径（总数包含跳过的用例）累加解析出的用例
+func addTestCases(summaryResult *meta.TestSuite, testCases []meta.TestCase) {
+	for _, tc := range testCases {
+		summaryResult.Tests++
+		switch {
+		case tc.Skipped != nil:
+			summaryResult.Skips++
+		case tc.Error != nil:
+			summaryResult.Errors++
+		case tc.Failure != nil:
+			summaryResult.Failures++
+		}
+	}
+	summaryResult.TestCases = append(summaryResult.TestCases, testCases...)
+	if summaryResult.SuiteType == "" {
+		summaryResult.SuiteType = ReploaceTestSuites
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+)
+
+func TestParseGoTestJSON(t *testing.T) {
+	data := []byte(`{"Action":"run","Package":"pkg/a","Test":"TestPass"}
+{"Action":"output","Package":"pkg/a","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"pass","Package":"pkg/a","Test":"TestPass","Elapsed":0.5}
+{"Action":"run","Package":"pkg/a","Test":"TestFail"}
+{"Action":"output","Package":"pkg/a","Test":"TestFail","Output":"a_test.go:10: boom\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestFail","Elapsed":0.1}
+{"Action":"skip","Package":"pkg/a","Test":"TestSkip","Elapsed":0}
+{"Action":"fail","Package":"pkg/a","Elapsed":0.7}
+`)
+	testCases, err := parseGoTestJSON(data)
+	assert.Nil(t, err)
+	assert.Len(t, testCases, 3)
+	assert.Equal(t, "TestPass", testCases[0].Name)
+	assert.Equal(t, "pkg/a", testCases[0].ClassName)
+	assert.Equal(t, 0.5, testCases[0].Time)
+	assert.Nil(t, testCases[0].Failure)
+	assert.NotNil(t, testCases[1].Failure)
+	assert.Contains(t, testCases[1].Failure.Text, "boom")
+	assert.NotNil(t, testCases[2].Skipped)
+}
+
+func TestParseTAP(t *testing.T) {
+	data := []byte(`TAP version 13
+1..4
+ok 1 - first
+not ok 2 - second
+  ---
+  message: expected 1
+  ...
+ok 3 - third # SKIP not supported
+not ok 4 # TODO later
+`)
+	testCases, err := parseTAP(data)
+	assert.Nil(t, err)
+	assert.Len(t, testCases, 4)
+	assert.Equal(t, "first", testCases[0].Name)
+	assert.Nil(t, testCases[0].Failure)
+	assert.NotNil(t, testCases[1].Failure)
+	assert.Contains(t, testCases[1].Failure.Text, "expected 1")
+	assert.NotNil(t, testCases[2].Skipped)
+	assert.Equal(t, "test 4", testCases[3].Name)
+	assert.NotNil(t, testCases[3].Skipped)
+	assert.Nil(t, testCases[3].Failure)
+}
+
+func TestParseCoberturaCoverage(t *testing.T) {
+	data := []byte(`<?xml version="1.0" ?>
+<coverage line-rate="0.5" lines-covered="5" lines-valid="10" branch-rate="0.25" branches-covered="1" branches-valid="4" version="1.9"></coverage>`)
+	assert.Equal(t, "coverage", xmlRootElement(data))
+	coverage, err := parseCoberturaCoverage(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, coverage.LinesCovered)
+	assert.Equal(t, 10, coverage.LinesValid)
+	assert.Equal(t, 0.5, coverage.LineRate)
+	assert.Equal(t, 0.25, coverage.BranchRate)
+
+	// 旧版本报告只有 line 节点
+	data = []byte(`<coverage line-rate="0.67"><packages><package><classes><class><lines>
+<line number="1" hits="1"/>
+<line number="2" hits="0"/>
+<line number="3" hits="2" branch="true" condition-coverage="50% (1/2)"/>
+</lines></class></classes></package></packages></coverage>`)
+	coverage, err = parseCoberturaCoverage(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, coverage.LinesCovered)
+	assert.Equal(t, 3, coverage.LinesValid)
+	assert.Equal(t, 1, coverage.BranchesCovered)
+	assert.Equal(t, 2, coverage.BranchesValid)
+}
+
+func TestParseLCOVCoverage(t *testing.T) {
+	data := []byte(`TN:
+SF:src/a.js
+DA:1,1
+LF:4
+LH:3
+BRF:2
+BRH:1
+end_of_record
+SF:src/b.js
+LF:6
+LH:3
+end_of_record`)
+	coverage, err := parseLCOVCoverage(data)
+	assert.Nil(t, err)
+	assert.Equal(t, CoverageFormatLCOV, coverage.Format)
+	assert.Equal(t, 6, coverage.LinesCovered)
+	assert.Equal(t, 10, coverage.LinesValid)
+	assert.Equal(t, 0.6, coverage.LineRate)
+	assert.Equal(t, 0.5, coverage.BranchRate)
+
+	merged := mergeCoverage(coverage, &meta.CoverageSummary{Format: CoverageFormatCobertura, LinesCovered: 4, LinesValid: 10})
+	assert.Equal(t, "lcov,cobertura", merged.Format)
+	assert.Equal(t, 0.5, merged.LineRate)
+}
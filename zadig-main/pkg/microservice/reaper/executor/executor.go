@@ -17,14 +17,20 @@ limitations under the License.
 package executor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	commonconfig "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/reaper"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
@@ -39,11 +45,16 @@ func Execute() error {
 	start := time.Now()
 	log.Info("====================== Build Start ======================")
 
-	var err error
+	var (
+		err        error
+		testFailed bool
+	)
 	defer func() {
 		// Create dog food file to tell wd that task has finished.
 		resultMsg := types.JobSuccess
-		if err != nil {
+		if testFailed {
+			resultMsg = types.JobTestFail
+		} else if err != nil {
 			resultMsg = types.JobFail
 		}
 		log.Infof("Job Status: %s", resultMsg)
@@ -68,17 +79,47 @@ func Execute() error {
 		return fmt.Errorf("failed to new reaper: %s", err)
 	}
 
-	if err = r.BeforeExec(); err != nil {
+	// 构建的 span 挂在 warpdrive 子任务的 span 下, 需要在等待退出前上报
+	shutdownTracing, tracingErr := tracing.Init("reaper", r.Ctx.OTLPEndpoint)
+	if tracingErr != nil {
+		log.Warnf("Failed to init tracing: %s", tracingErr)
+	} else {
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Warnf("Failed to flush spans: %s", err)
+			}
+		}()
+	}
+
+	var span trace.Span
+	r.TraceCtx, span = tracing.Start(tracing.Extract(context.Background(), r.Ctx.TraceContext), "Reaper", trace.WithAttributes(
+		attribute.String("zadig.pipeline", r.Ctx.PipelineName),
+		attribute.Int64("zadig.task_id", r.Ctx.TaskID),
+		attribute.String("zadig.service", r.Ctx.ServiceName),
+	))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	if err = r.TraceStep("BeforeExec", r.BeforeExec); err != nil {
 		return fmt.Errorf("failed to prepare before building: %s", err)
 	}
 
 	if r.Ctx.ArtifactInfo == nil {
 		if err = r.Exec(); err != nil {
-			return fmt.Errorf("failed to build: %s", err)
+			if !errors.Is(err, reaper.ErrTestScriptFailed) {
+				return fmt.Errorf("failed to build: %s", err)
+			}
+			// 测试脚本失败时仍然归档测试结果，warpdrive 根据测试报告判断失败的用例是否都已被隔离
+			if afterErr := r.TraceStep("AfterExec", r.AfterExec); afterErr != nil {
+				return fmt.Errorf("%s, failed to work after testing: %s", err, afterErr)
+			}
+			testFailed = true
+			return err
 		}
 	}
 
-	if err = r.AfterExec(); err != nil {
+	if err = r.TraceStep("AfterExec", r.AfterExec); err != nil {
 		return fmt.Errorf("failed to work after building: %s", err)
 	}
 
@@ -30,6 +30,14 @@ func WarpDrivePodName() string {
 	return viper.GetString(setting.WarpDrivePodName)
 }
 
+// TaskConcurrency is the max number of pipeline tasks a warpdrive instance runs at the same time
+func TaskConcurrency() int {
+	if c := viper.GetInt(setting.WarpDriveTaskConcurrency); c > 0 {
+		return c
+	}
+	return 1
+}
+
 func NSQLookupAddrs() []string {
 	return strings.Split(viper.GetString(setting.ENVNsqLookupAddrs), ",")
 }
@@ -37,6 +37,8 @@ const (
 	TaskTrigger         TaskType = "trigger"
 	TaskExtension       TaskType = "extension"
 	TaskArtifactPackage TaskType = "artifact_package"
+	TaskExternalPlugin  TaskType = "external_plugin"
+	TaskApproval        TaskType = "approval"
 )
 
 type Status string
@@ -130,3 +132,14 @@ const (
 
 	APIServer = "https://api.github.com/"
 )
+
+// 漏洞门禁不通过时的处理方式
+const (
+	SecurityPolicyActionFail = "fail"
+	SecurityPolicyActionWarn = "warn"
+)
+
+// 镜像漏洞扫描后端
+const (
+	ScannerClair = "clair"
+)
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskcontroller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var (
+	pluginDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "warpdrive",
+		Name:      "plugin_duration_seconds",
+		Help:      "Execution time of task plugins, partitioned by plugin type and final status.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	}, []string{"plugin", "status"})
+
+	runningTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "warpdrive",
+		Name:      "running_tasks",
+		Help:      "Number of pipeline tasks running in this warpdrive instance.",
+	})
+)
+
+func observePluginDuration(plugin config.TaskType, status config.Status, start time.Time) {
+	pluginDuration.WithLabelValues(string(plugin), string(status)).Observe(time.Since(start).Seconds())
+}
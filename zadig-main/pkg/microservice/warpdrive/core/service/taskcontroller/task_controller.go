@@ -78,7 +78,8 @@ func InitTaskController(ctx context.Context) error {
 	}
 
 	execHandler := &ExecHandler{
-		Sender: sender,
+		Sender:      sender,
+		Concurrency: config.TaskConcurrency(),
 	}
 
 	processor.AddHandler(execHandler)
@@ -86,7 +87,9 @@ func InitTaskController(ctx context.Context) error {
 	//Add task plugin initiators to exec Handler
 	initTaskPlugins(execHandler)
 
-	cancelHandler := &CancelHandler{}
+	cancelHandler := &CancelHandler{
+		ExecHandler: execHandler,
+	}
 
 	canceller.AddHandler(cancelHandler)
 
@@ -21,10 +21,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nsqio/go-nsq"
 	uuid "github.com/satori/go.uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
@@ -34,26 +37,99 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/util/rand"
 )
 
-var (
+// Sender: sender to send ack/notification
+// TaskPlugins: registered task plugin initiators to initiate specific plugin to execute task
+// Concurrency: max number of pipeline tasks executed in parallel by this warpdrive instance
+type ExecHandler struct {
+	Sender      *nsq.Producer
+	TaskPlugins map[config.TaskType]plugins.Initiator
+	Concurrency int
+
+	mu         sync.Mutex
+	executions map[string]*taskExecution
+}
+
+// taskExecution holds everything a single running pipeline task needs,
+// so that several tasks can be executed by one warpdrive instance in isolation.
+type taskExecution struct {
+	handler *ExecHandler
+
 	ctx          context.Context
 	cancel       context.CancelFunc
 	pipelineTask *task.Task
 	pipelineCtx  *task.PipelineCtx
-	itReport     *types.ItReport
 	xl           *zap.SugaredLogger
-)
+}
 
-// Sender: sender to send ack/notification
-// TaskPlugins: registered task plugin initiators to initiate specific plugin to execute task
-type ExecHandler struct {
-	Sender      *nsq.Producer
-	TaskPlugins map[config.TaskType]plugins.Initiator
+type CancelHandler struct {
+	ExecHandler *ExecHandler
 }
 
-type CancelHandler struct{}
+func executionKey(pipelineName string, taskID int64) string {
+	return fmt.Sprintf("%s:%d", pipelineName, taskID)
+}
+
+// RunningTaskCount returns the number of pipeline tasks running in this warpdrive instance
+func (h *ExecHandler) RunningTaskCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.executions)
+}
+
+// acquire registers the execution if there is a free slot, returns false if the instance is full
+// or the same pipeline task is already running in this instance
+func (h *ExecHandler) acquire(e *taskExecution) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.executions == nil {
+		h.executions = make(map[string]*taskExecution)
+	}
+
+	concurrency := h.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	key := executionKey(e.pipelineTask.PipelineName, e.pipelineTask.TaskID)
+	if _, ok := h.executions[key]; ok || len(h.executions) >= concurrency {
+		return false
+	}
+
+	h.executions[key] = e
+	runningTasks.Set(float64(len(h.executions)))
+	return true
+}
+
+func (h *ExecHandler) release(e *taskExecution) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.executions, executionKey(e.pipelineTask.PipelineName, e.pipelineTask.TaskID))
+	runningTasks.Set(float64(len(h.executions)))
+}
+
+// Cancel cancels the running pipeline task, returns false if the task is not running in this instance
+func (h *ExecHandler) Cancel(pipelineName string, taskID int64, revoker string) bool {
+	h.mu.Lock()
+	e, ok := h.executions[executionKey(pipelineName, taskID)]
+	h.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	e.pipelineTask.RwLock.Lock()
+	e.pipelineTask.TaskRevoker = revoker
+	e.pipelineTask.RwLock.Unlock()
+
+	e.cancel()
+	return true
+}
 
 // Message handler to handle task execution message
 func (h *ExecHandler) HandleMessage(message *nsq.Message) error {
@@ -62,36 +138,53 @@ func (h *ExecHandler) HandleMessage(message *nsq.Message) error {
 		time.Sleep(time.Second * 10)
 	}()
 
-	xl = log.SugaredLogger()
-
-	// 如果存在运行中的 PipelineTask, 则重新requeue pipeline task
-	// task处理逻辑全部放在requeue之后，防止requeue影响正在运行的task
-	if pipelineTask != nil {
-		xl.Infof("warpdrive instance have one running pipeline task %s:%d", pipelineTask.PipelineName, pipelineTask.TaskID)
-		message.Requeue(time.Millisecond * 100)
-		return nil
-	}
+	xl := log.SugaredLogger()
 
 	// 获取 PipelineTask 内容
+	var pipelineTask *task.Task
 	if err := json.Unmarshal(message.Body, &pipelineTask); err != nil {
 		xl.Errorf("unmarshal PipelineTask error: %v", err)
 		return nil
 	}
 	xl.Infof("receiving pipeline task %s:%d message", pipelineTask.PipelineName, pipelineTask.TaskID)
 
-	// xl - global logger
-	xl = Logger(pipelineTask)
-
 	// 初始化 Context, CancelFunc, PipelineTask
-	ctx, cancel = context.WithCancel(context.Background())
+	// Context 中带有 aslan 创建任务时生成的 trace 上下文
+	ctx, cancel := context.WithCancel(tracing.Extract(context.Background(), pipelineTask.TraceContext))
+	e := &taskExecution{
+		handler:      h,
+		ctx:          ctx,
+		cancel:       cancel,
+		pipelineTask: pipelineTask,
+		xl:           Logger(pipelineTask),
+	}
+
+	// 如果运行中的 PipelineTask 已达到并发上限, 则重新requeue pipeline task
+	if !h.acquire(e) {
+		cancel()
+		xl.Infof("warpdrive instance have %d running pipeline tasks, requeue %s:%d", h.RunningTaskCount(), pipelineTask.PipelineName, pipelineTask.TaskID)
+		message.Requeue(time.Millisecond * 100)
+		return nil
+	}
 
-	go h.runPipelineTask(ctx, cancel, xl)
+	go e.run()
 	return nil
 }
 
-func (h *ExecHandler) runPipelineTask(ctx context.Context, cancel context.CancelFunc, xl *zap.SugaredLogger) {
+func (e *taskExecution) run() {
+	pipelineTask := e.pipelineTask
+	xl := e.xl
+
+	var span trace.Span
+	e.ctx, span = tracing.Start(e.ctx, "ExecuteTask", trace.WithAttributes(
+		attribute.String("zadig.pipeline", pipelineTask.PipelineName),
+		attribute.Int64("zadig.task_id", pipelineTask.TaskID),
+		attribute.String("zadig.task_type", string(pipelineTask.Type)),
+	))
+
 	defer func() {
-		h.SendNotification()
+		endSpan(span, pipelineTask.Status)
+		e.sendNotification()
 
 		if pipelineTask.Type == config.SingleType || pipelineTask.Type == config.WorkflowType {
 			xl.Infof("Pipeline completeGitCheck %s:%d:%s", pipelineTask.PipelineName, pipelineTask.TaskID, pipelineTask.Status)
@@ -100,13 +193,11 @@ func (h *ExecHandler) runPipelineTask(ctx context.Context, cancel context.Cancel
 			}
 		}
 
-		h.SendAck()
+		e.sendAck()
 
-		// 重置 task/itrepot 防止新的task Unmarshal到上次内容
-		pipelineTask = nil
-		pipelineCtx = nil
-		itReport = nil
-		xl.Info("Pipeline task all done, tear down vars.")
+		e.cancel()
+		e.handler.release(e)
+		xl.Info("Pipeline task all done, release execution slot.")
 	}()
 
 	// Step 1.1 - 检查配置，如果配置为空，则结束此次Task执行
@@ -136,14 +227,14 @@ func (h *ExecHandler) runPipelineTask(ctx context.Context, cancel context.Cancel
 		return
 	}
 
-	// Step 2 - 初始化pipeline task执行全局的Context
+	// Step 2 - 初始化pipeline task执行的Context
 	// PipelineCtx -
 	// DockerHost: to config job when pod need to set docker daemon socket option
 	// Workspace: Pipeline workspace
 	// DistDir: pipeline distribute dir
 	// DockerMountDir: docker mount dir
 	// ConfigMapMountDir: config map mount dir
-	pipelineCtx = &task.PipelineCtx{
+	e.pipelineCtx = &task.PipelineCtx{
 		DockerHost:        dockerHost,
 		Workspace:         fmt.Sprintf("%s/%s", pipelineTask.ConfigPayload.S3Storage.Path, pipelineTask.PipelineName),
 		DistDir:           fmt.Sprintf("%s/%s/dist/%d", pipelineTask.ConfigPayload.S3Storage.Path, pipelineTask.PipelineName, pipelineTask.TaskID),
@@ -156,18 +247,18 @@ func (h *ExecHandler) runPipelineTask(ctx context.Context, cancel context.Cancel
 	xl.Infof("start to run pipeline task %s:%d ......", pipelineTask.PipelineName, pipelineTask.TaskID)
 	initPipelineTask(pipelineTask, xl)
 	// 发送初始状态ACK给backend，更新pipeline状态
-	h.SendAck()
-	h.SendNotification()
+	e.sendAck()
+	e.sendNotification()
 
 	// Step 3 - pipelineTask执行，真的开始了...
-	h.execute(ctx, pipelineTask, pipelineCtx, xl)
+	e.execute()
 
 	// Return 之前会执行defer内容，更新pipeline end time, 发送ACK，发送notification
 }
 
 // HandleMessage ...
 func (h *CancelHandler) HandleMessage(message *nsq.Message) error {
-	xl = Logger(pipelineTask)
+	xl := log.SugaredLogger()
 
 	// 获取 cancel message
 	var msg *CancelMessage
@@ -179,13 +270,8 @@ func (h *CancelHandler) HandleMessage(message *nsq.Message) error {
 	xl.Infof("receiving cancel task %s:%d message", msg.PipelineName, msg.TaskID)
 
 	// 如果存在处理的 PipelineTask 并且匹配 PipelineName, 则取消PipelineTask
-	if pipelineTask != nil && pipelineTask.PipelineName == msg.PipelineName && pipelineTask.TaskID == msg.TaskID {
+	if h.ExecHandler.Cancel(msg.PipelineName, msg.TaskID, msg.Revoker) {
 		xl.Infof("cancelling message: %+v", msg)
-		pipelineTask.TaskRevoker = msg.Revoker
-
-		//取消pipelineTask
-		cancel()
-		//xl.Debug("no matched pipeline task found on this warpdrive")
 	}
 	return nil
 }
@@ -194,10 +280,10 @@ func (h *CancelHandler) HandleMessage(message *nsq.Message) error {
 // helper functions
 // ----------------------------------------------------------------------------------------------
 
-// SendAck 发送task实时状态信息
+// sendAck 发送task实时状态信息
 // 无需发送cancel信息
-func (h *ExecHandler) SendAck() {
-	xl = Logger(pipelineTask)
+func (e *taskExecution) sendAck() {
+	pipelineTask, xl := e.pipelineTask, e.xl
 	pb, err := func() ([]byte, error) {
 		pipelineTask.RwLock.Lock()
 		defer pipelineTask.RwLock.Unlock()
@@ -217,28 +303,15 @@ func (h *ExecHandler) SendAck() {
 	//DEBUG ONLY
 	xl.Infof("Sending ACK: %#v", pipelineTask)
 
-	if err := h.Sender.Publish(setting.TopicAck, pb); err != nil {
+	if err := e.handler.Sender.Publish(setting.TopicAck, pb); err != nil {
 		xl.Errorf("publish [%s] error: %v", setting.TopicAck, err)
 		return
 	}
 }
 
-// SendItReport ...
-func (h *ExecHandler) SendItReport() {
-	pb, err := json.Marshal(&itReport)
-	if err != nil {
-		xl.Errorf("marshal itReport error: %v", err)
-		return
-	}
-
-	if err := h.Sender.Publish(setting.TopicItReport, pb); err != nil {
-		xl.Errorf("publish [%s] error: %v", setting.TopicItReport, err)
-		return
-	}
-}
-
-// SendNotification ...
-func (h *ExecHandler) SendNotification() {
+// sendNotification ...
+func (e *taskExecution) sendNotification() {
+	pipelineTask, xl := e.pipelineTask, e.xl
 	notify := &types.Notify{
 		Type:     config.PipelineStatus,
 		Receiver: pipelineTask.TaskCreator,
@@ -261,15 +334,16 @@ func (h *ExecHandler) SendNotification() {
 		return
 	}
 
-	if err := h.Sender.Publish(setting.TopicNotification, nb); err != nil {
+	if err := e.handler.Sender.Publish(setting.TopicNotification, nb); err != nil {
 		xl.Errorf("publish [%s] error: %v", setting.TopicNotification, err)
 		return
 	}
 }
 
-func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurrency int64) {
+func (e *taskExecution) runStage(stagePosition int, stage *common.Stage, concurrency int64) {
+	pipelineTask, xl := e.pipelineTask, e.xl
 	xl.Infof("start to execute pipeline stage: %s at position: %d", stage.TaskType, stagePosition)
-	pluginInitiator, ok := h.TaskPlugins[stage.TaskType]
+	pluginInitiator, ok := e.handler.TaskPlugins[stage.TaskType]
 	if !ok {
 		xl.Errorf("Error to find plugin initiator to init task plugin of type %s", stage.TaskType)
 		return
@@ -278,7 +352,7 @@ func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurren
 	xl.Info("start to init worker pool for execute tasks in stage")
 	// 初始化stage status为running
 	updatePipelineStageStatus(config.StatusRunning, pipelineTask, stagePosition, xl)
-	h.SendAck()
+	e.sendAck()
 	// runParallel: Stage内部是否支持并发
 	runParallel := stage.RunParallel
 	// Default worker concurrency is 1, run tasks sequentially
@@ -293,6 +367,8 @@ func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurren
 	}
 	xl.Infof("set worker concurrency to: %d", workerConcurrency)
 
+	stageCtx, span := tracing.Start(e.ctx, fmt.Sprintf("Stage %s", stage.TaskType), trace.WithAttributes(attribute.Int("zadig.stage", stagePosition)))
+
 	// Task is struct for worker
 	var tasks []*Task
 
@@ -302,7 +378,7 @@ func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurren
 		xl.Infof("new sub task of service name: %s, type: %s", serviceName, stage.TaskType)
 		pluginInstance = pluginInitiator(stage.TaskType)
 		//xl.Errorf("%v", ctx.Value(CtxKeyBuildInfos))
-		tasks = append(tasks, NewTask(ctx, h.executeTask, pluginInstance, subTask, stagePosition, serviceName, xl))
+		tasks = append(tasks, NewTask(stageCtx, e.executeTask, pluginInstance, subTask, stagePosition, serviceName, xl))
 	}
 	// 判断subTask是否是deploy，如果是的话判断是否是helm类型的服务，
 	//todo helm类型的服务的部署暂时只支持串行执行
@@ -324,15 +400,17 @@ func (h *ExecHandler) runStage(stagePosition int, stage *common.Stage, concurren
 	stageStatus := getStageStatus(workerPool.Tasks, xl)
 	xl.Infof("aggregated stage status of stage %d with type %s is: %s", stagePosition, stage.TaskType, stageStatus)
 	stage.Status = stageStatus
+	endSpan(span, stage.Status)
 	// 更新Stage状态
 	updatePipelineStageStatus(stage.Status, pipelineTask, stagePosition, xl)
-	h.SendAck()
+	e.sendAck()
 }
 
 // execute: PipelineTask Executor
 // 兼容支持1.0和2.0的数据结构
 // 支持根据RunParallel参数指定的并发或串行执行
-func (h *ExecHandler) execute(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, xl *zap.SugaredLogger) {
+func (e *taskExecution) execute() {
+	pipelineTask, xl := e.pipelineTask, e.xl
 	xl.Info("start pipeline task executor...")
 	// 如果是pipeline 1.0， 先将subtasks进行transform，转化为stages结构
 	if pipelineTask.Type == config.SingleType || pipelineTask.Type == "" || pipelineTask.Type == config.WorkflowTypeV3 {
@@ -348,7 +426,7 @@ func (h *ExecHandler) execute(ctx context.Context, pipelineTask *task.Task, pipe
 	// Stage之间仅支持串行
 	for stagePosition, stage := range pipelineTask.Stages {
 		if !stage.AfterAll {
-			h.runStage(stagePosition, stage, pipelineTask.ConfigPayload.BuildConcurrency)
+			e.runStage(stagePosition, stage, pipelineTask.ConfigPayload.BuildConcurrency)
 			// 如果一个Stage执行失败了，跳出执行循环，并且更新pipelinetask状态为失败，发送ACK，并返回
 			if stage.Status == config.StatusFailed || stage.Status == config.StatusCancelled || stage.Status == config.StatusTimeout {
 				break
@@ -379,20 +457,21 @@ func (h *ExecHandler) execute(ctx context.Context, pipelineTask *task.Task, pipe
 					}
 				}
 			}
-			h.runStage(stagePosition, stage, pipelineTask.ConfigPayload.BuildConcurrency)
+			e.runStage(stagePosition, stage, pipelineTask.ConfigPayload.BuildConcurrency)
 		}
 	}
 
 	// 根据stage status汇总pipeline task状态，并且更新pipeline状态，发送ACK
 	updatePipelineStatus(pipelineTask, xl)
-	h.SendAck()
+	e.sendAck()
 }
 
 // executeTask
 // 执行单个subtask，并将subtask执行状态更新到pipelineTask中
 // 返回Task状态+Error，Task Status将在Stage Level进行Aggregation到Stage Status
 // SubTask终止状态包括：disabled, passed, skipped, timeout, failed, cancelled.
-func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPlugin, subTask map[string]interface{}, pos int, servicename string, xl *zap.SugaredLogger) (config.Status, error) {
+func (e *taskExecution) executeTask(taskCtx context.Context, plugin plugins.TaskPlugin, subTask map[string]interface{}, pos int, servicename string, xl *zap.SugaredLogger) (config.Status, error) {
+	pipelineTask, pipelineCtx := e.pipelineTask, e.pipelineCtx
 	//设置Plugin执行参数：JOBNAME; 设置plugin logger;设置plugin log文件名称
 	//e.g. build task JOBNAME = pipelinename-taskid-buildv2-bsonId
 	//e.g. build task FILENAME(singgle模式) = pipelinename-taskid-buildv2-servicename
@@ -469,16 +548,22 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 
 	// 设置 SubTask 开始时间
 	plugin.SetStartTime()
+	startTime := time.Now()
+
+	taskCtx, span := tracing.Start(taskCtx, string(plugin.Type()), trace.WithAttributes(attribute.String("zadig.service", servicename)))
+	defer func() {
+		endSpan(span, plugin.Status())
+	}()
 
 	// 清除上一次错误信息
 	plugin.ResetError()
 
 	updatePipelineSubTask(plugin.GetTask(), pipelineTask, pos, servicename, xl)
-	h.SendAck()
+	e.sendAck()
 
 	plugin.SetAckFunc(func() {
 		updatePipelineSubTask(plugin.GetTask(), pipelineTask, pos, servicename, xl)
-		h.SendAck()
+		e.sendAck()
 	})
 
 	xl.Info("start to call plugin.Run")
@@ -488,12 +573,13 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 		runCtx.Workspace = fmt.Sprintf("%s/%s", pipelineCtx.Workspace, servicename)
 	}
 	// 运行 SubTask, 如果需要异步，请在方法内实现
-	plugin.Run(ctx, pipelineTask, &runCtx, servicename)
+	plugin.Run(taskCtx, pipelineTask, &runCtx, servicename)
 
 	// 如果 SubTask 执行失败, 则不继续执行, 发送 Task 失败执行结果
 	// Failed, Timeout, Cancelled
 	if plugin.IsTaskFailed() {
 		plugin.SetEndTime()
+		observePluginDuration(plugin.Type(), plugin.Status(), startTime)
 		updatePipelineSubTask(plugin.GetTask(), pipelineTask, pos, servicename, xl)
 		return plugin.Status(), fmt.Errorf("pipeline task failed: task_handler:308")
 	}
@@ -502,24 +588,21 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 
 	// 等待完成前, 更新 SubTask 执行结果到 PipelineTask
 	updatePipelineSubTask(plugin.GetTask(), pipelineTask, pos, servicename, xl)
-	h.SendAck()
+	e.sendAck()
 
 	// 等待 SubTask 结束
 	xl.Infof("waiting %s task to complete ...", plugin.Type())
-	plugin.Wait(ctx)
+	plugin.Wait(taskCtx)
 	xl.Infof("task status: %s", plugin.Status())
 
-	plugin.Complete(ctx, pipelineTask, servicename)
+	plugin.Complete(taskCtx, pipelineTask, servicename)
 	xl.Infof("task status: %s", plugin.Status())
 
-	// XXX - TODO需要确认这里的逻辑是？
-	if itReport != nil {
-		h.SendItReport()
-	}
 	// 更新 SubTask 执行结果到 PipelineTask
 	plugin.SetEndTime()
+	observePluginDuration(plugin.Type(), plugin.Status(), startTime)
 	updatePipelineSubTask(plugin.GetTask(), pipelineTask, pos, servicename, xl)
-	h.SendAck()
+	e.sendAck()
 
 	xl.Infof("end sub task [%s:%s]", plugin.Type(), plugin.Status())
 	return plugin.Status(), nil
@@ -528,7 +611,7 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 func Logger(pipelineTask *task.Task) *zap.SugaredLogger {
 	l := log.Logger()
 	if pipelineTask != nil {
-		l.With(zap.String(setting.RequestID, pipelineTask.ReqID))
+		l = l.With(zap.String(setting.RequestID, pipelineTask.ReqID))
 	}
 
 	return l.Sugar()
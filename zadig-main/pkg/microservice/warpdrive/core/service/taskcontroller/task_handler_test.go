@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskcontroller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+)
+
+func newTestExecution(h *ExecHandler, pipelineName string, taskID int64) *taskExecution {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &taskExecution{
+		handler:      h,
+		ctx:          ctx,
+		cancel:       cancel,
+		pipelineTask: &task.Task{PipelineName: pipelineName, TaskID: taskID},
+	}
+}
+
+func TestExecHandlerConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	h := &ExecHandler{Concurrency: 2}
+
+	e1 := newTestExecution(h, "workflow-a", 1)
+	e2 := newTestExecution(h, "workflow-a", 2)
+	e3 := newTestExecution(h, "workflow-b", 1)
+
+	assert.True(h.acquire(e1))
+	assert.False(h.acquire(newTestExecution(h, "workflow-a", 1)))
+	assert.True(h.acquire(e2))
+	assert.False(h.acquire(e3))
+	assert.Equal(2, h.RunningTaskCount())
+
+	h.release(e1)
+	assert.True(h.acquire(e3))
+	assert.Equal(2, h.RunningTaskCount())
+}
+
+func TestExecHandlerCancel(t *testing.T) {
+	assert := assert.New(t)
+	h := &ExecHandler{}
+
+	e1 := newTestExecution(h, "workflow-a", 1)
+	e2 := newTestExecution(h, "workflow-a", 2)
+	assert.True(h.acquire(e1))
+	assert.False(h.acquire(e2))
+
+	assert.False(h.Cancel("workflow-a", 2, "admin"))
+	assert.True(h.Cancel("workflow-a", 1, "admin"))
+	assert.Equal("admin", e1.pipelineTask.TaskRevoker)
+	assert.Error(e1.ctx.Err())
+}
@@ -560,6 +560,8 @@ func initTaskPlugins(execHandler *ExecHandler) {
 		config.TaskTrigger:         plugins.InitializeTriggerTaskPlugin,
 		config.TaskArtifactPackage: plugins.InitializeArtifactPackagePlugin,
 		config.TaskExtension:       plugins.InitializeExtensionTaskPlugin,
+		config.TaskExternalPlugin:  plugins.InitializeExternalPluginTaskPlugin,
+		config.TaskApproval:        plugins.InitializeApprovalTaskPlugin,
 	}
 	for name, pluginInitiator := range pluginConf {
 		registerTaskPlugin(execHandler, name, pluginInitiator)
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskcontroller
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/tool/tracing"
+)
+
+// endSpan 记录任务的最终状态并结束 span, 失败, 超时和取消的任务标记为错误
+func endSpan(span trace.Span, status config.Status) {
+	span.SetAttributes(attribute.String("zadig.status", string(status)))
+
+	var err error
+	switch status {
+	case config.StatusFailed, config.StatusTimeout, config.StatusCancelled:
+		err = fmt.Errorf("task %s", status)
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	ApprovalTaskTimeout = 60 * 60 * 24 // 24 hours
+)
+
+// InitializeApprovalTaskPlugin to initialize approval task plugin, and return reference
+func InitializeApprovalTaskPlugin(taskType config.TaskType) TaskPlugin {
+	return &ApprovalTaskPlugin{
+		Name: taskType,
+	}
+}
+
+// ApprovalTaskPlugin blocks the pipeline in waiting status until the approval is resolved in aslan
+type ApprovalTaskPlugin struct {
+	Name         config.TaskType
+	JobName      string
+	FileName     string
+	Task         *task.Approval
+	Log          *zap.SugaredLogger
+	ack          func()
+	pipelineName string
+	taskID       int64
+}
+
+// workflowApproval is the approval record returned by aslan
+type workflowApproval struct {
+	Status    config.Status              `json:"status"`
+	Decisions []*models.ApprovalDecision `json:"decisions"`
+}
+
+func (p *ApprovalTaskPlugin) SetAckFunc(ack func()) {
+	p.ack = ack
+}
+
+// Init ...
+func (p *ApprovalTaskPlugin) Init(jobname, filename string, xl *zap.SugaredLogger) {
+	p.JobName = jobname
+	p.Log = xl
+	p.FileName = filename
+}
+
+func (p *ApprovalTaskPlugin) Type() config.TaskType {
+	return p.Name
+}
+
+// Status ...
+func (p *ApprovalTaskPlugin) Status() config.Status {
+	return p.Task.TaskStatus
+}
+
+// SetStatus ...
+func (p *ApprovalTaskPlugin) SetStatus(status config.Status) {
+	p.Task.TaskStatus = status
+}
+
+// TaskTimeout ...
+func (p *ApprovalTaskPlugin) TaskTimeout() int {
+	if p.Task.Timeout == 0 {
+		return ApprovalTaskTimeout
+	}
+	return p.Task.Timeout * 60
+}
+
+func (p *ApprovalTaskPlugin) approvalURL() string {
+	return fmt.Sprintf("/api/workflow/workflowtask/approval/id/%d/name/%s", p.taskID, p.pipelineName)
+}
+
+// Run opens the approval in aslan which notifies the approvers
+func (p *ApprovalTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	p.pipelineName = pipelineTask.PipelineName
+	p.taskID = pipelineTask.TaskID
+	p.Task.Decisions = nil
+
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+	if _, err := httpClient.Post(p.approvalURL()); err != nil {
+		p.Log.Errorf("failed to start approval of %s:%d: %s", p.pipelineName, p.taskID, err)
+		p.Task.TaskStatus = config.StatusFailed
+		p.Task.Error = err.Error()
+		return
+	}
+
+	p.Log.Infof("approval of %s:%d is waiting for %d approvers", p.pipelineName, p.taskID, len(p.Task.Approvers))
+	p.Task.TaskStatus = config.StatusWaiting
+}
+
+// Wait polls aslan until the approval is passed, rejected or timed out
+func (p *ApprovalTaskPlugin) Wait(ctx context.Context) {
+	if p.IsTaskDone() {
+		return
+	}
+	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.Task.TaskStatus = config.StatusCancelled
+			p.cancelApproval()
+			return
+		case <-timeout:
+			p.Task.TaskStatus = config.StatusTimeout
+			p.Task.Error = "approval timeout"
+			p.cancelApproval()
+			return
+		default:
+			time.Sleep(time.Second * 3)
+			approval, err := p.getApproval()
+			if err != nil {
+				p.Log.Warnf("failed to get approval of %s:%d: %s", p.pipelineName, p.taskID, err)
+				continue
+			}
+
+			if len(approval.Decisions) != len(p.Task.Decisions) {
+				p.Task.Decisions = approval.Decisions
+				if p.ack != nil {
+					p.ack()
+				}
+			}
+
+			switch approval.Status {
+			case config.StatusPassed:
+				p.Task.TaskStatus = config.StatusPassed
+				return
+			case config.StatusFailed:
+				p.Task.TaskStatus = config.StatusFailed
+				p.Task.Error = rejectedMessage(approval.Decisions)
+				return
+			case config.StatusTimeout:
+				p.Task.TaskStatus = config.StatusTimeout
+				p.Task.Error = "approval timeout"
+				return
+			}
+		}
+	}
+}
+
+func (p *ApprovalTaskPlugin) getApproval() (*workflowApproval, error) {
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	approval := new(workflowApproval)
+	if _, err := httpClient.Get(p.approvalURL(), httpclient.SetResult(approval)); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// cancelApproval closes the waiting approval in aslan so that it can not be approved after the stage ends
+func (p *ApprovalTaskPlugin) cancelApproval() {
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+	if _, err := httpClient.Delete(p.approvalURL()); err != nil {
+		p.Log.Errorf("failed to cancel approval of %s:%d: %s", p.pipelineName, p.taskID, err)
+	}
+}
+
+func rejectedMessage(decisions []*models.ApprovalDecision) string {
+	msgs := make([]string, 0)
+	for _, decision := range decisions {
+		if decision.Approved {
+			continue
+		}
+		msg := fmt.Sprintf("rejected by %s", decision.UserName)
+		if decision.Comment != "" {
+			msg = fmt.Sprintf("%s: %s", msg, decision.Comment)
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Complete ...
+func (p *ApprovalTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
+}
+
+// SetTask ...
+func (p *ApprovalTaskPlugin) SetTask(t map[string]interface{}) error {
+	task, err := ToApprovalTask(t)
+	if err != nil {
+		return err
+	}
+	p.Task = task
+	return nil
+}
+
+// GetTask ...
+func (p *ApprovalTaskPlugin) GetTask() interface{} {
+	return p.Task
+}
+
+// IsTaskDone ...
+func (p *ApprovalTaskPlugin) IsTaskDone() bool {
+	if p.Task.TaskStatus != config.StatusCreated && p.Task.TaskStatus != config.StatusRunning && p.Task.TaskStatus != config.StatusWaiting {
+		return true
+	}
+	return false
+}
+
+// IsTaskFailed ...
+func (p *ApprovalTaskPlugin) IsTaskFailed() bool {
+	if p.Task.TaskStatus == config.StatusFailed || p.Task.TaskStatus == config.StatusTimeout || p.Task.TaskStatus == config.StatusCancelled {
+		return true
+	}
+	return false
+}
+
+// SetStartTime ...
+func (p *ApprovalTaskPlugin) SetStartTime() {
+	p.Task.StartTime = time.Now().Unix()
+}
+
+// SetEndTime ...
+func (p *ApprovalTaskPlugin) SetEndTime() {
+	p.Task.EndTime = time.Now().Unix()
+}
+
+// IsTaskEnabled ...
+func (p *ApprovalTaskPlugin) IsTaskEnabled() bool {
+	return p.Task.Enabled
+}
+
+// ResetError ...
+func (p *ApprovalTaskPlugin) ResetError() {
+	p.Task.Error = ""
+}
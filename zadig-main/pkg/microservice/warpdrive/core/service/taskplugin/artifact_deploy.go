@@ -34,6 +34,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 const (
@@ -157,11 +158,12 @@ func (p *ArtifactDeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.T
 	}
 
 	jobCtx := JobCtxBuilder{
-		JobName:     p.JobName,
-		PipelineCtx: pipelineCtx,
-		ArchiveFile: p.Task.JobCtx.PackageFile,
-		JobCtx:      p.Task.JobCtx,
-		Installs:    p.Task.InstallCtx,
+		JobName:      p.JobName,
+		PipelineCtx:  pipelineCtx,
+		ArchiveFile:  p.Task.JobCtx.PackageFile,
+		JobCtx:       p.Task.JobCtx,
+		Installs:     p.Task.InstallCtx,
+		TraceContext: tracing.Inject(ctx),
 	}
 
 	if p.Task.BuildStatus == nil {
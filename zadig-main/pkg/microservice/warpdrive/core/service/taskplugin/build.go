@@ -37,6 +37,7 @@ import (
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
@@ -105,6 +106,27 @@ func (p *BuildTaskPlugin) SetBuildStatusCompleted(status config.Status) {
 
 //TODO: Binded Archive File logic
 func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	p.Task.Cached = false
+	p.Task.CacheKey = ""
+	// 缓存 Key 需要在注入任务相关的变量之前计算
+	if p.Task.ResultCacheEnable && !pipelineTask.ConfigPayload.ResetCache {
+		var envName string
+		if pipelineTask.WorkflowArgs != nil {
+			envName = pipelineTask.WorkflowArgs.Namespace
+		}
+		if key, ok := buildCacheKey(p.Task, envName); ok {
+			p.Task.CacheKey = key
+			if p.reuseBuildCache(pipelineTask) {
+				now := time.Now().Unix()
+				p.Task.Cached = true
+				p.Task.BuildStatus = &task.BuildStatus{StepStatus: task.StepStatus{StartTime: now, EndTime: now, Status: config.StatusPassed}}
+				p.Task.DockerBuildStatus = &task.DockerBuildStatus{StepStatus: task.StepStatus{StartTime: now, EndTime: now, Status: config.StatusPassed}}
+				p.Task.TaskStatus = config.StatusPassed
+				return
+			}
+		}
+	}
+
 	if p.Task.CacheEnable && !pipelineTask.ConfigPayload.ResetCache {
 		pipelineCtx.CacheEnable = true
 		pipelineCtx.Cache = p.Task.Cache
@@ -227,11 +249,12 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 	}
 
 	jobCtx := JobCtxBuilder{
-		JobName:     p.JobName,
-		PipelineCtx: pipelineCtx,
-		ArchiveFile: p.Task.JobCtx.PackageFile,
-		JobCtx:      p.Task.JobCtx,
-		Installs:    p.Task.InstallCtx,
+		JobName:      p.JobName,
+		PipelineCtx:  pipelineCtx,
+		ArchiveFile:  p.Task.JobCtx.PackageFile,
+		JobCtx:       p.Task.JobCtx,
+		Installs:     p.Task.InstallCtx,
+		TraceContext: tracing.Inject(ctx),
 	}
 
 	if p.Task.BuildStatus == nil {
@@ -242,7 +265,18 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 	p.Task.BuildStatus.StartTime = time.Now().Unix()
 	p.ack()
 
-	jobCtxBytes, err := yaml.Marshal(jobCtx.BuildReaperContext(pipelineTask, serviceName))
+	reaperCtx := jobCtx.BuildReaperContext(pipelineTask, serviceName)
+	if reaperCtx.DockerBuildCtx != nil && reaperCtx.DockerBuildCtx.SigningKey != nil {
+		if err := loadSigningKey(reaperCtx.DockerBuildCtx.SigningKey); err != nil {
+			p.Log.Error(err)
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = err.Error()
+			p.SetBuildStatusCompleted(config.StatusFailed)
+			return
+		}
+	}
+
+	jobCtxBytes, err := yaml.Marshal(reaperCtx)
 	if err != nil {
 		msg := fmt.Sprintf("cannot reaper.Context data: %v", err)
 		p.Log.Error(msg)
@@ -329,6 +363,9 @@ func (p *BuildTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipe
 }
 
 func (p *BuildTaskPlugin) Wait(ctx context.Context) {
+	if p.Task.Cached {
+		return
+	}
 	status := waitJobEndWithFile(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.Log)
 	p.SetBuildStatusCompleted(status)
 
@@ -358,6 +395,13 @@ func (p *BuildTaskPlugin) Wait(ctx context.Context) {
 }
 
 func (p *BuildTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
+	if p.Task.Cached {
+		return
+	}
+	if p.Task.CacheKey != "" && p.Task.TaskStatus == config.StatusPassed {
+		p.saveBuildCache(pipelineTask)
+	}
+
 	jobLabel := &JobLabel{
 		PipelineName: pipelineTask.PipelineName,
 		ServiceName:  serviceName,
@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+// volatileBuildEnvs 每次任务都会变化的系统变量，不参与编译缓存 Key 的计算
+var volatileBuildEnvs = sets.NewString("TASK_ID", "BUILD_URL", "DIST_DIR", "IMAGE", "PKG_FILE")
+
+// buildCache is the build result index stored in aslan
+type buildCache struct {
+	Key          string `json:"key"`
+	ProductName  string `json:"product_name"`
+	Service      string `json:"service"`
+	ServiceName  string `json:"service_name"`
+	PipelineName string `json:"pipeline_name"`
+	TaskID       int64  `json:"task_id"`
+	Image        string `json:"image,omitempty"`
+	PackageFile  string `json:"package_file,omitempty"`
+}
+
+// buildCacheSource 编译结果的全部输入，序列化后的哈希作为缓存 Key
+type buildCacheSource struct {
+	ProductName string               `json:"product_name"`
+	ServiceName string               `json:"service_name"`
+	Repos       []string             `json:"repos"`
+	BuildSteps  []*task.BuildStep    `json:"build_steps"`
+	PostScripts string               `json:"post_scripts"`
+	DockerBuild *task.DockerBuildCtx `json:"docker_build"`
+	Registry    string               `json:"registry"`
+	HasPackage  bool                 `json:"has_package"`
+	Envs        []string             `json:"envs"`
+	BuildOS     string               `json:"build_os"`
+	ImageFrom   string               `json:"image_from"`
+	Installs    []string             `json:"installs"`
+	EnvName     string               `json:"env_name"`
+}
+
+// buildCacheKey computes the content key of the build, it returns false if any repository is not pinned to a commit
+// or the image is signed or attached with an SBOM
+func buildCacheKey(t *task.Build, envName string) (string, bool) {
+	if len(t.JobCtx.Builds) == 0 {
+		return "", false
+	}
+
+	source := &buildCacheSource{
+		ProductName: t.ProductName,
+		ServiceName: t.ServiceName,
+		BuildSteps:  t.JobCtx.BuildSteps,
+		PostScripts: t.JobCtx.PostScripts,
+		HasPackage:  t.JobCtx.FileArchiveCtx != nil,
+		BuildOS:     t.BuildOS,
+		ImageFrom:   t.ImageFrom,
+	}
+
+	for _, repo := range t.JobCtx.Builds {
+		if repo.CommitID == "" {
+			return "", false
+		}
+		source.Repos = append(source.Repos, fmt.Sprintf("%s/%s/%s@%s:%s", repo.Source, repo.RepoOwner, repo.RepoName, repo.CommitID, repo.CheckoutPath))
+	}
+	sort.Strings(source.Repos)
+
+	if ctx := t.JobCtx.DockerBuildCtx; ctx != nil {
+		// SBOM 和签名属于本次任务的产物，不复用缓存
+		if ctx.SBOMFormat != "" || ctx.SigningKey != nil {
+			return "", false
+		}
+		source.DockerBuild = &task.DockerBuildCtx{
+			WorkDir:               ctx.WorkDir,
+			DockerFile:            ctx.DockerFile,
+			BuildArgs:             ctx.BuildArgs,
+			Source:                ctx.Source,
+			DockerTemplateContent: ctx.DockerTemplateContent,
+			EnableBuildkit:        ctx.EnableBuildkit,
+			Platforms:             ctx.Platforms,
+		}
+		// 同一份代码推送到不同镜像仓库时各自缓存
+		image := resolveImageUrl(t.JobCtx.Image)
+		source.Registry = image[setting.PathSearchComponentRepo] + "/" + image[setting.PathSearchComponentImage]
+	}
+
+	// 相同的变量以最后一次出现的值为准
+	envs := make(map[string]string)
+	for _, env := range t.JobCtx.EnvVars {
+		if env == nil || volatileBuildEnvs.Has(env.Key) {
+			continue
+		}
+		envs[env.Key] = env.Value
+	}
+	for key, value := range envs {
+		source.Envs = append(source.Envs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(source.Envs)
+
+	for _, item := range t.InstallItems {
+		source.Installs = append(source.Installs, fmt.Sprintf("%s:%s", item.Name, item.Version))
+	}
+	sort.Strings(source.Installs)
+
+	// 只有编译脚本使用了环境名称时，编译结果才和环境相关
+	for _, step := range t.JobCtx.BuildSteps {
+		if strings.Contains(step.Scripts, "ENV_NAME") {
+			source.EnvName = envName
+		}
+	}
+
+	b, err := json.Marshal(source)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), true
+}
+
+func getBuildCache(key string) (*buildCache, error) {
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	cache := new(buildCache)
+	url := fmt.Sprintf("/api/build/buildcache/%s", key)
+	if _, err := httpClient.Get(url, httpclient.SetResult(cache)); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func saveBuildCache(cache *buildCache) error {
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	_, err := httpClient.Post("/api/build/buildcache", httpclient.SetBody(cache))
+	return err
+}
+
+// copyBuildPackage copies the package file of the cached task to the file path of the current task,
+// so the following tasks are able to find it with the original name
+func copyBuildPackage(pipelineTask *task.Task, cache *buildCache, packageFile string) error {
+	store, err := s3.NewS3StorageFromEncryptedURI(pipelineTask.StorageURI)
+	if err != nil {
+		return err
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		return err
+	}
+
+	src := store.GetObjectPath(fmt.Sprintf("%s/%d/file/%s", cache.PipelineName, cache.TaskID, cache.PackageFile))
+	dest := store.GetObjectPath(fmt.Sprintf("%s/%d/file/%s", pipelineTask.PipelineName, pipelineTask.TaskID, packageFile))
+	return s3client.CopyObject(store.Bucket, src, dest)
+}
+
+// replaceSubTaskImage points the following sub tasks which use the image of this build to the cached image
+func replaceSubTaskImage(pipelineTask *task.Task, taskType config.TaskType, oldImage, newImage string) {
+	if oldImage == "" || oldImage == newImage {
+		return
+	}
+
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	for _, stage := range pipelineTask.Stages {
+		if stage == nil || stage.TaskType == taskType {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			for key, value := range subTask {
+				if image, ok := value.(string); ok && image == oldImage {
+					subTask[key] = newImage
+				}
+			}
+		}
+	}
+	if pipelineTask.TaskArgs != nil && pipelineTask.TaskArgs.Deploy.Image == oldImage {
+		pipelineTask.TaskArgs.Deploy.Image = newImage
+	}
+}
+
+// reuseBuildCache returns true if a previous build with the same content key is found,
+// the image and package of that build are reused and the build job is skipped
+func (p *BuildTaskPlugin) reuseBuildCache(pipelineTask *task.Task) bool {
+	cache, err := getBuildCache(p.Task.CacheKey)
+	if err != nil {
+		p.Log.Infof("build cache %s of %s is missed", p.Task.CacheKey, p.Task.ServiceName)
+		return false
+	}
+	if p.Task.JobCtx.DockerBuildCtx != nil && cache.Image == "" {
+		return false
+	}
+	if p.Task.JobCtx.FileArchiveCtx != nil {
+		if cache.PackageFile == "" {
+			return false
+		}
+		if err := copyBuildPackage(pipelineTask, cache, p.Task.JobCtx.PackageFile); err != nil {
+			p.Log.Warnf("failed to copy package of build cache %s: %s", p.Task.CacheKey, err)
+			return false
+		}
+	}
+
+	if p.Task.JobCtx.DockerBuildCtx != nil {
+		replaceSubTaskImage(pipelineTask, p.Type(), p.Task.JobCtx.Image, cache.Image)
+		p.Task.JobCtx.Image = cache.Image
+		p.Task.JobCtx.DockerBuildCtx.ImageName = cache.Image
+	}
+
+	p.Log.Infof("build of %s reuses the result of %s:%d", p.Task.ServiceName, cache.PipelineName, cache.TaskID)
+	return true
+}
+
+func (p *BuildTaskPlugin) saveBuildCache(pipelineTask *task.Task) {
+	cache := &buildCache{
+		Key:          p.Task.CacheKey,
+		ProductName:  p.Task.ProductName,
+		Service:      p.Task.Service,
+		ServiceName:  p.Task.ServiceName,
+		PipelineName: pipelineTask.PipelineName,
+		TaskID:       pipelineTask.TaskID,
+	}
+	if p.Task.JobCtx.DockerBuildCtx != nil {
+		cache.Image = p.Task.JobCtx.Image
+	}
+	if p.Task.JobCtx.FileArchiveCtx != nil {
+		cache.PackageFile = p.Task.JobCtx.PackageFile
+	}
+	if cache.Image == "" && cache.PackageFile == "" {
+		return
+	}
+
+	if err := saveBuildCache(cache); err != nil {
+		p.Log.Warnf("failed to save build cache %s: %s", cache.Key, err)
+	}
+}
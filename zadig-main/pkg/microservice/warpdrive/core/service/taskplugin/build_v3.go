@@ -33,6 +33,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 const (
@@ -136,11 +137,12 @@ func (p *BuildTaskV3Plugin) Run(ctx context.Context, pipelineTask *task.Task, pi
 	}
 
 	jobCtx := JobCtxBuilder{
-		JobName:     p.JobName,
-		PipelineCtx: pipelineCtx,
-		ArchiveFile: p.Task.JobCtx.PackageFile,
-		JobCtx:      p.Task.JobCtx,
-		Installs:    p.Task.InstallCtx,
+		JobName:      p.JobName,
+		PipelineCtx:  pipelineCtx,
+		ArchiveFile:  p.Task.JobCtx.PackageFile,
+		JobCtx:       p.Task.JobCtx,
+		Installs:     p.Task.InstallCtx,
+		TraceContext: tracing.Inject(ctx),
 	}
 
 	if p.Task.BuildStatus == nil {
@@ -49,6 +49,7 @@ import (
 	"github.com/koderover/zadig/pkg/tool/kube/getter"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/util"
 	"github.com/koderover/zadig/pkg/util/converter"
 	fsutil "github.com/koderover/zadig/pkg/util/fs"
@@ -78,9 +79,17 @@ type DeployTaskPlugin struct {
 	ReplaceImage string
 
 	httpClient *httpclient.Client
+	ack        func()
+
+	// the deployment and container released by canary or blue-green strategy
+	strategyTarget    *appsv1.Deployment
+	strategyContainer string
+	// the selector of the k8s service before the release, restored if the release fails
+	strategySelector map[string]string
 }
 
-func (p *DeployTaskPlugin) SetAckFunc(func()) {
+func (p *DeployTaskPlugin) SetAckFunc(ack func()) {
+	p.ack = ack
 }
 
 const (
@@ -212,6 +221,12 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 			return
 		}
 	}
+	if pipelineTask.VerifyImageSignature && p.Task.Image != "" {
+		if err = verifyImageSignature(pipelineTask, p.Task.Image); err != nil {
+			return
+		}
+	}
+
 	containerName := p.Task.ContainerName
 	containerName = strings.TrimSuffix(containerName, "_"+p.Task.ServiceName)
 	if p.Task.ServiceType != setting.HelmDeployType {
@@ -228,6 +243,10 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 				return
 			}
 		}
+		if p.useStrategy() {
+			err = p.prepareStrategy(ctx, serviceInfo, containerName)
+			return
+		}
 		if serviceInfo.WorkloadType == "" {
 			selector := labels.Set{setting.ProductLabel: p.Task.ProductName, setting.ServiceLabel: p.Task.ServiceName}.AsSelector()
 
@@ -574,7 +593,7 @@ func (p *DeployTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, _ *
 
 		done := make(chan bool)
 		go func(chan bool) {
-			if _, err = helmClient.InstallOrUpgradeChart(context.TODO(), &chartSpec); err != nil {
+			if _, err = helmClient.InstallOrUpgradeChart(tracing.Detach(ctx), &chartSpec); err != nil {
 				err = errors.WithMessagef(
 					err,
 					"failed to Install helm chart %s/%s",
@@ -623,7 +642,7 @@ func (p *DeployTaskPlugin) getProductInfo(ctx context.Context, args *EnvArgs) (*
 	url := fmt.Sprintf("/api/environment/environments/%s/productInfo", args.EnvName)
 
 	prod := &types.Product{}
-	_, err := p.httpClient.Get(url, httpclient.SetResult(prod), httpclient.SetQueryParam("projectName", args.ProductName))
+	_, err := p.httpClient.Get(url, httpclient.SetContext(ctx), httpclient.SetResult(prod), httpclient.SetQueryParam("projectName", args.ProductName))
 	if err != nil {
 		return nil, err
 	}
@@ -634,7 +653,7 @@ func (p *DeployTaskPlugin) getService(ctx context.Context, name, serviceType, pr
 	url := fmt.Sprintf("/api/service/services/%s/%s", name, serviceType)
 
 	s := &types.ServiceTmpl{}
-	_, err := p.httpClient.Get(url, httpclient.SetResult(s), httpclient.SetQueryParams(map[string]string{
+	_, err := p.httpClient.Get(url, httpclient.SetContext(ctx), httpclient.SetResult(s), httpclient.SetQueryParams(map[string]string{
 		"projectName": productName,
 		"revision":    fmt.Sprintf("%d", revision),
 	}))
@@ -699,7 +718,7 @@ func (p *DeployTaskPlugin) getRenderSet(ctx context.Context, name string, revisi
 	url := fmt.Sprintf("/api/project/renders/render/%s/revision/%d", name, revision)
 
 	rs := &types.RenderSet{}
-	_, err := p.httpClient.Get(url, httpclient.SetResult(rs))
+	_, err := p.httpClient.Get(url, httpclient.SetContext(ctx), httpclient.SetResult(rs))
 	if err != nil {
 		return nil, err
 	}
@@ -710,7 +729,7 @@ func (p *DeployTaskPlugin) getRenderSet(ctx context.Context, name string, revisi
 func (p *DeployTaskPlugin) updateRenderSet(ctx context.Context, args *types.RenderSet) error {
 	url := "/api/project/renders"
 
-	_, err := p.httpClient.Put(url, httpclient.SetBody(args))
+	_, err := p.httpClient.Put(url, httpclient.SetContext(ctx), httpclient.SetBody(args))
 
 	return err
 }
@@ -812,6 +831,11 @@ func (p *DeployTaskPlugin) Wait(ctx context.Context) {
 		return
 	}
 
+	if p.useStrategy() {
+		p.waitStrategy(ctx)
+		return
+	}
+
 	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
 
 	selector := labels.Set{setting.ProductLabel: p.Task.ProductName, setting.ServiceLabel: p.Task.ServiceName}.AsSelector()
@@ -0,0 +1,563 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/kube/wrapper"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+)
+
+const (
+	ingressCanaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	ingressCanaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
+)
+
+// strategyStep is one step of canary or blue-green deployment,
+// rollback is called in reverse order for all started steps once a step fails
+type strategyStep struct {
+	name     string
+	run      func(ctx context.Context, timeout <-chan time.Time) error
+	rollback func()
+}
+
+func (p *DeployTaskPlugin) useStrategy() bool {
+	return p.Task.Strategy != nil && p.Task.Strategy.Type != setting.DeployStrategyRolling &&
+		p.Task.ServiceType != setting.HelmDeployType
+}
+
+// prepareStrategy finds the deployment to release and records the planned steps
+func (p *DeployTaskPlugin) prepareStrategy(ctx context.Context, serviceInfo *types.ServiceTmpl, containerName string) error {
+	if serviceInfo.WorkloadType != "" && serviceInfo.WorkloadType != setting.Deployment {
+		return errors.Errorf("deploy strategy %s only supports deployments", p.Task.Strategy.Type)
+	}
+
+	var deployments []*appsv1.Deployment
+	if serviceInfo.WorkloadType == setting.Deployment {
+		deployment, found, err := getter.GetDeployment(p.Task.Namespace, p.Task.ServiceName, p.kubeClient)
+		if err != nil {
+			return err
+		}
+		if found {
+			deployments = append(deployments, deployment)
+		}
+	} else {
+		selector := labels.Set{setting.ProductLabel: p.Task.ProductName, setting.ServiceLabel: p.Task.ServiceName}.AsSelector()
+		list, err := getter.ListDeployments(p.Task.Namespace, selector, p.kubeClient)
+		if err != nil {
+			return err
+		}
+		deployments = list
+	}
+
+	for _, deployment := range deployments {
+		// skip the workloads created by an unfinished canary or blue-green deployment
+		if _, ok := deployment.Spec.Template.Labels[setting.DeployTrackLabel]; ok {
+			continue
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			if container.Name == containerName {
+				p.strategyTarget = deployment
+				p.strategyContainer = containerName
+				p.Task.ReplaceResources = append(p.Task.ReplaceResources, task.Resource{
+					Kind:      setting.Deployment,
+					Container: container.Name,
+					Origin:    container.Image,
+					Name:      deployment.Name,
+				})
+				break
+			}
+		}
+		if p.strategyTarget != nil {
+			break
+		}
+	}
+	if p.strategyTarget == nil {
+		return errors.Errorf("container %s is not found in deployments of service %s", containerName, p.Task.ServiceName)
+	}
+
+	if p.Task.Strategy.K8sServiceName != "" {
+		svc, found, err := getter.GetService(p.Task.Namespace, p.Task.Strategy.K8sServiceName, p.kubeClient)
+		if err != nil || !found {
+			return errors.Errorf("failed to find service %s: %v", p.Task.Strategy.K8sServiceName, err)
+		}
+		// the track label is never part of the original selector, drop it in case an earlier release was interrupted
+		p.strategySelector = make(map[string]string, len(svc.Spec.Selector))
+		for k, v := range svc.Spec.Selector {
+			if k != setting.DeployTrackLabel {
+				p.strategySelector[k] = v
+			}
+		}
+	}
+
+	p.Task.StrategySteps = make([]*task.DeployStrategyStep, 0)
+	for _, step := range p.strategySteps() {
+		p.Task.StrategySteps = append(p.Task.StrategySteps, &task.DeployStrategyStep{
+			Name:   step.name,
+			Status: config.StatusCreated,
+		})
+	}
+	return nil
+}
+
+func (p *DeployTaskPlugin) strategySteps() []*strategyStep {
+	if p.Task.Strategy.Type == setting.DeployStrategyBlueGreen {
+		return p.blueGreenSteps()
+	}
+	return p.canarySteps()
+}
+
+// waitStrategy runs the steps of the deploy strategy and rolls back if any of them fails
+func (p *DeployTaskPlugin) waitStrategy(ctx context.Context) {
+	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
+	steps := p.strategySteps()
+
+	for i, step := range steps {
+		status := p.Task.StrategySteps[i]
+		status.Status = config.StatusRunning
+		status.StartTime = time.Now().Unix()
+		p.sendAck()
+
+		err := step.run(ctx, timeout)
+		status.EndTime = time.Now().Unix()
+		if err == nil {
+			status.Status = config.StatusPassed
+			p.sendAck()
+			continue
+		}
+
+		p.Log.Errorf("deploy strategy step %s failed: %s", step.name, err)
+		status.Message = err.Error()
+		switch {
+		case ctx.Err() != nil:
+			status.Status = config.StatusCancelled
+			p.Task.TaskStatus = config.StatusCancelled
+		case errors.Is(err, errStrategyTimeout):
+			status.Status = config.StatusTimeout
+			p.Task.TaskStatus = config.StatusTimeout
+		default:
+			status.Status = config.StatusFailed
+			p.Task.TaskStatus = config.StatusFailed
+		}
+		p.Task.Error = fmt.Sprintf("%s: %s", step.name, err)
+		p.sendAck()
+
+		for j := i; j >= 0; j-- {
+			if steps[j].rollback != nil {
+				steps[j].rollback()
+			}
+		}
+		return
+	}
+
+	p.Task.TaskStatus = config.StatusPassed
+}
+
+func (p *DeployTaskPlugin) sendAck() {
+	if p.ack != nil {
+		p.ack()
+	}
+}
+
+var errStrategyTimeout = errors.New("timeout")
+
+// waitDeploymentReady polls until the deployment is ready, or returns error if it is cancelled or timed out
+func (p *DeployTaskPlugin) waitDeploymentReady(ctx context.Context, timeout <-chan time.Time, name string) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return errors.Wrapf(errStrategyTimeout, "deployment %s is not ready", name)
+		default:
+			time.Sleep(time.Second * 2)
+			d, found, err := getter.GetDeployment(p.Task.Namespace, name, p.kubeClient)
+			if err != nil || !found {
+				p.Log.Errorf("failed to check deployment ready status %s/%s - %v", p.Task.Namespace, name, err)
+				continue
+			}
+			if wrapper.Deployment(d).Ready() {
+				return nil
+			}
+		}
+	}
+}
+
+// verifyDeployment keeps checking the deployment is ready during the pause of a step
+func (p *DeployTaskPlugin) verifyDeployment(ctx context.Context, timeout <-chan time.Time, name string, pause int) error {
+	end := time.After(time.Duration(pause) * time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return errStrategyTimeout
+		case <-end:
+			return nil
+		default:
+			time.Sleep(time.Second * 2)
+			d, found, err := getter.GetDeployment(p.Task.Namespace, name, p.kubeClient)
+			if err != nil {
+				p.Log.Errorf("failed to check deployment ready status %s/%s - %v", p.Task.Namespace, name, err)
+				continue
+			}
+			if !found || !wrapper.Deployment(d).Ready() {
+				return errors.Errorf("deployment %s is not ready during verification", name)
+			}
+		}
+	}
+}
+
+// newTrackDeployment copies the target deployment with the new image, its pods are labeled with the track
+// so that they can be selected separately
+func (p *DeployTaskPlugin) newTrackDeployment(name, track string, replicas int32) *appsv1.Deployment {
+	origin := p.strategyTarget
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   origin.Namespace,
+			Labels:      copyLabels(origin.Labels, track),
+			Annotations: map[string]string{},
+		},
+		Spec: *origin.Spec.DeepCopy(),
+	}
+	d.Spec.Replicas = &replicas
+	if d.Spec.Selector == nil {
+		d.Spec.Selector = &metav1.LabelSelector{}
+	}
+	d.Spec.Selector.MatchLabels = copyLabels(d.Spec.Selector.MatchLabels, track)
+	d.Spec.Template.Labels = copyLabels(d.Spec.Template.Labels, track)
+	for i, container := range d.Spec.Template.Spec.Containers {
+		if container.Name == p.strategyContainer {
+			d.Spec.Template.Spec.Containers[i].Image = p.Task.Image
+		}
+	}
+	return d
+}
+
+func copyLabels(origin map[string]string, track string) map[string]string {
+	ret := make(map[string]string, len(origin)+1)
+	for k, v := range origin {
+		ret[k] = v
+	}
+	ret[setting.DeployTrackLabel] = track
+	return ret
+}
+
+// restoreServiceSelector sets the selector of the k8s service back to the one recorded before the release,
+// removing any keys added since then
+func (p *DeployTaskPlugin) restoreServiceSelector() error {
+	if p.strategySelector == nil {
+		return nil
+	}
+	name := p.Task.Strategy.K8sServiceName
+	svc, found, err := getter.GetService(p.Task.Namespace, name, p.kubeClient)
+	if err != nil || !found {
+		return errors.Errorf("failed to find service %s: %v", name, err)
+	}
+
+	selector := make(map[string]interface{}, len(svc.Spec.Selector)+len(p.strategySelector))
+	for k := range svc.Spec.Selector {
+		if _, ok := p.strategySelector[k]; !ok {
+			selector[k] = nil
+		}
+	}
+	for k, v := range p.strategySelector {
+		selector[k] = v
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"selector": selector}})
+	if err != nil {
+		return err
+	}
+	return updater.PatchService(p.Task.Namespace, name, patch, p.kubeClient)
+}
+
+func (p *DeployTaskPlugin) deleteIgnoreNotFound(kind, name string, del func(ns, name string) error) {
+	if err := del(p.Task.Namespace, name); err != nil && !apierrors.IsNotFound(err) {
+		p.Log.Errorf("failed to delete %s %s/%s: %s", kind, p.Task.Namespace, name, err)
+	}
+}
+
+// promoteStep updates the image of the target deployment in place and restores it on failure
+func (p *DeployTaskPlugin) promoteStep() *strategyStep {
+	origin := p.strategyTarget
+	originImage := ""
+	for _, container := range origin.Spec.Template.Spec.Containers {
+		if container.Name == p.strategyContainer {
+			originImage = container.Image
+		}
+	}
+
+	return &strategyStep{
+		name: "promote",
+		run: func(ctx context.Context, timeout <-chan time.Time) error {
+			err := updater.UpdateDeploymentImage(origin.Namespace, origin.Name, p.strategyContainer, p.Task.Image, p.kubeClient)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to update container image in %s/deployments/%s/%s",
+					origin.Namespace, origin.Name, p.strategyContainer)
+			}
+			return p.waitDeploymentReady(ctx, timeout, origin.Name)
+		},
+		rollback: func() {
+			p.Log.Infof("rollback image of %s/deployments/%s/%s to %s", origin.Namespace, origin.Name, p.strategyContainer, originImage)
+			err := updater.UpdateDeploymentImage(origin.Namespace, origin.Name, p.strategyContainer, originImage, p.kubeClient)
+			if err != nil {
+				p.Log.Errorf("failed to rollback image of %s/deployments/%s: %s", origin.Namespace, origin.Name, err)
+			}
+		},
+	}
+}
+
+func (p *DeployTaskPlugin) canarySteps() []*strategyStep {
+	strategy := p.Task.Strategy
+	origin := p.strategyTarget
+	canaryName := origin.Name + "-" + setting.DeployTrackCanary
+	canaryServiceName := strategy.K8sServiceName + "-" + setting.DeployTrackCanary
+	canaryIngressName := strategy.IngressName + "-" + setting.DeployTrackCanary
+
+	cleanup := func() {
+		p.deleteIgnoreNotFound("deployment", canaryName, func(ns, name string) error {
+			return updater.DeleteDeployment(ns, name, p.kubeClient)
+		})
+		if strategy.IngressName == "" {
+			return
+		}
+		p.deleteIgnoreNotFound("ingress", canaryIngressName, func(ns, name string) error {
+			return updater.DeleteIngress(ns, name, p.kubeClient)
+		})
+		p.deleteIgnoreNotFound("service", canaryServiceName, func(ns, name string) error {
+			return updater.DeleteService(ns, name, p.kubeClient)
+		})
+	}
+
+	steps := []*strategyStep{{
+		name: "create canary",
+		run: func(ctx context.Context, timeout <-chan time.Time) error {
+			replicas := strategy.CanaryReplicas
+			if replicas <= 0 {
+				replicas = 1
+			}
+			if err := updater.CreateOrPatchDeployment(p.newTrackDeployment(canaryName, setting.DeployTrackCanary, replicas), p.kubeClient); err != nil {
+				return errors.WithMessagef(err, "failed to create canary deployment %s", canaryName)
+			}
+			if strategy.IngressName != "" {
+				if err := p.createCanaryIngress(canaryServiceName, canaryIngressName); err != nil {
+					return err
+				}
+			}
+			return p.waitDeploymentReady(ctx, timeout, canaryName)
+		},
+		rollback: func() {
+			if err := p.restoreServiceSelector(); err != nil {
+				p.Log.Errorf("failed to restore selector of service %s: %s", strategy.K8sServiceName, err)
+			}
+			cleanup()
+		},
+	}}
+
+	for _, canaryStep := range strategy.CanarySteps {
+		weight, pause := canaryStep.Weight, canaryStep.Pause
+		steps = append(steps, &strategyStep{
+			name: fmt.Sprintf("canary %d%%", weight),
+			run: func(ctx context.Context, timeout <-chan time.Time) error {
+				if err := p.setCanaryWeight(canaryName, canaryIngressName, weight); err != nil {
+					return err
+				}
+				if err := p.waitDeploymentReady(ctx, timeout, canaryName); err != nil {
+					return err
+				}
+				return p.verifyDeployment(ctx, timeout, canaryName, pause)
+			},
+		})
+	}
+
+	promote := p.promoteStep()
+	return append(steps, promote, &strategyStep{
+		name: "cleanup",
+		run: func(ctx context.Context, timeout <-chan time.Time) error {
+			cleanup()
+			return nil
+		},
+	})
+}
+
+// createCanaryIngress creates a service selecting the canary pods and an nginx canary ingress routing to it
+func (p *DeployTaskPlugin) createCanaryIngress(canaryServiceName, canaryIngressName string) error {
+	strategy := p.Task.Strategy
+	svc, found, err := getter.GetService(p.Task.Namespace, strategy.K8sServiceName, p.kubeClient)
+	if err != nil || !found {
+		return errors.Errorf("failed to find service %s: %v", strategy.K8sServiceName, err)
+	}
+	ing, found, err := getter.GetIngress(p.Task.Namespace, strategy.IngressName, p.kubeClient)
+	if err != nil || !found {
+		return errors.Errorf("failed to find ingress %s: %v", strategy.IngressName, err)
+	}
+
+	canaryService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      canaryServiceName,
+			Namespace: svc.Namespace,
+			Labels:    copyLabels(svc.Labels, setting.DeployTrackCanary),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Ports:    make([]corev1.ServicePort, 0, len(svc.Spec.Ports)),
+			Selector: copyLabels(svc.Spec.Selector, setting.DeployTrackCanary),
+		},
+	}
+	for _, port := range svc.Spec.Ports {
+		port.NodePort = 0
+		canaryService.Spec.Ports = append(canaryService.Spec.Ports, port)
+	}
+	if err := updater.CreateOrPatchService(canaryService, p.kubeClient); err != nil {
+		return errors.WithMessagef(err, "failed to create canary service %s", canaryServiceName)
+	}
+
+	canaryIngress := &extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      canaryIngressName,
+			Namespace: ing.Namespace,
+			Labels:    copyLabels(ing.Labels, setting.DeployTrackCanary),
+			Annotations: map[string]string{
+				ingressCanaryAnnotation:       "true",
+				ingressCanaryWeightAnnotation: "0",
+			},
+		},
+		Spec: *ing.Spec.DeepCopy(),
+	}
+	if class, ok := ing.Annotations["kubernetes.io/ingress.class"]; ok {
+		canaryIngress.Annotations["kubernetes.io/ingress.class"] = class
+	}
+	if canaryIngress.Spec.Backend != nil && canaryIngress.Spec.Backend.ServiceName == strategy.K8sServiceName {
+		canaryIngress.Spec.Backend.ServiceName = canaryServiceName
+	}
+	for i := range canaryIngress.Spec.Rules {
+		if canaryIngress.Spec.Rules[i].HTTP == nil {
+			continue
+		}
+		for j, path := range canaryIngress.Spec.Rules[i].HTTP.Paths {
+			if path.Backend.ServiceName == strategy.K8sServiceName {
+				canaryIngress.Spec.Rules[i].HTTP.Paths[j].Backend.ServiceName = canaryServiceName
+			}
+		}
+	}
+	if err := updater.CreateOrPatchIngress(canaryIngress, p.kubeClient); err != nil {
+		return errors.WithMessagef(err, "failed to create canary ingress %s", canaryIngressName)
+	}
+	return nil
+}
+
+// setCanaryWeight sets the weight of the canary ingress, or scales the canary deployment in proportion to
+// the target deployment if no ingress is used
+func (p *DeployTaskPlugin) setCanaryWeight(canaryName, canaryIngressName string, weight int) error {
+	if p.Task.Strategy.IngressName != "" {
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{"%s":"%s"}}}`, ingressCanaryWeightAnnotation, strconv.Itoa(weight))
+		if err := updater.PatchIngress(p.Task.Namespace, canaryIngressName, []byte(patch), p.kubeClient); err != nil {
+			return errors.WithMessagef(err, "failed to set weight of canary ingress %s", canaryIngressName)
+		}
+		return nil
+	}
+
+	replicas := int32(1)
+	if p.strategyTarget.Spec.Replicas != nil {
+		replicas = *p.strategyTarget.Spec.Replicas
+	}
+	canaryReplicas := int(math.Ceil(float64(replicas) * float64(weight) / 100))
+	if canaryReplicas < 1 {
+		canaryReplicas = 1
+	}
+	if err := updater.ScaleDeployment(p.Task.Namespace, canaryName, canaryReplicas, p.kubeClient); err != nil {
+		return errors.WithMessagef(err, "failed to scale canary deployment %s", canaryName)
+	}
+	return nil
+}
+
+func (p *DeployTaskPlugin) blueGreenSteps() []*strategyStep {
+	strategy := p.Task.Strategy
+	origin := p.strategyTarget
+	greenName := origin.Name + "-" + setting.DeployTrackBlueGreen
+
+	deleteGreen := func() {
+		p.deleteIgnoreNotFound("deployment", greenName, func(ns, name string) error {
+			return updater.DeleteDeployment(ns, name, p.kubeClient)
+		})
+	}
+	restoreService := func() {
+		if err := p.restoreServiceSelector(); err != nil {
+			p.Log.Errorf("failed to switch service %s back: %s", strategy.K8sServiceName, err)
+		}
+	}
+
+	return []*strategyStep{
+		{
+			name: "create green",
+			run: func(ctx context.Context, timeout <-chan time.Time) error {
+				replicas := int32(1)
+				if origin.Spec.Replicas != nil {
+					replicas = *origin.Spec.Replicas
+				}
+				if err := updater.CreateOrPatchDeployment(p.newTrackDeployment(greenName, setting.DeployTrackBlueGreen, replicas), p.kubeClient); err != nil {
+					return errors.WithMessagef(err, "failed to create green deployment %s", greenName)
+				}
+				return p.waitDeploymentReady(ctx, timeout, greenName)
+			},
+			rollback: deleteGreen,
+		},
+		{
+			name: "switch traffic",
+			run: func(ctx context.Context, timeout <-chan time.Time) error {
+				patch := fmt.Sprintf(`{"spec":{"selector":{"%s":%q}}}`, setting.DeployTrackLabel, setting.DeployTrackBlueGreen)
+				if err := updater.PatchService(p.Task.Namespace, strategy.K8sServiceName, []byte(patch), p.kubeClient); err != nil {
+					return errors.WithMessagef(err, "failed to switch service %s to green", strategy.K8sServiceName)
+				}
+				return p.verifyDeployment(ctx, timeout, greenName, 0)
+			},
+			rollback: restoreService,
+		},
+		p.promoteStep(),
+		{
+			name: "switch back",
+			run: func(ctx context.Context, timeout <-chan time.Time) error {
+				if err := p.restoreServiceSelector(); err != nil {
+					return errors.WithMessagef(err, "failed to switch service %s back", strategy.K8sServiceName)
+				}
+				deleteGreen()
+				return nil
+			},
+			rollback: restoreService,
+		},
+	}
+}
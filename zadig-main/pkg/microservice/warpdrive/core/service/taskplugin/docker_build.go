@@ -139,6 +139,10 @@ func (p *DockerBuildPlugin) Run(ctx context.Context, pipelineTask *task.Task, pi
 			DockerFile: fmt.Sprintf("%s/%s", pipelineCtx.Workspace, p.Task.DockerFile),
 			ImageName:  p.Task.Image,
 			BuildArgs:  p.Task.BuildArgs,
+
+			EnableBuildkit: p.Task.EnableBuildkit,
+			Platforms:      p.Task.Platforms,
+			RemoteCache:    p.Task.RemoteCache,
 		},
 		//Registry host/user/password
 		DockerRegistry: &types.DockerRegistry{
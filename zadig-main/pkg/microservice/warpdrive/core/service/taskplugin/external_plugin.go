@@ -0,0 +1,375 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	ExternalPluginTaskTimeout = 60 * 60 * 1 // 60 minutes
+)
+
+// ExternalPluginRequest is sent to the plugin to create an execution.
+//
+// The external plugin protocol, all paths are relative to the registered plugin address:
+//
+//	POST /init           create an execution, returns ExternalPluginExecution
+//	POST /run/:id        start the execution
+//	GET  /status/:id     returns ExternalPluginStatus, polled until the status is final
+//	POST /cancel/:id     cancel the execution when the task is cancelled or timeout
+//	POST /complete/:id   release the execution, returns ExternalPluginStatus with the full log
+type ExternalPluginRequest struct {
+	ProjectName  string              `json:"project_name"`
+	PipelineName string              `json:"pipeline_name"`
+	TaskID       int64               `json:"task_id"`
+	Creator      string              `json:"creator"`
+	Params       []*models.KeyVal    `json:"params"`
+	ServiceInfos []*task.ServiceInfo `json:"service_infos"`
+}
+
+type ExternalPluginExecution struct {
+	ExecutionID string `json:"execution_id"`
+}
+
+type ExternalPluginStatus struct {
+	Status    config.Status                  `json:"status"`
+	Message   string                         `json:"message"`
+	Error     string                         `json:"error"`
+	Log       string                         `json:"log"`
+	Artifacts []*task.ExternalPluginArtifact `json:"artifacts"`
+}
+
+// InitializeExternalPluginTaskPlugin to initialize external plugin task plugin, and return reference
+func InitializeExternalPluginTaskPlugin(taskType config.TaskType) TaskPlugin {
+	return &ExternalPluginTaskPlugin{
+		Name: taskType,
+	}
+}
+
+// ExternalPluginTaskPlugin is Plugin, name should be compatible with task type
+type ExternalPluginTaskPlugin struct {
+	Name         config.TaskType
+	JobName      string
+	FileName     string
+	Task         *task.ExternalPlugin
+	Log          *zap.SugaredLogger
+	plugin       *models.ExternalTaskPlugin
+	ack          func()
+	pipelineTask *task.Task
+}
+
+func (p *ExternalPluginTaskPlugin) SetAckFunc(ack func()) {
+	p.ack = ack
+}
+
+// Init ...
+func (p *ExternalPluginTaskPlugin) Init(jobname, filename string, xl *zap.SugaredLogger) {
+	p.JobName = jobname
+	p.Log = xl
+	p.FileName = filename
+}
+
+func (p *ExternalPluginTaskPlugin) Type() config.TaskType {
+	return p.Name
+}
+
+// Status ...
+func (p *ExternalPluginTaskPlugin) Status() config.Status {
+	return p.Task.TaskStatus
+}
+
+// SetStatus ...
+func (p *ExternalPluginTaskPlugin) SetStatus(status config.Status) {
+	p.Task.TaskStatus = status
+}
+
+// TaskTimeout ...
+func (p *ExternalPluginTaskPlugin) TaskTimeout() int {
+	if p.Task.Timeout == 0 {
+		p.Task.Timeout = ExternalPluginTaskTimeout
+	} else {
+		if !p.Task.IsRestart {
+			p.Task.Timeout = p.Task.Timeout * 60
+		}
+	}
+	return p.Task.Timeout
+}
+
+// loadPlugin fetches the address and headers of the plugin from aslan, they are not saved in the task
+// because the headers may contain credentials
+func (p *ExternalPluginTaskPlugin) loadPlugin() error {
+	if p.plugin != nil {
+		return nil
+	}
+
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+	plugin := new(models.ExternalTaskPlugin)
+	if _, err := httpClient.Get(fmt.Sprintf("/api/system/taskPlugin/%s", p.Task.PluginID), httpclient.SetResult(plugin)); err != nil {
+		return fmt.Errorf("failed to get external plugin %s: %s", p.Task.PluginName, err)
+	}
+	p.plugin = plugin
+	return nil
+}
+
+func (p *ExternalPluginTaskPlugin) client() *httpclient.Client {
+	return httpclient.New(
+		httpclient.SetHostURL(strings.TrimSuffix(p.plugin.Address, "/")),
+	)
+}
+
+func (p *ExternalPluginTaskPlugin) headers() []httpclient.RequestFunc {
+	rfs := make([]httpclient.RequestFunc, 0, len(p.plugin.Headers)+1)
+	rfs = append(rfs, httpclient.SetHeader(ZadigEvent, EventName))
+	for _, header := range p.plugin.Headers {
+		rfs = append(rfs, httpclient.SetHeader(header.Key, header.Value))
+	}
+	return rfs
+}
+
+func (p *ExternalPluginTaskPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineCtx *task.PipelineCtx, serviceName string) {
+	var err error
+	defer func() {
+		if err != nil {
+			p.Log.Error(err)
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = err.Error()
+		}
+	}()
+	p.pipelineTask = pipelineTask
+	if err = p.loadPlugin(); err != nil {
+		return
+	}
+
+	req := &ExternalPluginRequest{
+		ProjectName:  pipelineTask.ProductName,
+		PipelineName: pipelineTask.PipelineName,
+		TaskID:       pipelineTask.TaskID,
+		Creator:      pipelineTask.TaskCreator,
+		Params:       p.Task.Params,
+		ServiceInfos: p.Task.ServiceInfos,
+	}
+	execution := new(ExternalPluginExecution)
+	rfs := append(p.headers(), httpclient.SetBody(req), httpclient.SetResult(execution))
+	if _, err = p.client().Post("/init", rfs...); err != nil {
+		err = fmt.Errorf("failed to init external plugin %s: %s", p.Task.PluginName, err)
+		return
+	}
+	if execution.ExecutionID == "" {
+		err = fmt.Errorf("external plugin %s returns empty execution id", p.Task.PluginName)
+		return
+	}
+	p.Task.ExecutionID = execution.ExecutionID
+
+	if _, err = p.client().Post(fmt.Sprintf("/run/%s", p.Task.ExecutionID), p.headers()...); err != nil {
+		err = fmt.Errorf("failed to run external plugin %s: %s", p.Task.PluginName, err)
+		return
+	}
+	p.Log.Infof("succeed to run external plugin %s, execution id: %s", p.Task.PluginName, p.Task.ExecutionID)
+}
+
+func (p *ExternalPluginTaskPlugin) getStatus(action string) (*ExternalPluginStatus, error) {
+	if err := p.loadPlugin(); err != nil {
+		return nil, err
+	}
+	status := new(ExternalPluginStatus)
+	rfs := append(p.headers(), httpclient.SetResult(status))
+
+	var err error
+	if action == "status" {
+		_, err = p.client().Get(fmt.Sprintf("/status/%s", p.Task.ExecutionID), rfs...)
+	} else {
+		_, err = p.client().Post(fmt.Sprintf("/%s/%s", action, p.Task.ExecutionID), rfs...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (p *ExternalPluginTaskPlugin) cancelExecution() {
+	if _, err := p.getStatus("cancel"); err != nil {
+		p.Log.Warnf("failed to cancel external plugin %s execution %s: %s", p.Task.PluginName, p.Task.ExecutionID, err)
+	}
+}
+
+// Wait ...
+func (p *ExternalPluginTaskPlugin) Wait(ctx context.Context) {
+	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.cancelExecution()
+			p.Task.TaskStatus = config.StatusCancelled
+			return
+		case <-timeout:
+			p.cancelExecution()
+			p.Task.TaskStatus = config.StatusTimeout
+			p.Task.Error = "timeout"
+			return
+		default:
+			time.Sleep(time.Second * 3)
+			status, err := p.getStatus("status")
+			if err != nil {
+				p.Log.Warnf("failed to get external plugin %s status: %s", p.Task.PluginName, err)
+				continue
+			}
+
+			if status.Message != p.Task.Message {
+				p.Task.Message = status.Message
+				if p.ack != nil {
+					p.ack()
+				}
+			}
+
+			switch status.Status {
+			case config.StatusPassed, config.StatusFailed, config.StatusTimeout, config.StatusCancelled, config.StatusSkipped:
+				p.Task.TaskStatus = status.Status
+				p.Task.Error = status.Error
+				p.Task.Artifacts = status.Artifacts
+				return
+			}
+		}
+	}
+}
+
+// Complete ...
+func (p *ExternalPluginTaskPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
+	if p.Task.ExecutionID == "" {
+		return
+	}
+
+	status, err := p.getStatus("complete")
+	if err != nil {
+		p.Log.Warnf("failed to complete external plugin %s execution %s: %s", p.Task.PluginName, p.Task.ExecutionID, err)
+		return
+	}
+	if len(status.Artifacts) > 0 {
+		p.Task.Artifacts = status.Artifacts
+	}
+
+	if status.Log != "" {
+		if err := saveExternalPluginLog(pipelineTask, p.FileName, status.Log); err != nil {
+			p.Log.Errorf("failed to save external plugin %s log: %s", p.Task.PluginName, err)
+		}
+	}
+}
+
+// saveExternalPluginLog uploads the log to the same place as job container logs, so it can be fetched by the log API
+func saveExternalPluginLog(pipelineTask *task.Task, fileName, content string) error {
+	tempFileName, err := util.GenerateTmpFile()
+	if err != nil {
+		return fmt.Errorf("saveExternalPluginLog GenerateTmpFile error: %v", err)
+	}
+	defer func() {
+		_ = os.Remove(tempFileName)
+	}()
+
+	if err = saveFile(bytes.NewBufferString(content), tempFileName); err != nil {
+		return fmt.Errorf("saveExternalPluginLog saveFile error: %v", err)
+	}
+
+	store, err := s3.NewS3StorageFromEncryptedURI(pipelineTask.StorageURI)
+	if err != nil {
+		return err
+	}
+	if store.Subfolder != "" {
+		store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, strings.ToLower(pipelineTask.PipelineName), pipelineTask.TaskID, "log")
+	} else {
+		store.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(pipelineTask.PipelineName), pipelineTask.TaskID, "log")
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		return fmt.Errorf("saveExternalPluginLog s3 create client error: %v", err)
+	}
+	return s3client.Upload(store.Bucket, tempFileName, store.GetObjectPath(fileName+".log"))
+}
+
+// SetTask ...
+func (p *ExternalPluginTaskPlugin) SetTask(t map[string]interface{}) error {
+	task, err := ToExternalPluginTask(t)
+	if err != nil {
+		return err
+	}
+	p.Task = task
+	return nil
+}
+
+// GetTask ...
+func (p *ExternalPluginTaskPlugin) GetTask() interface{} {
+	return p.Task
+}
+
+// IsTaskDone ...
+func (p *ExternalPluginTaskPlugin) IsTaskDone() bool {
+	if p.Task.TaskStatus != config.StatusCreated && p.Task.TaskStatus != config.StatusRunning {
+		return true
+	}
+	return false
+}
+
+// IsTaskFailed ...
+func (p *ExternalPluginTaskPlugin) IsTaskFailed() bool {
+	if p.Task.TaskStatus == config.StatusFailed || p.Task.TaskStatus == config.StatusTimeout || p.Task.TaskStatus == config.StatusCancelled {
+		return true
+	}
+	return false
+}
+
+// SetStartTime ...
+func (p *ExternalPluginTaskPlugin) SetStartTime() {
+	p.Task.StartTime = time.Now().Unix()
+}
+
+// SetEndTime ...
+func (p *ExternalPluginTaskPlugin) SetEndTime() {
+	p.Task.EndTime = time.Now().Unix()
+}
+
+// IsTaskEnabled ...
+func (p *ExternalPluginTaskPlugin) IsTaskEnabled() bool {
+	return p.Task.Enabled
+}
+
+// ResetError ...
+func (p *ExternalPluginTaskPlugin) ResetError() {
+	p.Task.Error = ""
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"fmt"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type imageSignatureResult struct {
+	Image      string `json:"image"`
+	Digest     string `json:"digest"`
+	Signed     bool   `json:"signed"`
+	SigningKey string `json:"signing_key"`
+}
+
+type signingKeyResult struct {
+	PrivateKey string `json:"private_key"`
+	Password   string `json:"password"`
+}
+
+// verifyImageSignature asks aslan whether the image is signed by the signing key configured for the build of the image
+func verifyImageSignature(pipelineTask *task.Task, image string) error {
+	keyID := buildSigningKeyID(pipelineTask, image)
+	if keyID == "" {
+		return fmt.Errorf("image %s is not built with a signing key in this task", image)
+	}
+
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	result := new(imageSignatureResult)
+	body := map[string]string{"image": image, "signing_key_id": keyID}
+	if _, err := httpClient.Post("/api/system/signingKey/verify", httpclient.SetBody(body), httpclient.SetResult(result)); err != nil {
+		return fmt.Errorf("failed to verify signature of image %s: %s", image, err)
+	}
+	if !result.Signed {
+		return fmt.Errorf("image %s is not signed by the signing key of its build", image)
+	}
+	return nil
+}
+
+// buildSigningKeyID returns the signing key of the build which pushes the image in the pipeline task
+func buildSigningKeyID(pipelineTask *task.Task, image string) string {
+	pipelineTask.RwLock.Lock()
+	defer pipelineTask.RwLock.Unlock()
+
+	for _, stage := range pipelineTask.Stages {
+		if stage == nil || stage.TaskType != config.TaskBuild {
+			continue
+		}
+		for _, subTask := range stage.SubTasks {
+			build, err := ToBuildTask(subTask)
+			if err != nil || build.JobCtx.DockerBuildCtx == nil || build.JobCtx.DockerBuildCtx.SigningKey == nil {
+				continue
+			}
+			if build.JobCtx.Image == image || build.JobCtx.DockerBuildCtx.ImageName == image {
+				return build.JobCtx.DockerBuildCtx.SigningKey.ID
+			}
+		}
+	}
+	return ""
+}
+
+// loadSigningKey gets the private key of the signing key from aslan, the private key is only passed to the build job
+// and never saved in the task
+func loadSigningKey(key *task.SigningKey) error {
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(configbase.AslanServiceAddress()),
+	)
+
+	result := new(signingKeyResult)
+	url := fmt.Sprintf("/api/system/signingKey/%s", key.ID)
+	if _, err := httpClient.Get(url, httpclient.SetResult(result)); err != nil {
+		return fmt.Errorf("failed to get signing key %s: %s", key.Name, err)
+	}
+	key.PrivateKey = result.PrivateKey
+	key.Password = result.Password
+	return nil
+}
@@ -39,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	zadigconfig "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
@@ -84,34 +85,44 @@ func saveFile(src io.Reader, localFile string) error {
 }
 
 func saveContainerLog(pipelineTask *task.Task, namespace, clusterID, fileName string, jobLabel *JobLabel, kubeClient client.Client) error {
-	selector := labels.Set(getJobLabels(jobLabel)).AsSelector()
-	pods, err := getter.ListPods(namespace, selector, kubeClient)
+	return saveContainerLogs(pipelineTask, namespace, clusterID, fileName, []*JobLabel{jobLabel}, kubeClient)
+}
+
+// saveContainerLogs 将多个 job 的日志按顺序合并后保存为同一个日志文件，用于测试分片
+func saveContainerLogs(pipelineTask *task.Task, namespace, clusterID, fileName string, jobLabels []*JobLabel, kubeClient client.Client) error {
+	clientSet, err := kubeclient.GetClientset(pipelineTask.ConfigPayload.HubServerAddr, clusterID)
 	if err != nil {
+		log.Errorf("saveContainerLog, get client set error: %s", err)
 		return err
 	}
 
-	if len(pods) < 1 {
-		return fmt.Errorf("no pod found with selector: %s", selector)
-	}
+	buf := new(bytes.Buffer)
+	for _, jobLabel := range jobLabels {
+		selector := labels.Set(getJobLabels(jobLabel)).AsSelector()
+		pods, err := getter.ListPods(namespace, selector, kubeClient)
+		if err != nil {
+			return err
+		}
 
-	if len(pods[0].Status.ContainerStatuses) < 1 {
-		return fmt.Errorf("no cotainer statuses : %s", selector)
-	}
+		if len(pods) < 1 {
+			return fmt.Errorf("no pod found with selector: %s", selector)
+		}
 
-	buf := new(bytes.Buffer)
-	// 默认取第一个build job的第一个pod的第一个container的日志
-	sort.SliceStable(pods, func(i, j int) bool {
-		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
-	})
+		if len(pods[0].Status.ContainerStatuses) < 1 {
+			return fmt.Errorf("no cotainer statuses : %s", selector)
+		}
 
-	clientSet, err := kubeclient.GetClientset(pipelineTask.ConfigPayload.HubServerAddr, clusterID)
-	if err != nil {
-		log.Errorf("saveContainerLog, get client set error: %s", err)
-		return err
-	}
+		// 默认取第一个build job的第一个pod的第一个container的日志
+		sort.SliceStable(pods, func(i, j int) bool {
+			return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+		})
 
-	if err := containerlog.GetContainerLogs(namespace, pods[0].Name, pods[0].Spec.Containers[0].Name, false, int64(0), buf, clientSet); err != nil {
-		return err
+		if len(jobLabels) > 1 {
+			buf.WriteString(fmt.Sprintf("========== shard %s/%d ==========\n", jobLabel.Shard, len(jobLabels)))
+		}
+		if err := containerlog.GetContainerLogs(namespace, pods[0].Name, pods[0].Spec.Containers[0].Name, false, int64(0), buf, clientSet); err != nil {
+			return err
+		}
 	}
 
 	if tempFileName, err := util.GenerateTmpFile(); err == nil {
@@ -174,6 +185,7 @@ type JobCtxBuilder struct {
 	PipelineCtx    *task.PipelineCtx
 	JobCtx         task.JobCtx
 	Installs       []*task.Install
+	TraceContext   map[string]string
 }
 
 func replaceWrapLine(script string) string {
@@ -224,6 +236,8 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 		ServiceName:     serviceName,
 		StorageEndpoint: pipelineTask.StorageEndpoint,
 		AesKey:          pipelineTask.ConfigPayload.AesKey,
+		TraceContext:    b.TraceContext,
+		OTLPEndpoint:    zadigconfig.OTLPEndpoint(),
 	}
 
 	if b.PipelineCtx.CacheEnable && !pipelineTask.ConfigPayload.ResetCache {
@@ -313,6 +327,14 @@ func (b *JobCtxBuilder) BuildReaperContext(pipelineTask *task.Task, serviceName
 			ImageName:             b.JobCtx.DockerBuildCtx.ImageName,
 			BuildArgs:             b.JobCtx.DockerBuildCtx.BuildArgs,
 			DockerTemplateContent: b.JobCtx.DockerBuildCtx.DockerTemplateContent,
+			EnableBuildkit:        b.JobCtx.DockerBuildCtx.EnableBuildkit,
+			Platforms:             b.JobCtx.DockerBuildCtx.Platforms,
+			RemoteCache:           b.JobCtx.DockerBuildCtx.RemoteCache,
+			SBOMFormat:            b.JobCtx.DockerBuildCtx.SBOMFormat,
+			SBOMFile:              b.JobCtx.DockerBuildCtx.SBOMFile,
+		}
+		if key := b.JobCtx.DockerBuildCtx.SigningKey; key != nil {
+			ctx.DockerBuildCtx.SigningKey = &task.SigningKey{ID: key.ID, Name: key.Name}
 		}
 	}
 
@@ -367,6 +389,8 @@ type JobLabel struct {
 	TaskType     string
 	ServiceName  string
 	PipelineType string
+	// Shard 测试分片序号，为空时匹配所有分片
+	Shard string
 }
 
 const (
@@ -374,6 +398,7 @@ const (
 	jobLabelServiceKey = "s-service"
 	jobLabelSTypeKey   = "s-type"
 	jobLabelPTypeKey   = "p-type"
+	jobLabelShardKey   = "s-shard"
 )
 
 // getJobLabels get labels k-v map from JobLabel struct
@@ -383,6 +408,7 @@ func getJobLabels(jobLabel *JobLabel) map[string]string {
 		jobLabelServiceKey: strings.ToLower(jobLabel.ServiceName),
 		jobLabelSTypeKey:   strings.Replace(jobLabel.TaskType, "_", "-", -1),
 		jobLabelPTypeKey:   jobLabel.PipelineType,
+		jobLabelShardKey:   jobLabel.Shard,
 	}
 	// no need to add labels with empty value to a job
 	for k, v := range retMap {
@@ -805,7 +831,13 @@ func waitJobReady(ctx context.Context, namespace, jobName string, kubeClient cli
 	return config.StatusRunning
 }
 
-func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName string, checkFile bool, kubeClient client.Client, xl *zap.SugaredLogger) (status config.Status) {
+func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName string, checkFile bool, kubeClient client.Client, xl *zap.SugaredLogger) config.Status {
+	status, _ := waitJobEndWithDogFood(ctx, taskTimeout, namespace, jobName, checkFile, kubeClient, xl)
+	return status
+}
+
+// waitJobEndWithDogFood 同时返回 dog food 文件中记录的任务结果，未读取到时为空
+func waitJobEndWithDogFood(ctx context.Context, taskTimeout int, namespace, jobName string, checkFile bool, kubeClient client.Client, xl *zap.SugaredLogger) (config.Status, commontypes.JobStatus) {
 	xl.Infof("wait job to start: %s/%s", namespace, jobName)
 	timeout := time.After(time.Duration(taskTimeout) * time.Second)
 	podTimeout := time.After(120 * time.Second)
@@ -815,7 +847,7 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 	for {
 		select {
 		case <-podTimeout:
-			return config.StatusTimeout
+			return config.StatusTimeout, ""
 		default:
 			job, _, err := getter.GetJob(namespace, jobName, kubeClient)
 			if err != nil {
@@ -837,16 +869,16 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 	for {
 		select {
 		case <-ctx.Done():
-			return config.StatusCancelled
+			return config.StatusCancelled, ""
 
 		case <-timeout:
-			return config.StatusTimeout
+			return config.StatusTimeout, ""
 
 		default:
 			job, found, err := getter.GetJob(namespace, jobName, kubeClient)
 			if err != nil || !found {
 				xl.Errorf("failed to get pod with label job-name=%s %v", jobName, err)
-				return config.StatusFailed
+				return config.StatusFailed, ""
 			}
 			// pod is still running
 			if job.Status.Active != 0 {
@@ -858,7 +890,7 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 				pods, err := getter.ListPods(namespace, labels.Set{"job-name": jobName}.AsSelector(), kubeClient)
 				if err != nil {
 					xl.Errorf("failed to find pod with label job-name=%s %v", jobName, err)
-					return config.StatusFailed
+					return config.StatusFailed, ""
 				}
 
 				var done, exists bool
@@ -869,7 +901,7 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 						continue
 					}
 					if ipod.Failed() {
-						return config.StatusFailed
+						return config.StatusFailed, ""
 					}
 
 					if !ipod.Finished() {
@@ -889,16 +921,16 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 					xl.Infof("Dog food is found, stop to wait %s. Job status: %s.", job.Name, jobStatus)
 
 					switch jobStatus {
-					case commontypes.JobFail:
-						return config.StatusFailed
+					case commontypes.JobFail, commontypes.JobTestFail:
+						return config.StatusFailed, jobStatus
 					default:
-						return config.StatusPassed
+						return config.StatusPassed, jobStatus
 					}
 				}
 			} else if job.Status.Succeeded != 0 {
-				return config.StatusPassed
+				return config.StatusPassed, ""
 			} else {
-				return config.StatusFailed
+				return config.StatusFailed, ""
 			}
 		}
 
@@ -121,6 +121,15 @@ func (p *ReleaseImagePlugin) Run(ctx context.Context, pipelineTask *task.Task, p
 		return
 	}
 
+	if pipelineTask.VerifyImageSignature {
+		if err := verifyImageSignature(pipelineTask, p.Task.ImageTest); err != nil {
+			p.Log.Error(err)
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = err.Error()
+			return
+		}
+	}
+
 	jobCtx := &types.PredatorContext{
 		JobType: setting.ReleaseImageJob,
 		//Docker build context
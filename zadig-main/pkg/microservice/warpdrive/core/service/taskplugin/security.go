@@ -61,10 +61,6 @@ type SecurityPlugin struct {
 	httpClient *httpclient.Client
 }
 
-type deliverySecurityInfo struct {
-	Result string `json:"result"`
-}
-
 func (p *SecurityPlugin) SetAckFunc(func()) {
 }
 
@@ -112,8 +108,14 @@ func (p *SecurityPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipel
 
 	imageName := p.Task.ImageName
 	go func() {
-		// send request to clair client to analysis image
-		body, err := p.analysis(ctx, imageName, pipelineCtx.DockerHost, namespace)
+		scanner, err := newImageScanner(p.Task.Scanner)
+		if err != nil {
+			p.errorChan <- err
+			return
+		}
+
+		// send request to the scanner to analysis image
+		findings, err := scanner.Scan(ctx, imageName, pipelineCtx.DockerHost, namespace)
 		if err != nil {
 			p.Log.Errorf("analysis err:%+v", err)
 			p.errorChan <- err
@@ -122,7 +124,7 @@ func (p *SecurityPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipel
 
 		var imageID string
 		// send analysis result to aslan to store
-		if imageID, err = p.report(ctx, imageName, body); err != nil {
+		if imageID, err = p.report(ctx, imageName, findings); err != nil {
 			p.Log.Errorf("report err:%+v", err)
 			p.errorChan <- err
 			return
@@ -137,6 +139,18 @@ func (p *SecurityPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipel
 			return
 		}
 		p.Task.Summary = summary
+
+		if p.Task.Policy != nil {
+			violations, reason := evaluateSecurityPolicy(p.Task.Policy, findings, time.Now().Unix())
+			if len(violations) > 0 {
+				p.Task.Violations = violations
+				if p.Task.Policy.Action != config.SecurityPolicyActionWarn {
+					p.errorChan <- fmt.Errorf("漏洞门禁未通过: %s", reason)
+					return
+				}
+				p.Task.Warning = reason
+			}
+		}
 		p.Task.TaskStatus = config.StatusPassed
 	}()
 }
@@ -152,9 +166,10 @@ func (p *SecurityPlugin) getSummary(ctx context.Context, imageID string) (map[st
 	return summary, nil
 }
 
-func (p *SecurityPlugin) report(ctx context.Context, imageName string, body []byte) (string, error) {
+func (p *SecurityPlugin) report(ctx context.Context, imageName string, findings []*vulnerabilityFinding) (string, error) {
 	url := "/api/delivery/security"
 
+	body := &clairAnalysisResult{Result: "success", Findings: findings}
 	res, err := p.httpClient.Post(url, httpclient.SetBody(body))
 	if err != nil {
 		return "", err
@@ -165,27 +180,6 @@ func (p *SecurityPlugin) report(ctx context.Context, imageName string, body []by
 	return p.Task.ImageID, nil
 }
 
-func (p *SecurityPlugin) analysis(ctx context.Context, imageName, dockerHost, namespace string) ([]byte, error) {
-	url := fmt.Sprintf("%s/analyzeLocalImage", configbase.ClairServiceAddress())
-	qs := map[string]string{
-		"imageName":  imageName,
-		"dockerHost": dockerHost,
-		"namespace":  namespace,
-	}
-
-	si := &deliverySecurityInfo{}
-	res, err := httpclient.Get(url, httpclient.SetQueryParams(qs), httpclient.SetResult(si), httpclient.ForceContentType("application/json"))
-	if err != nil {
-		return nil, err
-	}
-
-	if si.Result == "success" {
-		return res.Body(), nil
-	}
-
-	return nil, fmt.Errorf("failed to analysis %s", imageName)
-}
-
 func (p *SecurityPlugin) Wait(ctx context.Context) {
 	timeout := time.After(time.Duration(p.TaskTimeout()) * time.Second)
 	defer p.cancel()
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// vulnerabilityFinding 镜像中的单个漏洞，字段与 aslan 中保存的 DeliverySecurity 一致
+type vulnerabilityFinding struct {
+	ImageID       string               `json:"imageId"`
+	ImageName     string               `json:"imageName"`
+	LayerID       string               `json:"layerId"`
+	Vulnerability findingVulnerability `json:"vulnerability"`
+	Feature       findingFeature       `json:"feature"`
+	Severity      string               `json:"severity"`
+}
+
+type findingVulnerability struct {
+	Name          string                 `json:"name,omitempty"`
+	NamespaceName string                 `json:"namespaceName,omitempty"`
+	Description   string                 `json:"description,omitempty"`
+	Link          string                 `json:"link,omitempty"`
+	Severity      string                 `json:"severity,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	FixedBy       string                 `json:"fixedBy,omitempty"`
+}
+
+type findingFeature struct {
+	Name          string `json:"name,omitempty"`
+	NamespaceName string `json:"namespaceName,omitempty"`
+	VersionFormat string `json:"versionFormat,omitempty"`
+	Version       string `json:"version,omitempty"`
+	AddedBy       string `json:"addedBy,omitempty"`
+}
+
+// imageScanner 镜像漏洞扫描后端，新的后端实现该接口并注册到 imageScanners 即可
+type imageScanner interface {
+	Scan(ctx context.Context, imageName, dockerHost, namespace string) ([]*vulnerabilityFinding, error)
+}
+
+var imageScanners = map[string]func() imageScanner{
+	config.ScannerClair: func() imageScanner { return &clairScanner{} },
+}
+
+func newImageScanner(name string) (imageScanner, error) {
+	if name == "" {
+		name = config.ScannerClair
+	}
+	newScanner, ok := imageScanners[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image scanner %s", name)
+	}
+	return newScanner(), nil
+}
+
+// clairScanner 通过 clair 客户端分析 docker daemon 中的本地镜像
+type clairScanner struct{}
+
+type clairAnalysisResult struct {
+	Result   string                  `json:"result"`
+	Findings []*vulnerabilityFinding `json:"message"`
+}
+
+func (s *clairScanner) Scan(ctx context.Context, imageName, dockerHost, namespace string) ([]*vulnerabilityFinding, error) {
+	url := fmt.Sprintf("%s/analyzeLocalImage", configbase.ClairServiceAddress())
+	qs := map[string]string{
+		"imageName":  imageName,
+		"dockerHost": dockerHost,
+		"namespace":  namespace,
+	}
+
+	result := &clairAnalysisResult{}
+	if _, err := httpclient.Get(url, httpclient.SetQueryParams(qs), httpclient.SetResult(result), httpclient.ForceContentType("application/json")); err != nil {
+		return nil, err
+	}
+	if result.Result != "success" {
+		return nil, fmt.Errorf("failed to analysis %s", imageName)
+	}
+	return result.Findings, nil
+}
+
+// evaluateSecurityPolicy 返回超出门禁上限的漏洞及原因，未过期的豁免漏洞和 FixableOnly 时没有修复版本的漏洞不计入
+func evaluateSecurityPolicy(policy *task.SecurityPolicy, findings []*vulnerabilityFinding, now int64) ([]string, string) {
+	allowed := sets.NewString()
+	for _, item := range policy.Allowlist {
+		if item.ExpireTime == 0 || item.ExpireTime > now {
+			allowed.Insert(item.CVE)
+		}
+	}
+
+	// 同一漏洞可能出现在多个软件包中，按漏洞名称去重
+	counted := make(map[string]sets.String)
+	for _, finding := range findings {
+		name := finding.Vulnerability.Name
+		if name == "" || allowed.Has(name) {
+			continue
+		}
+		if policy.FixableOnly && finding.Vulnerability.FixedBy == "" {
+			continue
+		}
+		severity := finding.Severity
+		if severity == "" {
+			severity = finding.Vulnerability.Severity
+		}
+		if counted[severity] == nil {
+			counted[severity] = sets.NewString()
+		}
+		counted[severity].Insert(name)
+	}
+
+	var violations, reasons []string
+	check := func(severity string, max int) {
+		if max < 0 {
+			return
+		}
+		if cves := counted[severity]; cves.Len() > max {
+			violations = append(violations, cves.List()...)
+			reasons = append(reasons, fmt.Sprintf("%s 漏洞 %d 个，超过上限 %d 个", severity, cves.Len(), max))
+		}
+	}
+	check("Critical", policy.MaxCritical)
+	check("High", policy.MaxHigh)
+	return violations, strings.Join(reasons, "; ")
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -39,6 +40,7 @@ import (
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	commontypes "github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util"
 )
@@ -59,6 +61,9 @@ type TestPlugin struct {
 	kubeClient    client.Client
 	Task          *task.Testing
 	Log           *zap.SugaredLogger
+
+	// onlyTestScriptFailed 任务失败仅由测试脚本导致，测试结果已经归档
+	onlyTestScriptFailed bool
 }
 
 func (p *TestPlugin) SetAckFunc(func()) {
@@ -204,24 +209,6 @@ func (p *TestPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineC
 		pipelineCtx.CacheUserDir = p.renderEnv(pipelineCtx.CacheUserDir)
 	}
 
-	jobCtx := JobCtxBuilder{
-		JobName:        p.JobName,
-		PipelineCtx:    pipelineCtx,
-		ArchiveFile:    fileName,
-		TestReportFile: testReportFile,
-		JobCtx:         p.Task.JobCtx,
-		Installs:       p.Task.InstallCtx,
-	}
-
-	jobCtxBytes, err := yaml.Marshal(jobCtx.BuildReaperContext(pipelineTask, serviceName))
-	if err != nil {
-		msg := fmt.Sprintf("cannot reaper.Context data: %v", err)
-		p.Log.Error(msg)
-		p.Task.TaskStatus = config.StatusFailed
-		p.Task.Error = msg
-		return
-	}
-
 	jobLabel := &JobLabel{
 		PipelineName: pipelineTask.PipelineName,
 		ServiceName:  serviceName,
@@ -237,62 +224,112 @@ func (p *TestPlugin) Run(ctx context.Context, pipelineTask *task.Task, pipelineC
 		return
 	}
 
-	if err := createJobConfigMap(p.KubeNamespace, p.JobName, jobLabel, string(jobCtxBytes), p.kubeClient); err != nil {
-		msg := fmt.Sprintf("createJobConfigMap error: %v", err)
+	if err := ensureDeleteJob(p.KubeNamespace, jobLabel, p.kubeClient); err != nil {
+		msg := fmt.Sprintf("delete testing job error: %v", err)
 		p.Log.Error(msg)
 		p.Task.TaskStatus = config.StatusFailed
 		p.Task.Error = msg
 		return
 	}
 
+	// 将集成到KodeRover的私有镜像仓库的访问权限设置到namespace中
+	if err := createOrUpdateRegistrySecrets(p.KubeNamespace, pipelineTask.ConfigPayload.RegistryID, p.Task.Registries, p.kubeClient); err != nil {
+		p.Log.Errorf("create secret error: %v", err)
+	}
+
 	jobImage := fmt.Sprintf("%s-%s", pipelineTask.ConfigPayload.Release.ReaperImage, p.Task.BuildOS)
 	if p.Task.ImageFrom == config.ImageFromCustom {
 		jobImage = p.Task.BuildOS
 	}
 
+	jobNames := p.shardJobNames()
+	shardLabels := p.shardJobLabels(jobLabel)
+	for i, jobName := range jobNames {
+		jobCtx := JobCtxBuilder{
+			JobName:        jobName,
+			PipelineCtx:    pipelineCtx,
+			ArchiveFile:    fileName,
+			TestReportFile: testReportFile,
+			JobCtx:         p.Task.JobCtx,
+			Installs:       p.Task.InstallCtx,
+			TraceContext:   tracing.Inject(ctx),
+		}
+		// 开启分片时每个分片分别保存测试结果，html 测试报告仅保留第一个分片的原有名称
+		if len(jobNames) > 1 {
+			jobCtx.ArchiveFile = shardFileName(fileName, i)
+			if i > 0 {
+				jobCtx.TestReportFile = shardFileName(testReportFile, i)
+			}
+			jobCtx.JobCtx = p.shardJobCtx(i)
+		}
+
+		if err := p.createTestJob(jobCtx, jobImage, serviceName, shardLabels[i], pipelineCtx, pipelineTask, linkedNamespace); err != nil {
+			p.Log.Error(err)
+			p.Task.TaskStatus = config.StatusFailed
+			p.Task.Error = err.Error()
+			return
+		}
+	}
+
+	p.Task.TaskStatus = waitJobReady(ctx, p.KubeNamespace, jobNames[0], p.kubeClient, p.Log)
+}
+
+func (p *TestPlugin) createTestJob(jobCtx JobCtxBuilder, jobImage, serviceName string, jobLabel *JobLabel, pipelineCtx *task.PipelineCtx, pipelineTask *task.Task, linkedNamespace string) error {
+	jobCtxBytes, err := yaml.Marshal(jobCtx.BuildReaperContext(pipelineTask, serviceName))
+	if err != nil {
+		return fmt.Errorf("cannot reaper.Context data: %v", err)
+	}
+
+	if err := createJobConfigMap(p.KubeNamespace, jobCtx.JobName, jobLabel, string(jobCtxBytes), p.kubeClient); err != nil {
+		return fmt.Errorf("createJobConfigMap error: %v", err)
+	}
+
 	// search namespace should also include desired namespace
 	job, err := buildJobWithLinkedNs(
-		p.Type(), jobImage, p.JobName, serviceName, p.Task.ClusterID, pipelineTask.ConfigPayload.Test.KubeNamespace, p.Task.ResReq, p.Task.ResReqSpec, pipelineCtx, pipelineTask, p.Task.Registries,
+		p.Type(), jobImage, jobCtx.JobName, serviceName, p.Task.ClusterID, pipelineTask.ConfigPayload.Test.KubeNamespace, p.Task.ResReq, p.Task.ResReqSpec, pipelineCtx, pipelineTask, p.Task.Registries,
 		p.KubeNamespace,
 		linkedNamespace,
 	)
-
-	job.Namespace = p.KubeNamespace
-
 	if err != nil {
-		msg := fmt.Sprintf("create testing job context error: %v", err)
-		p.Log.Error(msg)
-		p.Task.TaskStatus = config.StatusFailed
-		p.Task.Error = msg
-		return
+		return fmt.Errorf("create testing job context error: %v", err)
 	}
 
-	if err := ensureDeleteJob(p.KubeNamespace, jobLabel, p.kubeClient); err != nil {
-		msg := fmt.Sprintf("delete testing job error: %v", err)
-		p.Log.Error(msg)
-		p.Task.TaskStatus = config.StatusFailed
-		p.Task.Error = msg
-		return
+	job.Namespace = p.KubeNamespace
+	if jobLabel.Shard != "" {
+		job.Labels[jobLabelShardKey] = jobLabel.Shard
+		job.Spec.Template.Labels[jobLabelShardKey] = jobLabel.Shard
 	}
 
-	// 将集成到KodeRover的私有镜像仓库的访问权限设置到namespace中
-	if err := createOrUpdateRegistrySecrets(p.KubeNamespace, pipelineTask.ConfigPayload.RegistryID, p.Task.Registries, p.kubeClient); err != nil {
-		p.Log.Errorf("create secret error: %v", err)
-	}
 	if err := updater.CreateJob(job, p.kubeClient); err != nil {
-		msg := fmt.Sprintf("create testing job error: %v", err)
-		p.Log.Error(msg)
-		p.Task.TaskStatus = config.StatusFailed
-		p.Task.Error = msg
-		return
+		return fmt.Errorf("create testing job error: %v", err)
 	}
-
-	p.Task.TaskStatus = waitJobReady(ctx, p.KubeNamespace, p.JobName, p.kubeClient, p.Log)
+	return nil
 }
 
 func (p *TestPlugin) Wait(ctx context.Context) {
-	status := waitJobEndWithFile(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.Log)
-	p.SetStatus(status)
+	jobNames := p.shardJobNames()
+	if len(jobNames) == 1 {
+		status, jobStatus := waitJobEndWithDogFood(ctx, p.TaskTimeout(), p.KubeNamespace, p.JobName, true, p.kubeClient, p.Log)
+		p.onlyTestScriptFailed = jobStatus == commontypes.JobTestFail
+		p.SetStatus(status)
+		return
+	}
+
+	// 各分片并行执行，等待所有分片结束后汇总状态
+	timeout := p.TaskTimeout()
+	statuses := make([]config.Status, len(jobNames))
+	jobStatuses := make([]commontypes.JobStatus, len(jobNames))
+	var wg sync.WaitGroup
+	for i, jobName := range jobNames {
+		wg.Add(1)
+		go func(i int, jobName string) {
+			defer wg.Done()
+			statuses[i], jobStatuses[i] = waitJobEndWithDogFood(ctx, timeout, p.KubeNamespace, jobName, true, p.kubeClient, p.Log)
+		}(i, jobName)
+	}
+	wg.Wait()
+	p.onlyTestScriptFailed = onlyTestScriptFailed(statuses, jobStatuses)
+	p.SetStatus(mergeShardStatus(statuses))
 }
 
 func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serviceName string) {
@@ -320,7 +357,7 @@ func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serv
 		return
 	}()
 
-	err := saveContainerLog(pipelineTask, p.KubeNamespace, p.Task.ClusterID, p.FileName, jobLabel, p.kubeClient)
+	err := saveContainerLogs(pipelineTask, p.KubeNamespace, p.Task.ClusterID, p.FileName, p.shardJobLabels(jobLabel), p.kubeClient)
 	if err != nil {
 		p.Log.Error(err)
 		p.Task.Error = err.Error()
@@ -387,6 +424,16 @@ func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serv
 	}()
 
 	store.Subfolder = strings.Replace(store.Subfolder, fmt.Sprintf("%s/%d/%s", pipelineName, pipelineTaskID, "artifact"), fmt.Sprintf("%s/%d/%s", pipelineName, pipelineTaskID, "test"), -1)
+	if p.shardCount() > 1 && p.Task.JobCtx.TestType == setting.FunctionTest {
+		missingShards, err := p.mergeShardTestReports(s3client, store, fileName)
+		if err != nil {
+			p.Log.Errorf("failed to merge test results of shards: %s", err)
+		}
+		if len(missingShards) > 0 {
+			// 合并后的报告缺少部分分片的结果，无论报告内容如何都不能视为测试通过
+			defer p.markShardsMissing(missingShards)
+		}
+	}
 	objectKey := store.GetObjectPath(fileName)
 	err = s3client.Download(store.Bucket, objectKey, tmpFilename)
 	if err != nil {
@@ -412,12 +459,24 @@ func (p *TestPlugin) Complete(ctx context.Context, pipelineTask *task.Task, serv
 			p.Task.TaskStatus = config.StatusFailed
 			return
 		}
+		quarantinedFailures := countQuarantinedFailures(p.Task.QuarantinedCases, testReport.FunctionTestSuite.TestCases)
 		p.Task.ReportReady = true
 		testReport.FunctionTestSuite.TestCases = []types.TestCase{}
 		//测试报告
 		pipelineTask.TestReports[serviceName] = testReport
 
-		if testReport.FunctionTestSuite.Errors > 0 || testReport.FunctionTestSuite.Failures > 0 {
+		failures := testReport.FunctionTestSuite.Errors + testReport.FunctionTestSuite.Failures
+		if failures > 0 && quarantinedFailures >= failures {
+			// 失败的用例都已被隔离，仍然在报告中展示，但不影响测试结果
+			p.Log.Warnf("%d quarantined failure case(s) ignored", quarantinedFailures)
+			// 脚本、后置脚本等与用例无关的失败仍然保留
+			if p.Task.TaskStatus == config.StatusFailed && p.onlyTestScriptFailed {
+				p.Task.TaskStatus = config.StatusPassed
+				p.Task.Error = ""
+			}
+			return
+		}
+		if failures > 0 {
 			msg := fmt.Sprintf(
 				"%d failure case(s) found",
 				testReport.FunctionTestSuite.Errors+testReport.FunctionTestSuite.Failures,
@@ -556,3 +615,23 @@ func (p *TestPlugin) renderEnv(data string) string {
 
 	return os.Expand(data, mapper)
 }
+
+func countQuarantinedFailures(quarantinedCases []*task.QuarantinedTestCase, testCases []types.TestCase) int {
+	if len(quarantinedCases) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, testCase := range testCases {
+		if testCase.Failure == nil && testCase.Error == nil {
+			continue
+		}
+		for _, quarantined := range quarantinedCases {
+			if quarantined.ClassName == testCase.ClassName && quarantined.CaseName == testCase.Name {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskplugin
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskplugin/s3"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	commontypes "github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	testShardIndexEnv = "TEST_SHARD_INDEX"
+	testShardTotalEnv = "TEST_SHARD_TOTAL"
+	testShardCasesEnv = "TEST_SHARD_CASES"
+)
+
+// shardCount 测试分片数量，未开启分片时为 1
+func (p *TestPlugin) shardCount() int {
+	if p.Task.ShardCount > 1 {
+		return p.Task.ShardCount
+	}
+	return 1
+}
+
+// shardJobNames 各分片对应的 job 名称，未开启分片时保持原有的 job 名称
+func (p *TestPlugin) shardJobNames() []string {
+	if p.shardCount() == 1 {
+		return []string{p.JobName}
+	}
+
+	names := make([]string, 0, p.shardCount())
+	for i := 0; i < p.shardCount(); i++ {
+		names = append(names, fmt.Sprintf("%s-%d", p.JobName, i))
+	}
+	return names
+}
+
+// shardJobLabels 各分片对应的 job label，用于分别获取每个分片的日志
+func (p *TestPlugin) shardJobLabels(jobLabel *JobLabel) []*JobLabel {
+	if p.shardCount() == 1 {
+		return []*JobLabel{jobLabel}
+	}
+
+	jobLabels := make([]*JobLabel, 0, p.shardCount())
+	for i := 0; i < p.shardCount(); i++ {
+		shardLabel := *jobLabel
+		shardLabel.Shard = strconv.Itoa(i)
+		jobLabels = append(jobLabels, &shardLabel)
+	}
+	return jobLabels
+}
+
+// shardFileName 分片测试结果的保存名称，合并后使用原有名称保存
+func shardFileName(fileName string, index int) string {
+	return fmt.Sprintf("%s-shard-%d", fileName, index)
+}
+
+// shardJobCtx 为分片注入分片序号、分片总数以及按耗时切分好的用例
+func (p *TestPlugin) shardJobCtx(index int) task.JobCtx {
+	jobCtx := p.Task.JobCtx
+	jobCtx.EnvVars = make([]*task.KeyVal, 0, len(p.Task.JobCtx.EnvVars)+3)
+	jobCtx.EnvVars = append(jobCtx.EnvVars, p.Task.JobCtx.EnvVars...)
+	jobCtx.EnvVars = append(jobCtx.EnvVars,
+		&task.KeyVal{Key: testShardIndexEnv, Value: strconv.Itoa(index)},
+		&task.KeyVal{Key: testShardTotalEnv, Value: strconv.Itoa(p.shardCount())},
+	)
+	if index < len(p.Task.ShardCases) {
+		jobCtx.EnvVars = append(jobCtx.EnvVars, &task.KeyVal{Key: testShardCasesEnv, Value: strings.Join(p.Task.ShardCases[index], "\n")})
+	}
+	return jobCtx
+}
+
+// mergeShardStatus 任意分片取消、超时或失败时，整个测试任务即为对应状态
+func mergeShardStatus(statuses []config.Status) config.Status {
+	for _, expected := range []config.Status{config.StatusCancelled, config.StatusTimeout, config.StatusFailed} {
+		for _, status := range statuses {
+			if status == expected {
+				return status
+			}
+		}
+	}
+	return config.StatusPassed
+}
+
+// onlyTestScriptFailed 失败的分片均仅由测试脚本导致
+func onlyTestScriptFailed(statuses []config.Status, jobStatuses []commontypes.JobStatus) bool {
+	failed := false
+	for i, status := range statuses {
+		if status == config.StatusPassed {
+			continue
+		}
+		if status != config.StatusFailed || jobStatuses[i] != commontypes.JobTestFail {
+			return false
+		}
+		failed = true
+	}
+	return failed
+}
+
+// mergeTestSuites 合并各分片的测试结果，分片并行执行，耗时取最大值
+func mergeTestSuites(suites []*types.TestSuite) *types.TestSuite {
+	merged := &types.TestSuite{TestCases: []types.TestCase{}}
+	for _, suite := range suites {
+		merged.Tests += suite.Tests
+		merged.Failures += suite.Failures
+		merged.Successes += suite.Successes
+		merged.Skips += suite.Skips
+		merged.Errors += suite.Errors
+		if suite.Time > merged.Time {
+			merged.Time = suite.Time
+		}
+		merged.TestCases = append(merged.TestCases, suite.TestCases...)
+		// 各分片只执行了部分用例，无法从汇总数据中计算覆盖率的并集，这里取覆盖率最高的分片作为下限
+		if suite.Coverage != nil && (merged.Coverage == nil || suite.Coverage.LineRate > merged.Coverage.LineRate) {
+			merged.Coverage = suite.Coverage
+		}
+	}
+	return merged
+}
+
+// mergeShardTestReports 下载各分片的测试结果，合并后使用原有名称上传，后续流程与未分片时一致
+// 返回没有测试结果的分片序号，由调用方将测试标记为失败
+func (p *TestPlugin) mergeShardTestReports(s3client *s3tool.Client, store *s3.S3, fileName string) ([]int, error) {
+	suites := make([]*types.TestSuite, 0, p.shardCount())
+	missingShards := make([]int, 0)
+	for i := 0; i < p.shardCount(); i++ {
+		suite, err := downloadTestSuite(s3client, store, shardFileName(fileName, i))
+		if err != nil {
+			p.Log.Warnf("failed to get test result of shard %d: %s", i, err)
+			missingShards = append(missingShards, i)
+			continue
+		}
+		suites = append(suites, suite)
+	}
+	if len(suites) == 0 {
+		return missingShards, fmt.Errorf("no test result is found in %d shards", p.shardCount())
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(buf).EncodeElement(mergeTestSuites(suites), xml.StartElement{Name: xml.Name{Local: "testsuite"}}); err != nil {
+		return missingShards, fmt.Errorf("marshal merged test result error: %s", err)
+	}
+
+	tmpFilename, err := util.GenerateTmpFile()
+	if err != nil {
+		return missingShards, err
+	}
+	defer func() {
+		_ = os.Remove(tmpFilename)
+	}()
+	if err := saveFile(buf, tmpFilename); err != nil {
+		return missingShards, err
+	}
+	return missingShards, s3client.Upload(store.Bucket, tmpFilename, store.GetObjectPath(fileName))
+}
+
+// markShardsMissing 有分片没有上传测试结果时，测试结果不完整，将任务标记为失败
+func (p *TestPlugin) markShardsMissing(missingShards []int) {
+	msg := fmt.Sprintf("no test result is found in shard(s) %v of %d", missingShards, p.shardCount())
+	p.Log.Error(msg)
+	if p.Task.Error != "" {
+		msg = p.Task.Error + "; " + msg
+	}
+	p.Task.Error = msg
+	p.Task.TaskStatus = config.StatusFailed
+}
+
+func downloadTestSuite(s3client *s3tool.Client, store *s3.S3, fileName string) (*types.TestSuite, error) {
+	tmpFilename, err := util.GenerateTmpFile()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(tmpFilename)
+	}()
+
+	if err := s3client.Download(store.Bucket, store.GetObjectPath(fileName), tmpFilename); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(tmpFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	suite := new(types.TestSuite)
+	if err := xml.Unmarshal(b, suite); err != nil {
+		return nil, err
+	}
+	return suite, nil
+}
@@ -219,3 +219,19 @@ func ToExtensionTask(sb map[string]interface{}) (*task.Extension, error) {
 	}
 	return extension, nil
 }
+
+func ToExternalPluginTask(sb map[string]interface{}) (*task.ExternalPlugin, error) {
+	var plugin *task.ExternalPlugin
+	if err := task.IToi(sb, &plugin); err != nil {
+		return nil, fmt.Errorf("convert interface to externalPluginTask error: %s", err)
+	}
+	return plugin, nil
+}
+
+func ToApprovalTask(sb map[string]interface{}) (*task.Approval, error) {
+	var approval *task.Approval
+	if err := task.IToi(sb, &approval); err != nil {
+		return nil, fmt.Errorf("convert interface to approvalTask error: %s", err)
+	}
+	return approval, nil
+}
@@ -65,6 +65,19 @@ type TestSuite struct {
 	TestCases []TestCase `bson:"testcase"                json:"testcase"                 xml:"testcase"`
 	SuiteType string     `bson:"-"                       json:"-"                        xml:"-"`
 	Name      string     `bson:"name"                    json:"-"                        xml:"-"`
+	// Coverage 测试结果目录中的覆盖率报告汇总
+	Coverage *CoverageSummary `bson:"coverage,omitempty"      json:"coverage,omitempty"       xml:"coverage,omitempty"`
+}
+
+// CoverageSummary 覆盖率汇总，LineRate 和 BranchRate 取值范围 0~1
+type CoverageSummary struct {
+	Format          string  `bson:"format"                  json:"format"                   xml:"format,attr"`
+	LineRate        float64 `bson:"line_rate"               json:"line_rate"                xml:"line-rate,attr"`
+	LinesCovered    int     `bson:"lines_covered"           json:"lines_covered"            xml:"lines-covered,attr"`
+	LinesValid      int     `bson:"lines_valid"             json:"lines_valid"              xml:"lines-valid,attr"`
+	BranchRate      float64 `bson:"branch_rate"             json:"branch_rate"              xml:"branch-rate,attr"`
+	BranchesCovered int     `bson:"branches_covered"        json:"branches_covered"         xml:"branches-covered,attr"`
+	BranchesValid   int     `bson:"branches_valid"          json:"branches_valid"           xml:"branches-valid,attr"`
 }
 
 type Skipped struct {
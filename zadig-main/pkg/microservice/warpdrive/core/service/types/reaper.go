@@ -123,6 +123,10 @@ type Context struct {
 	Cache        types.Cache        `yaml:"cache"`
 	CacheDirType types.CacheDirType `yaml:"cache_dir_type"`
 	CacheUserDir string             `yaml:"cache_user_dir"`
+
+	// TraceContext 子任务 span 的 trace 上下文, OTLPEndpoint 为接收 span 的 collector 地址
+	TraceContext map[string]string `yaml:"trace_context,omitempty"`
+	OTLPEndpoint string            `yaml:"otlp_endpoint,omitempty"`
 }
 
 type ArtifactInfo struct {
@@ -66,6 +66,11 @@ type Build struct {
 	CacheEnable  bool               `bson:"cache_enable"        json:"cache_enable"`
 	CacheDirType types.CacheDirType `bson:"cache_dir_type"      json:"cache_dir_type"`
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
+
+	// ResultCacheEnable 相同代码版本和编译配置的编译结果可以直接复用
+	ResultCacheEnable bool   `bson:"result_cache_enable"             json:"result_cache_enable"`
+	CacheKey          string `bson:"cache_key,omitempty"             json:"cache_key,omitempty"`
+	Cached            bool   `bson:"cached"                          json:"cached"`
 }
 
 type ArtifactInfo struct {
@@ -222,6 +227,22 @@ type DockerBuildCtx struct {
 	ImageReleaseTag       string `yaml:"image_release_tag,omitempty" bson:"image_release_tag,omitempty" json:"image_release_tag"`
 	Source                string `yaml:"source" bson:"source" json:"source"`
 	DockerTemplateContent string `yaml:"docker_template_content" bson:"docker_template_content" json:"docker_template_content"`
+	// EnableBuildkit 使用 buildx 构建, Platforms 和 RemoteCache 仅在 buildx 下生效
+	EnableBuildkit bool     `yaml:"enable_buildkit" bson:"enable_buildkit" json:"enable_buildkit"`
+	Platforms      []string `yaml:"platforms,omitempty" bson:"platforms,omitempty" json:"platforms,omitempty"`
+	RemoteCache    bool     `yaml:"remote_cache" bson:"remote_cache" json:"remote_cache"`
+	// SBOMFormat 非空时推送镜像后生成 SBOM, 文件名为 SBOMFile; SigningKey 非空时使用其签名镜像
+	SBOMFormat string      `yaml:"sbom_format,omitempty" bson:"sbom_format,omitempty" json:"sbom_format,omitempty"`
+	SBOMFile   string      `yaml:"sbom_file,omitempty" bson:"sbom_file,omitempty" json:"sbom_file,omitempty"`
+	SigningKey *SigningKey `yaml:"signing_key,omitempty" bson:"signing_key,omitempty" json:"signing_key,omitempty"`
+}
+
+// SigningKey 签名镜像使用的密钥, 私钥及其密码只在运行时获取并传给 reaper, 不随任务保存和返回
+type SigningKey struct {
+	ID         string `yaml:"id"          bson:"id"  json:"id"`
+	Name       string `yaml:"name"        bson:"name" json:"name"`
+	PrivateKey string `yaml:"private_key" bson:"-"    json:"-"`
+	Password   string `yaml:"password"    bson:"-"    json:"-"`
 }
 
 type FileArchiveCtx struct {
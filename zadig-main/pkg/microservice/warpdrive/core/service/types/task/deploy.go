@@ -53,6 +53,37 @@ type Deploy struct {
 	IsRestart        bool                         `bson:"is_restart"                    json:"is_restart"`
 	ResetImage       bool                         `bson:"reset_image"                   json:"reset_image"`
 	ResetImagePolicy setting.ResetImagePolicyType `bson:"reset_image_policy"            json:"reset_image_policy"`
+	Strategy         *DeployStrategy              `bson:"strategy,omitempty"            json:"strategy,omitempty"`
+	StrategySteps    []*DeployStrategyStep        `bson:"strategy_steps,omitempty"      json:"strategy_steps,omitempty"`
+}
+
+// DeployStrategyStep 记录灰度或蓝绿发布每一步的执行状态
+type DeployStrategyStep struct {
+	Name      string        `bson:"name"                          json:"name"`
+	Status    config.Status `bson:"status"                        json:"status"`
+	Message   string        `bson:"message,omitempty"             json:"message,omitempty"`
+	StartTime int64         `bson:"start_time,omitempty"          json:"start_time,omitempty"`
+	EndTime   int64         `bson:"end_time,omitempty"            json:"end_time,omitempty"`
+}
+
+// DeployStrategy 部署策略，默认为原地滚动更新
+type DeployStrategy struct {
+	Type setting.DeployStrategyType `bson:"type"                          json:"type"`
+	// CanarySteps 灰度发布时逐步切换的流量比例
+	CanarySteps []*CanaryStep `bson:"canary_steps,omitempty"        json:"canary_steps,omitempty"`
+	// CanaryReplicas 灰度负载的副本数，默认为1
+	CanaryReplicas int32 `bson:"canary_replicas,omitempty"     json:"canary_replicas,omitempty"`
+	// IngressName 设置后通过 nginx ingress canary 注解按权重切分流量，否则按副本数比例切分 Service 流量
+	IngressName string `bson:"ingress_name,omitempty"        json:"ingress_name,omitempty"`
+	// K8sServiceName 蓝绿发布时切换 selector 的 Service，灰度发布时用于生成灰度 Service
+	K8sServiceName string `bson:"k8s_service_name,omitempty"    json:"k8s_service_name,omitempty"`
+}
+
+type CanaryStep struct {
+	// Weight 流量比例，取值 1-100
+	Weight int `bson:"weight"                        json:"weight"`
+	// Pause 当前比例验证通过后等待的时间，单位秒
+	Pause int `bson:"pause"                         json:"pause"`
 }
 
 // SetNamespace ...
@@ -39,6 +39,10 @@ type DockerBuild struct {
 	EndTime    int64           `bson:"end_time"                json:"end_time,omitempty"`
 	JobName    string          `bson:"job_name"                json:"job_name"`
 	LogFile    string          `bson:"log_file"                json:"log_file"`
+	// buildx 构建参数
+	EnableBuildkit bool     `bson:"enable_buildkit"         json:"enable_buildkit"`
+	Platforms      []string `bson:"platforms,omitempty"     json:"platforms,omitempty"`
+	RemoteCache    bool     `bson:"remote_cache"            json:"remote_cache"`
 }
 
 func (db *DockerBuild) ToSubTask() (map[string]interface{}, error) {
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package task
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/warpdrive/config"
+)
+
+type ExternalPlugin struct {
+	TaskType     config.TaskType           `bson:"type"                       json:"type"`
+	Enabled      bool                      `bson:"enabled"                    json:"enabled"`
+	TaskStatus   config.Status             `bson:"status"                     json:"status"`
+	PluginID     string                    `bson:"plugin_id"                  json:"plugin_id"`
+	PluginName   string                    `bson:"plugin_name"                json:"plugin_name"`
+	Params       []*models.KeyVal          `bson:"params,omitempty"           json:"params"`
+	ServiceInfos []*ServiceInfo            `bson:"service_infos"              json:"service_infos"`
+	Timeout      int                       `bson:"timeout"                    json:"timeout,omitempty"`
+	IsRestart    bool                      `bson:"is_restart"                 json:"is_restart"`
+	ExecutionID  string                    `bson:"execution_id,omitempty"     json:"execution_id,omitempty"`
+	Message      string                    `bson:"message,omitempty"          json:"message,omitempty"`
+	Artifacts    []*ExternalPluginArtifact `bson:"artifacts,omitempty"        json:"artifacts,omitempty"`
+	Error        string                    `bson:"error,omitempty"            json:"error,omitempty"`
+	StartTime    int64                     `bson:"start_time"                 json:"start_time,omitempty"`
+	EndTime      int64                     `bson:"end_time"                   json:"end_time,omitempty"`
+}
+
+type ExternalPluginArtifact struct {
+	Name string `bson:"name"                       json:"name"`
+	URL  string `bson:"url"                        json:"url"`
+}
+
+// ToSubTask ...
+func (t *ExternalPlugin) ToSubTask() (map[string]interface{}, error) {
+	var task map[string]interface{}
+	if err := IToi(t, &task); err != nil {
+		return nil, fmt.Errorf("convert external plugin to interface error: %s", err)
+	}
+	return task, nil
+}
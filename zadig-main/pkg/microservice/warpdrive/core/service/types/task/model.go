@@ -84,6 +84,10 @@ type Task struct {
 	IsRestart        bool                         `bson:"is_restart"                  json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"            json:"storage_endpoint"`
 	ArtifactInfo     *ArtifactInfo                `bson:"artifact_info"               json:"artifact_info"`
+	// VerifyImageSignature 部署和分发镜像前校验镜像签名
+	VerifyImageSignature bool `bson:"verify_image_signature" json:"verify_image_signature"`
+	// TraceContext aslan 创建任务时生成的 trace 上下文
+	TraceContext map[string]string `bson:"trace_context,omitempty" json:"trace_context,omitempty"`
 }
 
 type RenderInfo struct {
@@ -120,6 +124,8 @@ type DeployArgs struct {
 	// 格式: {service name}-{timestamp}-{suffix}}.tar.gz
 	// timestamp format: 20060102150405
 	PackageFile string `json:"package_file"`
+	// 部署策略
+	Strategy *DeployStrategy `json:"strategy,omitempty"`
 }
 
 type CallbackArgs struct {
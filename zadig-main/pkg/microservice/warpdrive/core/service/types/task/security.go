@@ -34,6 +34,27 @@ type Security struct {
 	EndTime    int64           `bson:"end_time,omitempty"            json:"end_time,omitempty"`
 	LogFile    string          `bson:"log_file"                      json:"log_file"`
 	Summary    map[string]int  `bson:"summary"                       json:"summary"`
+	// Scanner 和 Policy 来自工作流的安全扫描配置，Violations 为超出门禁的漏洞，Warning 为仅告警时的提示
+	Scanner    string          `bson:"scanner,omitempty"             json:"scanner,omitempty"`
+	Policy     *SecurityPolicy `bson:"policy,omitempty"              json:"policy,omitempty"`
+	Violations []string        `bson:"violations,omitempty"          json:"violations,omitempty"`
+	Warning    string          `bson:"warning,omitempty"             json:"warning,omitempty"`
+}
+
+// SecurityPolicy 漏洞门禁策略，MaxCritical 和 MaxHigh 为允许的漏洞数上限，负数表示不限制
+type SecurityPolicy struct {
+	Action      string              `bson:"action"                json:"action"`
+	MaxCritical int                 `bson:"max_critical"          json:"max_critical"`
+	MaxHigh     int                 `bson:"max_high"              json:"max_high"`
+	FixableOnly bool                `bson:"fixable_only"          json:"fixable_only"`
+	Allowlist   []*CVEAllowlistItem `bson:"allowlist,omitempty"   json:"allowlist,omitempty"`
+}
+
+// CVEAllowlistItem 豁免的漏洞，ExpireTime 为 0 时永久豁免
+type CVEAllowlistItem struct {
+	CVE        string `bson:"cve"                   json:"cve"`
+	ExpireTime int64  `bson:"expire_time"           json:"expire_time"`
+	Reason     string `bson:"reason"                json:"reason"`
 }
 
 func (s *Security) SetImageName(imageName string) {
@@ -49,6 +49,12 @@ type Testing struct {
 	Registries     []*RegistryNamespace `bson:"-"                               json:"registries"`
 	ClusterID      string               `bson:"cluster_id"                      json:"cluster_id"`
 	Namespace      string               `bson:"namespace"                       json:"namespace"`
+	// QuarantinedCases 被隔离的用例，只有这些用例失败时不会导致测试失败
+	QuarantinedCases []*QuarantinedTestCase `bson:"quarantined_cases,omitempty"     json:"quarantined_cases,omitempty"`
+	// ShardCount 大于 1 时会启动多个测试 Job 并行执行，并合并各分片的测试报告
+	ShardCount int `bson:"shard_count,omitempty"           json:"shard_count,omitempty"`
+	// ShardCases 按照历史耗时切分好的各分片用例，为空时由测试脚本根据分片序号自行选择用例
+	ShardCases [][]string `bson:"shard_cases,omitempty"           json:"shard_cases,omitempty"`
 
 	// New since V1.10.0.
 	Cache        types.Cache        `bson:"cache"               json:"cache"`
@@ -57,6 +63,11 @@ type Testing struct {
 	CacheUserDir string             `bson:"cache_user_dir"      json:"cache_user_dir"`
 }
 
+type QuarantinedTestCase struct {
+	ClassName string `bson:"class_name"    json:"class_name"`
+	CaseName  string `bson:"case_name"     json:"case_name"`
+}
+
 func (t *Testing) ToSubTask() (map[string]interface{}, error) {
 	var task map[string]interface{}
 	if err := IToi(t, &task); err != nil {
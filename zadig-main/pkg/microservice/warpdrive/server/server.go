@@ -25,6 +25,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskcontroller"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 func Serve(ctx context.Context) error {
@@ -37,11 +39,24 @@ func Serve(ctx context.Context) error {
 
 	log.Info("Warpdrive service start ... ")
 
+	metrics.RegisterKubeClientMetrics()
+
+	shutdownTracing, err := tracing.Init("warpdrive", commonconfig.OTLPEndpoint())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Errorf("Failed to flush spans, error: %s", err)
+		}
+	}()
+
 	if err := taskcontroller.InitTaskController(ctx); err != nil {
 		log.Fatalf("NewTaskController error: %v", err)
 	}
 
 	http.HandleFunc("/ping", ping)
+	http.Handle("/metrics", metrics.Handler())
 	server := &http.Server{Addr: ":25001", Handler: nil}
 
 	stopChan := make(chan struct{})
@@ -35,6 +35,7 @@ const (
 	ENVMysqlPassword           = "MYSQL_PASSWORD"
 	ENVMysqlHost               = "MYSQL_HOST"
 	ENVMysqlUserDb             = "MYSQL_USER_DB"
+	ENVOTLPEndpoint            = "OTLP_ENDPOINT"
 
 	// Aslan
 	ENVPodName              = "BE_POD_NAME"
@@ -85,11 +86,12 @@ const (
 	Params                = "X-API-Tunnel-Params"
 
 	// warpdrive
-	WarpDrivePodName    = "WD_POD_NAME"
-	ReleaseImageTimeout = "RELEASE_IMAGE_TIMEOUT"
-	DefaultRegistryAddr = "DEFAULT_REG_ADDRESS"
-	DefaultRegistryAK   = "DEFAULT_REG_ACCESS_KEY"
-	DefaultRegistrySK   = "DEFAULT_REG_SECRET_KEY"
+	WarpDrivePodName         = "WD_POD_NAME"
+	WarpDriveTaskConcurrency = "WD_TASK_CONCURRENCY"
+	ReleaseImageTimeout      = "RELEASE_IMAGE_TIMEOUT"
+	DefaultRegistryAddr      = "DEFAULT_REG_ADDRESS"
+	DefaultRegistryAK        = "DEFAULT_REG_ACCESS_KEY"
+	DefaultRegistrySK        = "DEFAULT_REG_SECRET_KEY"
 
 	// reaper
 	Home          = "HOME"
@@ -160,6 +162,7 @@ const (
 	IngressProxySendTimeoutLabel    = "nginx.ingress.kubernetes.io/proxy-send-timeout"
 	IngressProxyReadTimeoutLabel    = "nginx.ingress.kubernetes.io/proxy-read-timeout"
 	ComponentLabel                  = "app.kubernetes.io/component"
+	InstanceLabel                   = "app.kubernetes.io/instance"
 	companyLabel                    = "koderover.io"
 	DirtyLabel                      = companyLabel + "/" + "modified-since-last-update"
 	OwnerLabel                      = companyLabel + "/" + "owner"
@@ -203,6 +206,10 @@ const (
 	APIVersionAppsV1 = "apps/v1"
 
 	DefaultImagePullSecret = "default-registry-secret"
+
+	// EnvResourceQuotaName 和 EnvLimitRangeName 是环境 namespace 中由 zadig 管理的配额对象
+	EnvResourceQuotaName = "zadig-env-quota"
+	EnvLimitRangeName    = "zadig-env-limit-range"
 )
 
 const (
@@ -599,6 +606,21 @@ const (
 	ResetImagePolicyTestFailed         ResetImagePolicyType = "testFailed"
 )
 
+type DeployStrategyType string
+
+const (
+	DeployStrategyRolling   DeployStrategyType = ""
+	DeployStrategyCanary    DeployStrategyType = "canary"
+	DeployStrategyBlueGreen DeployStrategyType = "blue_green"
+)
+
+// labels set on the pods created by canary and blue-green deployments
+const (
+	DeployTrackLabel     = "s-track"
+	DeployTrackCanary    = "canary"
+	DeployTrackBlueGreen = "green"
+)
+
 const LocalClusterID = "0123456789abcdef12345678"
 
 const RequestModeOpenAPI = "openAPI"
@@ -76,3 +76,14 @@ func (c *Client) GetLocalCluster() (*clusterResp, error) {
 
 	return clusterResp, nil
 }
+
+func (c *Client) NotifyClusterDisconnected(clusterID string) error {
+	url := fmt.Sprintf("/cluster/clusters/%s/disconnected", clusterID)
+
+	_, err := c.Post(url)
+	if err != nil {
+		return fmt.Errorf("Failed to notify cluster disconnected, error: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:   1024,
+	WriteBufferSize:  1024,
+	HandshakeTimeout: 5 * time.Second,
+	CheckOrigin:      checkSameOrigin,
+}
+
+// checkSameOrigin rejects cross-site requests so that other sites can not open a websocket with the user's session.
+// Requests without Origin header are not sent by browsers and are allowed.
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// WebSocketStream works like Stream, but pushes the messages produced by p to the client as json over WebSocket.
+// The producer is cancelled once the client disconnects.
+func WebSocketStream(c *gin.Context, p producer, log *zap.SugaredLogger) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Errorf("Failed to upgrade to websocket, error: %s", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the client never sends data messages, a read error means the connection is closed
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	streamChan := make(chan interface{}, 10)
+	go func() {
+		p(ctx, streamChan)
+		close(streamChan)
+	}()
+
+	for msg := range streamChan {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Infof("Connection closed, stopping websocket stream: %s", err)
+			cancel()
+			break
+		}
+	}
+	// drain the channel so that the producer will not be blocked
+	for range streamChan {
+	}
+
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Check websocket origin", func() {
+	newRequest := func(host, origin string) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "http://"+host+"/api/aslan/logs/log/tasks/workflow/demo/1/build/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	ginkgo.It("should allow requests of the same origin", func() {
+		Expect(checkSameOrigin(newRequest("zadig.example.com", "https://zadig.example.com"))).To(BeTrue())
+	})
+
+	ginkgo.It("should allow requests without origin", func() {
+		Expect(checkSameOrigin(newRequest("zadig.example.com", ""))).To(BeTrue())
+	})
+
+	ginkgo.It("should reject cross-site requests", func() {
+		Expect(checkSameOrigin(newRequest("zadig.example.com", "https://evil.example.com"))).To(BeFalse())
+	})
+})
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildx
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	dockerExe = "/usr/local/bin/docker"
+
+	// BuilderName is the docker-container builder shared by the builds on the same docker daemon
+	BuilderName = "zadig-builder"
+	// BinfmtImage installs the qemu emulators, it is pinned to keep the emulators stable across builds
+	BinfmtImage = "tonistiigi/binfmt:qemu-v6.2.0"
+	// CacheTag is the tag of the layer cache image pushed along with the image
+	CacheTag = "buildcache"
+)
+
+// BuilderCmd reuses the builder if it exists, otherwise creates one,
+// exporting cache and building images of multiple platforms require a docker-container builder
+func BuilderCmd() *exec.Cmd {
+	return exec.Command(
+		"sh", "-c",
+		fmt.Sprintf("%[1]s buildx use %[2]s || %[1]s buildx create --name %[2]s --driver docker-container --use", dockerExe, BuilderName),
+	)
+}
+
+// BinfmtInstallCmd registers the emulators used to build images of other platforms
+func BinfmtInstallCmd() *exec.Cmd {
+	return exec.Command(dockerExe, "run", "--privileged", "--rm", BinfmtImage, "--install", "all")
+}
+
+// CacheRef returns the cache image in the same repository of the image,
+// e.g. xxx.com/namespace/image:tag => xxx.com/namespace/image:buildcache
+func CacheRef(image string) string {
+	repo := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo = image[:i]
+	}
+	return repo + ":" + CacheTag
+}
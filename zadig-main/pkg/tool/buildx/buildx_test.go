@@ -0,0 +1,29 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheRef(t *testing.T) {
+	assert.Equal(t, "xxx.com/ns/app:buildcache", CacheRef("xxx.com/ns/app:v1"))
+	assert.Equal(t, "xxx.com:5000/ns/app:buildcache", CacheRef("xxx.com:5000/ns/app"))
+	assert.Equal(t, "xxx.com:5000/ns/app:buildcache", CacheRef("xxx.com:5000/ns/app:v1"))
+}
@@ -686,4 +686,110 @@ var (
 	//-----------------------------------------------------------------------------------------------
 	ErrListHelmReleases = NewHTTPError(6850, "获取release失败")
 	ErrGetHelmCharts    = NewHTTPError(6851, "获取chart信息失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// external task plugin Error Range: 6870 - 6879
+	//-----------------------------------------------------------------------------------------------
+	ErrCreateExternalTaskPlugin = NewHTTPError(6870, "创建外部任务插件失败")
+	ErrUpdateExternalTaskPlugin = NewHTTPError(6871, "更新外部任务插件失败")
+	ErrDeleteExternalTaskPlugin = NewHTTPError(6872, "删除外部任务插件失败")
+	ErrListExternalTaskPlugin   = NewHTTPError(6873, "获取外部任务插件列表失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// workflow approval Error Range: 6880 - 6889
+	//-----------------------------------------------------------------------------------------------
+	ErrStartWorkflowApproval  = NewHTTPError(6880, "发起工作流审批失败")
+	ErrGetWorkflowApproval    = NewHTTPError(6881, "获取工作流审批失败")
+	ErrApproveWorkflowTask    = NewHTTPError(6882, "审批工作流失败")
+	ErrNotWorkflowApprover    = NewHTTPError(6883, "当前用户不是该工作流的审批人")
+	ErrCancelWorkflowApproval = NewHTTPError(6884, "取消工作流审批失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// service release Error Range: 6890 - 6899
+	//-----------------------------------------------------------------------------------------------
+	ErrListServiceReleases    = NewHTTPError(6890, "获取服务发布记录失败")
+	ErrRollbackServiceRelease = NewHTTPError(6891, "回滚服务失败")
+	ErrServiceReleaseNotFound = NewHTTPError(6892, "未找到服务可回滚的发布记录")
+
+	//-----------------------------------------------------------------------------------------------
+	// workflow as code Error Range: 6900 - 6909
+	//-----------------------------------------------------------------------------------------------
+	ErrValidateWorkflowCode = NewHTTPError(6900, "工作流文件校验失败")
+	ErrImportWorkflowCode   = NewHTTPError(6901, "导入工作流文件失败")
+	ErrExportWorkflowCode   = NewHTTPError(6902, "导出工作流文件失败")
+	ErrWorkflowCodeDrift    = NewHTTPError(6903, "检测工作流文件差异失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// build cache Error Range: 6910 - 6919
+	//-----------------------------------------------------------------------------------------------
+	ErrGetBuildCache    = NewHTTPError(6910, "获取编译缓存失败")
+	ErrCreateBuildCache = NewHTTPError(6911, "保存编译缓存失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// image signing Error Range: 6920 - 6929
+	//-----------------------------------------------------------------------------------------------
+	ErrListSigningKeys      = NewHTTPError(6920, "获取签名密钥列表失败")
+	ErrCreateSigningKey     = NewHTTPError(6921, "新建签名密钥失败")
+	ErrUpdateSigningKey     = NewHTTPError(6922, "更新签名密钥失败")
+	ErrDeleteSigningKey     = NewHTTPError(6923, "删除签名密钥失败")
+	ErrDeleteUsedSigningKey = NewHTTPError(6924, "签名密钥已被构建引用，无法删除")
+	ErrVerifyImageSignature = NewHTTPError(6925, "校验镜像签名失败")
+	ErrGetSigningKey        = NewHTTPError(6926, "获取签名密钥失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// flaky test case Error Range: 6930 - 6939
+	//-----------------------------------------------------------------------------------------------
+	ErrListFlakyTestCases       = NewHTTPError(6930, "获取不稳定测试用例失败")
+	ErrListQuarantinedTestCases = NewHTTPError(6931, "获取隔离测试用例列表失败")
+	ErrQuarantineTestCase       = NewHTTPError(6932, "隔离测试用例失败")
+	ErrDeleteTestCaseQuarantine = NewHTTPError(6933, "取消隔离测试用例失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// notification subscription Error Range: 6940 - 6949
+	//-----------------------------------------------------------------------------------------------
+	ErrListNotificationSubscriptions  = NewHTTPError(6940, "获取通知订阅列表失败")
+	ErrCreateNotificationSubscription = NewHTTPError(6941, "新建通知订阅失败")
+	ErrUpdateNotificationSubscription = NewHTTPError(6942, "更新通知订阅失败")
+	ErrDeleteNotificationSubscription = NewHTTPError(6943, "删除通知订阅失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// task log Error Range: 6950 - 6959
+	//-----------------------------------------------------------------------------------------------
+	ErrGetTaskLog    = NewHTTPError(6950, "获取任务日志失败")
+	ErrSearchTaskLog = NewHTTPError(6951, "日志搜索条件不合法")
+
+	//-----------------------------------------------------------------------------------------------
+	// env sleep Error Range: 6960 - 6969
+	//-----------------------------------------------------------------------------------------------
+	ErrSleepEnv            = NewHTTPError(6960, "环境休眠失败")
+	ErrWakeEnv             = NewHTTPError(6961, "环境唤醒失败")
+	ErrUpdateSleepSchedule = NewHTTPError(6962, "更新环境休眠策略失败")
+	ErrUpdateEnvTTL        = NewHTTPError(6963, "更新环境有效期失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env snapshot Error Range: 6970 - 6979
+	//-----------------------------------------------------------------------------------------------
+	ErrCreateEnvSnapshot  = NewHTTPError(6970, "创建环境快照失败")
+	ErrListEnvSnapshots   = NewHTTPError(6971, "获取环境快照列表失败")
+	ErrGetEnvSnapshot     = NewHTTPError(6972, "获取环境快照失败")
+	ErrDeleteEnvSnapshot  = NewHTTPError(6973, "删除环境快照失败")
+	ErrExportEnvSnapshot  = NewHTTPError(6974, "导出环境快照失败")
+	ErrImportEnvSnapshot  = NewHTTPError(6975, "导入环境快照失败")
+	ErrRestoreEnvSnapshot = NewHTTPError(6976, "从快照恢复环境失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env drift Error Range: 6980 - 6989
+	//-----------------------------------------------------------------------------------------------
+	ErrCheckEnvDrift     = NewHTTPError(6980, "环境漂移检测失败")
+	ErrReconcileEnvDrift = NewHTTPError(6981, "环境漂移修复失败")
+	ErrAdoptEnvDrift     = NewHTTPError(6982, "采纳线上配置失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env quota Error Range: 6990 - 6999
+	//-----------------------------------------------------------------------------------------------
+	ErrGetProjectQuota    = NewHTTPError(6990, "获取项目资源配额失败")
+	ErrUpdateProjectQuota = NewHTTPError(6991, "更新项目资源配额失败")
+	ErrUpdateEnvQuota     = NewHTTPError(6992, "更新环境资源配额失败")
+	ErrApplyEnvQuota      = NewHTTPError(6993, "应用环境资源配额失败")
+	ErrGetResourceReport  = NewHTTPError(6994, "获取资源使用报告失败")
 )
@@ -23,8 +23,11 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	hc "github.com/mittwald/go-helm-client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
@@ -41,6 +44,8 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	yamlutil "github.com/koderover/zadig/pkg/util/yaml"
 )
 
@@ -428,11 +433,42 @@ func (hClient *HelmClient) InstallOrUpgradeChart(ctx context.Context, spec *hc.C
 		return nil, err
 	}
 
+	operation := "upgrade"
 	if install {
-		return hClient.installChart(ctx, spec)
+		operation = "install"
+	}
+	ctx, span := tracing.StartChild(ctx, fmt.Sprintf("helm %s", operation), trace.WithAttributes(
+		attribute.String("helm.release", spec.ReleaseName),
+		attribute.String("helm.namespace", spec.Namespace),
+	))
+
+	start := time.Now()
+	var rel *release.Release
+	if install {
+		rel, err = hClient.installChart(ctx, spec)
 	} else {
-		return hClient.upgradeChart(ctx, spec)
+		rel, err = hClient.upgradeChart(ctx, spec)
 	}
+	metrics.ObserveHelmOperation(operation, start, err)
+	tracing.End(span, err)
+
+	return rel, err
+}
+
+// UninstallRelease uninstalls the release of the spec and records the duration
+func (hClient *HelmClient) UninstallRelease(spec *hc.ChartSpec) error {
+	start := time.Now()
+	err := hClient.HelmClient.UninstallRelease(spec)
+	metrics.ObserveHelmOperation("uninstall", start, err)
+	return err
+}
+
+// RollbackRelease rolls back the release of the spec to the given version and records the duration
+func (hClient *HelmClient) RollbackRelease(spec *hc.ChartSpec, version int) error {
+	start := time.Now()
+	err := hClient.HelmClient.RollbackRelease(spec, version)
+	metrics.ObserveHelmOperation("rollback", start, err)
+	return err
 }
 
 // mergeInstallOptions merges values of the provided chart to helm install options used by the client.
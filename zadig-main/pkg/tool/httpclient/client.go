@@ -18,14 +18,18 @@ package httpclient
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 const (
@@ -148,7 +152,20 @@ func (c *Client) Request(method, url string, rfs ...RequestFunc) (*resty.Respons
 	for _, rf := range rfs {
 		rf(r)
 	}
-	return c.wrapError(r.Execute(method, url))
+
+	ctx, span := tracing.StartChild(r.Context(), fmt.Sprintf("HTTP %s", method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(method), semconv.HTTPTargetKey.String(url)),
+	)
+	tracing.InjectHeader(ctx, r.Header)
+
+	res, err := c.wrapError(r.Execute(method, url))
+	if res != nil {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode()))
+	}
+	tracing.End(span, err)
+
+	return res, err
 }
 
 func (c *Client) wrapError(res *resty.Response, err error) (*resty.Response, error) {
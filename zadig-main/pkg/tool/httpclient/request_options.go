@@ -17,6 +17,7 @@ limitations under the License.
 package httpclient
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
@@ -25,6 +26,13 @@ import (
 
 type RequestFunc func(request *resty.Request)
 
+// SetContext sets the context of the request, the trace context in it is propagated to the server.
+func SetContext(ctx context.Context) RequestFunc {
+	return func(r *resty.Request) {
+		r.SetContext(ctx)
+	}
+}
+
 func SetResult(res interface{}) RequestFunc {
 	return func(r *resty.Request) {
 		r.SetResult(res)
@@ -69,6 +69,15 @@ func DeleteDeployments(ns string, selector labels.Selector, cl client.Client) er
 	return deleteObjectsWithDefaultOptions(ns, selector, &appsv1.Deployment{}, cl)
 }
 
+func DeleteDeployment(ns, name string, cl client.Client) error {
+	return deleteObjectWithDefaultOptions(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, cl)
+}
+
 func UpdateDeploymentImage(ns, name, container, image string, cl client.Client) error {
 	patchBytes := []byte(fmt.Sprintf(`{"spec":{"template":{"spec":{"containers":[{"name":"%s","image":"%s"}]}}}}`, container, image))
 
@@ -18,6 +18,7 @@ package updater
 
 import (
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,3 +26,25 @@ import (
 func DeleteIngresses(ns string, selector labels.Selector, cl client.Client) error {
 	return deleteObjectsWithDefaultOptions(ns, selector, &extensionsv1beta1.Ingress{}, cl)
 }
+
+func DeleteIngress(ns, name string, cl client.Client) error {
+	return deleteObjectWithDefaultOptions(&extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, cl)
+}
+
+func CreateOrPatchIngress(ing *extensionsv1beta1.Ingress, cl client.Client) error {
+	return createOrPatchObject(ing, cl)
+}
+
+func PatchIngress(ns, name string, patchBytes []byte, cl client.Client) error {
+	return patchObject(&extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, patchBytes, cl)
+}
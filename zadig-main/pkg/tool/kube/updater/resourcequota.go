@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package updater
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func CreateOrPatchResourceQuota(rq *corev1.ResourceQuota, cl client.Client) error {
+	return createOrPatchObject(rq, cl)
+}
+
+func DeleteResourceQuota(ns, name string, cl client.Client) error {
+	return deleteObjectWithDefaultOptions(&corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, cl)
+}
+
+func CreateOrPatchLimitRange(lr *corev1.LimitRange, cl client.Client) error {
+	return createOrPatchObject(lr, cl)
+}
+
+func DeleteLimitRange(ns, name string, cl client.Client) error {
+	return deleteObjectWithDefaultOptions(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, cl)
+}
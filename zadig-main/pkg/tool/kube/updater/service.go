@@ -53,3 +53,16 @@ func DeleteService(ns, name string, cl client.Client) error {
 		},
 	}, cl, &client.DeleteOptions{PropagationPolicy: &deletePolicy})
 }
+
+func PatchService(ns, name string, patchBytes []byte, cl client.Client) error {
+	return patchObject(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, patchBytes, cl)
+}
+
+func CreateOrPatchService(s *corev1.Service, cl client.Client) error {
+	return createOrPatchObject(s, cl)
+}
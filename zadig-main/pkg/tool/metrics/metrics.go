@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	k8smetrics "k8s.io/client-go/tools/metrics"
+)
+
+const (
+	Namespace = "zadig"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	kubeRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "kube",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests sent to Kubernetes API servers, partitioned by verb and host.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"verb", "host"})
+
+	kubeRequestResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kube",
+		Name:      "requests_total",
+		Help:      "Number of requests sent to Kubernetes API servers, partitioned by status code, method and host.",
+	}, []string{"code", "method", "host"})
+
+	helmOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "helm",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of helm operations, partitioned by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"operation", "result"})
+
+	registerKubeOnce sync.Once
+)
+
+// Handler returns the http handler serving metrics in Prometheus format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Result converts an error to the result label value.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
+
+// RegisterKubeClientMetrics makes all client-go rest clients in this process report request latencies and results.
+// k8smetrics.Register only takes effect once per process and controller-runtime has already called it in its init,
+// so the metrics are assigned directly.
+func RegisterKubeClientMetrics() {
+	registerKubeOnce.Do(func() {
+		k8smetrics.RequestLatency = &kubeLatencyAdapter{}
+		k8smetrics.RequestResult = &kubeResultAdapter{}
+	})
+}
+
+// ObserveHelmOperation records the duration of a helm operation started at start.
+func ObserveHelmOperation(operation string, start time.Time, err error) {
+	helmOperationDuration.WithLabelValues(operation, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+type kubeLatencyAdapter struct{}
+
+func (*kubeLatencyAdapter) Observe(_ context.Context, verb string, u url.URL, latency time.Duration) {
+	kubeRequestDuration.WithLabelValues(verb, u.Host).Observe(latency.Seconds())
+}
+
+type kubeResultAdapter struct{}
+
+func (*kubeResultAdapter) Increment(_ context.Context, code, method, host string) {
+	kubeRequestResults.WithLabelValues(code, method, host).Inc()
+}
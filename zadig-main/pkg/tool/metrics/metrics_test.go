@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	// controller-runtime registers its own client-go metrics in init, as it does in aslan and warpdrive
+	_ "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestRegisterKubeClientMetrics(t *testing.T) {
+	RegisterKubeClientMetrics()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"default"}}`))
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+
+	host := server.Listener.Addr().String()
+	before := testutil.ToFloat64(kubeRequestResults.WithLabelValues("200", http.MethodGet, host))
+	_, err = clientset.CoreV1().Namespaces().Get(context.TODO(), "default", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(kubeRequestResults.WithLabelValues("200", http.MethodGet, host)))
+	assert.Equal(t, 1, testutil.CollectAndCount(kubeRequestDuration))
+}
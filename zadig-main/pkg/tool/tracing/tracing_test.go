@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestInjectExtract(t *testing.T) {
+	setupRecorder()
+
+	assert.Nil(t, Inject(context.Background()))
+
+	ctx, span := Start(context.Background(), "CreateTask")
+	carrier := Inject(ctx)
+	span.End()
+	assert.Contains(t, carrier, "traceparent")
+
+	_, child := Start(Extract(context.Background(), carrier), "ExecuteTask")
+	defer child.End()
+	assert.Equal(t, span.SpanContext().TraceID(), child.SpanContext().TraceID())
+}
+
+func TestStartChild(t *testing.T) {
+	recorder := setupRecorder()
+
+	_, span := StartChild(context.Background(), "orphan")
+	span.End()
+	assert.False(t, span.SpanContext().IsValid())
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := StartChild(ctx, "child")
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "child", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := setupRecorder()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// 不带 trace 上下文的请求不记录
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/1", nil))
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := Start(context.Background(), "client")
+	req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	InjectHeader(ctx, req.Header)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "GET /tasks/:id", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	}
+}
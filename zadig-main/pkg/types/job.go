@@ -21,4 +21,6 @@ type JobStatus string
 const (
 	JobSuccess JobStatus = "success"
 	JobFail    JobStatus = "fail"
+	// JobTestFail 仅测试脚本执行失败，测试结果已经归档，由测试报告决定任务结果
+	JobTestFail JobStatus = "test_fail"
 )
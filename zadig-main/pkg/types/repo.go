@@ -34,6 +34,7 @@ type Repository struct {
 	Tag           string `bson:"tag,omitempty"             json:"tag,omitempty"`
 	CommitID      string `bson:"commit_id,omitempty"       json:"commit_id,omitempty"`
 	CommitMessage string `bson:"commit_message,omitempty"  json:"commit_message,omitempty"`
+	CommitTime    int64  `bson:"commit_time,omitempty"     json:"commit_time,omitempty"`
 	CheckoutPath  string `bson:"checkout_path,omitempty"   json:"checkout_path,omitempty"`
 	SubModules    bool   `bson:"submodules,omitempty"      json:"submodules,omitempty"`
 	// UseDefault defines if the repo can be configured in start pipeline task page